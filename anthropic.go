@@ -2,26 +2,237 @@ package echo
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 )
 
 type AnthropicMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string                  `json:"role"`
+	Content string                  `json:"content"`
+	Blocks  []AnthropicContentBlock `json:"-"` // when set, sent as the content array instead of Content
+}
+
+// AnthropicContentBlock is a single text, image, tool use or tool result block of a message
+type AnthropicContentBlock struct {
+	Type   string                `json:"type"`
+	Text   string                `json:"text,omitempty"`
+	Source *AnthropicImageSource `json:"source,omitempty"`
+
+	ID        string          `json:"id,omitempty"`          // tool_use
+	Name      string          `json:"name,omitempty"`        // tool_use
+	Input     json.RawMessage `json:"input,omitempty"`       // tool_use
+	ToolUseID string          `json:"tool_use_id,omitempty"` // tool_result
+	Content   string          `json:"content,omitempty"`     // tool_result
+}
+
+// AnthropicImageSource holds inline (base64 or text) or remote (url) image and document data
+type AnthropicImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// MarshalJSON writes the content as a string or as an array of blocks
+func (m AnthropicMessage) MarshalJSON() ([]byte, error) {
+	if len(m.Blocks) == 0 {
+		return json.Marshal(struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		}{m.Role, m.Content})
+	}
+	return json.Marshal(struct {
+		Role    string                  `json:"role"`
+		Content []AnthropicContentBlock `json:"content"`
+	}{m.Role, m.Blocks})
+}
+
+// UnmarshalJSON accepts both string and array content; text of array blocks is joined into Content
+func (m *AnthropicMessage) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	m.Role = raw.Role
+	m.Content = ""
+	m.Blocks = nil
+	if len(raw.Content) == 0 || string(raw.Content) == "null" {
+		return nil
+	}
+	if raw.Content[0] == '"' {
+		return json.Unmarshal(raw.Content, &m.Content)
+	}
+
+	if err := json.Unmarshal(raw.Content, &m.Blocks); err != nil {
+		return err
+	}
+	var texts []string
+	for _, block := range m.Blocks {
+		if block.Type == "text" {
+			texts = append(texts, block.Text)
+		}
+	}
+	m.Content = strings.Join(texts, "\n")
+	return nil
+}
+
+// toAnthropicMessage converts a message to Anthropic format, using content blocks when it has media parts
+func toAnthropicMessage(role string, msg Message) (AnthropicMessage, error) {
+	out := AnthropicMessage{Role: role, Content: msg.Content}
+	if len(msg.Parts) == 0 {
+		return out, nil
+	}
+
+	for _, part := range msg.Parts {
+		switch {
+		case part.Type == PartText:
+			out.Blocks = append(out.Blocks, AnthropicContentBlock{Type: "text", Text: part.Text})
+		case part.Type != PartImage && part.Type != PartDocument:
+			return out, fmt.Errorf("%s parts are not supported", part.Type)
+		case part.Type == PartDocument && part.MimeType != "application/pdf" && part.MimeType != "text/plain":
+			return out, fmt.Errorf("unsupported document type %q, Anthropic accepts application/pdf and text/plain", part.MimeType)
+		case part.MimeType == "text/plain" && len(part.Data) > 0:
+			out.Blocks = append(out.Blocks, AnthropicContentBlock{Type: part.Type, Source: &AnthropicImageSource{
+				Type:      "text",
+				MediaType: part.MimeType,
+				Data:      string(part.Data),
+			}})
+		case len(part.Data) > 0:
+			// Part types match the Anthropic block types, "image" and "document"
+			out.Blocks = append(out.Blocks, AnthropicContentBlock{Type: part.Type, Source: &AnthropicImageSource{
+				Type:      "base64",
+				MediaType: part.MimeType,
+				Data:      base64.StdEncoding.EncodeToString(part.Data),
+			}})
+		case part.URL != "":
+			out.Blocks = append(out.Blocks, AnthropicContentBlock{Type: part.Type, Source: &AnthropicImageSource{
+				Type: "url",
+				URL:  part.URL,
+			}})
+		default:
+			return out, fmt.Errorf("empty %s part", part.Type)
+		}
+	}
+	// Anthropic recommends placing images before the text that refers to them
+	if msg.Content != "" {
+		out.Blocks = append(out.Blocks, AnthropicContentBlock{Type: "text", Text: msg.Content})
+	}
+	return out, nil
+}
+
+// toAnthropicAgentMessage converts an agent message, its tool calls become tool_use blocks
+func toAnthropicAgentMessage(msg Message) AnthropicMessage {
+	out := AnthropicMessage{Role: "assistant", Content: msg.Content}
+	if len(msg.ToolCalls) == 0 {
+		return out
+	}
+
+	if msg.Content != "" {
+		out.Blocks = append(out.Blocks, AnthropicContentBlock{Type: "text", Text: msg.Content})
+	}
+	for _, call := range msg.ToolCalls {
+		out.Blocks = append(out.Blocks, AnthropicContentBlock{
+			Type:  "tool_use",
+			ID:    call.ID,
+			Name:  call.Name,
+			Input: toolArguments(call),
+		})
+	}
+	return out
+}
+
+// appendAnthropicToolResult adds a tool result to the chain; results of consecutive calls
+// share a single user message, as Anthropic expects them together
+func appendAnthropicToolResult(messages []AnthropicMessage, msg Message) []AnthropicMessage {
+	block := AnthropicContentBlock{Type: "tool_result", ToolUseID: msg.ToolCallID, Content: msg.Content}
+	if n := len(messages); n > 0 {
+		last := &messages[n-1]
+		if last.Role == "user" && len(last.Blocks) > 0 && last.Blocks[len(last.Blocks)-1].Type == "tool_result" {
+			last.Blocks = append(last.Blocks, block)
+			return messages
+		}
+	}
+	return append(messages, AnthropicMessage{Role: "user", Blocks: []AnthropicContentBlock{block}})
+}
+
+// AnthropicTool declares a function the model may call
+type AnthropicTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"` // JSON Schema object
+}
+
+// anthropicTools converts tool declarations to Anthropic format
+func anthropicTools(tools []Tool) []AnthropicTool {
+	var out []AnthropicTool
+	for _, t := range tools {
+		var schema any = t.Parameters
+		if t.Parameters == nil {
+			// input_schema is required, even for tools without arguments
+			schema = map[string]any{"type": "object"}
+		}
+		out = append(out, AnthropicTool{Name: t.Name, Description: t.Description, InputSchema: schema})
+	}
+	return out
 }
 
 type AnthropicRequest struct {
-	Model        string                 `json:"model"`
-	Messages     []AnthropicMessage     `json:"messages"`
-	MaxTokens    int                    `json:"max_tokens"`
-	Temperature  *float32               `json:"temperature,omitempty"`
-	System       string                 `json:"system,omitempty"`
-	Stream       bool                   `json:"stream,omitempty"`
-	OutputFormat *AnthropicOutputFormat `json:"output_format,omitempty"`
-	OutputConfig *AnthropicOutputConfig `json:"output_config,omitempty"`
+	Model         string                 `json:"model"`
+	Messages      []AnthropicMessage     `json:"messages"`
+	MaxTokens     int                    `json:"max_tokens"`
+	Temperature   *float32               `json:"temperature,omitempty"`
+	TopP          *float32               `json:"top_p,omitempty"`
+	TopK          *int                   `json:"top_k,omitempty"`
+	StopSequences []string               `json:"stop_sequences,omitempty"`
+	System        string                 `json:"system,omitempty"`
+	Stream        bool                   `json:"stream,omitempty"`
+	OutputFormat  *AnthropicOutputFormat `json:"output_format,omitempty"`
+	OutputConfig  *AnthropicOutputConfig `json:"output_config,omitempty"`
+	Tools         []AnthropicTool        `json:"tools,omitempty"`
+	ToolChoice    *AnthropicToolChoice   `json:"tool_choice,omitempty"`
+	Thinking      *AnthropicThinking     `json:"thinking,omitempty"`
+	Metadata      *AnthropicMetadata     `json:"metadata,omitempty"`
+}
+
+// AnthropicMetadata identifies the end user of a request
+type AnthropicMetadata struct {
+	UserID string `json:"user_id"`
+}
+
+// AnthropicThinking enables extended thinking
+type AnthropicThinking struct {
+	Type         string `json:"type"` // "enabled"
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+// anthropicMinThinkingBudget is the smallest thinking budget Anthropic accepts
+const anthropicMinThinkingBudget = 1024
+
+// AnthropicToolChoice forces or forbids tool use
+type AnthropicToolChoice struct {
+	Type string `json:"type"`           // "auto", "none", "any" or "tool"
+	Name string `json:"name,omitempty"` // tool to call when Type is "tool"
+}
+
+// anthropicToolChoice converts the tool choice to Anthropic format, "required" is "any" there
+func anthropicToolChoice(choice string) *AnthropicToolChoice {
+	switch choice {
+	case "":
+		return nil
+	case ToolChoiceAuto, ToolChoiceNone:
+		return &AnthropicToolChoice{Type: choice}
+	case ToolChoiceRequired:
+		return &AnthropicToolChoice{Type: "any"}
+	}
+	return &AnthropicToolChoice{Type: "tool", Name: choice}
 }
 
 // AnthropicOutputFormat specifies the output format for structured output
@@ -43,14 +254,42 @@ type AnthropicError struct {
 type AnthropicResponse struct {
 	Error   *AnthropicError `json:"error,omitempty"`
 	Content []struct {
-		Type string `json:"type"`
-		Text string `json:"text"`
+		Type     string          `json:"type"`
+		Text     string          `json:"text"`
+		Thinking string          `json:"thinking,omitempty"` // thinking
+		ID       string          `json:"id,omitempty"`       // tool_use
+		Name     string          `json:"name,omitempty"`     // tool_use
+		Input    json.RawMessage `json:"input,omitempty"`    // tool_use
 	} `json:"content"`
-	StopReason string `json:"stop_reason"`
-	Usage      struct {
-		InputTokens  int `json:"input_tokens"`
-		OutputTokens int `json:"output_tokens"`
-	} `json:"usage"`
+	Model      string         `json:"model,omitempty"`
+	StopReason string         `json:"stop_reason"`
+	Usage      AnthropicUsage `json:"usage"`
+}
+
+// AnthropicUsage is the token usage of a response. Input tokens don't include the
+// tokens read from or written to the prompt cache, those are counted separately.
+type AnthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+}
+
+// anthropicMetadata returns the response metadata with the usage and the stop reason
+func anthropicMetadata(u AnthropicUsage, stopReason, finish string) Metadata {
+	meta := Metadata{
+		"stop_reason":   stopReason,
+		"finish_reason": finish,
+		"input_tokens":  u.InputTokens,
+		"output_tokens": u.OutputTokens,
+	}
+	if u.CacheCreationInputTokens > 0 {
+		meta["cache_creation_input_tokens"] = u.CacheCreationInputTokens
+	}
+	if u.CacheReadInputTokens > 0 {
+		meta["cache_read_input_tokens"] = u.CacheReadInputTokens
+	}
+	return meta
 }
 
 // Anthropic streaming response structures
@@ -61,17 +300,14 @@ type AnthropicStreamEvent struct {
 type AnthropicMessageStart struct {
 	Type    string `json:"type"`
 	Message struct {
-		ID           string  `json:"id"`
-		Type         string  `json:"type"`
-		Role         string  `json:"role"`
-		Content      []any   `json:"content"`
-		Model        string  `json:"model"`
-		StopReason   *string `json:"stop_reason"`
-		StopSequence *string `json:"stop_sequence"`
-		Usage        struct {
-			InputTokens  int `json:"input_tokens"`
-			OutputTokens int `json:"output_tokens"`
-		} `json:"usage"`
+		ID           string         `json:"id"`
+		Type         string         `json:"type"`
+		Role         string         `json:"role"`
+		Content      []any          `json:"content"`
+		Model        string         `json:"model"`
+		StopReason   *string        `json:"stop_reason"`
+		StopSequence *string        `json:"stop_sequence"`
+		Usage        AnthropicUsage `json:"usage"`
 	} `json:"message"`
 }
 
@@ -88,11 +324,34 @@ type AnthropicContentBlockDelta struct {
 	Type  string `json:"type"`
 	Index int    `json:"index"`
 	Delta struct {
-		Type string `json:"type"`
-		Text string `json:"text"`
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		Thinking    string `json:"thinking,omitempty"`     // thinking_delta
+		PartialJSON string `json:"partial_json,omitempty"` // input_json_delta of a tool_use block
 	} `json:"delta"`
 }
 
+// chunk returns the stream chunk of the delta, false for deltas without content
+func (d AnthropicContentBlockDelta) chunk() (StreamChunk, bool) {
+	if d.Delta.Type == "thinking_delta" {
+		return StreamChunk{Thinking: d.Delta.Thinking}, d.Delta.Thinking != ""
+	}
+	text := d.text()
+	return StreamChunk{Data: text}, text != ""
+}
+
+// text returns the streamed text of the delta; the input of the schema tool is the text
+// of structured output, other tools are not used in streams
+func (d AnthropicContentBlockDelta) text() string {
+	switch d.Delta.Type {
+	case "text_delta":
+		return d.Delta.Text
+	case "input_json_delta":
+		return d.Delta.PartialJSON
+	}
+	return ""
+}
+
 type AnthropicContentBlockStop struct {
 	Type  string `json:"type"`
 	Index int    `json:"index"`
@@ -129,6 +388,81 @@ func NewAnthropicClient(apiKey, model string, opts ...CallOption) Client {
 	return client
 }
 
+// AnthropicBetaContext1M enables the 1M token context window of Claude Sonnet 4 and 4.5
+const AnthropicBetaContext1M = "context-1m-2025-08-07"
+
+// anthropicDefaultMaxTokens is used when max_tokens is not set and the model is not in
+// the capability table, Anthropic requires it
+const anthropicDefaultMaxTokens = 4096
+
+// anthropicMaxTokens returns the requested max_tokens, or the output limit of the model
+// from the capability table
+func anthropicMaxTokens(model string, requested *int) int {
+	if requested != nil {
+		return *requested
+	}
+	if info, ok := LookupModel("anthropic/" + model); ok && info.MaxOutputTokens > 0 {
+		return info.MaxOutputTokens
+	}
+	return anthropicDefaultMaxTokens
+}
+
+// anthropicNativeSchema tells which models accept a native output_format,
+// models missing from the table are assumed to accept it
+var anthropicNativeSchema = map[string]bool{
+	"claude-opus-4-5":   true,
+	"claude-opus-4-1":   true,
+	"claude-sonnet-4-5": true,
+	"claude-haiku-4-5":  true,
+	"claude-opus-4":     false,
+	"claude-sonnet-4":   false,
+	"claude-3":          false,
+}
+
+// anthropicSchemaTool returns the name of the tool carrying structured output
+// for models without native support
+func anthropicSchemaTool(cfg CallConfig) (string, bool) {
+	if cfg.StructuredOutput == nil {
+		return "", false
+	}
+	if native, ok := matchModel(anthropicNativeSchema, cfg.Model); ok && !native {
+		name := cfg.StructuredOutput.Name
+		if name == "" {
+			name = "response"
+		}
+		return name, true
+	}
+	return "", false
+}
+
+// requestInit sets the authentication, version and beta headers of a request
+func (p *AnthropicProvider) requestInit(cfg CallConfig) RequestInit {
+	return func(req *http.Request) {
+		req.Header.Set("anthropic-version", "2023-06-01")
+		req.Header.Set("x-api-key", cfg.apiKey(p.Key))
+		if beta := anthropicBetaFeatures(cfg); len(beta) > 0 {
+			req.Header.Set("anthropic-beta", strings.Join(beta, ","))
+		}
+	}
+}
+
+// anthropicBetaFeatures lists the beta features required by the call, without duplicates
+func anthropicBetaFeatures(cfg CallConfig) []string {
+	var features []string
+	if _, tool := anthropicSchemaTool(cfg); cfg.StructuredOutput != nil && !tool {
+		features = append(features, "structured-outputs-2025-11-13")
+	}
+	if cfg.ReasoningEffort != "" {
+		features = append(features, "effort-2025-11-24")
+	}
+	for _, f := range cfg.AnthropicBeta {
+		if !slices.Contains(features, f) {
+			features = append(features, f)
+		}
+	}
+	return features
+}
+
 // prepareAnthropicRequest builds the Anthropic request with the given configuration
 func prepareAnthropicRequest(messages []Message, streaming bool, cfg CallConfig) (AnthropicRequest, error) {
 	// Validate messages
@@ -136,6 +470,22 @@ func prepareAnthropicRequest(messages []Message, streaming bool, cfg CallConfig)
 		return AnthropicRequest{}, fmt.Errorf("invalid message chain: %w", err)
 	}
 
+	if cfg.JSONMode && cfg.StructuredOutput == nil {
+		return AnthropicRequest{}, fmt.Errorf("JSON mode is not supported by Anthropic, use WithResponseSchema")
+	}
+	if len(cfg.LogitBias) > 0 {
+		return AnthropicRequest{}, fmt.Errorf("logit bias is not supported by Anthropic")
+	}
+	if cfg.FrequencyPenalty != nil || cfg.PresencePenalty != nil {
+		return AnthropicRequest{}, fmt.Errorf("frequency and presence penalties are not supported by Anthropic")
+	}
+	if cfg.Seed != nil {
+		return AnthropicRequest{}, fmt.Errorf("seed is not supported by Anthropic")
+	}
+	if cfg.N > 1 {
+		return AnthropicRequest{}, fmt.Errorf("multiple answers are not supported by Anthropic, make separate calls")
+	}
+
 	// Convert messages to Anthropic format
 	anthropicMessages := []AnthropicMessage{}
 	var systemMsg string
@@ -145,30 +495,32 @@ func prepareAnthropicRequest(messages []Message, streaming bool, cfg CallConfig)
 		case System:
 			systemMsg = msg.Content
 		case User:
-			anthropicMessages = append(anthropicMessages, AnthropicMessage{
-				Role:    "user",
-				Content: msg.Content,
-			})
+			userMsg, err := toAnthropicMessage("user", msg)
+			if err != nil {
+				return AnthropicRequest{}, err
+			}
+			anthropicMessages = append(anthropicMessages, userMsg)
 		case Agent:
-			anthropicMessages = append(anthropicMessages, AnthropicMessage{
-				Role:    "assistant",
-				Content: msg.Content,
-			})
+			anthropicMessages = append(anthropicMessages, toAnthropicAgentMessage(msg))
+		case ToolResult:
+			anthropicMessages = appendAnthropicToolResult(anthropicMessages, msg)
 		}
 	}
 
-	// Anthropic requires max_tokens to be set
-	maxTokens := 4096
-	if cfg.MaxTokens != nil {
-		maxTokens = *cfg.MaxTokens
-	}
-
 	body := AnthropicRequest{
-		Model:       cfg.Model,
-		Messages:    anthropicMessages,
-		MaxTokens:   maxTokens,
-		Temperature: cfg.Temperature,
-		Stream:      streaming,
+		Model:         cfg.Model,
+		Messages:      anthropicMessages,
+		MaxTokens:     anthropicMaxTokens(cfg.Model, cfg.MaxTokens),
+		Temperature:   cfg.Temperature,
+		TopP:          cfg.TopP,
+		TopK:          cfg.TopK,
+		StopSequences: cfg.StopSequences,
+		Stream:        streaming,
+		Tools:         anthropicTools(cfg.Tools),
+		ToolChoice:    anthropicToolChoice(cfg.ToolChoice),
+	}
+	if cfg.UserID != "" {
+		body.Metadata = &AnthropicMetadata{UserID: cfg.UserID}
 	}
 
 	// Handle system message - WithSystemMessage overrides message chain system
@@ -178,8 +530,16 @@ func prepareAnthropicRequest(messages []Message, streaming bool, cfg CallConfig)
 		body.System = systemMsg
 	}
 
-	// Handle structured output via native output_format API
-	if cfg.StructuredOutput != nil {
+	// Handle structured output via native output_format API, or through a forced tool call
+	// on models without it
+	if name, ok := anthropicSchemaTool(cfg); ok {
+		body.Tools = append(body.Tools, AnthropicTool{
+			Name:        name,
+			Description: "Answer with the result in this format",
+			InputSchema: cfg.StructuredOutput.Schema,
+		})
+		body.ToolChoice = anthropicToolChoice(name)
+	} else if cfg.StructuredOutput != nil {
 		body.OutputFormat = &AnthropicOutputFormat{
 			Type:   "json_schema",
 			Schema: cfg.StructuredOutput.Schema,
@@ -193,9 +553,46 @@ func prepareAnthropicRequest(messages []Message, streaming bool, cfg CallConfig)
 		}
 	}
 
+	if cfg.ThinkingBudget > 0 {
+		if err := checkAnthropicThinking(body, cfg); err != nil {
+			return AnthropicRequest{}, err
+		}
+		body.Thinking = &AnthropicThinking{Type: "enabled", BudgetTokens: cfg.ThinkingBudget}
+	}
+
 	return body, nil
 }
 
+// checkAnthropicThinking verifies the limits of extended thinking: the budget must fit
+// into max tokens, and thinking works neither with changed sampling nor with forced tool use
+func checkAnthropicThinking(body AnthropicRequest, cfg CallConfig) error {
+	if cfg.ThinkingBudget < anthropicMinThinkingBudget {
+		return fmt.Errorf("thinking budget must be at least %d tokens", anthropicMinThinkingBudget)
+	}
+	if cfg.ThinkingBudget >= body.MaxTokens {
+		return fmt.Errorf("thinking budget %d must be less than max tokens %d", cfg.ThinkingBudget, body.MaxTokens)
+	}
+	if cfg.Temperature != nil && *cfg.Temperature != 1 {
+		return fmt.Errorf("thinking is not compatible with temperature, remove WithTemperature")
+	}
+	if cfg.TopK != nil {
+		return fmt.Errorf("thinking is not compatible with top k, remove WithTopK")
+	}
+	if cfg.TopP != nil && *cfg.TopP < 0.95 {
+		return fmt.Errorf("thinking requires top p of at least 0.95, got %v", *cfg.TopP)
+	}
+	if body.ToolChoice != nil && body.ToolChoice.Type != ToolChoiceAuto && body.ToolChoice.Type != ToolChoiceNone {
+		return fmt.Errorf("thinking is not compatible with forced tool use (WithToolChoice, or WithResponseSchema on %s)", cfg.Model)
+	}
+	return nil
+}
+
+// validateCompletion implements the provider interface
+func (p *AnthropicProvider) validateCompletion(messages []Message, cfg CallConfig) error {
+	_, err := prepareAnthropicRequest(messages, false, cfg)
+	return err
+}
+
 // call implements the provider interface for Anthropic
 func (p *AnthropicProvider) call(ctx context.Context, messages []Message, cfg CallConfig) (*Response, error) {
 	body, err := prepareAnthropicRequest(messages, false, cfg)
@@ -210,21 +607,7 @@ func (p *AnthropicProvider) call(ctx context.Context, messages []Message, cfg Ca
 	}
 
 	resp := AnthropicResponse{}
-	err = callHTTPAPI(ctx, baseURL, func(req *http.Request) {
-		req.Header.Set("anthropic-version", "2023-06-01")
-		req.Header.Set("x-api-key", p.Key)
-		// Add beta headers for features that require them
-		var betaFeatures []string
-		if cfg.StructuredOutput != nil {
-			betaFeatures = append(betaFeatures, "structured-outputs-2025-11-13")
-		}
-		if cfg.ReasoningEffort != "" {
-			betaFeatures = append(betaFeatures, "effort-2025-11-24")
-		}
-		if len(betaFeatures) > 0 {
-			req.Header.Set("anthropic-beta", strings.Join(betaFeatures, ","))
-		}
-	}, body, &resp)
+	err = callHTTPAPI(ctx, cfg, baseURL, p.requestInit(cfg), body, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("api call failed: %w", err)
 	}
@@ -239,21 +622,39 @@ func (p *AnthropicProvider) call(ctx context.Context, messages []Message, cfg Ca
 		return nil, fmt.Errorf("no content in Anthropic response")
 	}
 
-	// Combine all text content
-	var text string
+	// Combine all text content and collect tool calls
+	var text, thinking string
+	var calls []ToolCall
 	for _, content := range resp.Content {
-		if content.Type == "text" {
+		switch content.Type {
+		case "text":
 			text += content.Text
+		case "thinking":
+			thinking += content.Thinking
+		case "tool_use":
+			calls = append(calls, ToolCall{ID: content.ID, Name: content.Name, Arguments: content.Input})
+		}
+	}
+
+	finish := anthropicFinishReason(resp.StopReason)
+	if name, ok := anthropicSchemaTool(cfg); ok {
+		// The input of the schema tool is the answer
+		for i, call := range calls {
+			if call.Name == name {
+				text = string(call.Arguments)
+				calls = slices.Delete(calls, i, i+1)
+				finish = FinishStop
+				break
+			}
 		}
 	}
 
 	return &Response{
-		Text: text,
-		Metadata: map[string]any{
-			"stop_reason":   resp.StopReason,
-			"input_tokens":  resp.Usage.InputTokens,
-			"output_tokens": resp.Usage.OutputTokens,
-		},
+		Text:      text,
+		Thinking:  thinking,
+		Model:     resp.Model,
+		ToolCalls: calls,
+		Metadata:  anthropicMetadata(resp.Usage, resp.StopReason, finish),
 	}, nil
 }
 
@@ -271,21 +672,7 @@ func (p *AnthropicProvider) streamCall(ctx context.Context, messages []Message,
 	}
 
 	// Get streaming response
-	respBody, err := streamHTTPAPI(ctx, baseURL, func(req *http.Request) {
-		req.Header.Set("anthropic-version", "2023-06-01")
-		req.Header.Set("x-api-key", p.Key)
-		// Add beta headers for features that require them
-		var betaFeatures []string
-		if cfg.StructuredOutput != nil {
-			betaFeatures = append(betaFeatures, "structured-outputs-2025-11-13")
-		}
-		if cfg.ReasoningEffort != "" {
-			betaFeatures = append(betaFeatures, "effort-2025-11-24")
-		}
-		if len(betaFeatures) > 0 {
-			req.Header.Set("anthropic-beta", strings.Join(betaFeatures, ","))
-		}
-	}, body)
+	respBody, err := streamHTTPAPI(ctx, cfg, baseURL, p.requestInit(cfg), body)
 	if err != nil {
 		return nil, fmt.Errorf("Anthropic streaming API call failed: %w", err)
 	}
@@ -296,23 +683,51 @@ func (p *AnthropicProvider) streamCall(ctx context.Context, messages []Message,
 	// Start goroutine to process stream
 	go func() {
 		defer close(ch)
+		defer closeOnDone(ctx, respBody)()
+		out := chunkSender{ctx: ctx, ch: ch}
 
-		var totalInputTokens, totalOutputTokens int
+		var usage AnthropicUsage
+		var stopReason string
+		_, schemaTool := anthropicSchemaTool(cfg)
 
 		err := parseSSEStream(respBody, func(msg SSEMessage) error {
-			return processAnthropicSSEMessage(msg, ch, &totalInputTokens, &totalOutputTokens)
+			return processAnthropicSSEMessage(msg, out, &usage, &stopReason, schemaTool)
 		})
 
 		if err != nil {
-			ch <- StreamChunk{Error: fmt.Errorf("SSE stream error: %w", err)}
+			out.fail(fmt.Errorf("SSE stream error: %w", err))
 		}
 	}()
 
 	return &StreamResponse{Stream: ch}, nil
 }
 
+// anthropicFinishReason maps an Anthropic stop_reason to the common finish reasons
+func anthropicFinishReason(stopReason string) string {
+	switch stopReason {
+	case "end_turn", "stop_sequence":
+		return FinishStop
+	case "max_tokens", "model_context_window_exceeded":
+		return FinishLength
+	case "tool_use":
+		return FinishToolCalls
+	case "refusal":
+		return FinishContentFilter
+	}
+	return stopReason
+}
+
+// anthropicStreamFinish maps the stop reason of a stream; the call of the schema tool
+// is the answer, not a tool call
+func anthropicStreamFinish(stopReason string, schemaTool bool) string {
+	if schemaTool && stopReason == "tool_use" {
+		return FinishStop
+	}
+	return anthropicFinishReason(stopReason)
+}
+
 // processAnthropicSSEMessage processes individual Anthropic SSE messages
-func processAnthropicSSEMessage(msg SSEMessage, ch chan StreamChunk, totalInputTokens, totalOutputTokens *int) error {
+func processAnthropicSSEMessage(msg SSEMessage, out chunkSender, usage *AnthropicUsage, stopReason *string, schemaTool bool) error {
 	if len(msg.Data) == 0 {
 		return nil
 	}
@@ -325,8 +740,7 @@ func processAnthropicSSEMessage(msg SSEMessage, ch chan StreamChunk, totalInputT
 			return fmt.Errorf("json parse error for message_start: %w", err)
 		}
 		// Store initial token counts
-		*totalInputTokens = messageStart.Message.Usage.InputTokens
-		*totalOutputTokens = messageStart.Message.Usage.OutputTokens
+		*usage = messageStart.Message.Usage
 
 	case "content_block_start":
 		// Content block started, no action needed
@@ -336,10 +750,10 @@ func processAnthropicSSEMessage(msg SSEMessage, ch chan StreamChunk, totalInputT
 		if err := json.Unmarshal(msg.Data, &contentDelta); err != nil {
 			return fmt.Errorf("json parse error for content_block_delta: %w", err)
 		}
-		// Send the text delta
-		if contentDelta.Delta.Type == "text_delta" && contentDelta.Delta.Text != "" {
-			ch <- StreamChunk{
-				Data: contentDelta.Delta.Text,
+		// Send the text or thinking delta
+		if chunk, ok := contentDelta.chunk(); ok {
+			if err := out.send(chunk); err != nil {
+				return err
 			}
 		}
 
@@ -353,17 +767,19 @@ func processAnthropicSSEMessage(msg SSEMessage, ch chan StreamChunk, totalInputT
 		}
 		// Update output token count if provided
 		if messageDelta.Usage != nil {
-			*totalOutputTokens = messageDelta.Usage.OutputTokens
+			usage.OutputTokens = messageDelta.Usage.OutputTokens
+		}
+		if messageDelta.Delta.StopReason != nil {
+			*stopReason = *messageDelta.Delta.StopReason
 		}
 
 	case "message_stop":
 		// Send final metadata
-		meta := Metadata{
-			"input_tokens":  *totalInputTokens,
-			"output_tokens": *totalOutputTokens,
-		}
-		ch <- StreamChunk{
+		meta := anthropicMetadata(*usage, *stopReason, anthropicStreamFinish(*stopReason, schemaTool))
+		if err := out.send(StreamChunk{
 			Meta: &meta,
+		}); err != nil {
+			return err
 		}
 
 	case "ping":
@@ -381,9 +797,9 @@ func processAnthropicSSEMessage(msg SSEMessage, ch chan StreamChunk, totalInputT
 		case "content_block_delta":
 			var contentDelta AnthropicContentBlockDelta
 			if err := json.Unmarshal(msg.Data, &contentDelta); err == nil {
-				if contentDelta.Delta.Type == "text_delta" && contentDelta.Delta.Text != "" {
-					ch <- StreamChunk{
-						Data: contentDelta.Delta.Text,
+				if chunk, ok := contentDelta.chunk(); ok {
+					if err := out.send(chunk); err != nil {
+						return err
 					}
 				}
 			}
@@ -391,16 +807,18 @@ func processAnthropicSSEMessage(msg SSEMessage, ch chan StreamChunk, totalInputT
 			var messageDelta AnthropicMessageDelta
 			if err := json.Unmarshal(msg.Data, &messageDelta); err == nil {
 				if messageDelta.Usage != nil {
-					*totalOutputTokens = messageDelta.Usage.OutputTokens
+					usage.OutputTokens = messageDelta.Usage.OutputTokens
+				}
+				if messageDelta.Delta.StopReason != nil {
+					*stopReason = *messageDelta.Delta.StopReason
 				}
 			}
 		case "message_stop":
-			meta := Metadata{
-				"input_tokens":  *totalInputTokens,
-				"output_tokens": *totalOutputTokens,
-			}
-			ch <- StreamChunk{
+			meta := anthropicMetadata(*usage, *stopReason, anthropicStreamFinish(*stopReason, schemaTool))
+			if err := out.send(StreamChunk{
 				Meta: &meta,
+			}); err != nil {
+				return err
 			}
 		}
 	}
@@ -414,6 +832,12 @@ func (p *AnthropicProvider) getEmbeddings(ctx context.Context, text string, cfg
 	return nil, fmt.Errorf("Anthropic does not support embeddings API")
 }
 
+// getMultimodalEmbeddings implements the provider interface for Anthropic
+// Note: Anthropic does not support multimodal embeddings
+func (p *AnthropicProvider) getMultimodalEmbeddings(ctx context.Context, parts []Part, cfg CallConfig) (*EmbeddingResponse, error) {
+	return nil, fmt.Errorf("Anthropic does not support multimodal embeddings")
+}
+
 // reRank implements the provider interface for Anthropic
 // Note: Anthropic does not currently support reranking API
 func (p *AnthropicProvider) reRank(ctx context.Context, query string, documents []string, cfg CallConfig) (*RerankResponse, error) {
@@ -490,15 +914,10 @@ func (p *AnthropicProvider) buildCompletionRequest(ctx context.Context, req *Com
 	anthropicReq := AnthropicRequest{
 		Model:       req.Model,
 		Temperature: req.Temperature,
-		MaxTokens:   4096, // Default
+		MaxTokens:   anthropicMaxTokens(req.Model, req.MaxTokens),
 		Stream:      req.Stream,
 	}
 
-	// Override max tokens if provided
-	if req.MaxTokens != nil {
-		anthropicReq.MaxTokens = *req.MaxTokens
-	}
-
 	// Convert messages
 	var systemMsg string
 	anthropicReq.Messages = make([]AnthropicMessage, 0, len(req.Messages))
@@ -524,10 +943,7 @@ func (p *AnthropicProvider) buildCompletionRequest(ctx context.Context, req *Com
 
 	// Make the API call
 	var anthropicResp AnthropicResponse
-	err := callHTTPAPI(ctx, baseURL, func(httpReq *http.Request) {
-		httpReq.Header.Set("anthropic-version", "2023-06-01")
-		httpReq.Header.Set("x-api-key", p.Key)
-	}, anthropicReq, &anthropicResp)
+	err := callHTTPAPI(ctx, cfg, baseURL, p.requestInit(cfg), anthropicReq, &anthropicResp)
 	if err != nil {
 		return nil, fmt.Errorf("Anthropic API call failed: %w", err)
 	}
@@ -564,7 +980,7 @@ func (p *AnthropicProvider) buildCompletionRequest(ctx context.Context, req *Com
 	completionResp.Choices[0].Index = 0
 	completionResp.Choices[0].Message.Role = "assistant"
 	completionResp.Choices[0].Message.Content = text
-	completionResp.Choices[0].FinishReason = anthropicResp.StopReason
+	completionResp.Choices[0].FinishReason = anthropicFinishReason(anthropicResp.StopReason)
 
 	// Add usage information
 	completionResp.Usage = &struct {
@@ -598,6 +1014,11 @@ func (p *AnthropicProvider) writeCompletionResponse(w http.ResponseWriter, resp
 	return json.NewEncoder(w).Encode(resp)
 }
 
+// writeCompletionStream writes a streamed completion as Anthropic messages events
+func (p *AnthropicProvider) writeCompletionStream(w http.ResponseWriter, model string, stream *StreamResponse) error {
+	return writeAnthropicStream(w, model, stream)
+}
+
 // writeEmbeddingResponse writes a UnifiedEmbeddingResponse as JSON to the HTTP response writer
 // Anthropic does not support embeddings, so this returns an error
 func (p *AnthropicProvider) writeEmbeddingResponse(w http.ResponseWriter, resp *UnifiedEmbeddingResponse) error {
@@ -0,0 +1,262 @@
+package echo
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Statuses of async jobs
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+)
+
+// AsyncJob is the state of a completion executed in the background, POSTed to the callback URL
+// and kept in the JobStore
+type AsyncJob struct {
+	ID        string              `json:"id"`
+	Status    string              `json:"status"`
+	Output    string              `json:"output,omitempty"` // text generated so far
+	Response  *CompletionResponse `json:"response,omitempty"`
+	Error     string              `json:"error,omitempty"`
+	UserID    string              `json:"user_id,omitempty"` // end user set by WithUserID, see DeleteUserData
+	UpdatedAt time.Time           `json:"updated_at"`
+	Sealed    []byte              `json:"sealed,omitempty"` // encrypted Output, Response and Error, see StoreCipher
+}
+
+// SignatureHeader carries the HMAC-SHA256 of the callback body, as "sha256=<hex>"
+const SignatureHeader = "X-Echo-Signature"
+
+// callbackAttempts is the number of times a callback is sent when the receiver fails
+const callbackAttempts = 3
+
+// callbackRetryDelay is the delay before the second callback attempt, doubled for each next one
+var callbackRetryDelay = time.Second
+
+// jobProgressInterval limits how often the partial output of a running job is saved
+var jobProgressInterval = 500 * time.Millisecond
+
+// ExecCompleteAsync implements the ProxyClient interface.
+// The request is executed in the background; the finished AsyncJob is POSTed to the callback URL,
+// signed with the secret set by WithCallbackSecret, and kept in the store set by WithJobStore,
+// along with the partial output while the job runs. The callback URL can be empty when a store is set.
+// The model is taken from the call options, as for ExecComplete. Returns the job ID.
+func (c *CommonClient) ExecCompleteAsync(ctx context.Context, req *CompletionRequest, callbackURL string, opts ...CallOption) (string, error) {
+	cfg := c.baseConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if callbackURL == "" {
+		if cfg.JobStore == nil {
+			return "", fmt.Errorf("callback URL or job store is required")
+		}
+	} else if u, err := url.Parse(callbackURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid callback URL: %s", callbackURL)
+	}
+
+	messages, opts, err := completionCall(req, opts)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return "", err
+	}
+	job := AsyncJob{ID: id, Status: JobPending, UserID: cfg.UserID, UpdatedAt: time.Now()}
+	if cfg.JobStore != nil {
+		if err := cfg.JobStore.Save(ctx, job); err != nil {
+			return "", fmt.Errorf("failed to save job: %w", err)
+		}
+	}
+
+	// The job outlives the request that started it
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		job := c.runJob(ctx, job, req.Model, messages, cfg.JobStore, opts)
+		if cfg.JobStore != nil {
+			cfg.JobStore.Save(ctx, job)
+		}
+		if callbackURL != "" {
+			sendCallback(ctx, cfg, callbackURL, job)
+		}
+	}()
+
+	return id, nil
+}
+
+// runJob streams the completion, saving the partial output to the store, and returns the finished job
+func (c *CommonClient) runJob(ctx context.Context, job AsyncJob, model string, messages []Message, store JobStore, opts []CallOption) AsyncJob {
+	fail := func(err error) AsyncJob {
+		job.Status, job.Error, job.UpdatedAt = JobFailed, err.Error(), time.Now()
+		return job
+	}
+
+	stream, err := c.StreamComplete(ctx, messages, opts...)
+	if err != nil {
+		return fail(err)
+	}
+
+	job.Status = JobRunning
+	meta := Metadata{}
+	var output strings.Builder
+	for chunk := range stream.Stream {
+		if chunk.Error != nil {
+			drain(stream)
+			return fail(chunk.Error)
+		}
+		if chunk.Meta != nil {
+			maps.Copy(meta, *chunk.Meta)
+		}
+		output.WriteString(chunk.Data)
+
+		if store != nil && time.Since(job.UpdatedAt) >= jobProgressInterval {
+			job.Output, job.UpdatedAt = output.String(), time.Now()
+			store.Save(ctx, job)
+		}
+	}
+
+	job.Status, job.Output, job.UpdatedAt = JobCompleted, output.String(), time.Now()
+	job.Response = completionResponse(model, job.Output, meta)
+	return job
+}
+
+// completionCall converts a proxied completion request to the messages and options of a client call
+func completionCall(req *CompletionRequest, opts []CallOption) ([]Message, []CallOption, error) {
+	data, err := json.Marshal(req.Messages)
+	if err != nil {
+		return nil, nil, err
+	}
+	messages, err := FromOpenAIMessages(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts = slices.Clip(opts)
+	if req.Temperature != nil {
+		opts = append(opts, WithTemperature(*req.Temperature))
+	}
+	if req.MaxTokens != nil {
+		opts = append(opts, WithMaxTokens(*req.MaxTokens))
+	}
+	return messages, opts, nil
+}
+
+// completionResponse builds the proxy response of a streamed completion from its text and metadata
+func completionResponse(model, content string, meta Metadata) *CompletionResponse {
+	resp := &CompletionResponse{Object: "chat.completion", Model: model}
+	resp.Choices = make([]struct {
+		Index   int `json:"index"`
+		Message struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason,omitempty"`
+	}, 1)
+	resp.Choices[0].Message.Role = "assistant"
+	resp.Choices[0].Message.Content = content
+	resp.Choices[0].FinishReason, _ = meta["finish_reason"].(string)
+
+	// Providers report usage as prompt/completion or input/output tokens
+	prompt, okPrompt := metaInt(meta, "prompt_tokens", "input_tokens")
+	completion, okCompletion := metaInt(meta, "completion_tokens", "output_tokens")
+	if okPrompt || okCompletion {
+		resp.Usage = &struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+			TotalTokens      int `json:"total_tokens"`
+		}{prompt, completion, prompt + completion}
+	}
+	return resp
+}
+
+// metaInt returns the first integer value found under the keys. Metadata decoded
+// from JSON, e.g. of stored responses, holds numbers as float64.
+func metaInt(meta Metadata, keys ...string) (int, bool) {
+	for _, key := range keys {
+		switch v := meta[key].(type) {
+		case int:
+			return v, true
+		case float64:
+			return int(v), true
+		}
+	}
+	return 0, false
+}
+
+// newJobID returns a random job identifier
+func newJobID() (string, error) {
+	return randomID("job_")
+}
+
+// randomID returns the prefix followed by 32 random hex digits
+func randomID(prefix string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate id: %w", err)
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+// sendCallback POSTs the job to the callback URL, repeating the request when it fails
+func sendCallback(ctx context.Context, cfg CallConfig, callbackURL string, job AsyncJob) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	delay := callbackRetryDelay
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", callbackURL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", cfg.userAgent())
+		req.Header.Set("X-Echo-Job-ID", job.ID)
+		if cfg.CallbackSecret != "" {
+			req.Header.Set(SignatureHeader, SignCallback(body, cfg.CallbackSecret))
+		}
+
+		resp, err := cfg.httpClient().Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode < 300 {
+				return nil
+			}
+			err = fmt.Errorf("callback status code: %d", resp.StatusCode)
+		}
+		if attempt >= callbackAttempts {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// SignCallback returns the signature of a callback body, as sent in the SignatureHeader
+func SignCallback(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyCallback checks the signature of a received callback body in constant time
+func VerifyCallback(body []byte, signature, secret string) bool {
+	return hmac.Equal([]byte(SignCallback(body, secret)), []byte(signature))
+}
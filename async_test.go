@@ -0,0 +1,81 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExecCompleteAsync(t *testing.T) {
+	defer func(delay time.Duration) { callbackRetryDelay = delay }(callbackRetryDelay)
+	callbackRetryDelay = time.Millisecond
+
+	var calls atomic.Int32
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first delivery fails and is repeated
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	c, _ := NewCommonClient(nil, WithModel("mock/test"), WithCallbackSecret("secret"))
+	client := c.(ProxyClient)
+	req := &CompletionRequest{Model: "mock/test", Messages: []OpenAIMessage{{Role: "user", Content: "Hello"}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	id, err := client.ExecCompleteAsync(ctx, req, server.URL)
+	cancel() // the job outlives the request context
+	if err != nil {
+		t.Fatalf("ExecCompleteAsync() error = %v", err)
+	}
+	if !strings.HasPrefix(id, "job_") {
+		t.Errorf("Unexpected job id %q", id)
+	}
+
+	var r *http.Request
+	var body []byte
+	select {
+	case r = <-received:
+		body = <-bodies
+	case <-time.After(5 * time.Second):
+		t.Fatal("Callback was not delivered")
+	}
+
+	if r.Header.Get("X-Echo-Job-ID") != id {
+		t.Errorf("Expected job id header %q, got %q", id, r.Header.Get("X-Echo-Job-ID"))
+	}
+	if !VerifyCallback(body, r.Header.Get(SignatureHeader), "secret") {
+		t.Errorf("Signature %q does not match the body", r.Header.Get(SignatureHeader))
+	}
+	if VerifyCallback(body, r.Header.Get(SignatureHeader), "other") {
+		t.Error("Signature matches a different secret")
+	}
+
+	var job AsyncJob
+	if err := json.Unmarshal(body, &job); err != nil {
+		t.Fatalf("Invalid callback body: %v", err)
+	}
+	if job.ID != id || job.Status != JobCompleted || job.Response == nil {
+		t.Fatalf("Unexpected job %+v", job)
+	}
+	if content := job.Response.Choices[0].Message.Content; content != "[user]: Hello" {
+		t.Errorf("Unexpected content %q", content)
+	}
+
+	if _, err := client.ExecCompleteAsync(context.Background(), req, "ftp://example.com"); err == nil {
+		t.Error("Expected error for a non-HTTP callback URL")
+	}
+}
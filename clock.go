@@ -0,0 +1,33 @@
+package echo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// timeNow returns the current time for WithCurrentTime, replaced in tests
+var timeNow = time.Now
+
+// localeTag matches BCP 47 language tags such as "en", "de-AT" or "zh-Hant-TW"
+var localeTag = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// timeInstruction tells the model the current date, time and timezone,
+// which it can't know from its training data
+func timeInstruction(now time.Time) string {
+	return fmt.Sprintf("Current date and time: %s (%s). Use it for anything that depends on the current date.",
+		now.Format(time.RFC3339), now.Format("Monday, January 2, 2006, 15:04 MST"))
+}
+
+// localeInstruction tells the model the locale of the user
+func localeInstruction(tag string) (string, error) {
+	if !localeTag.MatchString(tag) {
+		return "", fmt.Errorf("invalid locale tag: %q", tag)
+	}
+	lang, _, _ := strings.Cut(strings.ToLower(tag), "-")
+	if name, ok := languageNames[lang]; ok {
+		tag += ", " + name
+	}
+	return "User locale: " + tag + ". Format dates, times, numbers and currencies according to this locale.", nil
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mkozhukh/echo"
+)
+
+// benchResult sums up the runs of one model, durations are in milliseconds
+type benchResult struct {
+	Model        string  `json:"model"`
+	Runs         int     `json:"runs"`
+	Errors       int     `json:"errors"`
+	TTFTMedian   float64 `json:"ttft_p50_ms"`
+	TTFTMean     float64 `json:"ttft_avg_ms"`
+	LatencyMed   float64 `json:"latency_p50_ms"`
+	LatencyMean  float64 `json:"latency_avg_ms"`
+	TokensPerSec float64 `json:"tokens_per_sec"`
+	CostPerRun   float64 `json:"cost_per_run,omitempty"`   // USD, reported by OpenRouter or estimated from list prices
+	CostEstimate bool    `json:"cost_estimated,omitempty"` // the cost is computed from the token usage at list prices
+	LastError    string  `json:"last_error,omitempty"`
+}
+
+// benchRun is the measurement of a single call
+type benchRun struct {
+	ttft, latency time.Duration
+	usage         echo.Usage
+}
+
+// runBench implements "ec bench": it streams the same prompt to each model several times
+// and prints time to first token, throughput, latency and cost side by side
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	models := fs.String("models", "", "Comma-separated models to compare, e.g. openai/gpt-5,anthropic/balanced")
+	promptFile := fs.String("prompt-file", "", "File with the prompt, the arguments are used when not set")
+	runs := fs.Int("runs", 10, "Calls per model")
+	maxTokens := fs.Int("max-tokens", 1000, "Output limit of each call")
+	asJSON := fs.Bool("json", false, "Print the results as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: ec bench --models a,b,c [--prompt-file p.txt] [--runs 10] [--json] [message...]")
+		fmt.Fprintln(os.Stderr, "The cost is reported by OpenRouter; for other providers it is estimated from list prices")
+		fmt.Fprintln(os.Stderr, "without cache discounts (shown as ~$), and left out for models with unknown prices.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	prompt := strings.Join(fs.Args(), " ")
+	if *promptFile != "" {
+		data, err := os.ReadFile(*promptFile)
+		if err != nil {
+			return err
+		}
+		prompt = string(data)
+	}
+	if strings.TrimSpace(prompt) == "" || *models == "" || *runs < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var refs []echo.ModelRef
+	for _, name := range strings.Split(*models, ",") {
+		var ref echo.ModelRef
+		if err := ref.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+			return err
+		}
+		if err := ref.Validate(); err != nil {
+			return err
+		}
+		refs = append(refs, ref)
+	}
+
+	client, err := echo.NewCommonClient(nil, echo.WithMaxTokens(*maxTokens))
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+
+	ctx := context.Background()
+	var results []benchResult
+	for _, ref := range refs {
+		result := benchResult{Model: ref.String(), Runs: *runs}
+		var measured []benchRun
+		for i := 0; i < *runs; i++ {
+			run, err := benchCall(ctx, client, prompt, ref)
+			if err != nil {
+				result.Errors++
+				result.LastError = err.Error()
+				continue
+			}
+			measured = append(measured, run)
+		}
+		info, _ := echo.LookupModel(ref.String())
+		summarize(&result, measured, info)
+		results = append(results, result)
+		fmt.Fprintf(os.Stderr, "%s: %d runs, %d errors\n", result.Model, result.Runs, result.Errors)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	printBenchTable(results)
+	return nil
+}
+
+// benchCall streams one answer, timing the first chunk of text and the end of the stream
+func benchCall(ctx context.Context, client echo.Client, prompt string, ref echo.ModelRef) (benchRun, error) {
+	start := time.Now()
+	stream, err := client.StreamComplete(ctx, echo.QuickMessage(prompt), echo.WithModelRef(ref))
+	if err != nil {
+		return benchRun{}, err
+	}
+
+	var run benchRun
+	var text strings.Builder
+	meta := echo.Metadata{}
+	for chunk := range stream.Stream {
+		if chunk.Error != nil {
+			return benchRun{}, chunk.Error
+		}
+		if chunk.Data != "" && run.ttft == 0 {
+			run.ttft = time.Since(start)
+		}
+		text.WriteString(chunk.Data)
+		if chunk.Meta != nil {
+			for k, v := range *chunk.Meta {
+				meta[k] = v
+			}
+		}
+	}
+	run.latency = time.Since(start)
+	run.usage = (&echo.Response{Metadata: meta}).Usage()
+
+	// Providers that don't report usage in streams get an estimate from the texts
+	if run.usage.PromptTokens == 0 {
+		run.usage.PromptTokens = echo.EstimateTokens(prompt)
+	}
+	if run.usage.CompletionTokens == 0 {
+		run.usage.CompletionTokens = echo.EstimateTokens(text.String())
+	}
+	return run, nil
+}
+
+// summarize fills the statistics of the successful runs, info gives the prices
+// for the runs without a reported cost
+func summarize(result *benchResult, runs []benchRun, info echo.ModelInfo) {
+	if len(runs) == 0 {
+		return
+	}
+	var ttfts, latencies []float64
+	var tps, cost float64
+	for _, run := range runs {
+		ttfts = append(ttfts, ms(run.ttft))
+		latencies = append(latencies, ms(run.latency))
+
+		if generation := run.latency - run.ttft; generation > 0 {
+			tps += float64(run.usage.CompletionTokens) / generation.Seconds()
+		}
+		if run.usage.Cost > 0 {
+			cost += run.usage.Cost
+		} else if estimate, ok := info.Cost(run.usage); ok {
+			cost += estimate
+			result.CostEstimate = true
+		}
+	}
+	n := float64(len(runs))
+	result.TTFTMedian, result.TTFTMean = median(ttfts), mean(ttfts)
+	result.LatencyMed, result.LatencyMean = median(latencies), mean(latencies)
+	result.TokensPerSec = tps / n
+	result.CostPerRun = cost / n
+}
+
+func printBenchTable(results []benchResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "MODEL\tRUNS\tERRORS\tTTFT p50\tTTFT avg\tLATENCY p50\tLATENCY avg\tTOKENS/S\tCOST/RUN\t")
+	for _, r := range results {
+		cost := "-"
+		if r.CostEstimate {
+			cost = fmt.Sprintf("~$%.5f", r.CostPerRun)
+		} else if r.CostPerRun > 0 {
+			cost = fmt.Sprintf("$%.5f", r.CostPerRun)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.0fms\t%.0fms\t%.0fms\t%.0fms\t%.1f\t%s\t\n",
+			r.Model, r.Runs, r.Errors, r.TTFTMedian, r.TTFTMean, r.LatencyMed, r.LatencyMean, r.TokensPerSec, cost)
+	}
+	w.Flush()
+
+	for _, r := range results {
+		if r.LastError != "" {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", r.Model, r.LastError)
+		}
+	}
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func median(values []float64) float64 {
+	sorted := slices.Sorted(slices.Values(values))
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
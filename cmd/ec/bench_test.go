@@ -0,0 +1,82 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mkozhukh/echo"
+)
+
+func TestMedianMean(t *testing.T) {
+	tests := []struct {
+		values       []float64
+		median, mean float64
+	}{
+		{[]float64{5}, 5, 5},
+		{[]float64{9, 1, 5}, 5, 5},
+		{[]float64{4, 1, 3, 10}, 3.5, 4.5},
+	}
+	for _, tt := range tests {
+		if got := median(tt.values); got != tt.median {
+			t.Errorf("median(%v) = %v, want %v", tt.values, got, tt.median)
+		}
+		if got := mean(tt.values); got != tt.mean {
+			t.Errorf("mean(%v) = %v, want %v", tt.values, got, tt.mean)
+		}
+	}
+
+	// The input is not reordered
+	values := []float64{3, 1, 2}
+	median(values)
+	if values[0] != 3 || values[1] != 1 {
+		t.Errorf("median() changed the input to %v", values)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	runs := []benchRun{
+		{ttft: 100 * time.Millisecond, latency: 1100 * time.Millisecond, usage: echo.Usage{PromptTokens: 1000, CompletionTokens: 50}},
+		{ttft: 300 * time.Millisecond, latency: 2300 * time.Millisecond, usage: echo.Usage{PromptTokens: 1000, CompletionTokens: 300}},
+		{ttft: 200 * time.Millisecond, latency: 1200 * time.Millisecond, usage: echo.Usage{PromptTokens: 1000, CompletionTokens: 100}},
+	}
+	info := echo.ModelInfo{InputPrice: 2, OutputPrice: 10}
+
+	var result benchResult
+	summarize(&result, runs, info)
+	if result.TTFTMedian != 200 || result.TTFTMean != 200 || result.LatencyMed != 1200 || math.Abs(result.LatencyMean-1533.333) > 0.001 {
+		t.Errorf("Unexpected timings %+v", result)
+	}
+	// 50, 150 and 100 tokens per second of generation
+	if result.TokensPerSec != 100 {
+		t.Errorf("TokensPerSec = %v, want 100", result.TokensPerSec)
+	}
+	// 2000 µ$ for the prompt and 1500 µ$ on average for the output
+	if !result.CostEstimate || math.Abs(result.CostPerRun-0.0035) > 1e-12 {
+		t.Errorf("CostPerRun = %v, estimated %v", result.CostPerRun, result.CostEstimate)
+	}
+
+	// A reported cost is used as is
+	for i := range runs {
+		runs[i].usage.Cost = 0.01
+	}
+	result = benchResult{}
+	summarize(&result, runs, info)
+	if result.CostEstimate || math.Abs(result.CostPerRun-0.01) > 1e-12 {
+		t.Errorf("CostPerRun = %v, estimated %v", result.CostPerRun, result.CostEstimate)
+	}
+
+	// Unknown prices and no runs leave the cost out
+	result = benchResult{}
+	summarize(&result, runs[:0], info)
+	if result != (benchResult{}) {
+		t.Errorf("Expected no statistics without runs, got %+v", result)
+	}
+	for i := range runs {
+		runs[i].usage.Cost = 0
+	}
+	summarize(&result, runs, echo.ModelInfo{})
+	if result.CostPerRun != 0 || result.CostEstimate {
+		t.Errorf("Expected no cost for unknown prices, got %+v", result)
+	}
+}
@@ -11,9 +11,10 @@ import (
 )
 
 func main() {
-	var model, prompt string
+	var prompt string
+	var model echo.ModelRef
 	flag.StringVar(&prompt, "prompt", "", "Prompt to send to the model")
-	flag.StringVar(&model, "model", "", "Model in format provider/model-name")
+	flag.TextVar(&model, "model", echo.ModelRef{}, "Model in format provider/model-name")
 	flag.Parse()
 
 	if flag.NArg() < 1 {
@@ -21,8 +22,17 @@ func main() {
 		os.Exit(1)
 	}
 
-	if model == "" {
-		model = os.Getenv("ECHO_MODEL")
+	if model == (echo.ModelRef{}) {
+		if err := model.UnmarshalText([]byte(os.Getenv("ECHO_MODEL"))); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid ECHO_MODEL: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if model != (echo.ModelRef{}) {
+		if err := model.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid model: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
 	message := strings.Join(flag.Args(), " ")
@@ -35,8 +45,8 @@ func main() {
 	if prompt != "" {
 		options = append(options, echo.WithSystemMessage(prompt))
 	}
-	if model != "" {
-		options = append(options, echo.WithModel(model))
+	if model != (echo.ModelRef{}) {
+		options = append(options, echo.WithModelRef(model))
 	}
 
 	client, err = echo.NewCommonClient(nil, options...)
@@ -0,0 +1,70 @@
+package echo
+
+import "strings"
+
+// CodeBlock is a fenced code block of a markdown text
+type CodeBlock struct {
+	Lang string // language from the info string, e.g. "go"; empty when not given
+	Code string // content of the block, without the fences
+}
+
+// ExtractCodeBlocks returns the fenced (``` or ~~~) code blocks of the text in order.
+// A block left open at the end of the text, e.g. in an answer cut at the token limit,
+// is returned as well.
+func ExtractCodeBlocks(text string) []CodeBlock {
+	var blocks []CodeBlock
+	var fence string
+	var lang string
+	var code []string
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimRight(strings.TrimLeft(line, " "), "\r")
+		if fence == "" {
+			if f := codeFence(trimmed); f != "" && len(line)-len(strings.TrimLeft(line, " ")) <= 3 {
+				fence = f
+				lang, _, _ = strings.Cut(strings.TrimSpace(trimmed[len(f):]), " ")
+				code = code[:0]
+			}
+			continue
+		}
+
+		// The closing fence uses the same character and is at least as long as the opening one
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			blocks = append(blocks, CodeBlock{Lang: lang, Code: strings.Join(code, "\n")})
+			fence = ""
+			continue
+		}
+		code = append(code, strings.TrimRight(line, "\r"))
+	}
+
+	if fence != "" {
+		blocks = append(blocks, CodeBlock{Lang: lang, Code: strings.Join(code, "\n")})
+	}
+	return blocks
+}
+
+// ExtractFirstCode returns the content of the first code block in the given language,
+// compared case-insensitively. An empty lang matches any block.
+func ExtractFirstCode(text, lang string) (string, bool) {
+	for _, block := range ExtractCodeBlocks(text) {
+		if lang == "" || strings.EqualFold(block.Lang, lang) {
+			return block.Code, true
+		}
+	}
+	return "", false
+}
+
+// codeFence returns the opening fence at the start of the line, or an empty string
+func codeFence(line string) string {
+	if len(line) < 3 || (line[0] != '`' && line[0] != '~') {
+		return ""
+	}
+	n := 0
+	for n < len(line) && line[n] == line[0] {
+		n++
+	}
+	if n < 3 || (line[0] == '`' && strings.Contains(line[n:], "`")) {
+		return ""
+	}
+	return line[:n]
+}
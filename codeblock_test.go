@@ -0,0 +1,37 @@
+package echo
+
+import "testing"
+
+func TestExtractCodeBlocks(t *testing.T) {
+	text := "Here is the fix:\n\n```go\nfunc main() {\n\tfmt.Println(\"```\")\n}\n```\n\nAnd the config:\n" +
+		"~~~~yaml title=config.yml\nkey: value\n~~~~\n\n```\nplain\n````\n\n```Python\nprint(1)"
+
+	blocks := ExtractCodeBlocks(text)
+	if len(blocks) != 4 {
+		t.Fatalf("Expected 4 blocks, got %d: %+v", len(blocks), blocks)
+	}
+	expected := []CodeBlock{
+		{Lang: "go", Code: "func main() {\n\tfmt.Println(\"```\")\n}"},
+		{Lang: "yaml", Code: "key: value"},
+		{Lang: "", Code: "plain"},
+		{Lang: "Python", Code: "print(1)"},
+	}
+	for i, block := range blocks {
+		if block != expected[i] {
+			t.Errorf("Block %d = %+v, expected %+v", i, block, expected[i])
+		}
+	}
+
+	if code, ok := ExtractFirstCode(text, "python"); !ok || code != "print(1)" {
+		t.Errorf("ExtractFirstCode(python) = %q, %v", code, ok)
+	}
+	if code, ok := ExtractFirstCode(text, ""); !ok || code != expected[0].Code {
+		t.Errorf("ExtractFirstCode() = %q, %v", code, ok)
+	}
+	if _, ok := ExtractFirstCode(text, "rust"); ok {
+		t.Error("Expected no rust block")
+	}
+	if blocks := ExtractCodeBlocks("no code, only `inline` code"); len(blocks) != 0 {
+		t.Errorf("Expected no blocks, got %+v", blocks)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 )
 
 // provider interface for internal provider implementations
@@ -13,7 +14,10 @@ type Provider interface {
 	call(ctx context.Context, messages []Message, cfg CallConfig) (*Response, error)
 	streamCall(ctx context.Context, messages []Message, cfg CallConfig) (*StreamResponse, error)
 	getEmbeddings(ctx context.Context, text string, cfg CallConfig) (*EmbeddingResponse, error)
+	getMultimodalEmbeddings(ctx context.Context, parts []Part, cfg CallConfig) (*EmbeddingResponse, error)
 	reRank(ctx context.Context, query string, documents []string, cfg CallConfig) (*RerankResponse, error)
+	// validateCompletion builds the completion request without sending it
+	validateCompletion(messages []Message, cfg CallConfig) error
 
 	// Parse HTTP requests into unified request structures
 	parseCompletionRequest(req *http.Request) (*CompletionRequest, error)
@@ -27,6 +31,7 @@ type Provider interface {
 
 	// Write methods - write unified responses back as HTTP responses
 	writeCompletionResponse(w http.ResponseWriter, resp *CompletionResponse) error
+	writeCompletionStream(w http.ResponseWriter, model string, stream *StreamResponse) error
 	writeEmbeddingResponse(w http.ResponseWriter, resp *UnifiedEmbeddingResponse) error
 	writeRerankResponse(w http.ResponseWriter, resp *UnifiedRerankResponse) error
 }
@@ -36,6 +41,7 @@ type CommonClient struct {
 	apiKey      string
 	baseConfig  CallConfig
 	providerMap map[string]Provider
+	mu          sync.RWMutex // guards providerMap
 }
 
 // NewCommonClient creates a new CommonClient instance
@@ -46,6 +52,12 @@ func NewClient(opts ...CallOption) (Client, error) {
 		opt(&cfg)
 	}
 
+	// Each client keeps its own pool of connections to provider hosts
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = newHTTPClient(cfg.Transport)
+	}
+	cfg.quota = newQuotaTracker()
+
 	// Initialize client with provider map
 	client := &CommonClient{
 		baseConfig:  cfg,
@@ -55,10 +67,22 @@ func NewClient(opts ...CallOption) (Client, error) {
 	return client, nil
 }
 
+// SetProvider registers or replaces a provider. It is safe to call while other calls are
+// in progress; calls that already resolved their provider keep using the previous one.
 func (c *CommonClient) SetProvider(name string, provider Provider) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.providerMap[name] = provider
 }
 
+// provider returns the provider registered under the name
+func (c *CommonClient) provider(name string) (Provider, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	p, ok := c.providerMap[name]
+	return p, ok
+}
+
 type providerRetriver func(string) Provider
 
 var knownProviders = map[string]providerRetriver{
@@ -97,7 +121,7 @@ func NewCommonClient(keys map[string]string, opts ...CallOption) (Client, error)
 }
 
 // prepareCall resolves provider, model, and configuration for a call
-func (c *CommonClient) prepareCall(opts ...CallOption) (Provider, CallConfig, error) {
+func (c *CommonClient) prepareCall(ctx context.Context, opts ...CallOption) (Provider, CallConfig, error) {
 	// Merge configs
 	cfg := c.baseConfig
 	for _, opt := range opts {
@@ -105,28 +129,65 @@ func (c *CommonClient) prepareCall(opts ...CallOption) (Provider, CallConfig, er
 	}
 
 	// Resolve provider and model
-	providerName, resolvedModel, endpoint, err := c.resolveProviderAndModel(cfg.Model)
+	ref, err := c.resolveProviderAndModel(cfg.Model)
 	if err != nil {
 		return nil, cfg, err
 	}
 
 	// Update config with resolved model
-	cfg.Model = resolvedModel
-	cfg.EndPoint = endpoint
+	cfg.Model = ref.Model
+	if ref.Endpoint != "" {
+		cfg.EndPoint = ref.Endpoint
+	}
+	cfg.provider = ref.Provider
+	if cfg.Logger != nil {
+		warnDeprecated(cfg.Logger, cfg.modelRef().String())
+	}
 
 	// Get provider
-	p, ok := c.providerMap[providerName]
+	p, ok := c.provider(ref.Provider)
 	if !ok {
-		return nil, cfg, fmt.Errorf("unknown provider: %s", providerName)
+		return nil, cfg, fmt.Errorf("unknown provider: %s", ref.Provider)
 	}
 
 	// Special handling for openrouter
-	if providerName == "openrouter" {
+	if ref.Provider == "openrouter" {
 		if cfg.BaseURL == "" {
 			cfg.BaseURL = "https://openrouter.ai/api/v1/chat/completions"
 		}
 	}
 
+	if err := applyTenant(ctx, &cfg); err != nil {
+		return nil, cfg, err
+	}
+
+	if !validFaultKinds(cfg.FaultKinds) {
+		return nil, cfg, fmt.Errorf("unknown fault kind in %v", cfg.FaultKinds)
+	}
+
+	if err := checkToolChoice(cfg); err != nil {
+		return nil, cfg, err
+	}
+	if err := checkReasoningEffort(&cfg); err != nil {
+		return nil, cfg, err
+	}
+	if err := checkOpenRouterOptions(cfg); err != nil {
+		return nil, cfg, err
+	}
+	if err := checkSampling(cfg); err != nil {
+		return nil, cfg, err
+	}
+	if err := checkSafetySettings(cfg); err != nil {
+		return nil, cfg, err
+	}
+	if err := checkScoreNormalization(cfg); err != nil {
+		return nil, cfg, err
+	}
+
+	if len(cfg.StopSequences) > 0 {
+		p = stopProvider{p}
+	}
+
 	return p, cfg, nil
 }
 
@@ -139,54 +200,169 @@ func (c *CommonClient) getProvider(opts ...CallOption) (Provider, error) {
 	}
 
 	// Resolve provider and model
-	providerName, _, _, err := c.resolveProviderAndModel(cfg.Model)
+	ref, err := c.resolveProviderAndModel(cfg.Model)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get provider
-	p, ok := c.providerMap[providerName]
+	p, ok := c.provider(ref.Provider)
 	if !ok {
-		return nil, fmt.Errorf("unknown provider: %s", providerName)
+		return nil, fmt.Errorf("unknown provider: %s", ref.Provider)
 	}
 
 	return p, nil
 }
 
+// clientConfig returns the configuration of a call made through the client: the defaults
+// of a CommonClient with the call options applied
+func clientConfig(client Client, opts []CallOption) CallConfig {
+	var cfg CallConfig
+	if c, ok := client.(*CommonClient); ok {
+		cfg = c.baseConfig
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
 // Call implements the Client interface
 func (c *CommonClient) Complete(ctx context.Context, messages []Message, opts ...CallOption) (*Response, error) {
-	p, cfg, err := c.prepareCall(opts...)
+	p, cfg, err := c.prepareCall(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, hooks, err := c.prepareMessages(ctx, messages, &cfg)
 	if err != nil {
 		return nil, err
 	}
-	return p.call(ctx, messages, cfg)
+	if cfg.ResponseStore != nil {
+		return recordCall(ctx, &cfg, func() (*Response, error) {
+			return complete(ctx, p, messages, cfg, hooks)
+		})
+	}
+	return complete(ctx, p, messages, cfg, hooks)
+}
+
+// complete makes the provider call, continues answers cut at the token limit and applies the hooks
+func complete(ctx context.Context, p Provider, messages []Message, cfg CallConfig, hooks *callHooks) (*Response, error) {
+	resp, err := p.call(ctx, messages, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Model == "" {
+		resp.Model = cfg.Model
+	}
+	resp.Provider = cfg.provider
+	if cfg.AutoContinue > 1 {
+		if resp, err = continueCall(ctx, p, messages, cfg, resp); err != nil {
+			return nil, err
+		}
+	}
+	if err := hooks.apply(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
 }
 
 // StreamCall implements the Client interface
 func (c *CommonClient) StreamComplete(ctx context.Context, messages []Message, opts ...CallOption) (*StreamResponse, error) {
-	p, cfg, err := c.prepareCall(opts...)
+	p, cfg, err := c.prepareCall(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return p.streamCall(ctx, messages, cfg)
+	if len(cfg.Tools) > 0 {
+		return nil, fmt.Errorf("tools are not supported in streaming calls, use Complete")
+	}
+	if cfg.N > 1 {
+		return nil, fmt.Errorf("multiple answers are not supported in streaming calls, use Complete")
+	}
+
+	messages, hooks, err := c.prepareMessages(ctx, messages, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// A persisted stream is read to the end even when the consumer goes away
+	callCtx := ctx
+	if cfg.ResponseStore != nil {
+		callCtx = context.WithoutCancel(ctx)
+	}
+
+	stream, err := p.streamCall(callCtx, messages, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.AutoContinue > 1 {
+		stream = continueStream(callCtx, p, messages, cfg, stream)
+	}
+	stream = hooks.wrap(callCtx, stream)
+	if cfg.ResponseStore != nil {
+		if stream, err = persistStream(ctx, stream, &cfg); err != nil {
+			return nil, err
+		}
+	}
+	return stream, nil
 }
 
 // GetEmbeddings implements the Client interface
 func (c *CommonClient) GetEmbeddings(ctx context.Context, text string, opts ...CallOption) (*EmbeddingResponse, error) {
-	p, cfg, err := c.prepareCall(opts...)
+	p, cfg, err := c.prepareCall(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.getEmbeddings(ctx, text, cfg)
 	if err != nil {
 		return nil, err
 	}
-	return p.getEmbeddings(ctx, text, cfg)
+	return projectEmbeddingResponse(resp, cfg)
+}
+
+// GetMessageEmbeddings implements the Client interface
+func (c *CommonClient) GetMessageEmbeddings(ctx context.Context, messages []Message, opts ...CallOption) (*EmbeddingResponse, error) {
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("message chain cannot be empty")
+	}
+	return c.GetEmbeddings(ctx, RenderMessages(messages), opts...)
+}
+
+// GetMultimodalEmbeddings implements the Client interface
+func (c *CommonClient) GetMultimodalEmbeddings(ctx context.Context, parts []Part, opts ...CallOption) (*EmbeddingResponse, error) {
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("content parts cannot be empty")
+	}
+	p, cfg, err := c.prepareCall(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.getMultimodalEmbeddings(ctx, parts, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return projectEmbeddingResponse(resp, cfg)
 }
 
 // ReRank implements the Client interface
 func (c *CommonClient) ReRank(ctx context.Context, query string, documents []string, opts ...CallOption) (*RerankResponse, error) {
-	p, cfg, err := c.prepareCall(opts...)
+	p, cfg, err := c.prepareCall(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return p.reRank(ctx, query, documents, cfg)
+	resp, err := p.reRank(ctx, query, documents, cfg)
+	if err != nil || cfg.ScoreNormalization == "" {
+		return resp, err
+	}
+
+	if err := normalizeScores(resp.Scores, cfg.ScoreNormalization); err != nil {
+		return nil, err
+	}
+	if resp.Metadata == nil {
+		resp.Metadata = Metadata{}
+	}
+	resp.Metadata["score_normalization"] = cfg.ScoreNormalization
+	return resp, nil
 }
 
 func (c *CommonClient) ParseComplete(req *http.Request, opts ...CallOption) (*CompletionRequest, error) {
@@ -198,7 +374,7 @@ func (c *CommonClient) ParseComplete(req *http.Request, opts ...CallOption) (*Co
 }
 
 func (c *CommonClient) ExecComplete(ctx context.Context, CompletionRequest *CompletionRequest, opts ...CallOption) (*CompletionResponse, error) {
-	p, cfg, err := c.prepareCall(opts...)
+	p, cfg, err := c.prepareCall(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -222,11 +398,20 @@ func (c *CommonClient) ParseEmbedding(req *http.Request, opts ...CallOption) (*E
 }
 
 func (c *CommonClient) ExecEmbedding(ctx context.Context, EmbeddingRequest *EmbeddingRequest, opts ...CallOption) (*UnifiedEmbeddingResponse, error) {
-	p, cfg, err := c.prepareCall(opts...)
+	p, cfg, err := c.prepareCall(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return p.buildEmbeddingRequest(ctx, EmbeddingRequest, cfg)
+	resp, err := p.buildEmbeddingRequest(ctx, EmbeddingRequest, cfg)
+	if err != nil || (cfg.EmbeddingDimensions == 0 && cfg.EmbeddingProjection == nil) {
+		return resp, err
+	}
+	for i := range resp.Data {
+		if resp.Data[i].Embedding, err = projectEmbedding(resp.Data[i].Embedding, cfg); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
 }
 
 func (c *CommonClient) WriteEmbedding(w http.ResponseWriter, resp *UnifiedEmbeddingResponse, opts ...CallOption) error {
@@ -246,7 +431,7 @@ func (c *CommonClient) ParseRerank(req *http.Request, opts ...CallOption) (*Rera
 }
 
 func (c *CommonClient) ExecRerank(ctx context.Context, RerankRequest *RerankRequest, opts ...CallOption) (*UnifiedRerankResponse, error) {
-	p, cfg, err := c.prepareCall(opts...)
+	p, cfg, err := c.prepareCall(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -262,7 +447,7 @@ func (c *CommonClient) WriteRerank(w http.ResponseWriter, resp *UnifiedRerankRes
 }
 
 // resolveProviderAndModel determines the provider and resolves model aliases
-func (c *CommonClient) resolveProviderAndModel(modelStr string) (string, string, string, error) {
+func (c *CommonClient) resolveProviderAndModel(modelStr string) (ModelRef, error) {
 	// Use override model if provided, otherwise use base config model
 	if modelStr == "" {
 		modelStr = c.baseConfig.Model
@@ -271,39 +456,36 @@ func (c *CommonClient) resolveProviderAndModel(modelStr string) (string, string,
 		modelStr = os.Getenv("ECHO_MODEL")
 	}
 	if modelStr == "" {
-		return "", "", "", fmt.Errorf("no model specified")
-	}
-
-	resolvedModel, ok := alises[modelStr]
-	if ok {
-		modelStr = resolvedModel
-	}
-
-	providerName, modelName, endpoint, err := parseModelString(modelStr)
-	if err != nil {
-		return "", "", "", err
+		return ModelRef{}, fmt.Errorf("no model specified")
 	}
 
-	return providerName, modelName, endpoint, nil
+	return ParseModelRef(resolveAlias(modelStr))
 }
 
-// parseModelString parses "provider/model@endpoint" format
-func parseModelString(fullModelName string) (string, string, string, error) {
-	parts := strings.SplitN(fullModelName, "/", 2)
-	if len(parts) != 2 {
-		return "", "", "", fmt.Errorf("invalid model format: %s. Expected provider/model-name@endpoint", fullModelName)
+// resolveAlias returns the model an alias points to: an ECHO_ALIAS_<NAME> environment variable
+// wins over the built-in table, so models can be swapped without a redeploy. NAME is the alias
+// in upper case with other characters than letters and digits replaced by "_", e.g.
+// ECHO_ALIAS_BEST for "best" and ECHO_ALIAS_OPENAI_BEST for "openai/best".
+// The variable may point to a built-in alias. Names that are not aliases are returned as is.
+func resolveAlias(model string) string {
+	if resolved := os.Getenv(aliasEnvName(model)); resolved != "" {
+		model = resolved
 	}
-
-	provider, modelName := parts[0], parts[1]
-	endpoint := ""
-
-	// Remove endpoint suffix if present (handled in CallConfig.EndPoint)
-	if atIndex := strings.Index(modelName, "@"); atIndex != -1 {
-		endpoint = modelName[atIndex+1:]
-		modelName = modelName[:atIndex]
+	if resolved, ok := alises[model]; ok {
+		model = resolved
 	}
+	return model
+}
 
-	return provider, modelName, endpoint, nil
+// aliasEnvName returns the name of the environment variable overriding the alias
+func aliasEnvName(alias string) string {
+	name := []byte(strings.ToUpper(alias))
+	for i, c := range name {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			name[i] = '_'
+		}
+	}
+	return "ECHO_ALIAS_" + string(name)
 }
 
 // Model aliases for each provider
@@ -0,0 +1,86 @@
+package echo
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Confidence methods, reported in the "confidence_method" metadata key
+const (
+	ConfidenceLogprobs = "logprobs" // mean token probability of the answer
+	ConfidenceSelf     = "self"     // calibrated self-assessment of the model
+)
+
+const confidenceInstruction = "After your answer, add a last line in the form \"Confidence: <number>\", " +
+	"where the number between 0 and 1 (not a percentage or a rating) is the probability that your answer is correct and complete. " +
+	"Be calibrated: use low values when you are guessing, lack information or the question is ambiguous."
+
+// confidenceLine matches the self-assessment line, as a fraction or a percentage
+var confidenceLine = regexp.MustCompile(`(?i)^[\s*_]*confidence[\s*_]*:[\s*_]*([0-9]*\.?[0-9]+)\s*(%?)[\s*_.]*$`)
+
+// logprobModel reports whether the call can use token log probabilities: OpenAI chat models
+// return them, reasoning models (o-series, gpt-5) reject the parameter
+func logprobModel(cfg CallConfig) bool {
+	return cfg.provider == "openai" && (strings.HasPrefix(cfg.Model, "gpt-4") || strings.HasPrefix(cfg.Model, "gpt-3.5"))
+}
+
+// logprobConfidence returns the geometric mean of the token probabilities
+func logprobConfidence(logprobs []float64) float64 {
+	var sum float64
+	for _, lp := range logprobs {
+		sum += lp
+	}
+	return math.Exp(sum / float64(len(logprobs)))
+}
+
+// parseConfidence removes the self-assessment line from the end of the text
+// and returns the remaining text with the score
+func parseConfidence(text string) (string, float64, bool) {
+	trimmed := strings.TrimRight(text, " \t\r\n")
+	start := strings.LastIndex(trimmed, "\n") + 1
+	m := confidenceLine.FindStringSubmatch(trimmed[start:])
+	if m == nil {
+		return text, 0, false
+	}
+	score, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return text, 0, false
+	}
+	// Integers up to 10 may be a 5/10 or 4/5 rating rather than a percentage, so only
+	// values with a '%' or above 10 are read as percent
+	switch {
+	case m[2] == "%" || score > 10:
+		score /= 100
+	case score > 1:
+		return text, 0, false
+	}
+	return strings.TrimRight(trimmed[:start], " \t\r\n"), min(max(score, 0), 1), true
+}
+
+// confidenceFilter drops the self-assessment line from the end of streamed text.
+// The last non-blank line is held back and checked when the stream ends.
+type confidenceFilter struct {
+	pending string
+}
+
+func (f *confidenceFilter) push(text string) (string, error) {
+	f.pending += text
+	i := strings.LastIndex(strings.TrimRight(f.pending, " \t\r\n"), "\n")
+	if i == -1 {
+		return "", nil
+	}
+	out := f.pending[:i+1]
+	f.pending = f.pending[i+1:]
+	return out, nil
+}
+
+func (f *confidenceFilter) flush() (string, error) {
+	out := f.pending
+	f.pending = ""
+	if confidenceLine.MatchString(strings.TrimSpace(out)) {
+		return "", nil
+	}
+	return out, nil
+}
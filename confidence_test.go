@@ -0,0 +1,94 @@
+package echo
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseConfidence(t *testing.T) {
+	tests := []struct {
+		text  string
+		want  string
+		score float64
+		ok    bool
+	}{
+		{"Paris.\nConfidence: 0.9", "Paris.", 0.9, true},
+		{"Paris.\n\n**Confidence:** 85%\n", "Paris.", 0.85, true},
+		{"Paris.\nconfidence: 70", "Paris.", 0.7, true},
+		{"Confidence: 0.4", "", 0.4, true},
+		{"Paris.\nConfidence: 1", "Paris.", 1, true},
+		{"Paris.\nConfidence: 5%", "Paris.", 0.05, true},
+		{"Paris.\nConfidence: 5", "Paris.\nConfidence: 5", 0, false},
+		{"Paris.\nConfidence: 8.5", "Paris.\nConfidence: 8.5", 0, false},
+		{"Paris. Confidence: 0.9 is high", "Paris. Confidence: 0.9 is high", 0, false},
+		{"Paris.", "Paris.", 0, false},
+	}
+	for _, tt := range tests {
+		text, score, ok := parseConfidence(tt.text)
+		if text != tt.want || math.Abs(score-tt.score) > 1e-9 || ok != tt.ok {
+			t.Errorf("parseConfidence(%q) = %q, %v, %v", tt.text, text, score, ok)
+		}
+	}
+}
+
+func TestConfidenceSelfAssessment(t *testing.T) {
+	provider := &scriptedProvider{answers: []string{"The answer is 42.\nConfidence: 0.35"}}
+	client, _ := NewClient(WithModel("mock/test"))
+	client.SetProvider("mock", provider)
+
+	resp, err := client.Complete(context.Background(), []Message{
+		{Role: System, Content: "You are helpful."},
+		{Role: User, Content: "What is the answer?"},
+	}, WithConfidenceScore())
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Text != "The answer is 42." || resp.Confidence != 0.35 || resp.Metadata["confidence_method"] != ConfidenceSelf {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	if system := provider.calls[0][0]; system.Role != System || !strings.Contains(system.Content, "Confidence: <number>") {
+		t.Errorf("Expected the self-assessment instruction, got %+v", provider.calls[0])
+	}
+}
+
+func TestConfidenceFilter(t *testing.T) {
+	f := &confidenceFilter{}
+	var out strings.Builder
+	for _, piece := range []string{"Line one\nLine", " two\nConfi", "dence: 0.8", "\n"} {
+		text, _ := f.push(piece)
+		out.WriteString(text)
+	}
+	text, _ := f.flush()
+	out.WriteString(text)
+	if out.String() != "Line one\nLine two\n" {
+		t.Errorf("Unexpected filtered text %q", out.String())
+	}
+}
+
+func TestConfidenceLogprobs(t *testing.T) {
+	var request string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request = string(body)
+		w.Write([]byte(`{"choices":[{"message":{"content":"Paris"},"finish_reason":"stop",
+			"logprobs":{"content":[{"token":"Par","logprob":-0.1},{"token":"is","logprob":-0.3}]}}]}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewCommonClient(map[string]string{"openai": "key"}, WithModel("openai/gpt-4.1"), WithBaseURL(server.URL))
+	resp, err := client.Complete(context.Background(), QuickMessage("Capital of France?"), WithConfidenceScore())
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if !strings.Contains(request, `"logprobs":true`) || strings.Contains(request, "Confidence:") {
+		t.Errorf("Expected logprobs instead of a self-assessment, got %s", request)
+	}
+	if want := math.Exp(-0.2); math.Abs(resp.Confidence-want) > 1e-9 || resp.Metadata["confidence_method"] != ConfidenceLogprobs {
+		t.Errorf("Confidence = %v (%v), want %v", resp.Confidence, resp.Metadata["confidence_method"], want)
+	}
+}
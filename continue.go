@@ -0,0 +1,116 @@
+package echo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// continuePrompt asks the model to resume an answer that was cut at the token limit
+const continuePrompt = "Continue exactly where you stopped. Do not repeat what you already wrote and do not comment on the continuation."
+
+// continueMessages returns the chain extended with the partial answer and a request to continue it
+func continueMessages(messages []Message, text string) []Message {
+	next := make([]Message, 0, len(messages)+2)
+	next = append(next, messages...)
+	return append(next,
+		Message{Role: Agent, Content: text},
+		Message{Role: User, Content: continuePrompt},
+	)
+}
+
+// addUsage merges src into dst, summing token counts and costs and replacing other values
+func addUsage(dst, src Metadata) {
+	for k, v := range src {
+		dst[k] = sumUsage(k, dst[k], v)
+	}
+}
+
+// sumUsage adds a token count or an OpenRouter cost to its previous value,
+// other values replace the previous one
+func sumUsage(key string, prev, v any) any {
+	if n, ok := v.(int); ok && strings.HasSuffix(key, "_tokens") {
+		p, _ := prev.(int)
+		return p + n
+	}
+	if f, ok := v.(float64); ok && key == "cost" {
+		p, _ := prev.(float64)
+		return p + f
+	}
+	return v
+}
+
+// continueCall requests continuations while the answer is cut at the token limit,
+// up to cfg.AutoContinue segments, and joins them into a single response
+func continueCall(ctx context.Context, p Provider, messages []Message, cfg CallConfig, resp *Response) (*Response, error) {
+	result := *resp
+	result.Metadata = Metadata{}
+	addUsage(result.Metadata, resp.Metadata)
+
+	segments := 1
+	for segments < cfg.AutoContinue && result.Metadata["finish_reason"] == FinishLength {
+		next, err := p.call(ctx, continueMessages(messages, result.Text), cfg)
+		if err != nil {
+			return nil, fmt.Errorf("continuation %d failed: %w", segments, err)
+		}
+		result.Text += next.Text
+		result.Thinking += next.Thinking
+		result.Audio = append(result.Audio, next.Audio...)
+		result.ToolCalls = append(result.ToolCalls, next.ToolCalls...)
+		addUsage(result.Metadata, next.Metadata)
+		segments++
+	}
+
+	result.Metadata["segments"] = segments
+	return &result, nil
+}
+
+// continueStream passes the chunks through and, when the answer is cut at the token limit,
+// streams continuations up to cfg.AutoContinue segments. Token counts in the metadata of
+// later segments include the previous segments, so the last value is the total.
+func continueStream(ctx context.Context, p Provider, messages []Message, cfg CallConfig, stream *StreamResponse) *StreamResponse {
+	ch := make(chan StreamChunk)
+	go func() {
+		defer close(ch)
+		out := chunkSender{ctx: ctx, ch: ch}
+
+		var text strings.Builder
+		totals := Metadata{}
+		for segment := 1; ; segment++ {
+			segmentMeta := Metadata{}
+			for chunk := range stream.Stream {
+				if chunk.Meta != nil {
+					for k, v := range *chunk.Meta {
+						segmentMeta[k] = v
+					}
+					meta := Metadata{"segments": segment}
+					for k, v := range *chunk.Meta {
+						meta[k] = sumUsage(k, totals[k], v)
+					}
+					chunk.Meta = &meta
+				}
+				text.WriteString(chunk.Data)
+				if out.send(chunk) != nil {
+					drain(stream)
+					return
+				}
+				if chunk.Error != nil {
+					drain(stream)
+					return
+				}
+			}
+			addUsage(totals, segmentMeta)
+
+			if segmentMeta["finish_reason"] != FinishLength || segment >= cfg.AutoContinue {
+				return
+			}
+			next, err := p.streamCall(ctx, continueMessages(messages, text.String()), cfg)
+			if err != nil {
+				out.fail(fmt.Errorf("continuation %d failed: %w", segment, err))
+				return
+			}
+			stream = next
+		}
+	}()
+	return &StreamResponse{Stream: ch}
+}
@@ -0,0 +1,122 @@
+package echo
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newSegmentServer answers with "Hello " cut at the token limit, and with "world" to the continuation
+func newSegmentServer(requests *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*requests = append(*requests, string(body))
+
+		text, reason := "Hello ", "length"
+		if strings.Contains(string(body), continuePrompt) {
+			text, reason = "world", "stop"
+		}
+		if strings.Contains(string(body), `"stream":true`) {
+			w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"" + text + "\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"" + reason + "\"}]}\n\n" +
+				"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":5,\"total_tokens\":10}}\n\ndata: [DONE]\n\n"))
+			return
+		}
+		w.Write([]byte(`{"choices": [{"message": {"content": "` + text + `"}, "finish_reason": "` + reason + `"}],
+			"usage": {"prompt_tokens": 5, "completion_tokens": 5, "total_tokens": 10}}`))
+	}))
+}
+
+func TestAutoContinue(t *testing.T) {
+	var requests []string
+	server := newSegmentServer(&requests)
+	defer server.Close()
+
+	client, _ := NewCommonClient(map[string]string{"openai": "key"},
+		WithModel("openai/gpt-4.1"), WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	resp, err := client.Complete(ctx, QuickMessage("Hi"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Text != "Hello " || resp.Metadata["finish_reason"] != FinishLength {
+		t.Errorf("Expected a cut answer without auto-continue, got %q %v", resp.Text, resp.Metadata["finish_reason"])
+	}
+
+	requests = nil
+	resp, err = client.Complete(ctx, QuickMessage("Hi"), WithAutoContinue(3))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Text != "Hello world" || len(requests) != 2 {
+		t.Errorf("Expected joined answer from 2 requests, got %q from %d", resp.Text, len(requests))
+	}
+	if !strings.Contains(requests[1], `{"role":"assistant","content":"Hello "}`) {
+		t.Errorf("Expected the partial answer in the continuation, got %s", requests[1])
+	}
+	if resp.Metadata["finish_reason"] != FinishStop || resp.Metadata["total_tokens"] != 20 || resp.Metadata["segments"] != 2 {
+		t.Errorf("Unexpected metadata: %v", resp.Metadata)
+	}
+
+	requests = nil
+	stream, err := client.StreamComplete(ctx, QuickMessage("Hi"), WithAutoContinue(3))
+	if err != nil {
+		t.Fatalf("StreamComplete() error = %v", err)
+	}
+	var text strings.Builder
+	meta := Metadata{}
+	for chunk := range stream.Stream {
+		if chunk.Error != nil {
+			t.Fatalf("Stream error = %v", chunk.Error)
+		}
+		if chunk.Meta != nil {
+			for k, v := range *chunk.Meta {
+				meta[k] = v
+			}
+		}
+		text.WriteString(chunk.Data)
+	}
+	if text.String() != "Hello world" || len(requests) != 2 {
+		t.Errorf("Expected joined stream from 2 requests, got %q from %d", text.String(), len(requests))
+	}
+	if meta["finish_reason"] != FinishStop || meta["total_tokens"] != 20 {
+		t.Errorf("Unexpected stream metadata: %v", meta)
+	}
+}
+
+func TestAutoContinueKeepsResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"model": "gpt-4.1-2025-04-14", "choices": [{"message": {"content": "{\"a\": 1}",
+			"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "lookup", "arguments": "{}"}}]},
+			"finish_reason": "stop"}], "usage": {"prompt_tokens": 5, "completion_tokens": 5, "total_tokens": 10}}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewCommonClient(map[string]string{"openai": "key"},
+		WithModel("openai/gpt-4.1"), WithBaseURL(server.URL), WithJSONMode())
+	ctx := context.Background()
+
+	plain, err := client.Complete(ctx, QuickMessage("Hi"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	continued, err := client.Complete(ctx, QuickMessage("Hi"), WithAutoContinue(3))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if continued.Metadata["segments"] != 1 {
+		t.Errorf("Expected a single segment, got %v", continued.Metadata["segments"])
+	}
+	delete(continued.Metadata, "segments")
+	if !reflect.DeepEqual(plain, continued) {
+		t.Errorf("Auto-continue changed the response:\n%+v\n%+v", plain, continued)
+	}
+	if plain.Provider != "openai" || len(plain.ToolCalls) != 1 || plain.JSON == nil {
+		t.Errorf("Unexpected response %+v", plain)
+	}
+}
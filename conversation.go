@@ -0,0 +1,220 @@
+package echo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Conversation is a multi-turn chat with the branches a chat UI creates when answers
+// are regenerated or user messages are edited. Each branch holds its full message chain,
+// so switching between branches never rebuilds state. The exported fields can be stored
+// as JSON; Client and Options are set again after loading.
+// A Conversation is not safe for concurrent use.
+type Conversation struct {
+	Client  Client       `json:"-"`
+	Options []CallOption `json:"-"` // applied to every completion
+
+	Branches []Branch `json:"branches"`
+	Active   int      `json:"active"` // index of the current branch
+
+	Tags   []string `json:"tags,omitempty"`   // labels for filtering, e.g. by ExportTraining
+	Rating int      `json:"rating,omitempty"` // quality rating set by the application, 0 when unrated
+
+	Title        string        `json:"title,omitempty"`   // set by GenerateTitle, or by the application
+	SummaryCache *SummaryCache `json:"summary,omitempty"` // last result of Summary
+}
+
+// SummaryCache is a summary of the first messages of a branch
+type SummaryCache struct {
+	Text     string `json:"text"`
+	Branch   int    `json:"branch"`
+	Messages int    `json:"messages"` // number of messages summarized
+}
+
+// Branch is one version of a conversation
+type Branch struct {
+	Messages []Message `json:"messages"`
+	Parent   int       `json:"parent"`    // branch it was forked from, -1 for the first one
+	ForkedAt int       `json:"forked_at"` // number of messages shared with the parent
+}
+
+// NewConversation starts a conversation with the optional initial messages, e.g. a system prompt
+func NewConversation(client Client, messages []Message, opts ...CallOption) *Conversation {
+	return &Conversation{
+		Client:   client,
+		Options:  opts,
+		Branches: []Branch{{Messages: slices.Clone(messages), Parent: -1}},
+	}
+}
+
+// Messages returns the message chain of the active branch
+func (c *Conversation) Messages() []Message {
+	return c.Branches[c.Active].Messages
+}
+
+// Send adds a user message to the active branch and appends the answer.
+// When the call fails the user message stays, Regenerate retries it.
+func (c *Conversation) Send(ctx context.Context, text string, opts ...CallOption) (*Response, error) {
+	branch := &c.Branches[c.Active]
+	branch.Messages = append(branch.Messages, Message{Role: User, Content: text})
+	return c.complete(ctx, opts)
+}
+
+// Regenerate answers the last user message again. When the branch already ends with an
+// answer, a new branch is forked without it, so the previous answer stays available.
+func (c *Conversation) Regenerate(ctx context.Context, opts ...CallOption) (*Response, error) {
+	messages := c.Messages()
+	last := len(messages) - 1
+	for last >= 0 && messages[last].Role == Agent {
+		last--
+	}
+	if last < 0 || messages[last].Role != User {
+		return nil, fmt.Errorf("conversation has no user message to answer")
+	}
+
+	if last < len(messages)-1 {
+		c.Fork(last + 1)
+	}
+	return c.complete(ctx, opts)
+}
+
+// EditUserMessage forks a branch where the i-th message of the active branch has the new text
+// and later messages are dropped; call Regenerate to answer it
+func (c *Conversation) EditUserMessage(i int, text string) error {
+	messages := c.Messages()
+	if i < 0 || i >= len(messages) || messages[i].Role != User {
+		return fmt.Errorf("message %d is not a user message", i)
+	}
+
+	edited := messages[i]
+	edited.Content = text
+	c.Fork(i)
+	branch := &c.Branches[c.Active]
+	branch.Messages = append(branch.Messages, edited)
+	return nil
+}
+
+// Fork creates a branch with the first n messages of the active branch, makes it active
+// and returns its index
+func (c *Conversation) Fork(n int) int {
+	messages := c.Messages()
+	n = min(max(n, 0), len(messages))
+	c.Branches = append(c.Branches, Branch{
+		Messages: slices.Clone(messages[:n]),
+		Parent:   c.Active,
+		ForkedAt: n,
+	})
+	c.Active = len(c.Branches) - 1
+	return c.Active
+}
+
+// Switch makes the branch active
+func (c *Conversation) Switch(branch int) error {
+	if branch < 0 || branch >= len(c.Branches) {
+		return fmt.Errorf("unknown branch %d", branch)
+	}
+	c.Active = branch
+	return nil
+}
+
+// complete answers the active branch and appends the answer to it
+func (c *Conversation) complete(ctx context.Context, opts []CallOption) (*Response, error) {
+	branch := &c.Branches[c.Active]
+	resp, err := c.Client.Complete(ctx, branch.Messages, append(slices.Clip(c.Options), opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	branch.Messages = append(branch.Messages, ToolCallsMessage(resp))
+	return resp, nil
+}
+
+const titlePrompt = "Write a title of 3 to 6 words for the chat below. Use the language of the chat. " +
+	"Answer with the title only, without quotes or a final period."
+
+const conversationSummaryPrompt = "Summarize the chat below in a few sentences: what the user wants, " +
+	"the key facts and decisions, and open questions. Use the language of the chat. Answer with the summary only."
+
+// GenerateTitle returns a short title for the conversation, made from its first exchange
+// with the light model of the conversation provider. The title is kept in Title and
+// generated only once; clear it to generate a new one.
+func (c *Conversation) GenerateTitle(ctx context.Context, opts ...CallOption) (string, error) {
+	if c.Title != "" {
+		return c.Title, nil
+	}
+
+	messages := c.Messages()
+	first := slices.IndexFunc(messages, func(m Message) bool { return m.Role == User })
+	if first < 0 {
+		return "", fmt.Errorf("conversation has no user message")
+	}
+	exchange := messages[first : first+1]
+	if first+1 < len(messages) && messages[first+1].Role == Agent {
+		exchange = messages[first : first+2]
+	}
+
+	resp, err := c.housekeeping(ctx, titlePrompt, exchange, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate title: %w", err)
+	}
+	c.Title = strings.TrimRight(strings.Trim(strings.TrimSpace(resp.Text), `"'`), ".")
+	return c.Title, nil
+}
+
+// Summary returns a few sentences about the active branch, made with the light model
+// of the conversation provider. The result is kept in SummaryCache and reused until
+// the branch changes.
+func (c *Conversation) Summary(ctx context.Context, opts ...CallOption) (string, error) {
+	messages := c.Messages()
+	if cache := c.SummaryCache; cache != nil && cache.Branch == c.Active && cache.Messages == len(messages) {
+		return cache.Text, nil
+	}
+
+	resp, err := c.housekeeping(ctx, conversationSummaryPrompt, messages, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize conversation: %w", err)
+	}
+	c.SummaryCache = &SummaryCache{Text: strings.TrimSpace(resp.Text), Branch: c.Active, Messages: len(messages)}
+	return c.SummaryCache.Text, nil
+}
+
+// housekeeping runs an instruction over a part of the conversation with the light model.
+// Options of the conversation are not applied, as they are meant for the chat itself.
+func (c *Conversation) housekeeping(ctx context.Context, instruction string, messages []Message, opts []CallOption) (*Response, error) {
+	var chat []Message
+	for _, msg := range messages {
+		if msg.Role == User || msg.Role == Agent {
+			chat = append(chat, msg)
+		}
+	}
+	if len(chat) == 0 {
+		return nil, fmt.Errorf("conversation is empty")
+	}
+
+	prompt := []Message{{Role: User, Content: RenderMessages(chat)}}
+	return c.Client.Complete(ctx, prompt, append(append([]CallOption{c.lightModel()}, opts...), WithSystemInstructions(instruction))...)
+}
+
+// lightModel switches the call to the "light" alias of the provider used by the conversation,
+// the model is kept for providers without one
+func (c *Conversation) lightModel() CallOption {
+	return func(cfg *CallConfig) {
+		probe := *cfg
+		for _, opt := range c.Options {
+			opt(&probe)
+		}
+		model := probe.Model
+		if model == "" {
+			model = os.Getenv("ECHO_MODEL")
+		}
+
+		ref, err := ParseModelRef(resolveAlias(model))
+		if _, ok := alises[ref.Provider+"/light"]; ok && err == nil {
+			cfg.Model = ref.Provider + "/light"
+		} else {
+			cfg.Model = model
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestConversationBranches(t *testing.T) {
+	provider := &scriptedProvider{answers: []string{"Paris", "It is Paris", "Berlin"}}
+	client, _ := NewClient(WithModel("mock/test"))
+	client.SetProvider("mock", provider)
+	ctx := context.Background()
+
+	conv := NewConversation(client, []Message{{Role: System, Content: "Be brief"}})
+	if _, err := conv.Send(ctx, "Capital of France?"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	// Regenerate keeps the first answer in the original branch
+	resp, err := conv.Regenerate(ctx)
+	if err != nil || resp.Text != "It is Paris" {
+		t.Fatalf("Regenerate() = %v, %v", resp, err)
+	}
+	if len(conv.Branches) != 2 || conv.Active != 1 || len(conv.Messages()) != 3 {
+		t.Fatalf("Unexpected branches %+v", conv.Branches)
+	}
+	if conv.Branches[0].Messages[2].Content != "Paris" || conv.Branches[1].ForkedAt != 2 {
+		t.Errorf("Unexpected branches %+v", conv.Branches)
+	}
+
+	// Editing forks at the user message, Regenerate answers it without another fork
+	if err := conv.EditUserMessage(1, "Capital of Germany?"); err != nil {
+		t.Fatalf("EditUserMessage() error = %v", err)
+	}
+	if resp, err := conv.Regenerate(ctx); err != nil || resp.Text != "Berlin" {
+		t.Fatalf("Regenerate() = %v, %v", resp, err)
+	}
+	if len(conv.Branches) != 3 || conv.Branches[2].Parent != 1 || conv.Messages()[1].Content != "Capital of Germany?" {
+		t.Errorf("Unexpected branches %+v", conv.Branches)
+	}
+	if last := provider.calls[2]; len(last) != 2 || last[1].Content != "Capital of Germany?" {
+		t.Errorf("Unexpected messages sent %+v", last)
+	}
+
+	if err := conv.EditUserMessage(0, "x"); err == nil {
+		t.Error("Expected error when editing a system message")
+	}
+	if err := conv.Switch(0); err != nil || conv.Messages()[2].Content != "Paris" {
+		t.Errorf("Switch() error = %v", err)
+	}
+
+	// Branches survive a JSON round trip
+	data, _ := json.Marshal(conv)
+	var loaded Conversation
+	if err := json.Unmarshal(data, &loaded); err != nil || len(loaded.Branches) != 3 || loaded.Active != 0 {
+		t.Errorf("Unexpected loaded conversation %+v, %v", loaded, err)
+	}
+}
+
+func TestConversationTitleAndSummary(t *testing.T) {
+	provider := &scriptedProvider{answers: []string{"Paris", `"Capital of France."`, "The user asked about France.", "Berlin", "Two capitals."}}
+	client, _ := NewClient(WithModel("mock/test"), WithSystemMessage("You are helpful"))
+	client.SetProvider("mock", provider)
+	ctx := context.Background()
+
+	conv := NewConversation(client, nil, WithSystemMessage("Be brief"))
+	conv.Send(ctx, "Capital of France?")
+
+	for range 2 {
+		title, err := conv.GenerateTitle(ctx)
+		if err != nil || title != "Capital of France" {
+			t.Fatalf("GenerateTitle() = %q, %v", title, err)
+		}
+	}
+	if sent := provider.calls[1]; len(sent) != 1 || sent[0].Content != "user: Capital of France?\n\nagent: Paris" {
+		t.Errorf("Unexpected title prompt %+v", sent)
+	}
+	// The client prompt is kept, the conversation options are skipped
+	if system := provider.configs[1].SystemMsg; system != "You are helpful\n\n"+titlePrompt {
+		t.Errorf("Unexpected system prompt %q", system)
+	}
+
+	// The summary is cached until the branch changes
+	for range 2 {
+		if summary, err := conv.Summary(ctx); err != nil || summary != "The user asked about France." {
+			t.Fatalf("Summary() = %q, %v", summary, err)
+		}
+	}
+	conv.Send(ctx, "And Germany?")
+	if summary, _ := conv.Summary(ctx); summary != "Two capitals." || len(provider.calls) != 5 {
+		t.Errorf("Expected a new summary, got %q", summary)
+	}
+
+	// Housekeeping calls use the light model of the conversation provider
+	conv.Options = []CallOption{WithModel("anthropic/best")}
+	cfg := CallConfig{Model: "openai/gpt-5"}
+	conv.lightModel()(&cfg)
+	if cfg.Model != "anthropic/light" {
+		t.Errorf("Expected the light alias, got %q", cfg.Model)
+	}
+}
+
+// toolCallProvider asks for a tool call in every answer
+type toolCallProvider struct {
+	MockProvider
+}
+
+func (p *toolCallProvider) call(ctx context.Context, messages []Message, cfg CallConfig) (*Response, error) {
+	return &Response{ToolCalls: []ToolCall{{ID: "call_1", Name: "weather", Arguments: json.RawMessage(`{}`)}}}, nil
+}
+
+func TestConversationToolCalls(t *testing.T) {
+	client, _ := NewClient(WithModel("mock/test"))
+	client.SetProvider("mock", &toolCallProvider{})
+
+	conv := NewConversation(client, nil)
+	if _, err := conv.Send(context.Background(), "Weather?"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if last := conv.Messages()[1]; last.Role != Agent || len(last.ToolCalls) != 1 || last.ToolCalls[0].ID != "call_1" {
+		t.Errorf("Expected the tool calls in the conversation, got %+v", last)
+	}
+}
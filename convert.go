@@ -0,0 +1,250 @@
+package echo
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// FromOpenAIMessages converts a conversation stored in the OpenAI chat completions format,
+// either a JSON array of messages or a request object with a "messages" field.
+// Text, image and audio content is converted; tool calls are not supported.
+func FromOpenAIMessages(data []byte) ([]Message, error) {
+	raw, _, err := splitConversation(data)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]Message, 0, len(raw))
+	for i, item := range raw {
+		var msg OpenAIMessage
+		var probe struct {
+			ToolCalls []json.RawMessage `json:"tool_calls"`
+		}
+		if err := json.Unmarshal(item, &msg); err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		json.Unmarshal(item, &probe)
+		if len(probe.ToolCalls) > 0 {
+			return nil, fmt.Errorf("message %d: tool calls are not supported", i)
+		}
+
+		out := Message{Content: msg.Content}
+		switch msg.Role {
+		case "system", "developer":
+			out.Role = System
+		case "user":
+			out.Role = User
+		case "assistant":
+			out.Role = Agent
+		default:
+			return nil, fmt.Errorf("message %d: unsupported role %q", i, msg.Role)
+		}
+
+		for _, part := range msg.Parts {
+			switch part.Type {
+			case "text":
+				// Already joined into Content
+			case "image_url":
+				if part.ImageURL == nil {
+					return nil, fmt.Errorf("message %d: image part without url", i)
+				}
+				p, err := partFromURL(PartImage, part.ImageURL.URL)
+				if err != nil {
+					return nil, fmt.Errorf("message %d: %w", i, err)
+				}
+				out.Parts = append(out.Parts, p)
+			case "input_audio":
+				if part.InputAudio == nil {
+					return nil, fmt.Errorf("message %d: audio part without data", i)
+				}
+				audio, err := base64.StdEncoding.DecodeString(part.InputAudio.Data)
+				if err != nil {
+					return nil, fmt.Errorf("message %d: invalid audio data: %w", i, err)
+				}
+				mime := "audio/" + part.InputAudio.Format
+				if part.InputAudio.Format == "mp3" {
+					mime = "audio/mpeg"
+				}
+				out.Parts = append(out.Parts, AudioPart(audio, mime))
+			default:
+				return nil, fmt.Errorf("message %d: unsupported content type %q", i, part.Type)
+			}
+		}
+		messages = append(messages, out)
+	}
+	return messages, nil
+}
+
+// ToOpenAIMessages converts the messages to a JSON array in the OpenAI chat completions format,
+// tool calls and tool results are encoded as in a request
+func ToOpenAIMessages(messages []Message) ([]byte, error) {
+	out := make([]OpenAIMessage, 0, len(messages))
+	for i, msg := range messages {
+		switch msg.Role {
+		case System, User:
+			converted, err := toOpenAIMessage(msg.Role, msg)
+			if err != nil {
+				return nil, fmt.Errorf("message %d: %w", i, err)
+			}
+			out = append(out, converted)
+		case Agent:
+			out = append(out, toOpenAIAgentMessage(msg))
+		case ToolResult:
+			out = append(out, OpenAIMessage{Role: "tool", Content: msg.Content, ToolCallID: msg.ToolCallID})
+		default:
+			return nil, fmt.Errorf("message %d: unsupported role %q", i, msg.Role)
+		}
+	}
+	return json.Marshal(out)
+}
+
+// FromAnthropicMessages converts a conversation stored in the Anthropic messages format,
+// either a JSON array of messages or a request object with "messages" and "system" fields.
+// Text and image content is converted, thinking blocks are skipped; tool use is not supported.
+func FromAnthropicMessages(data []byte) ([]Message, error) {
+	raw, system, err := splitConversation(data)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]Message, 0, len(raw)+1)
+	if len(system) > 0 && string(system) != "null" {
+		text, err := anthropicSystemText(system)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, Message{Role: System, Content: text})
+	}
+
+	for i, item := range raw {
+		var msg AnthropicMessage
+		if err := json.Unmarshal(item, &msg); err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+
+		out := Message{Content: msg.Content}
+		switch msg.Role {
+		case "user":
+			out.Role = User
+		case "assistant":
+			out.Role = Agent
+		default:
+			return nil, fmt.Errorf("message %d: unsupported role %q", i, msg.Role)
+		}
+
+		for _, block := range msg.Blocks {
+			switch block.Type {
+			case "text", "thinking", "redacted_thinking":
+				// Text is already joined into Content, thinking is not part of the conversation
+			case "image":
+				if block.Source == nil {
+					return nil, fmt.Errorf("message %d: image block without source", i)
+				}
+				if block.Source.Type == "url" {
+					out.Parts = append(out.Parts, ImageURLPart(block.Source.URL))
+					continue
+				}
+				image, err := base64.StdEncoding.DecodeString(block.Source.Data)
+				if err != nil {
+					return nil, fmt.Errorf("message %d: invalid image data: %w", i, err)
+				}
+				out.Parts = append(out.Parts, ImagePart(image, block.Source.MediaType))
+			default:
+				return nil, fmt.Errorf("message %d: unsupported content block %q", i, block.Type)
+			}
+		}
+		messages = append(messages, out)
+	}
+	return messages, nil
+}
+
+// ToAnthropicMessages converts the messages to a JSON object in the Anthropic messages format,
+// with the system message in the "system" field; tool calls become tool_use blocks and tool
+// results tool_result blocks, as in a request
+func ToAnthropicMessages(messages []Message) ([]byte, error) {
+	var out struct {
+		System   string             `json:"system,omitempty"`
+		Messages []AnthropicMessage `json:"messages"`
+	}
+	out.Messages = make([]AnthropicMessage, 0, len(messages))
+
+	for i, msg := range messages {
+		switch msg.Role {
+		case System:
+			out.System = msg.Content
+		case User:
+			converted, err := toAnthropicMessage("user", msg)
+			if err != nil {
+				return nil, fmt.Errorf("message %d: %w", i, err)
+			}
+			out.Messages = append(out.Messages, converted)
+		case Agent:
+			out.Messages = append(out.Messages, toAnthropicAgentMessage(msg))
+		case ToolResult:
+			out.Messages = appendAnthropicToolResult(out.Messages, msg)
+		default:
+			return nil, fmt.Errorf("message %d: unsupported role %q", i, msg.Role)
+		}
+	}
+	return json.Marshal(out)
+}
+
+// splitConversation returns the raw messages of a stored conversation, given as an array
+// of messages or as a request object, and the raw system field of the request
+func splitConversation(data []byte) ([]json.RawMessage, json.RawMessage, error) {
+	data = bytes.TrimSpace(data)
+	var messages []json.RawMessage
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse messages: %w", err)
+		}
+		return messages, nil, nil
+	}
+
+	var request struct {
+		Messages []json.RawMessage `json:"messages"`
+		System   json.RawMessage   `json:"system"`
+	}
+	if err := json.Unmarshal(data, &request); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse messages: %w", err)
+	}
+	return request.Messages, request.System, nil
+}
+
+// anthropicSystemText returns the text of an Anthropic system prompt, a string or an array of text blocks
+func anthropicSystemText(system json.RawMessage) (string, error) {
+	var text string
+	if err := json.Unmarshal(system, &text); err == nil {
+		return text, nil
+	}
+	var blocks []AnthropicContentBlock
+	if err := json.Unmarshal(system, &blocks); err != nil {
+		return "", fmt.Errorf("failed to parse system prompt: %w", err)
+	}
+	texts := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		texts = append(texts, block.Text)
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// partFromURL creates a media part from a URL, decoding data: URLs into inline data
+func partFromURL(partType, url string) (Part, error) {
+	rest, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return Part{Type: partType, URL: url}, nil
+	}
+	meta, encoded, ok := strings.Cut(rest, ",")
+	mime, isBase64 := strings.CutSuffix(meta, ";base64")
+	if !ok || !isBase64 {
+		return Part{}, fmt.Errorf("unsupported data URL, base64 encoding expected")
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return Part{}, fmt.Errorf("invalid data URL: %w", err)
+	}
+	return Part{Type: partType, Data: data, MimeType: mime}, nil
+}
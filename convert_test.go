@@ -0,0 +1,119 @@
+package echo
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOpenAIConversion(t *testing.T) {
+	data := []byte(`{"model": "gpt-4.1", "messages": [
+		{"role": "developer", "content": "Be brief."},
+		{"role": "user", "content": [
+			{"type": "text", "text": "What is this?"},
+			{"type": "image_url", "image_url": {"url": "data:image/png;base64,iVBORw=="}},
+			{"type": "input_audio", "input_audio": {"data": "UklGRg==", "format": "mp3"}}
+		]},
+		{"role": "assistant", "content": "A cat."}
+	]}`)
+
+	messages, err := FromOpenAIMessages(data)
+	if err != nil {
+		t.Fatalf("FromOpenAIMessages() error = %v", err)
+	}
+	if len(messages) != 3 || messages[0].Role != System || messages[2].Role != Agent || messages[2].Content != "A cat." {
+		t.Fatalf("Unexpected messages: %+v", messages)
+	}
+	user := messages[1]
+	if user.Content != "What is this?" || len(user.Parts) != 2 {
+		t.Fatalf("Unexpected user message: %+v", user)
+	}
+	if user.Parts[0].MimeType != "image/png" || !bytes.Equal(user.Parts[0].Data, []byte{0x89, 'P', 'N', 'G'}) {
+		t.Errorf("Unexpected image part: %+v", user.Parts[0])
+	}
+	if user.Parts[1].Type != PartAudio || user.Parts[1].MimeType != "audio/mpeg" {
+		t.Errorf("Unexpected audio part: %+v", user.Parts[1])
+	}
+
+	out, err := ToOpenAIMessages(messages)
+	if err != nil {
+		t.Fatalf("ToOpenAIMessages() error = %v", err)
+	}
+	again, err := FromOpenAIMessages(out)
+	if err != nil || len(again) != 3 || again[1].Parts[0].MimeType != "image/png" || again[1].Parts[1].MimeType != "audio/mpeg" {
+		t.Errorf("Round trip failed: %s, %v", out, err)
+	}
+
+	_, err = FromOpenAIMessages([]byte(`[{"role": "assistant", "content": null, "tool_calls": [{"id": "1"}]}]`))
+	if err == nil || !strings.Contains(err.Error(), "tool calls") {
+		t.Errorf("Expected tool calls error, got %v", err)
+	}
+}
+
+func TestAnthropicConversion(t *testing.T) {
+	data := []byte(`{"system": [{"type": "text", "text": "Be brief."}], "messages": [
+		{"role": "user", "content": [
+			{"type": "image", "source": {"type": "url", "url": "https://example.com/cat.png"}},
+			{"type": "text", "text": "What is this?"}
+		]},
+		{"role": "assistant", "content": [{"type": "thinking", "thinking": "..."}, {"type": "text", "text": "A cat."}]}
+	]}`)
+
+	messages, err := FromAnthropicMessages(data)
+	if err != nil {
+		t.Fatalf("FromAnthropicMessages() error = %v", err)
+	}
+	if len(messages) != 3 || messages[0].Content != "Be brief." || messages[2].Content != "A cat." {
+		t.Fatalf("Unexpected messages: %+v", messages)
+	}
+	if messages[1].Content != "What is this?" || messages[1].Parts[0].URL != "https://example.com/cat.png" {
+		t.Errorf("Unexpected user message: %+v", messages[1])
+	}
+
+	out, err := ToAnthropicMessages(messages)
+	if err != nil {
+		t.Fatalf("ToAnthropicMessages() error = %v", err)
+	}
+	var request struct {
+		System   string            `json:"system"`
+		Messages []json.RawMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(out, &request); err != nil || request.System != "Be brief." || len(request.Messages) != 2 {
+		t.Errorf("Unexpected output: %s", out)
+	}
+
+	_, err = FromAnthropicMessages([]byte(`[{"role": "user", "content": [{"type": "tool_result", "tool_use_id": "1"}]}]`))
+	if err == nil {
+		t.Error("Expected an error for tool results")
+	}
+}
+
+func TestToolCallConversion(t *testing.T) {
+	messages := []Message{
+		{Role: User, Content: "Weather in Paris?"},
+		{Role: Agent, ToolCalls: []ToolCall{{ID: "call_1", Name: "weather", Arguments: json.RawMessage(`{"city":"Paris"}`)}}},
+		ToolResultMessage("call_1", "21C"),
+		{Role: Agent, Content: "It is 21C."},
+	}
+
+	out, err := ToOpenAIMessages(messages)
+	if err != nil {
+		t.Fatalf("ToOpenAIMessages() error = %v", err)
+	}
+	var openai []map[string]any
+	json.Unmarshal(out, &openai)
+	calls, _ := openai[1]["tool_calls"].([]any)
+	if len(calls) != 1 || openai[2]["role"] != "tool" || openai[2]["tool_call_id"] != "call_1" {
+		t.Errorf("Unexpected OpenAI messages: %s", out)
+	}
+
+	out, err = ToAnthropicMessages(messages)
+	if err != nil {
+		t.Fatalf("ToAnthropicMessages() error = %v", err)
+	}
+	if !strings.Contains(string(out), `"type":"tool_use","id":"call_1","name":"weather","input":{"city":"Paris"}`) ||
+		!strings.Contains(string(out), `"type":"tool_result","tool_use_id":"call_1","content":"21C"`) {
+		t.Errorf("Unexpected Anthropic messages: %s", out)
+	}
+}
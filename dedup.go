@@ -0,0 +1,58 @@
+package echo
+
+// Deduplicator drops near-duplicate embeddings during ingestion: an embedding is kept
+// only when its cosine similarity to every embedding kept so far is below the threshold
+type Deduplicator struct {
+	Threshold float32 // e.g. 0.95; similarity at which an embedding counts as a duplicate
+
+	kept [][]float32 // unit-length copies of the kept embeddings
+}
+
+// NewDeduplicator creates a deduplicator with the similarity threshold
+func NewDeduplicator(threshold float32) *Deduplicator {
+	return &Deduplicator{Threshold: threshold}
+}
+
+// Add returns true and remembers the embedding when it is not a near-duplicate of the
+// embeddings kept so far. Embeddings of a different dimension never match.
+func (d *Deduplicator) Add(v []float32) bool {
+	unit := make([]float32, len(v))
+	if n := norm(v); n > 0 {
+		for i, x := range v {
+			unit[i] = x / n
+		}
+	}
+
+	for _, k := range d.kept {
+		if len(k) != len(unit) {
+			continue
+		}
+		var dot float32
+		for i, x := range k {
+			dot += x * unit[i]
+		}
+		if dot >= d.Threshold {
+			return false
+		}
+	}
+	d.kept = append(d.kept, unit)
+	return true
+}
+
+// Len returns the number of kept embeddings
+func (d *Deduplicator) Len() int {
+	return len(d.kept)
+}
+
+// DedupEmbeddings returns the indexes of the vectors to keep, in order; a vector is dropped
+// when its cosine similarity to an earlier kept vector reaches the threshold
+func DedupEmbeddings(vectors [][]float32, threshold float32) []int {
+	d := NewDeduplicator(threshold)
+	keep := make([]int, 0, len(vectors))
+	for i, v := range vectors {
+		if d.Add(v) {
+			keep = append(keep, i)
+		}
+	}
+	return keep
+}
@@ -0,0 +1,29 @@
+package echo
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDedupEmbeddings(t *testing.T) {
+	vectors := [][]float32{
+		{1, 0, 0},
+		{0.99, 0.05, 0}, // near-duplicate of the first
+		{0, 1, 0},
+		{2, 0, 0}, // same direction as the first, different length
+		{0, 0.7, 0.7},
+	}
+	if got := DedupEmbeddings(vectors, 0.95); !slices.Equal(got, []int{0, 2, 4}) {
+		t.Errorf("DedupEmbeddings() = %v, want [0 2 4]", got)
+	}
+	if got := DedupEmbeddings(vectors, 1.01); len(got) != len(vectors) {
+		t.Errorf("Expected all vectors kept above the maximum similarity, got %v", got)
+	}
+
+	// Ingestion in batches keeps the state
+	d := NewDeduplicator(0.95)
+	d.Add(vectors[0])
+	if d.Add(vectors[3]) || !d.Add(vectors[2]) || d.Len() != 2 {
+		t.Errorf("Unexpected deduplication across batches, kept %d", d.Len())
+	}
+}
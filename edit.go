@@ -0,0 +1,276 @@
+package echo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Edit output formats
+const (
+	EditSearchReplace = "search_replace" // SEARCH/REPLACE blocks, the default
+	EditUnifiedDiff   = "unified_diff"   // unified diff hunks
+)
+
+// editAttempts is the number of calls made when the model returns a patch that cannot be applied
+const editAttempts = 3
+
+const searchReplacePrompt = `Apply the instructions to the file below. Reply only with edit blocks in this exact format:
+
+<<<<<<< SEARCH
+lines copied exactly from the file
+=======
+lines to put in their place
+>>>>>>> REPLACE
+
+Each SEARCH part must match the file exactly, including whitespace, and occur only once; include enough lines to make it unique.
+Use several blocks for changes in different places. Do not repeat unchanged parts of the file.`
+
+const unifiedDiffPrompt = `Apply the instructions to the file below. Reply only with a unified diff of the file:
+hunks starting with "@@ -start,count +start,count @@", context lines prefixed with a space,
+removed lines with "-" and added lines with "+". Keep 2-3 lines of context around each change.`
+
+// Edit applies the instructions to the text through a patch generated by the model.
+// The model answers with a patch instead of the whole file; the patch is applied locally,
+// and when it doesn't match the file the error is sent back and the model asked to fix it.
+func Edit(ctx context.Context, client Client, original string, instructions string, opts ...CallOption) (*Response, error) {
+	cfg := clientConfig(client, opts)
+
+	prompt := searchReplacePrompt
+	apply := ApplySearchReplace
+	switch cfg.EditFormat {
+	case "", EditSearchReplace:
+	case EditUnifiedDiff:
+		prompt, apply = unifiedDiffPrompt, ApplyUnifiedDiff
+	default:
+		return nil, fmt.Errorf("unknown edit format: %s", cfg.EditFormat)
+	}
+
+	messages := []Message{{Role: User, Content: "Instructions:\n" + instructions + "\n\nFile:\n" + original}}
+	opts = append(slices.Clip(opts), WithSystemInstructions(prompt))
+
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		attemptOpts, ok := attemptOptions(cfg.RetrySchedule, opts, attempt, editAttempts)
+		if !ok {
+			break
+		}
+
+		var resp *Response
+		resp, err = client.Complete(ctx, messages, attemptOpts...)
+		if err != nil {
+			return nil, err
+		}
+
+		var edited string
+		if edited, err = apply(original, resp.Text); err == nil {
+			if resp.Metadata == nil {
+				resp.Metadata = Metadata{}
+			}
+			resp.Metadata["edit_attempts"] = attempt
+			resp.Metadata["patch"] = resp.Text
+			resp.Text = edited
+			return resp, nil
+		}
+
+		messages = append(messages,
+			Message{Role: Agent, Content: resp.Text},
+			Message{Role: User, Content: "The patch could not be applied: " + err.Error() +
+				"\nReply with the corrected patch for the original file, in the same format."},
+		)
+	}
+
+	return nil, fmt.Errorf("failed to apply edit after %d attempts: %w", attempt-1, err)
+}
+
+// ApplySearchReplace applies SEARCH/REPLACE blocks to the text. Each search part must
+// occur exactly once in the text, at the moment its block is applied.
+func ApplySearchReplace(text, patch string) (string, error) {
+	lines := strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+	blocks := 0
+
+	for i := 0; i < len(lines); i++ {
+		if !strings.HasPrefix(lines[i], "<<<<<<< SEARCH") {
+			continue
+		}
+		blocks++
+
+		var search, replace []string
+		part := &search
+		closed := false
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if strings.HasPrefix(line, "=======") && part == &search {
+				part = &replace
+				continue
+			}
+			if strings.HasPrefix(line, ">>>>>>> REPLACE") {
+				closed = true
+				break
+			}
+			*part = append(*part, line)
+		}
+		if !closed || part != &replace {
+			return "", fmt.Errorf("block %d is not terminated with ======= and >>>>>>> REPLACE", blocks)
+		}
+
+		from, to := strings.Join(search, "\n"), strings.Join(replace, "\n")
+		if from == "" {
+			if text != "" {
+				return "", fmt.Errorf("block %d has an empty SEARCH part", blocks)
+			}
+			text = to
+			continue
+		}
+		switch strings.Count(text, from) {
+		case 0:
+			return "", fmt.Errorf("SEARCH part of block %d does not match the file", blocks)
+		case 1:
+			text = strings.Replace(text, from, to, 1)
+		default:
+			return "", fmt.Errorf("SEARCH part of block %d matches several places, add more lines", blocks)
+		}
+	}
+
+	if blocks == 0 {
+		return "", fmt.Errorf("no SEARCH/REPLACE blocks found")
+	}
+	return text, nil
+}
+
+// ApplyUnifiedDiff applies the hunks of a unified diff to the text. Line numbers in hunk
+// headers are used as hints only: each hunk is placed where its context matches,
+// the closest match to the stated position wins.
+func ApplyUnifiedDiff(text, diff string) (string, error) {
+	// The diff may come wrapped in a code block
+	if code, ok := ExtractFirstCode(diff, ""); ok {
+		diff = code
+	}
+
+	lines := strings.Split(text, "\n")
+	diffLines := strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n")
+	hunks := 0
+	offset := 0 // shift of positions caused by the previous hunks
+
+	for i := 0; i < len(diffLines); i++ {
+		if !strings.HasPrefix(diffLines[i], "@@") {
+			continue
+		}
+		hunks++
+		start, oldLines, newLines, counted := hunkRange(diffLines[i])
+		hint := start + offset
+
+		var from, to []string
+		for i+1 < len(diffLines) && !strings.HasPrefix(diffLines[i+1], "@@") {
+			line := diffLines[i+1]
+			// Once the ranges of the header are covered, a file header ends the hunk;
+			// within them "--- " and "+++ " are removed and added lines
+			if counted && len(from) >= oldLines && len(to) >= newLines && fileHeader(line) {
+				break
+			}
+			i++
+			switch {
+			case strings.HasPrefix(line, `\`):
+				// "\ No newline at end of file"
+			case line == "":
+				// Models often drop the space of empty context lines
+				from, to = append(from, ""), append(to, "")
+			case line[0] == ' ':
+				from, to = append(from, line[1:]), append(to, line[1:])
+			case line[0] == '-':
+				from = append(from, line[1:])
+			case line[0] == '+':
+				to = append(to, line[1:])
+			default:
+				return "", fmt.Errorf("hunk %d has an invalid line: %q", hunks, line)
+			}
+		}
+
+		// Trailing empty lines usually come from the end of the diff, not from the file
+		for len(from) > 0 && len(to) > 0 && from[len(from)-1] == "" && to[len(to)-1] == "" {
+			from, to = from[:len(from)-1], to[:len(to)-1]
+		}
+
+		pos := findLines(lines, from, hint)
+		if pos == -1 {
+			return "", fmt.Errorf("hunk %d does not match the file", hunks)
+		}
+		updated := make([]string, 0, len(lines)-len(from)+len(to))
+		updated = append(updated, lines[:pos]...)
+		updated = append(updated, to...)
+		lines = append(updated, lines[pos+len(from):]...)
+		offset += len(to) - len(from)
+	}
+
+	if hunks == 0 {
+		return "", fmt.Errorf("no diff hunks found")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// hunkRange reads a "@@ -start,count +start,count @@" header and returns the zero-based
+// start line of the original file and the line counts of both sides. Models often omit
+// the numbers, counted is false when they can't be read.
+func hunkRange(header string) (start, oldLines, newLines int, counted bool) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, 0, false
+	}
+	oldStart, oldLines, oldOK := parseRange(fields[1][1:])
+	_, newLines, newOK := parseRange(fields[2][1:])
+	if oldStart > 0 {
+		start = oldStart - 1
+	}
+	return start, oldLines, newLines, oldOK && newOK
+}
+
+// parseRange reads the "start,count" range of a hunk header, the count is 1 when omitted
+func parseRange(field string) (start, count int, ok bool) {
+	first, second, hasCount := strings.Cut(field, ",")
+	start, err := strconv.Atoi(first)
+	if err != nil || start < 0 {
+		return 0, 0, false
+	}
+	if !hasCount {
+		return start, 1, true
+	}
+	count, err = strconv.Atoi(second)
+	if err != nil || count < 0 {
+		return start, 0, false
+	}
+	return start, count, true
+}
+
+// fileHeader reports whether the diff line starts the header of another file
+func fileHeader(line string) bool {
+	return strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ") ||
+		strings.HasPrefix(line, "diff ") || strings.HasPrefix(line, "index ")
+}
+
+// findLines returns the position of the block in the lines closest to the hint, or -1
+func findLines(lines, block []string, hint int) int {
+	best := -1
+	for pos := 0; pos+len(block) <= len(lines); pos++ {
+		match := true
+		for j, line := range block {
+			if lines[pos+j] != line {
+				match = false
+				break
+			}
+		}
+		if match && (best == -1 || abs(pos-hint) < abs(best-hint)) {
+			best = pos
+		}
+	}
+	return best
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,98 @@
+package echo
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// scriptedProvider answers calls with the prepared texts in order
+type scriptedProvider struct {
+	MockProvider
+	answers []string
+	calls   [][]Message
+	configs []CallConfig
+}
+
+func (p *scriptedProvider) call(ctx context.Context, messages []Message, cfg CallConfig) (*Response, error) {
+	p.calls = append(p.calls, messages)
+	p.configs = append(p.configs, cfg)
+	answer := p.answers[0]
+	p.answers = p.answers[1:]
+	return &Response{Text: answer}, nil
+}
+
+const editSource = "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n"
+
+func TestApplySearchReplace(t *testing.T) {
+	patch := "```\n<<<<<<< SEARCH\n\tprintln(\"hello\")\n=======\n\tprintln(\"hello, world\")\n>>>>>>> REPLACE\n```"
+	out, err := ApplySearchReplace(editSource, patch)
+	if err != nil || out != strings.Replace(editSource, `"hello"`, `"hello, world"`, 1) {
+		t.Errorf("ApplySearchReplace() = %q, %v", out, err)
+	}
+
+	if _, err := ApplySearchReplace(editSource, "<<<<<<< SEARCH\nmissing\n=======\nx\n>>>>>>> REPLACE"); err == nil {
+		t.Error("Expected an error for a search part that does not match")
+	}
+	if _, err := ApplySearchReplace(editSource, "<<<<<<< SEARCH\n}\n=======\n"); err == nil {
+		t.Error("Expected an error for an unterminated block")
+	}
+	if _, err := ApplySearchReplace("a\na\n", "<<<<<<< SEARCH\na\n=======\nb\n>>>>>>> REPLACE"); err == nil {
+		t.Error("Expected an error for an ambiguous search part")
+	}
+}
+
+func TestApplyUnifiedDiff(t *testing.T) {
+	// Wrong line numbers and a dropped space on the empty context line
+	diff := "```diff\n--- a/main.go\n+++ b/main.go\n@@ -10,4 +10,5 @@\n package main\n\n func main() {\n-\tprintln(\"hello\")\n+\tprintln(\"hello\")\n+\tprintln(\"world\")\n }\n```"
+	out, err := ApplyUnifiedDiff(editSource, diff)
+	expected := "package main\n\nfunc main() {\n\tprintln(\"hello\")\n\tprintln(\"world\")\n}\n"
+	if err != nil || out != expected {
+		t.Errorf("ApplyUnifiedDiff() = %q, %v", out, err)
+	}
+
+	if _, err := ApplyUnifiedDiff(editSource, "@@ -1,1 +1,1 @@\n-missing\n+x"); err == nil {
+		t.Error("Expected an error for a hunk that does not match")
+	}
+	if _, err := ApplyUnifiedDiff(editSource, "no diff here"); err == nil {
+		t.Error("Expected an error without hunks")
+	}
+
+	// Removed "-- " and added "++ " lines look like file headers
+	sql := "SELECT 1;\n-- old note\nSELECT 2;\n"
+	diff = "--- a/q.sql\n+++ b/q.sql\n@@ -1,3 +1,3 @@\n SELECT 1;\n--- old note\n+++ new note\n SELECT 2;\n--- a/other.sql\n+++ b/other.sql\n"
+	if out, err := ApplyUnifiedDiff(sql, diff); err != nil || out != "SELECT 1;\n++ new note\nSELECT 2;\n" {
+		t.Errorf("ApplyUnifiedDiff() = %q, %v", out, err)
+	}
+	if out, err := ApplyUnifiedDiff(sql, "@@ @@\n SELECT 1;\n--- old note\n SELECT 2;"); err != nil || out != "SELECT 1;\nSELECT 2;\n" {
+		t.Errorf("ApplyUnifiedDiff() without ranges = %q, %v", out, err)
+	}
+}
+
+func TestEdit(t *testing.T) {
+	provider := &scriptedProvider{answers: []string{
+		"<<<<<<< SEARCH\n\tprintln(\"bye\")\n=======\n\tprintln(\"hi\")\n>>>>>>> REPLACE",
+		"<<<<<<< SEARCH\n\tprintln(\"hello\")\n=======\n\tprintln(\"hi\")\n>>>>>>> REPLACE",
+	}}
+	client, _ := NewClient(WithModel("mock/any"), WithSystemMessage("You are helpful"))
+	client.SetProvider("mock", provider)
+
+	resp, err := Edit(context.Background(), client, editSource, "Greet with hi")
+	if err != nil {
+		t.Fatalf("Edit() error = %v", err)
+	}
+	if resp.Text != strings.Replace(editSource, "hello", "hi", 1) || resp.Metadata["edit_attempts"] != 2 {
+		t.Errorf("Unexpected result %q, %v", resp.Text, resp.Metadata)
+	}
+
+	// The second call gets the failed patch and the error
+	retry := provider.calls[1]
+	if len(retry) != 3 || !strings.Contains(retry[2].Content, "does not match") {
+		t.Errorf("Expected the error to be sent back, got %+v", retry)
+	}
+
+	// The format instructions are added to the client prompt
+	if system := provider.configs[1].SystemMsg; system != "You are helpful\n\n"+searchReplacePrompt {
+		t.Errorf("Unexpected system prompt %q", system)
+	}
+}
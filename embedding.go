@@ -0,0 +1,77 @@
+package echo
+
+import (
+	"fmt"
+	"math"
+)
+
+// EmbeddingProjection reduces embeddings with a linear map, e.g. PCA components
+// computed offline for the model: each output value is the dot product of a
+// component with the embedding, after the mean is subtracted
+type EmbeddingProjection struct {
+	Components [][]float32 // one row per output dimension, each as wide as the source embeddings
+	Mean       []float32   // optional, subtracted from embeddings before projecting
+}
+
+// projectEmbedding reduces the embedding to the configured width and renormalizes it
+// to unit length. Without a projection matrix the leading dimensions are kept, the
+// Matryoshka convention of models trained for truncation (OpenAI text-embedding-3, Gemini, Voyage).
+func projectEmbedding(v []float32, cfg CallConfig) ([]float32, error) {
+	var out []float32
+	if p := cfg.EmbeddingProjection; p != nil {
+		if p.Mean != nil && len(p.Mean) != len(v) {
+			return nil, fmt.Errorf("projection mean has dimension %d, embedding has %d", len(p.Mean), len(v))
+		}
+		out = make([]float32, len(p.Components))
+		for i, row := range p.Components {
+			if len(row) != len(v) {
+				return nil, fmt.Errorf("projection component %d has dimension %d, embedding has %d", i, len(row), len(v))
+			}
+			var sum float64
+			for j, x := range v {
+				if p.Mean != nil {
+					x -= p.Mean[j]
+				}
+				sum += float64(row[j]) * float64(x)
+			}
+			out[i] = float32(sum)
+		}
+	} else {
+		n := cfg.EmbeddingDimensions
+		if n > len(v) {
+			return nil, fmt.Errorf("cannot project embedding of dimension %d to %d", len(v), n)
+		}
+		out = append([]float32(nil), v[:n]...)
+	}
+
+	var norm float64
+	for _, x := range out {
+		norm += float64(x) * float64(x)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range out {
+			out[i] *= scale
+		}
+	}
+	return out, nil
+}
+
+// projectEmbeddingResponse applies the configured projection to the response in place
+func projectEmbeddingResponse(resp *EmbeddingResponse, cfg CallConfig) (*EmbeddingResponse, error) {
+	if cfg.EmbeddingDimensions == 0 && cfg.EmbeddingProjection == nil {
+		return resp, nil
+	}
+
+	source := len(resp.Embedding)
+	embedding, err := projectEmbedding(resp.Embedding, cfg)
+	if err != nil {
+		return nil, err
+	}
+	resp.Embedding = embedding
+	if resp.Metadata == nil {
+		resp.Metadata = Metadata{}
+	}
+	resp.Metadata["source_dimensions"] = source
+	return resp, nil
+}
@@ -0,0 +1,68 @@
+package echo
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func unitLength(v []float32) bool {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	return math.Abs(norm-1) < 1e-5
+}
+
+func TestProjectEmbedding(t *testing.T) {
+	v := []float32{3, 4, 12}
+
+	got, err := projectEmbedding(v, CallConfig{EmbeddingDimensions: 2})
+	if err != nil {
+		t.Fatalf("projectEmbedding() error = %v", err)
+	}
+	if len(got) != 2 || math.Abs(float64(got[0])-0.6) > 1e-6 || math.Abs(float64(got[1])-0.8) > 1e-6 {
+		t.Errorf("Truncation = %v, want [0.6 0.8]", got)
+	}
+	if v[0] != 3 {
+		t.Error("Source embedding was modified")
+	}
+
+	projection := &EmbeddingProjection{
+		Components: [][]float32{{0, 0, 1}, {1, 1, 0}},
+		Mean:       []float32{0, 0, 9},
+	}
+	got, err = projectEmbedding(v, CallConfig{EmbeddingProjection: projection})
+	if err != nil {
+		t.Fatalf("projectEmbedding() error = %v", err)
+	}
+	// (12-9, 3+4) = (3, 7), renormalized
+	if len(got) != 2 || !unitLength(got) || math.Abs(float64(got[0]/got[1])-3.0/7) > 1e-6 {
+		t.Errorf("Projection = %v", got)
+	}
+
+	if _, err := projectEmbedding(v, CallConfig{EmbeddingDimensions: 4}); err == nil {
+		t.Error("Expected error when projecting to more dimensions than the source")
+	}
+	projection.Components = append(projection.Components, []float32{1})
+	if _, err := projectEmbedding(v, CallConfig{EmbeddingProjection: projection}); err == nil {
+		t.Error("Expected error for a component of a different dimension")
+	}
+}
+
+func TestEmbeddingDimensions(t *testing.T) {
+	client, _ := NewCommonClient(nil, WithModel("mock/test"))
+
+	resp, err := client.GetEmbeddings(context.Background(), "fixed width vector column", WithEmbeddingDimensions(8))
+	if err != nil {
+		t.Fatalf("GetEmbeddings() error = %v", err)
+	}
+	if len(resp.Embedding) != 8 || resp.Metadata["source_dimensions"] != mockEmbeddingSize {
+		t.Errorf("Got %d dimensions, metadata %v", len(resp.Embedding), resp.Metadata)
+	}
+
+	resp, err = client.GetMultimodalEmbeddings(context.Background(), []Part{TextPart("a red bicycle")}, WithEmbeddingDimensions(8))
+	if err != nil || len(resp.Embedding) != 8 {
+		t.Errorf("GetMultimodalEmbeddings() = %v, %v", resp, err)
+	}
+}
@@ -0,0 +1,221 @@
+package echo
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// StoreCipher encrypts the user data of stored records with AES-GCM, so chat history
+// and answers kept by a ResponseStore, FeedbackStore or JobStore are protected at rest.
+// The texts are sealed into the Sealed field of the records; IDs, models, ratings and
+// timestamps stay readable for indexing and reports.
+type StoreCipher struct {
+	keys []cipher.AEAD
+}
+
+// NewStoreCipher creates a cipher with a 16, 24 or 32 byte key (AES-128, AES-192 or AES-256).
+// Records are sealed with the key; old keys only open records sealed before a key rotation.
+func NewStoreCipher(key []byte, oldKeys ...[]byte) (*StoreCipher, error) {
+	c := &StoreCipher{}
+	for _, k := range append([][]byte{key}, oldKeys...) {
+		block, err := aes.NewCipher(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key: %w", err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		c.keys = append(c.keys, aead)
+	}
+	return c, nil
+}
+
+// seal encrypts the JSON of v, the random nonce is prepended to the ciphertext
+func (c *StoreCipher) seal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	aead := c.keys[0]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, data, nil), nil
+}
+
+// open decrypts sealed data into v, trying the current key first
+func (c *StoreCipher) open(sealed []byte, v any) error {
+	for _, aead := range c.keys {
+		if len(sealed) < aead.NonceSize() {
+			break
+		}
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		if data, err := aead.Open(nil, nonce, ciphertext, nil); err == nil {
+			return json.Unmarshal(data, v)
+		}
+	}
+	return errors.New("failed to decrypt record: wrong key or corrupted data")
+}
+
+// sealedResponse is the encrypted part of a ResponseRecord
+type sealedResponse struct {
+	Response *Response `json:"response,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// SealResponse moves the response and the error of the record into Sealed
+func (c *StoreCipher) SealResponse(record ResponseRecord) (ResponseRecord, error) {
+	sealed, err := c.seal(sealedResponse{Response: record.Response, Error: record.Error})
+	if err != nil {
+		return record, err
+	}
+	record.Response, record.Error, record.Sealed = nil, "", sealed
+	return record, nil
+}
+
+// OpenResponse restores the response and the error of a record saved through the cipher.
+// Records without sealed data are returned as they are.
+func (c *StoreCipher) OpenResponse(record ResponseRecord) (ResponseRecord, error) {
+	if record.Sealed == nil {
+		return record, nil
+	}
+	var data sealedResponse
+	if err := c.open(record.Sealed, &data); err != nil {
+		return record, err
+	}
+	record.Response, record.Error, record.Sealed = data.Response, data.Error, nil
+	return record, nil
+}
+
+// SealFeedback moves the comment of the feedback into Sealed
+func (c *StoreCipher) SealFeedback(feedback Feedback) (Feedback, error) {
+	sealed, err := c.seal(feedback.Comment)
+	if err != nil {
+		return feedback, err
+	}
+	feedback.Comment, feedback.Sealed = "", sealed
+	return feedback, nil
+}
+
+// OpenFeedback restores the comment of feedback saved through the cipher
+func (c *StoreCipher) OpenFeedback(feedback Feedback) (Feedback, error) {
+	if feedback.Sealed == nil {
+		return feedback, nil
+	}
+	if err := c.open(feedback.Sealed, &feedback.Comment); err != nil {
+		return feedback, err
+	}
+	feedback.Sealed = nil
+	return feedback, nil
+}
+
+// sealedJob is the encrypted part of an AsyncJob
+type sealedJob struct {
+	Output   string              `json:"output,omitempty"`
+	Response *CompletionResponse `json:"response,omitempty"`
+	Error    string              `json:"error,omitempty"`
+}
+
+// ResponseStore wraps the store so records are sealed before they are saved.
+// When the store implements FeedbackStore, so does the returned one.
+func (c *StoreCipher) ResponseStore(store ResponseStore) ResponseStore {
+	s := encryptedResponseStore{cipher: c, store: store}
+	if feedback, ok := store.(FeedbackStore); ok {
+		return encryptedFeedbackStore{s, feedback}
+	}
+	return s
+}
+
+// JobStore wraps the store so the output, response and error of jobs are sealed
+// when saved and opened when read, e.g. by JobHandler
+func (c *StoreCipher) JobStore(store JobStore) JobStore {
+	return encryptedJobStore{cipher: c, store: store}
+}
+
+type encryptedResponseStore struct {
+	cipher *StoreCipher
+	store  ResponseStore
+}
+
+// SaveResponse implements the ResponseStore interface
+func (s encryptedResponseStore) SaveResponse(ctx context.Context, record ResponseRecord) error {
+	record, err := s.cipher.SealResponse(record)
+	if err != nil {
+		return err
+	}
+	return s.store.SaveResponse(ctx, record)
+}
+
+// DeleteByUser implements the UserDataStore interface, when the wrapped store does
+func (s encryptedResponseStore) DeleteByUser(ctx context.Context, userID string) error {
+	if store, ok := s.store.(UserDataStore); ok {
+		return store.DeleteByUser(ctx, userID)
+	}
+	return errors.ErrUnsupported
+}
+
+// ApplyRetention implements the RetentionStore interface, when the wrapped store does
+func (s encryptedResponseStore) ApplyRetention(ctx context.Context, policy RetentionPolicy) error {
+	if store, ok := s.store.(RetentionStore); ok {
+		return store.ApplyRetention(ctx, policy)
+	}
+	return errors.ErrUnsupported
+}
+
+type encryptedFeedbackStore struct {
+	encryptedResponseStore
+	feedback FeedbackStore
+}
+
+// SaveFeedback implements the FeedbackStore interface
+func (s encryptedFeedbackStore) SaveFeedback(ctx context.Context, feedback Feedback) error {
+	feedback, err := s.cipher.SealFeedback(feedback)
+	if err != nil {
+		return err
+	}
+	return s.feedback.SaveFeedback(ctx, feedback)
+}
+
+type encryptedJobStore struct {
+	cipher *StoreCipher
+	store  JobStore
+}
+
+// Save implements the JobStore interface
+func (s encryptedJobStore) Save(ctx context.Context, job AsyncJob) error {
+	sealed, err := s.cipher.seal(sealedJob{Output: job.Output, Response: job.Response, Error: job.Error})
+	if err != nil {
+		return err
+	}
+	job.Output, job.Response, job.Error, job.Sealed = "", nil, "", sealed
+	return s.store.Save(ctx, job)
+}
+
+// DeleteByUser implements the UserDataStore interface, when the wrapped store does
+func (s encryptedJobStore) DeleteByUser(ctx context.Context, userID string) error {
+	if store, ok := s.store.(UserDataStore); ok {
+		return store.DeleteByUser(ctx, userID)
+	}
+	return errors.ErrUnsupported
+}
+
+// Get implements the JobStore interface
+func (s encryptedJobStore) Get(ctx context.Context, id string) (AsyncJob, error) {
+	job, err := s.store.Get(ctx, id)
+	if err != nil || job.Sealed == nil {
+		return job, err
+	}
+	var data sealedJob
+	if err := s.cipher.open(job.Sealed, &data); err != nil {
+		return AsyncJob{}, err
+	}
+	job.Output, job.Response, job.Error, job.Sealed = data.Output, data.Response, data.Error, nil
+	return job, nil
+}
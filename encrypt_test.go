@@ -0,0 +1,87 @@
+package echo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreCipher(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	c, err := NewStoreCipher(key)
+	if err != nil {
+		t.Fatalf("NewStoreCipher() error = %v", err)
+	}
+	if _, err := NewStoreCipher([]byte("short")); err == nil {
+		t.Error("Expected an error for an invalid key size")
+	}
+
+	store := &feedbackStore{}
+	client, _ := NewCommonClient(nil, WithModel("mock/test"), WithStreamPersistence(c.ResponseStore(store)))
+	ctx := context.Background()
+
+	resp, err := client.Complete(ctx, QuickMessage("My card is 4111"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if err := client.RecordFeedback(ctx, resp.Metadata["call_id"].(string), -1, "Wrong card"); err != nil {
+		t.Fatalf("RecordFeedback() error = %v", err)
+	}
+
+	// The stored texts are sealed, the IDs stay readable
+	saved, _ := json.Marshal([]any{store.records, store.feedback})
+	record, feedback := store.records[0], store.feedback[0]
+	if record.Response != nil || record.CallID == "" || feedback.Comment != "" || feedback.Rating != -1 ||
+		strings.Contains(string(saved), "4111") || strings.Contains(string(saved), "Wrong card") {
+		t.Fatalf("Expected sealed records, got %+v, %+v", record, feedback)
+	}
+
+	record, err = c.OpenResponse(record)
+	if err != nil || record.Response == nil || record.Response.Text != resp.Text || record.Sealed != nil {
+		t.Errorf("OpenResponse() = %+v, %v", record, err)
+	}
+	if feedback, err = c.OpenFeedback(feedback); err != nil || feedback.Comment != "Wrong card" {
+		t.Errorf("OpenFeedback() = %+v, %v", feedback, err)
+	}
+
+	// Old keys open records sealed before a rotation, other keys don't
+	rotated, _ := NewStoreCipher(bytes.Repeat([]byte{2}, 32), key)
+	if _, err := rotated.OpenResponse(store.records[0]); err != nil {
+		t.Errorf("Expected the old key to open the record, got %v", err)
+	}
+	other, _ := NewStoreCipher(bytes.Repeat([]byte{3}, 32))
+	if _, err := other.OpenResponse(store.records[0]); err == nil {
+		t.Error("Expected an error for a wrong key")
+	}
+
+	// Feedback is supported only when the wrapped store supports it
+	if _, ok := c.ResponseStore(make(chanResponseStore, 1)).(FeedbackStore); ok {
+		t.Error("Expected no feedback support for a store without it")
+	}
+}
+
+func TestStoreCipherJobs(t *testing.T) {
+	c, _ := NewStoreCipher(bytes.Repeat([]byte{1}, 16))
+	inner := NewMemoryJobStore(time.Hour)
+	jobs := c.JobStore(inner)
+	ctx := context.Background()
+
+	job := AsyncJob{ID: "job_1", Status: JobCompleted, Output: "secret answer", UpdatedAt: time.Now()}
+	if err := jobs.Save(ctx, job); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if stored, _ := inner.Get(ctx, "job_1"); stored.Output != "" || stored.Sealed == nil || stored.Status != JobCompleted {
+		t.Errorf("Expected a sealed job, got %+v", stored)
+	}
+
+	// The handler serves the opened job
+	w := httptest.NewRecorder()
+	JobHandler(jobs).ServeHTTP(w, httptest.NewRequest("GET", "/v1/jobs/job_1", nil))
+	if !strings.Contains(w.Body.String(), `"output":"secret answer"`) || strings.Contains(w.Body.String(), "sealed") {
+		t.Errorf("Unexpected job %s", w.Body.String())
+	}
+}
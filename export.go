@@ -0,0 +1,205 @@
+package echo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// ExportOptions controls how a conversation is rendered by ExportMarkdown and ExportHTML
+type ExportOptions struct {
+	Title  string                // heading of the transcript, omitted when empty
+	Redact func(Message) Message // applied to each message before rendering, e.g. RedactPII()
+	Media  bool                  // HTML: embed media with inline data instead of a placeholder
+}
+
+// RedactPII returns a redaction hook that masks emails, phones and the given names.
+// The same value gets the same placeholder in every message of the transcript.
+func RedactPII(names ...string) func(Message) Message {
+	masker := NewPIIMasker(names...)
+	return func(msg Message) Message {
+		return masker.MaskMessages([]Message{msg})[0]
+	}
+}
+
+// roleTitle returns the display name of a message role
+func roleTitle(role string) string {
+	switch role {
+	case System:
+		return "System"
+	case User:
+		return "User"
+	case Agent:
+		return "Assistant"
+	case ToolResult:
+		return "Tool"
+	}
+	return role
+}
+
+// callArguments returns the arguments of a tool call, indented when they are valid JSON
+func callArguments(call ToolCall) string {
+	args := toolArguments(call)
+	var out bytes.Buffer
+	if err := json.Indent(&out, args, "", "  "); err != nil {
+		return string(args)
+	}
+	return out.String()
+}
+
+// partLabel describes a media part in a single line, e.g. "image/png, 12 KB"
+func partLabel(p Part) string {
+	if p.URL != "" {
+		return p.Type + ": " + p.URL
+	}
+	size := len(p.Data)
+	if size >= 1024 {
+		return fmt.Sprintf("%s: %s, %d KB", p.Type, p.MimeType, size/1024)
+	}
+	return fmt.Sprintf("%s: %s, %d bytes", p.Type, p.MimeType, size)
+}
+
+// redacted applies the redaction hook to the messages
+func (o ExportOptions) redacted(messages []Message) []Message {
+	if o.Redact == nil {
+		return messages
+	}
+	out := make([]Message, len(messages))
+	for i, msg := range messages {
+		out[i] = o.Redact(msg)
+	}
+	return out
+}
+
+// ExportMarkdown renders the conversation as a Markdown transcript. Message content is
+// kept as is, media parts are listed as placeholders, tool calls are shown with their arguments.
+func ExportMarkdown(messages []Message, opts ExportOptions) string {
+	var sb strings.Builder
+	if opts.Title != "" {
+		sb.WriteString("# " + opts.Title + "\n\n")
+	}
+
+	for i, msg := range opts.redacted(messages) {
+		if i > 0 {
+			sb.WriteString("\n---\n\n")
+		}
+		sb.WriteString("### " + roleTitle(msg.Role) + "\n\n")
+		if msg.ToolCallID != "" {
+			sb.WriteString("_Result of `" + msg.ToolCallID + "`_\n\n")
+		}
+		if content := strings.TrimSpace(msg.Content); content != "" {
+			sb.WriteString(content + "\n")
+		}
+		for _, part := range msg.Parts {
+			if part.Type == PartText {
+				sb.WriteString("\n" + strings.TrimSpace(part.Text) + "\n")
+			} else {
+				sb.WriteString("\n_[" + partLabel(part) + "]_\n")
+			}
+		}
+		for _, call := range msg.ToolCalls {
+			sb.WriteString("\n**Tool call** `" + call.Name + "` (`" + call.ID + "`)\n\n")
+			sb.WriteString("```json\n" + callArguments(call) + "\n```\n")
+		}
+	}
+	return sb.String()
+}
+
+var exportTemplate = template.Must(template.New("transcript").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{if .Title}}{{.Title}}{{else}}Conversation{{end}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+.message { border-radius: 8px; padding: 0.75rem 1rem; margin: 1rem 0; background: #f4f4f5; }
+.message.user { background: #e8f0fe; }
+.message.system { background: #fff8e1; }
+.message.tool { background: #eef7ee; }
+.role { font-weight: 600; font-size: 0.85rem; margin-bottom: 0.5rem; }
+.content { white-space: pre-wrap; }
+.media { color: #666; font-style: italic; margin-top: 0.5rem; }
+.call { margin-top: 0.5rem; }
+.call pre { background: #fff; border-radius: 4px; padding: 0.5rem; margin: 0.25rem 0 0; overflow-x: auto; }
+img, video { max-width: 100%; }
+</style>
+</head>
+<body>
+{{if .Title}}<h1>{{.Title}}</h1>
+{{end}}{{range .Messages}}<div class="message {{.Role}}">
+<div class="role">{{.Title}}</div>
+{{if .CallID}}<div class="media">Result of {{.CallID}}</div>
+{{end}}{{if .Content}}<div class="content">{{.Content}}</div>
+{{end}}{{range .Parts}}{{if .Text}}<div class="content">{{.Text}}</div>
+{{else if .Image}}<img src="{{.Image}}" alt="{{.Label}}">
+{{else if .Audio}}<audio controls src="{{.Audio}}"></audio>
+{{else if .Video}}<video controls src="{{.Video}}"></video>
+{{else if .Link}}<div class="media"><a href="{{.Link}}">{{.Label}}</a></div>
+{{else}}<div class="media">[{{.Label}}]</div>
+{{end}}{{end}}{{range .ToolCalls}}<div class="call"><div class="role">Tool call: {{.Name}} ({{.ID}})</div><pre>{{.Arguments}}</pre></div>
+{{end}}</div>
+{{end}}</body>
+</html>
+`))
+
+type exportPart struct {
+	Text                string
+	Label               string
+	Link                string
+	Image, Audio, Video template.URL // inline data URLs
+}
+
+type exportToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
+}
+
+type exportMessage struct {
+	Role      string
+	Title     string
+	Content   string
+	CallID    string // tool call answered by a tool result
+	Parts     []exportPart
+	ToolCalls []exportToolCall
+}
+
+// ExportHTML renders the conversation as a standalone HTML page. All content is escaped;
+// media is embedded only when opts.Media is set, remote media is linked.
+func ExportHTML(messages []Message, opts ExportOptions) string {
+	data := struct {
+		Title    string
+		Messages []exportMessage
+	}{Title: opts.Title}
+
+	for _, msg := range opts.redacted(messages) {
+		em := exportMessage{Role: msg.Role, Title: roleTitle(msg.Role), Content: strings.TrimSpace(msg.Content), CallID: msg.ToolCallID}
+		for _, part := range msg.Parts {
+			ep := exportPart{Label: partLabel(part)}
+			switch {
+			case part.Type == PartText:
+				ep.Text = strings.TrimSpace(part.Text)
+			case part.URL != "":
+				ep.Link = part.URL
+			case opts.Media && part.Type == PartImage:
+				ep.Image = template.URL(part.dataURL())
+			case opts.Media && part.Type == PartAudio:
+				ep.Audio = template.URL(part.dataURL())
+			case opts.Media && part.Type == PartVideo:
+				ep.Video = template.URL(part.dataURL())
+			}
+			em.Parts = append(em.Parts, ep)
+		}
+		for _, call := range msg.ToolCalls {
+			em.ToolCalls = append(em.ToolCalls, exportToolCall{ID: call.ID, Name: call.Name, Arguments: callArguments(call)})
+		}
+		data.Messages = append(data.Messages, em)
+	}
+
+	var sb strings.Builder
+	// The template is fixed and the data has no functions, so execution cannot fail
+	exportTemplate.Execute(&sb, data)
+	return sb.String()
+}
@@ -0,0 +1,85 @@
+package echo
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var exportConversation = []Message{
+	{Role: System, Content: "You are helpful."},
+	{Role: User, Content: "Mail <b>john@example.com</b> about this", Parts: []Part{
+		ImagePart(make([]byte, 2048), "image/png"),
+		ImageURLPart("https://example.com/cat.png"),
+	}},
+	{Role: Agent, Content: "Done, I wrote to john@example.com."},
+}
+
+func TestExportMarkdown(t *testing.T) {
+	md := ExportMarkdown(exportConversation, ExportOptions{Title: "Support chat", Redact: RedactPII()})
+
+	for _, expected := range []string{
+		"# Support chat\n",
+		"### Assistant\n\nDone, I wrote to <EMAIL_1>.",
+		"_[image: image/png, 2 KB]_",
+		"_[image: https://example.com/cat.png]_",
+	} {
+		if !strings.Contains(md, expected) {
+			t.Errorf("Expected %q in:\n%s", expected, md)
+		}
+	}
+	if strings.Contains(md, "john@example.com") {
+		t.Error("Expected the email to be redacted")
+	}
+}
+
+func TestExportHTML(t *testing.T) {
+	page := ExportHTML(exportConversation, ExportOptions{Media: true})
+
+	for _, expected := range []string{
+		"<title>Conversation</title>",
+		"Mail &lt;b&gt;john@example.com&lt;/b&gt; about this",
+		`<img src="data:image/png;base64,`,
+		`<a href="https://example.com/cat.png">`,
+		`<div class="message agent">`,
+	} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Expected %q in:\n%s", expected, page)
+		}
+	}
+
+	page = ExportHTML(exportConversation, ExportOptions{})
+	if strings.Contains(page, "data:image") || !strings.Contains(page, "[image: image/png, 2 KB]") {
+		t.Error("Expected media placeholders without the Media option")
+	}
+}
+
+func TestExportToolCalls(t *testing.T) {
+	messages := []Message{
+		{Role: User, Content: "Weather in Paris?"},
+		{Role: Agent, ToolCalls: []ToolCall{{ID: "call_1", Name: "weather", Arguments: json.RawMessage(`{"city":"<Paris>"}`)}}},
+		ToolResultMessage("call_1", "21C"),
+	}
+
+	md := ExportMarkdown(messages, ExportOptions{})
+	for _, expected := range []string{
+		"**Tool call** `weather` (`call_1`)\n\n```json\n{\n  \"city\": \"<Paris>\"\n}\n```",
+		"### Tool\n\n_Result of `call_1`_\n\n21C",
+	} {
+		if !strings.Contains(md, expected) {
+			t.Errorf("Expected %q in:\n%s", expected, md)
+		}
+	}
+
+	page := ExportHTML(messages, ExportOptions{})
+	for _, expected := range []string{
+		"Tool call: weather (call_1)",
+		"&#34;city&#34;: &#34;&lt;Paris&gt;&#34;",
+		`<div class="message tool">`,
+		"Result of call_1",
+	} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Expected %q in:\n%s", expected, page)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const extractInstruction = "Extract the requested information from the user's text. " +
+	"Use only facts stated in the text; leave fields empty when the information is missing."
+
+// Extract pulls structured information out of the text into a value of type T.
+// The JSON schema is derived from T (see SchemaOf) and the call uses structured output mode.
+func Extract[T any](ctx context.Context, client Client, text string, opts ...CallOption) (T, error) {
+	return completeTyped[T](ctx, client, []Message{{Role: User, Content: text}},
+		append(slices.Clip(opts), WithSystemInstructions(extractInstruction))...)
+}
+
+// Generate completes the message chain into a value of type T. The JSON schema is derived
+// from T (see SchemaOf), the call uses structured output, and it is repeated once when the
+// model returns invalid JSON; WithRetrySchedule changes the attempts.
+func Generate[T any](ctx context.Context, client Client, messages []Message, opts ...CallOption) (T, error) {
+	return completeTyped[T](ctx, client, messages, opts...)
+}
+
+// TypedClient completes message chains into values of T through structured output
+type TypedClient[T any] struct {
+	client Client
+	opts   []CallOption
+}
+
+// Typed wraps the client for completions decoded into T, with options applied to every call.
+// The schema is derived from T as in Extract.
+func Typed[T any](client Client, opts ...CallOption) *TypedClient[T] {
+	return &TypedClient[T]{client: client, opts: opts}
+}
+
+// Complete sends the message chain and decodes the answer into T,
+// repeating the call when the model returns invalid JSON
+func (c *TypedClient[T]) Complete(ctx context.Context, messages []Message, opts ...CallOption) (T, error) {
+	return completeTyped[T](ctx, c.client, messages, append(slices.Clip(c.opts), opts...)...)
+}
+
+// typedAttempts is the number of calls made when the model returns invalid JSON
+const typedAttempts = 2
+
+// completeTyped requests structured output matching T and decodes the response,
+// repeating the call when the model returns JSON that cannot be decoded
+func completeTyped[T any](ctx context.Context, client Client, messages []Message, opts ...CallOption) (T, error) {
+	var result T
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return result, fmt.Errorf("structured output requires a struct type, got %s", t)
+	}
+
+	schema, err := SchemaOfType(t)
+	if err != nil {
+		return result, err
+	}
+
+	name := strings.ToLower(t.Name())
+	if name == "" {
+		name = "result"
+	}
+	callOpts := append([]CallOption{WithStructuredOutput(name, schema)}, opts...)
+	// The schedule may be set on the client or for the call
+	cfg := clientConfig(client, opts)
+
+	for attempt := 1; ; attempt++ {
+		attemptOpts, ok := attemptOptions(cfg.RetrySchedule, callOpts, attempt, typedAttempts)
+		if !ok {
+			break
+		}
+
+		var resp *Response
+		resp, err = client.Complete(ctx, messages, attemptOpts...)
+		if err != nil {
+			return result, err
+		}
+
+		if err = json.Unmarshal([]byte(resp.Text), &result); err == nil {
+			return result, nil
+		}
+		err = fmt.Errorf("failed to decode structured output: %w", err)
+	}
+
+	return result, err
+}
@@ -0,0 +1,125 @@
+package echo
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Fault kinds of WithFaultInjection
+const (
+	FaultLatency   = "latency"    // the request is delayed
+	FaultRateLimit = "rate_limit" // the provider answers 429 Too Many Requests, the request is not sent
+	FaultTruncate  = "truncate"   // the response body ends early, cutting streams and JSON
+	FaultMalformed = "malformed"  // the response body starts with invalid JSON
+)
+
+// faultKinds are the kinds injected when none are given
+var faultKinds = []string{FaultLatency, FaultRateLimit, FaultTruncate, FaultMalformed}
+
+// faultMaxLatency is the longest delay of a latency fault
+var faultMaxLatency = 2 * time.Second
+
+// faultTransport injects failures into provider requests
+type faultTransport struct {
+	base  http.RoundTripper
+	rate  float64
+	kinds []string
+}
+
+// providerClient returns the HTTP client for provider requests, injecting faults when configured
+func (cfg CallConfig) providerClient() *http.Client {
+	client := cfg.httpClient()
+	if cfg.FaultRate <= 0 {
+		return client
+	}
+
+	kinds := cfg.FaultKinds
+	if len(kinds) == 0 {
+		kinds = faultKinds
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	faulty := *client
+	faulty.Transport = &faultTransport{base: base, rate: cfg.FaultRate, kinds: kinds}
+	return &faulty
+}
+
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rand.Float64() >= t.rate {
+		return t.base.RoundTrip(req)
+	}
+
+	switch t.kinds[rand.IntN(len(t.kinds))] {
+	case FaultLatency:
+		if err := sleep(req.Context(), rand.N(faultMaxLatency)); err != nil {
+			return nil, err
+		}
+	case FaultRateLimit:
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return &http.Response{
+			Status:     "429 Too Many Requests",
+			StatusCode: http.StatusTooManyRequests,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{"Content-Type": {"application/json"}, "Retry-After": {"1"}},
+			Body:       io.NopCloser(strings.NewReader(`{"error": {"type": "rate_limit_error", "message": "injected fault: rate limit"}}`)),
+			Request:    req,
+		}, nil
+	case FaultTruncate:
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		// Provider responses and first stream events are longer than 64 bytes
+		resp.Body = faultBody{io.LimitReader(resp.Body, int64(1+rand.IntN(64))), resp.Body}
+		resp.ContentLength = -1
+		return resp, nil
+	case FaultMalformed:
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		// Invalid both as a JSON document and as an SSE event
+		resp.Body = faultBody{io.MultiReader(strings.NewReader("data: {\"malformed\n\n"), resp.Body), resp.Body}
+		resp.ContentLength = -1
+		return resp, nil
+	}
+	return t.base.RoundTrip(req)
+}
+
+// faultBody reads the altered response and closes the original one
+type faultBody struct {
+	io.Reader
+	body io.Closer
+}
+
+func (b faultBody) Close() error {
+	return b.body.Close()
+}
+
+// sleep waits for the duration or until the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// validFaultKinds reports whether all kinds are known
+func validFaultKinds(kinds []string) bool {
+	return !slices.ContainsFunc(kinds, func(kind string) bool { return !slices.Contains(faultKinds, kind) })
+}
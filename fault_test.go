@@ -0,0 +1,43 @@
+package echo
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFaultInjection(t *testing.T) {
+	var requests []string
+	server := newSegmentServer(&requests)
+	defer server.Close()
+
+	defer func(d time.Duration) { faultMaxLatency = d }(faultMaxLatency)
+	faultMaxLatency = time.Millisecond
+
+	client, _ := NewCommonClient(map[string]string{"openai": "key"}, WithModel("openai/gpt-4o"), WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithFaultInjection(1, FaultRateLimit)); err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("Expected an injected rate limit, got %v", err)
+	}
+	if len(requests) != 0 {
+		t.Errorf("Rate limited request reached the server")
+	}
+
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithFaultInjection(1, FaultMalformed)); err == nil {
+		t.Error("Expected a decoding error for a malformed response")
+	}
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithFaultInjection(1, FaultTruncate)); err == nil {
+		t.Error("Expected a decoding error for a truncated response")
+	}
+	if resp, err := client.Complete(ctx, QuickMessage("Hi"), WithFaultInjection(1, FaultLatency)); err != nil || resp.Text != "Hello " {
+		t.Errorf("Expected a delayed response, got %v, %v", resp, err)
+	}
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithFaultInjection(0)); err != nil {
+		t.Errorf("Expected no faults at rate 0, got %v", err)
+	}
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithFaultInjection(1, "meteor")); err == nil {
+		t.Error("Expected an error for an unknown fault kind")
+	}
+}
@@ -0,0 +1,40 @@
+package echo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Feedback is a rating of a recorded response, given by the end user or a reviewer
+type Feedback struct {
+	CallID  string    `json:"call_id"` // call of the rated response, from the "call_id" metadata
+	Rating  int       `json:"rating"`  // e.g. 1 for thumbs-up and -1 for thumbs-down, or a 1-5 score
+	Comment string    `json:"comment,omitempty"`
+	UserID  string    `json:"user_id,omitempty"` // end user set by WithUserID, see DeleteUserData
+	Created time.Time `json:"created"`
+	Sealed  []byte    `json:"sealed,omitempty"` // encrypted Comment, see StoreCipher
+}
+
+// FeedbackStore is implemented by response stores that keep feedback next to the responses
+type FeedbackStore interface {
+	SaveFeedback(ctx context.Context, feedback Feedback) error
+}
+
+// RecordFeedback implements the Client interface.
+// The feedback is saved to the store set by WithStreamPersistence, which must implement FeedbackStore.
+func (c *CommonClient) RecordFeedback(ctx context.Context, callID string, rating int, comment string, opts ...CallOption) error {
+	cfg := c.baseConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if callID == "" {
+		return fmt.Errorf("feedback requires a call ID")
+	}
+	store, ok := cfg.ResponseStore.(FeedbackStore)
+	if !ok {
+		return fmt.Errorf("response store does not support feedback")
+	}
+	return store.SaveFeedback(ctx, Feedback{CallID: callID, Rating: rating, Comment: comment, UserID: cfg.UserID, Created: time.Now()})
+}
@@ -0,0 +1,60 @@
+package echo
+
+import (
+	"context"
+	"testing"
+)
+
+// feedbackStore keeps responses and feedback in memory
+type feedbackStore struct {
+	records  []ResponseRecord
+	feedback []Feedback
+}
+
+func (s *feedbackStore) SaveResponse(ctx context.Context, record ResponseRecord) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *feedbackStore) SaveFeedback(ctx context.Context, feedback Feedback) error {
+	s.feedback = append(s.feedback, feedback)
+	return nil
+}
+
+func TestRecordFeedback(t *testing.T) {
+	store := &feedbackStore{}
+	client, _ := NewCommonClient(nil, WithModel("mock/test"), WithStreamPersistence(store))
+	ctx := context.Background()
+
+	resp, err := client.Complete(ctx, QuickMessage("Hello"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	callID, _ := resp.Metadata["call_id"].(string)
+	if callID == "" || len(store.records) != 1 || store.records[0].CallID != callID {
+		t.Fatalf("Expected the response recorded with its call ID, got %q, %+v", callID, store.records)
+	}
+
+	if err := client.RecordFeedback(ctx, callID, -1, "Too short"); err != nil {
+		t.Fatalf("RecordFeedback() error = %v", err)
+	}
+	if len(store.feedback) != 1 || store.feedback[0].CallID != callID || store.feedback[0].Rating != -1 {
+		t.Errorf("Unexpected feedback %+v", store.feedback)
+	}
+
+	// Streams report the call ID in the first chunk; a fixed ID can be set per call
+	stream, err := client.StreamComplete(ctx, QuickMessage("Hello"), WithCallID("msg-42"))
+	if err != nil {
+		t.Fatalf("StreamComplete() error = %v", err)
+	}
+	chunk := <-stream.Stream
+	drain(stream)
+	if chunk.Meta == nil || (*chunk.Meta)["call_id"] != "msg-42" {
+		t.Errorf("Expected the call ID in the first chunk, got %+v", chunk)
+	}
+
+	plain, _ := NewCommonClient(nil, WithModel("mock/test"))
+	if err := plain.RecordFeedback(ctx, callID, 1, ""); err == nil {
+		t.Error("Expected an error without a feedback store")
+	}
+}
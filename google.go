@@ -2,10 +2,13 @@ package echo
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // GoogleProvider is a stateless provider for Google API
@@ -18,20 +21,108 @@ type GeminiRequest struct {
 	Contents          []GeminiContent         `json:"contents"`
 	SystemInstruction *GeminiContent          `json:"systemInstruction,omitempty"`
 	GenerationConfig  *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+	Tools             []GeminiTool            `json:"tools,omitempty"`
+	ToolConfig        *GeminiToolConfig       `json:"toolConfig,omitempty"`
+	SafetySettings    []SafetySetting         `json:"safetySettings,omitempty"`
+}
+
+// SafetySetting sets how likely harmful content must be before Gemini blocks it, e.g.
+// SafetySetting{Category: "HARM_CATEGORY_DANGEROUS_CONTENT", Threshold: "BLOCK_ONLY_HIGH"}
+type SafetySetting struct {
+	Category  string `json:"category"`  // HARM_CATEGORY_HARASSMENT, _HATE_SPEECH, _SEXUALLY_EXPLICIT, _DANGEROUS_CONTENT or _CIVIC_INTEGRITY
+	Threshold string `json:"threshold"` // BLOCK_NONE, BLOCK_ONLY_HIGH, BLOCK_MEDIUM_AND_ABOVE, BLOCK_LOW_AND_ABOVE or OFF
+}
+
+// SafetyRating is the probability of harmful content Gemini found in a category,
+// reported in the "safety_ratings" metadata
+type SafetyRating struct {
+	Category    string `json:"category"`
+	Probability string `json:"probability"` // NEGLIGIBLE, LOW, MEDIUM or HIGH
+	Blocked     bool   `json:"blocked,omitempty"`
+}
+
+// GeminiPromptFeedback explains why Gemini refused a prompt without answering
+type GeminiPromptFeedback struct {
+	BlockReason   string         `json:"blockReason,omitempty"` // SAFETY, BLOCKLIST, PROHIBITED_CONTENT or OTHER
+	SafetyRatings []SafetyRating `json:"safetyRatings,omitempty"`
+}
+
+// GeminiToolConfig sets the function calling mode
+type GeminiToolConfig struct {
+	FunctionCallingConfig struct {
+		Mode                 string   `json:"mode"` // "AUTO", "NONE" or "ANY"
+		AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+	} `json:"functionCallingConfig"`
+}
+
+// geminiToolConfig converts the tool choice to a function calling mode, a named tool
+// is an ANY mode limited to that function
+func geminiToolConfig(choice string) *GeminiToolConfig {
+	if choice == "" {
+		return nil
+	}
+	tc := &GeminiToolConfig{}
+	switch choice {
+	case ToolChoiceAuto:
+		tc.FunctionCallingConfig.Mode = "AUTO"
+	case ToolChoiceNone:
+		tc.FunctionCallingConfig.Mode = "NONE"
+	case ToolChoiceRequired:
+		tc.FunctionCallingConfig.Mode = "ANY"
+	default:
+		tc.FunctionCallingConfig.Mode = "ANY"
+		tc.FunctionCallingConfig.AllowedFunctionNames = []string{choice}
+	}
+	return tc
+}
+
+// GeminiTool groups the functions the model may call
+type GeminiTool struct {
+	FunctionDeclarations []GeminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+// GeminiFunctionDeclaration is the name and schema of a callable function
+type GeminiFunctionDeclaration struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+// GeminiFunctionCall is a function call requested by the model
+type GeminiFunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+// GeminiFunctionResponse holds the result of a function call
+type GeminiFunctionResponse struct {
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"` // JSON object
 }
 
 // GeminiGenerationConfig contains generation parameters for Gemini requests
 type GeminiGenerationConfig struct {
 	Temperature      *float32              `json:"temperature,omitempty"`
+	TopP             *float32              `json:"topP,omitempty"`
+	TopK             *int                  `json:"topK,omitempty"`
+	FrequencyPenalty *float32              `json:"frequencyPenalty,omitempty"`
+	PresencePenalty  *float32              `json:"presencePenalty,omitempty"`
+	Seed             *int                  `json:"seed,omitempty"`
+	CandidateCount   int                   `json:"candidateCount,omitempty"`
 	MaxOutputTokens  *int                  `json:"maxOutputTokens,omitempty"`
 	ResponseMimeType string                `json:"responseMimeType,omitempty"`
 	ResponseSchema   any                   `json:"responseSchema,omitempty"`
 	ThinkingConfig   *GeminiThinkingConfig `json:"thinkingConfig,omitempty"`
+	StopSequences    []string              `json:"stopSequences,omitempty"`
 }
 
 // GeminiThinkingConfig contains thinking/reasoning configuration
 type GeminiThinkingConfig struct {
-	ThinkingLevel string `json:"thinkingLevel"` // "low", "medium", "high"
+	ThinkingLevel   string `json:"thinkingLevel,omitempty"`   // "low", "medium", "high"
+	ThinkingBudget  int    `json:"thinkingBudget,omitempty"`  // tokens, gemini-2.5 models
+	IncludeThoughts bool   `json:"includeThoughts,omitempty"` // return thought summaries as parts marked with thought
 }
 
 type GeminiContent struct {
@@ -40,7 +131,160 @@ type GeminiContent struct {
 }
 
 type GeminiPart struct {
-	Text string `json:"text"`
+	Text          string               `json:"text,omitempty"`
+	InlineData    *GeminiBlob          `json:"inlineData,omitempty"`
+	FileData      *GeminiFileData      `json:"fileData,omitempty"`
+	VideoMetadata *GeminiVideoMetadata `json:"videoMetadata,omitempty"`
+
+	FunctionCall     *GeminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *GeminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+// GeminiVideoMetadata sets the clip interval and frame rate of a video part
+type GeminiVideoMetadata struct {
+	StartOffset string  `json:"startOffset,omitempty"`
+	EndOffset   string  `json:"endOffset,omitempty"`
+	FPS         float64 `json:"fps,omitempty"`
+}
+
+// GeminiBlob holds inline binary content
+type GeminiBlob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"` // base64 encoded
+}
+
+// GeminiFileData references content stored remotely (Files API or GCS)
+type GeminiFileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+// toGeminiParts converts content parts to Gemini format
+func toGeminiParts(parts []Part) ([]GeminiPart, error) {
+	result := make([]GeminiPart, 0, len(parts))
+	for _, part := range parts {
+		var gp GeminiPart
+		switch {
+		case part.Type == PartText:
+			gp.Text = part.Text
+		case len(part.Data) > 0:
+			if part.MimeType == "" {
+				return nil, fmt.Errorf("mime type is required for inline %s parts", part.Type)
+			}
+			gp.InlineData = &GeminiBlob{
+				MimeType: part.MimeType,
+				Data:     base64.StdEncoding.EncodeToString(part.Data),
+			}
+		case part.URL != "":
+			if part.Type == PartDocument && !isGeminiFileURI(part.URL) {
+				return nil, fmt.Errorf("gemini accepts only Files API or gs:// URIs, send %s as inline data with DocumentPart", part.URL)
+			}
+			if !isGeminiFileURI(part.URL) && !(part.Type == PartVideo && isYouTubeURL(part.URL)) {
+				return nil, fmt.Errorf("gemini accepts only Files API or gs:// URIs, use WithImageFetch to send %s as inline data", part.URL)
+			}
+			gp.FileData = &GeminiFileData{
+				MimeType: part.MimeType,
+				FileURI:  part.URL,
+			}
+		default:
+			return nil, fmt.Errorf("empty %s part", part.Type)
+		}
+
+		if part.Type == PartVideo && part.Video != nil {
+			gp.VideoMetadata = &GeminiVideoMetadata{
+				StartOffset: geminiOffset(part.Video.Start),
+				EndOffset:   geminiOffset(part.Video.End),
+				FPS:         part.Video.FPS,
+			}
+		}
+		result = append(result, gp)
+	}
+	return result, nil
+}
+
+// toGeminiAgentContent converts an agent message, its tool calls become function call parts
+func toGeminiAgentContent(msg Message) GeminiContent {
+	if len(msg.ToolCalls) == 0 {
+		return GeminiContent{Role: "model", Parts: []GeminiPart{{Text: msg.Content}}}
+	}
+
+	out := GeminiContent{Role: "model"}
+	if msg.Content != "" {
+		out.Parts = append(out.Parts, GeminiPart{Text: msg.Content})
+	}
+	for _, call := range msg.ToolCalls {
+		fc := &GeminiFunctionCall{ID: geminiNativeID(call.ID), Name: call.Name, Args: toolArguments(call)}
+		out.Parts = append(out.Parts, GeminiPart{FunctionCall: fc})
+	}
+	return out
+}
+
+// appendGeminiToolResult adds a tool result to the chain; results of consecutive calls
+// share a single content, as Gemini expects one response per call of the turn
+func appendGeminiToolResult(contents []GeminiContent, messages []Message, msg Message) []GeminiContent {
+	// Function responses must be JSON objects, other output is wrapped
+	response := json.RawMessage(msg.Content)
+	if !json.Valid(response) || !strings.HasPrefix(strings.TrimSpace(msg.Content), "{") {
+		response, _ = json.Marshal(map[string]string{"result": msg.Content})
+	}
+
+	fr := &GeminiFunctionResponse{
+		ID:       geminiNativeID(msg.ToolCallID),
+		Name:     toolCallName(messages, msg.ToolCallID),
+		Response: response,
+	}
+	part := GeminiPart{FunctionResponse: fr}
+
+	if n := len(contents); n > 0 {
+		last := &contents[n-1]
+		if last.Role == "user" && last.Parts[len(last.Parts)-1].FunctionResponse != nil {
+			last.Parts = append(last.Parts, part)
+			return contents
+		}
+	}
+	return append(contents, GeminiContent{Role: "user", Parts: []GeminiPart{part}})
+}
+
+// geminiLocalID prefixes the IDs given to function calls Gemini returned without one
+const geminiLocalID = "local_"
+
+// geminiNativeID returns the ID to send back to Gemini, empty for locally assigned IDs
+func geminiNativeID(id string) string {
+	if strings.HasPrefix(id, geminiLocalID) {
+		return ""
+	}
+	return id
+}
+
+// geminiTools converts tool declarations to Gemini format
+func geminiTools(tools []Tool) []GeminiTool {
+	if len(tools) == 0 {
+		return nil
+	}
+	var decls []GeminiFunctionDeclaration
+	for _, t := range tools {
+		decls = append(decls, GeminiFunctionDeclaration{Name: t.Name, Description: t.Description, Parameters: t.Parameters})
+	}
+	return []GeminiTool{{FunctionDeclarations: decls}}
+}
+
+// geminiOffset formats a duration as a Gemini offset string, e.g. "12.5s"
+func geminiOffset(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+// isYouTubeURL reports whether the URL points to a YouTube video, which Gemini can read directly
+func isYouTubeURL(url string) bool {
+	return strings.HasPrefix(url, "https://www.youtube.com/") || strings.HasPrefix(url, "https://youtube.com/") ||
+		strings.HasPrefix(url, "https://youtu.be/")
+}
+
+// isGeminiFileURI reports whether the URI can be passed to Gemini as file data
+func isGeminiFileURI(uri string) bool {
+	return strings.HasPrefix(uri, "gs://") || strings.HasPrefix(uri, "https://generativelanguage.googleapis.com/")
 }
 
 type GeminiError struct {
@@ -50,34 +294,48 @@ type GeminiError struct {
 }
 
 type GeminiResponse struct {
-	Error      *GeminiError `json:"error,omitempty"`
-	Candidates []struct {
+	Error          *GeminiError          `json:"error,omitempty"`
+	ModelVersion   string                `json:"modelVersion,omitempty"`
+	PromptFeedback *GeminiPromptFeedback `json:"promptFeedback,omitempty"`
+	Candidates     []struct {
 		Content struct {
 			Parts []struct {
-				Text string `json:"text"`
+				Text         string              `json:"text"`
+				Thought      bool                `json:"thought,omitempty"`
+				FunctionCall *GeminiFunctionCall `json:"functionCall,omitempty"`
 			} `json:"parts"`
 		} `json:"content"`
+		FinishReason  string         `json:"finishReason"`
+		SafetyRatings []SafetyRating `json:"safetyRatings,omitempty"`
 	} `json:"candidates"`
 	UsageMetadata *struct {
-		PromptTokenCount     int `json:"promptTokenCount"`
-		CandidatesTokenCount int `json:"candidatesTokenCount"`
-		TotalTokenCount      int `json:"totalTokenCount"`
+		PromptTokenCount        int `json:"promptTokenCount"`
+		CandidatesTokenCount    int `json:"candidatesTokenCount"`
+		TotalTokenCount         int `json:"totalTokenCount"`
+		CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty"` // part of the prompt served from the implicit or explicit cache
+		ThoughtsTokenCount      int `json:"thoughtsTokenCount,omitempty"`
 	} `json:"usageMetadata,omitempty"`
 }
 
 // GeminiStreamResponse represents a streaming response chunk from Gemini
 type GeminiStreamResponse struct {
-	Candidates []struct {
+	PromptFeedback *GeminiPromptFeedback `json:"promptFeedback,omitempty"`
+	Candidates     []struct {
 		Content struct {
 			Parts []struct {
-				Text string `json:"text"`
+				Text    string `json:"text"`
+				Thought bool   `json:"thought,omitempty"`
 			} `json:"parts"`
 		} `json:"content"`
+		FinishReason  string         `json:"finishReason"`
+		SafetyRatings []SafetyRating `json:"safetyRatings,omitempty"`
 	} `json:"candidates"`
 	UsageMetadata *struct {
-		PromptTokenCount     int `json:"promptTokenCount"`
-		CandidatesTokenCount int `json:"candidatesTokenCount"`
-		TotalTokenCount      int `json:"totalTokenCount"`
+		PromptTokenCount        int `json:"promptTokenCount"`
+		CandidatesTokenCount    int `json:"candidatesTokenCount"`
+		TotalTokenCount         int `json:"totalTokenCount"`
+		CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty"` // part of the prompt served from the implicit or explicit cache
+		ThoughtsTokenCount      int `json:"thoughtsTokenCount,omitempty"`
 	} `json:"usageMetadata,omitempty"`
 }
 
@@ -94,6 +352,12 @@ func prepareGoogleRequest(messages []Message, cfg CallConfig) (GeminiRequest, er
 	if err := validateMessages(messages); err != nil {
 		return GeminiRequest{}, fmt.Errorf("invalid message chain: %w", err)
 	}
+	if len(cfg.LogitBias) > 0 {
+		return GeminiRequest{}, fmt.Errorf("logit bias is not supported by Gemini")
+	}
+	if cfg.ThinkingBudget > 0 && cfg.ReasoningEffort != "" {
+		return GeminiRequest{}, fmt.Errorf("use either WithThinking or WithReasoningEffort for Gemini")
+	}
 
 	// Convert messages to Gemini format
 	geminiContents := []GeminiContent{}
@@ -104,25 +368,35 @@ func prepareGoogleRequest(messages []Message, cfg CallConfig) (GeminiRequest, er
 		case System:
 			systemMsg = msg.Content
 		case User:
+			parts := []GeminiPart{{Text: msg.Content}}
+			if len(msg.Parts) > 0 {
+				media, err := toGeminiParts(msg.Parts)
+				if err != nil {
+					return GeminiRequest{}, err
+				}
+				if msg.Content == "" {
+					parts = media
+				} else {
+					parts = append(parts, media...)
+				}
+			}
 			geminiContents = append(geminiContents, GeminiContent{
-				Role: "user",
-				Parts: []GeminiPart{
-					{Text: msg.Content},
-				},
+				Role:  "user",
+				Parts: parts,
 			})
 		case Agent:
-			geminiContents = append(geminiContents, GeminiContent{
-				Role: "model",
-				Parts: []GeminiPart{
-					{Text: msg.Content},
-				},
-			})
+			geminiContents = append(geminiContents, toGeminiAgentContent(msg))
+		case ToolResult:
+			geminiContents = appendGeminiToolResult(geminiContents, messages, msg)
 		}
 	}
 
 	// Create Gemini-specific request
 	geminiReq := GeminiRequest{
-		Contents: geminiContents,
+		Contents:       geminiContents,
+		Tools:          geminiTools(cfg.Tools),
+		ToolConfig:     geminiToolConfig(cfg.ToolChoice),
+		SafetySettings: cfg.SafetySettings,
 	}
 
 	// Handle system instruction - WithSystemMessage overrides message chain system
@@ -140,16 +414,25 @@ func prepareGoogleRequest(messages []Message, cfg CallConfig) (GeminiRequest, er
 		}
 	}
 
-	// Add generation config if temperature, max tokens, structured output, or reasoning effort are set
-	if cfg.Temperature != nil || cfg.MaxTokens != nil || cfg.StructuredOutput != nil || cfg.ReasoningEffort != "" {
+	// Add generation config if sampling, max tokens, stop sequences, JSON output, reasoning effort or thinking are set
+	if cfg.Temperature != nil || cfg.sampling() || cfg.MaxTokens != nil || len(cfg.StopSequences) > 0 || cfg.jsonOutput() || cfg.ReasoningEffort != "" || cfg.ThinkingBudget > 0 {
 		geminiReq.GenerationConfig = &GeminiGenerationConfig{
-			Temperature:     cfg.Temperature,
-			MaxOutputTokens: cfg.MaxTokens,
+			Temperature:      cfg.Temperature,
+			TopP:             cfg.TopP,
+			TopK:             cfg.TopK,
+			FrequencyPenalty: cfg.FrequencyPenalty,
+			PresencePenalty:  cfg.PresencePenalty,
+			Seed:             cfg.Seed,
+			CandidateCount:   cfg.N,
+			MaxOutputTokens:  cfg.MaxTokens,
+			StopSequences:    cfg.StopSequences,
 		}
 
-		// Add structured output configuration
-		if cfg.StructuredOutput != nil {
+		// Add structured output configuration, JSON mode is the mime type alone
+		if cfg.jsonOutput() {
 			geminiReq.GenerationConfig.ResponseMimeType = "application/json"
+		}
+		if cfg.StructuredOutput != nil {
 			geminiReq.GenerationConfig.ResponseSchema = cfg.StructuredOutput.Schema
 		}
 
@@ -159,11 +442,23 @@ func prepareGoogleRequest(messages []Message, cfg CallConfig) (GeminiRequest, er
 				ThinkingLevel: cfg.ReasoningEffort,
 			}
 		}
+		if cfg.ThinkingBudget > 0 {
+			geminiReq.GenerationConfig.ThinkingConfig = &GeminiThinkingConfig{
+				ThinkingBudget:  cfg.ThinkingBudget,
+				IncludeThoughts: true,
+			}
+		}
 	}
 
 	return geminiReq, nil
 }
 
+// validateCompletion implements the provider interface
+func (p *GoogleProvider) validateCompletion(messages []Message, cfg CallConfig) error {
+	_, err := prepareGoogleRequest(messages, cfg)
+	return err
+}
+
 // call implements the provider interface for Google
 func (p *GoogleProvider) call(ctx context.Context, messages []Message, cfg CallConfig) (*Response, error) {
 	geminiReq, err := prepareGoogleRequest(messages, cfg)
@@ -179,8 +474,8 @@ func (p *GoogleProvider) call(ctx context.Context, messages []Message, cfg CallC
 
 	// Call the Gemini API using shared HTTP function
 	var response GeminiResponse
-	err = callHTTPAPI(ctx, baseURL, func(req *http.Request) {
-		req.Header.Set("x-goog-api-key", p.Key)
+	err = callHTTPAPI(ctx, cfg, baseURL, func(req *http.Request) {
+		req.Header.Set("x-goog-api-key", cfg.apiKey(p.Key))
 	}, geminiReq, &response)
 	if err != nil {
 		return nil, fmt.Errorf("api call failed: %w", err)
@@ -191,22 +486,64 @@ func (p *GoogleProvider) call(ctx context.Context, messages []Message, cfg CallC
 		return nil, fmt.Errorf("Gemini API error: %s", response.Error.Message)
 	}
 
+	// A blocked prompt gets no candidates, only the reason
 	if len(response.Candidates) == 0 {
+		if fb := response.PromptFeedback; fb != nil && fb.BlockReason != "" {
+			meta := Metadata{"finish_reason": FinishContentFilter}
+			geminiSafetyMeta(meta, fb.BlockReason, fb.SafetyRatings)
+			return &Response{Model: response.ModelVersion, Metadata: meta}, nil
+		}
 		return nil, fmt.Errorf("no candidates in Gemini response")
 	}
 
-	if len(response.Candidates[0].Content.Parts) == 0 {
+	// A blocked answer has no content
+	first := response.Candidates[0]
+	if len(first.Content.Parts) == 0 && geminiFinishReason(first.FinishReason) != FinishContentFilter {
 		return nil, fmt.Errorf("no content parts in Gemini response")
 	}
 
-	result := &Response{Text: response.Candidates[0].Content.Parts[0].Text}
+	result := &Response{Model: response.ModelVersion}
+	choices := make([]Choice, len(response.Candidates))
+	for c, candidate := range response.Candidates {
+		choice := Choice{FinishReason: geminiFinishReason(candidate.FinishReason)}
+		for i, part := range candidate.Content.Parts {
+			// Thought summaries come before the answer when thinking is enabled
+			if part.Thought {
+				if c == 0 {
+					result.Thinking += part.Text
+				}
+			} else {
+				choice.Text += part.Text
+			}
+			if fc := part.FunctionCall; fc != nil {
+				id := fc.ID
+				if id == "" {
+					id = fmt.Sprintf("%s%s_%d", geminiLocalID, fc.Name, i)
+				}
+				choice.ToolCalls = append(choice.ToolCalls, ToolCall{ID: id, Name: fc.Name, Arguments: fc.Args})
+			}
+		}
+		// Gemini reports STOP for function calls
+		if len(choice.ToolCalls) > 0 {
+			choice.FinishReason = FinishToolCalls
+		}
+		choices[c] = choice
+	}
+	result.Text, result.ToolCalls = choices[0].Text, choices[0].ToolCalls
+	result.Metadata = Metadata{"finish_reason": choices[0].FinishReason}
+	geminiSafetyMeta(result.Metadata, "", first.SafetyRatings)
+	if len(choices) > 1 {
+		result.Choices = choices
+	}
 
 	// Add metadata if usage information is available
 	if response.UsageMetadata != nil {
-		result.Metadata = Metadata{
-			"total_tokens":      response.UsageMetadata.TotalTokenCount,
-			"prompt_tokens":     response.UsageMetadata.PromptTokenCount,
-			"completion_tokens": response.UsageMetadata.CandidatesTokenCount,
+		result.Metadata["total_tokens"] = response.UsageMetadata.TotalTokenCount
+		result.Metadata["prompt_tokens"] = response.UsageMetadata.PromptTokenCount
+		result.Metadata["completion_tokens"] = response.UsageMetadata.CandidatesTokenCount
+		result.Metadata["cached_tokens"] = response.UsageMetadata.CachedContentTokenCount
+		if n := response.UsageMetadata.ThoughtsTokenCount; n > 0 {
+			result.Metadata["thinking_tokens"] = n
 		}
 	}
 
@@ -230,8 +567,8 @@ func (p *GoogleProvider) streamCall(ctx context.Context, messages []Message, cfg
 	streamURL := strings.Replace(baseURL, ":generateContent", ":streamGenerateContent?alt=sse", 1)
 
 	// Get streaming response
-	respBody, err := streamHTTPAPI(ctx, streamURL, func(req *http.Request) {
-		req.Header.Set("x-goog-api-key", p.Key)
+	respBody, err := streamHTTPAPI(ctx, cfg, streamURL, func(req *http.Request) {
+		req.Header.Set("x-goog-api-key", cfg.apiKey(p.Key))
 	}, geminiReq)
 	if err != nil {
 		return nil, fmt.Errorf("Gemini streaming API call failed: %w", err)
@@ -243,40 +580,95 @@ func (p *GoogleProvider) streamCall(ctx context.Context, messages []Message, cfg
 	// Start goroutine to process stream
 	go func() {
 		defer close(ch)
+		defer closeOnDone(ctx, respBody)()
+		out := chunkSender{ctx: ctx, ch: ch}
 
 		err := parseSSEStream(respBody, func(msg SSEMessage) error {
-			processGeminiSSEMessage(msg, ch)
-			return nil
+			return processGeminiSSEMessage(msg, out)
 		})
 
 		if err != nil {
-			ch <- StreamChunk{Error: fmt.Errorf("SSE stream error: %w", err)}
+			out.fail(fmt.Errorf("SSE stream error: %w", err))
 		}
 	}()
 
 	return &StreamResponse{Stream: ch}, nil
 }
 
+// geminiFinishReason maps a Gemini finishReason to the common finish reasons
+func geminiFinishReason(reason string) string {
+	switch reason {
+	case "STOP":
+		return FinishStop
+	case "MAX_TOKENS":
+		return FinishLength
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII":
+		return FinishContentFilter
+	}
+	return strings.ToLower(reason)
+}
+
+// geminiSafetyMeta adds the reason a prompt was blocked and the safety ratings to the metadata
+func geminiSafetyMeta(meta Metadata, blockReason string, ratings []SafetyRating) {
+	if blockReason != "" {
+		meta["block_reason"] = blockReason
+	}
+	if len(ratings) > 0 {
+		meta["safety_ratings"] = ratings
+	}
+}
+
+// checkSafetySettings rejects safety settings in calls to other providers than Gemini
+func checkSafetySettings(cfg CallConfig) error {
+	if len(cfg.SafetySettings) > 0 && cfg.provider != "google" {
+		return fmt.Errorf("safety settings are supported by Gemini only, not %s", cfg.provider)
+	}
+	return nil
+}
+
 // processGeminiSSEMessage processes individual Gemini SSE messages
-func processGeminiSSEMessage(msg SSEMessage, ch chan StreamChunk) {
+func processGeminiSSEMessage(msg SSEMessage, out chunkSender) error {
 	if len(msg.Data) == 0 {
-		return
+		return nil
 	}
 
 	// Parse JSON
 	var streamResp GeminiStreamResponse
 	if err := json.Unmarshal(msg.Data, &streamResp); err != nil {
-		ch <- StreamChunk{Error: fmt.Errorf("json parse error: %w", err)}
-		return
+		return fmt.Errorf("json parse error: %w", err)
+	}
+
+	// A blocked prompt gets no candidates, only the reason
+	if fb := streamResp.PromptFeedback; fb != nil && fb.BlockReason != "" && len(streamResp.Candidates) == 0 {
+		meta := Metadata{"finish_reason": FinishContentFilter}
+		geminiSafetyMeta(meta, fb.BlockReason, fb.SafetyRatings)
+		if err := out.send(StreamChunk{Meta: &meta}); err != nil {
+			return err
+		}
 	}
 
 	// Check if we have candidates with content
-	if len(streamResp.Candidates) > 0 && len(streamResp.Candidates[0].Content.Parts) > 0 {
-		text := streamResp.Candidates[0].Content.Parts[0].Text
-		if text != "" {
-			ch <- StreamChunk{
-				Data: text,
+	if len(streamResp.Candidates) > 0 {
+		for _, part := range streamResp.Candidates[0].Content.Parts {
+			if part.Text == "" {
+				continue
 			}
+			chunk := StreamChunk{Data: part.Text}
+			if part.Thought {
+				chunk = StreamChunk{Thinking: part.Text}
+			}
+			if err := out.send(chunk); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The last chunk carries the finish reason
+	if len(streamResp.Candidates) > 0 && streamResp.Candidates[0].FinishReason != "" {
+		meta := Metadata{"finish_reason": geminiFinishReason(streamResp.Candidates[0].FinishReason)}
+		geminiSafetyMeta(meta, "", streamResp.Candidates[0].SafetyRatings)
+		if err := out.send(StreamChunk{Meta: &meta}); err != nil {
+			return err
 		}
 	}
 
@@ -286,11 +678,16 @@ func processGeminiSSEMessage(msg SSEMessage, ch chan StreamChunk) {
 			"total_tokens":      streamResp.UsageMetadata.TotalTokenCount,
 			"prompt_tokens":     streamResp.UsageMetadata.PromptTokenCount,
 			"completion_tokens": streamResp.UsageMetadata.CandidatesTokenCount,
+			"cached_tokens":     streamResp.UsageMetadata.CachedContentTokenCount,
 		}
-		ch <- StreamChunk{
-			Meta: &meta,
+		if n := streamResp.UsageMetadata.ThoughtsTokenCount; n > 0 {
+			meta["thinking_tokens"] = n
 		}
+		return out.send(StreamChunk{
+			Meta: &meta,
+		})
 	}
+	return nil
 }
 
 // Google Embedding structures
@@ -328,8 +725,8 @@ func (p *GoogleProvider) getEmbeddings(ctx context.Context, text string, cfg Cal
 	}
 
 	resp := GoogleEmbeddingResponse{}
-	err := callHTTPAPI(ctx, baseURL, func(req *http.Request) {
-		req.Header.Set("x-goog-api-key", p.Key)
+	err := callHTTPAPI(ctx, cfg, baseURL, func(req *http.Request) {
+		req.Header.Set("x-goog-api-key", cfg.apiKey(p.Key))
 	}, body, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("Google embedding API call failed: %w", err)
@@ -353,6 +750,53 @@ func (p *GoogleProvider) getEmbeddings(ctx context.Context, text string, cfg Cal
 	return response, nil
 }
 
+// getMultimodalEmbeddings implements the provider interface for Google multimodal embeddings
+func (p *GoogleProvider) getMultimodalEmbeddings(ctx context.Context, parts []Part, cfg CallConfig) (*EmbeddingResponse, error) {
+	model := cfg.Model
+	if model == "" {
+		model = "gemini-embedding-001"
+	}
+
+	geminiParts, err := toGeminiParts(parts)
+	if err != nil {
+		return nil, err
+	}
+
+	body := GoogleEmbeddingRequest{
+		Content: GeminiContent{
+			Parts: geminiParts,
+		},
+	}
+
+	// Build the base URL with model
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = "https://generativelanguage.googleapis.com/v1beta/models/" + model + ":embedContent"
+	}
+
+	resp := GoogleEmbeddingResponse{}
+	err = callHTTPAPI(ctx, cfg, baseURL, func(req *http.Request) {
+		req.Header.Set("x-goog-api-key", cfg.apiKey(p.Key))
+	}, body, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("Google embedding API call failed: %w", err)
+	}
+
+	// Check for errors in the response
+	if resp.Error != nil {
+		return nil, fmt.Errorf("Google embedding API error: %s", resp.Error.Message)
+	}
+
+	if len(resp.Embedding.Values) == 0 {
+		return nil, fmt.Errorf("no embedding data in response")
+	}
+
+	return &EmbeddingResponse{
+		Embedding: resp.Embedding.Values,
+		Metadata:  Metadata{},
+	}, nil
+}
+
 // reRank implements the provider interface for Google
 // Note: Google does not currently support reranking API
 func (p *GoogleProvider) reRank(ctx context.Context, query string, documents []string, cfg CallConfig) (*RerankResponse, error) {
@@ -504,8 +948,8 @@ func (p *GoogleProvider) buildCompletionRequest(ctx context.Context, req *Comple
 
 	// Make the API call
 	var geminiResp GeminiResponse
-	err := callHTTPAPI(ctx, baseURL, func(httpReq *http.Request) {
-		httpReq.Header.Set("x-goog-api-key", p.Key)
+	err := callHTTPAPI(ctx, cfg, baseURL, func(httpReq *http.Request) {
+		httpReq.Header.Set("x-goog-api-key", cfg.apiKey(p.Key))
 	}, geminiReq, &geminiResp)
 	if err != nil {
 		return nil, fmt.Errorf("Google API call failed: %w", err)
@@ -537,7 +981,7 @@ func (p *GoogleProvider) buildCompletionRequest(ctx context.Context, req *Comple
 		completionResp.Choices[0].Index = 0
 		completionResp.Choices[0].Message.Role = "assistant"
 		completionResp.Choices[0].Message.Content = geminiResp.Candidates[0].Content.Parts[0].Text
-		completionResp.Choices[0].FinishReason = "stop"
+		completionResp.Choices[0].FinishReason = geminiFinishReason(geminiResp.Candidates[0].FinishReason)
 	}
 
 	// Add usage information if available
@@ -579,8 +1023,8 @@ func (p *GoogleProvider) buildEmbeddingRequest(ctx context.Context, req *Embeddi
 	}
 
 	var googleResp GoogleEmbeddingResponse
-	err := callHTTPAPI(ctx, baseURL, func(httpReq *http.Request) {
-		httpReq.Header.Set("x-goog-api-key", p.Key)
+	err := callHTTPAPI(ctx, cfg, baseURL, func(httpReq *http.Request) {
+		httpReq.Header.Set("x-goog-api-key", cfg.apiKey(p.Key))
 	}, body, &googleResp)
 	if err != nil {
 		return nil, fmt.Errorf("Google embedding API call failed: %w", err)
@@ -621,6 +1065,11 @@ func (p *GoogleProvider) writeCompletionResponse(w http.ResponseWriter, resp *Co
 	return json.NewEncoder(w).Encode(resp)
 }
 
+// writeCompletionStream writes a streamed completion as OpenAI chunk events, like writeCompletionResponse
+func (p *GoogleProvider) writeCompletionStream(w http.ResponseWriter, model string, stream *StreamResponse) error {
+	return writeOpenAIStream(w, model, stream)
+}
+
 // writeEmbeddingResponse writes a UnifiedEmbeddingResponse as JSON to the HTTP response writer
 func (p *GoogleProvider) writeEmbeddingResponse(w http.ResponseWriter, resp *UnifiedEmbeddingResponse) error {
 	w.Header().Set("Content-Type", "application/json")
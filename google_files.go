@@ -0,0 +1,124 @@
+package echo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GeminiFile describes a file stored with the Gemini Files API
+type GeminiFile struct {
+	Name     string `json:"name"`
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType"`
+	State    string `json:"state"` // PROCESSING, ACTIVE or FAILED
+}
+
+// filePollInterval is the delay between file state checks while a file is being processed
+var filePollInterval = 2 * time.Second
+
+// UploadFile uploads media to the Gemini Files API and waits until it can be used in prompts.
+// Use it for videos and other files too large for inline data:
+//
+//	file, err := provider.UploadFile(ctx, data, "video/mp4")
+//	part := echo.VideoURLPart(file.URI)
+//
+// WithBaseURL overrides the API host.
+func (p *GoogleProvider) UploadFile(ctx context.Context, data []byte, mimeType string, opts ...CallOption) (*GeminiFile, error) {
+	cfg := CallConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	base := strings.TrimSuffix(cfg.BaseURL, "/")
+	if base == "" {
+		base = "https://generativelanguage.googleapis.com"
+	}
+
+	// Start a resumable upload session
+	meta, _ := json.Marshal(map[string]any{"file": map[string]string{"mimeType": mimeType}})
+	req, err := http.NewRequestWithContext(ctx, "POST", base+"/upload/v1beta/files", bytes.NewReader(meta))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", cfg.userAgent())
+	req.Header.Set("x-goog-api-key", cfg.apiKey(p.Key))
+	req.Header.Set("X-Goog-Upload-Protocol", "resumable")
+	req.Header.Set("X-Goog-Upload-Command", "start")
+	req.Header.Set("X-Goog-Upload-Header-Content-Length", strconv.Itoa(len(data)))
+	req.Header.Set("X-Goog-Upload-Header-Content-Type", mimeType)
+
+	resp, err := cfg.providerClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start upload: %w", err)
+	}
+	resp.Body.Close()
+	uploadURL := resp.Header.Get("X-Goog-Upload-URL")
+	if resp.StatusCode != http.StatusOK || uploadURL == "" {
+		return nil, fmt.Errorf("failed to start upload: status code %d", resp.StatusCode)
+	}
+
+	// Send the content and finalize the upload
+	req, err = http.NewRequestWithContext(ctx, "POST", uploadURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", cfg.userAgent())
+	req.Header.Set("X-Goog-Upload-Offset", "0")
+	req.Header.Set("X-Goog-Upload-Command", "upload, finalize")
+
+	var uploaded struct {
+		File GeminiFile `json:"file"`
+	}
+	if err := doFileRequest(cfg, req, &uploaded); err != nil {
+		return nil, fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	return p.waitForFile(ctx, base, uploaded.File, cfg)
+}
+
+// waitForFile polls the file state until processing is finished
+func (p *GoogleProvider) waitForFile(ctx context.Context, base string, file GeminiFile, cfg CallConfig) (*GeminiFile, error) {
+	for file.State == "PROCESSING" {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(filePollInterval):
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", base+"/v1beta/"+file.Name, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", cfg.userAgent())
+		req.Header.Set("x-goog-api-key", cfg.apiKey(p.Key))
+		if err := doFileRequest(cfg, req, &file); err != nil {
+			return nil, fmt.Errorf("failed to check file state: %w", err)
+		}
+	}
+
+	if file.State == "FAILED" {
+		return nil, fmt.Errorf("processing of file %s failed", file.Name)
+	}
+	return &file, nil
+}
+
+func doFileRequest(cfg CallConfig, req *http.Request, responsePtr any) error {
+	resp, err := cfg.providerClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return newAPIError(cfg, resp.StatusCode, body)
+	}
+	return json.NewDecoder(resp.Body).Decode(responsePtr)
+}
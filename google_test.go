@@ -0,0 +1,92 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGoogleCachedTokens(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"candidates": [{"content": {"parts": [{"text": "Hi"}]}, "finishReason": "STOP"}],
+			"usageMetadata": {"promptTokenCount": 1200, "candidatesTokenCount": 3, "totalTokenCount": 1203,
+			"cachedContentTokenCount": 1024}}`))
+	}))
+	defer server.Close()
+
+	provider := &GoogleProvider{Key: "test"}
+	resp, err := provider.call(context.Background(), QuickMessage("Hello"), CallConfig{Model: "gemini-2.5-flash", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("call() error = %v", err)
+	}
+	if resp.Metadata["cached_tokens"] != 1024 || resp.Metadata["prompt_tokens"] != 1200 {
+		t.Errorf("Unexpected usage metadata: %v", resp.Metadata)
+	}
+}
+
+func TestGoogleSafety(t *testing.T) {
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = nil
+		json.NewDecoder(r.Body).Decode(&request)
+		switch {
+		case r.URL.Query().Get("alt") == "sse":
+			w.Write([]byte(`data: {"promptFeedback": {"blockReason": "SAFETY", "safetyRatings": [{"category": "HARM_CATEGORY_HARASSMENT", "probability": "HIGH", "blocked": true}]}}` + "\n\n"))
+		case r.URL.Path == "/blocked-prompt:generateContent":
+			w.Write([]byte(`{"promptFeedback": {"blockReason": "SAFETY",
+				"safetyRatings": [{"category": "HARM_CATEGORY_HARASSMENT", "probability": "HIGH", "blocked": true}]}}`))
+		default:
+			w.Write([]byte(`{"candidates": [{"finishReason": "SAFETY",
+				"safetyRatings": [{"category": "HARM_CATEGORY_DANGEROUS_CONTENT", "probability": "MEDIUM", "blocked": true}]}]}`))
+		}
+	}))
+	defer server.Close()
+
+	setting := SafetySetting{Category: "HARM_CATEGORY_DANGEROUS_CONTENT", Threshold: "BLOCK_LOW_AND_ABOVE"}
+	client, _ := NewCommonClient(map[string]string{"google": "key", "openai": "key"}, WithSafetySettings(setting))
+	ctx := context.Background()
+
+	resp, err := client.Complete(ctx, QuickMessage("Hi"), WithModel("google/gemini-2.5-flash"), WithBaseURL(server.URL+"/blocked-answer:generateContent"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	settings, _ := request["safetySettings"].([]any)
+	if len(settings) != 1 || settings[0].(map[string]any)["threshold"] != "BLOCK_LOW_AND_ABOVE" {
+		t.Errorf("Unexpected safety settings in the request: %v", request["safetySettings"])
+	}
+	ratings, _ := resp.Metadata["safety_ratings"].([]SafetyRating)
+	if resp.Text != "" || resp.StopReason() != FinishContentFilter || len(ratings) != 1 || !ratings[0].Blocked {
+		t.Errorf("Unexpected blocked answer %q, metadata %v", resp.Text, resp.Metadata)
+	}
+
+	resp, err = client.Complete(ctx, QuickMessage("Hi"), WithModel("google/gemini-2.5-flash"), WithBaseURL(server.URL+"/blocked-prompt:generateContent"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.StopReason() != FinishContentFilter || resp.Metadata["block_reason"] != "SAFETY" || resp.Metadata["safety_ratings"] == nil {
+		t.Errorf("Unexpected blocked prompt metadata %v", resp.Metadata)
+	}
+
+	stream, err := client.StreamComplete(ctx, QuickMessage("Hi"), WithModel("google/gemini-2.5-flash"), WithBaseURL(server.URL+"/blocked-prompt:generateContent"))
+	if err != nil {
+		t.Fatalf("StreamComplete() error = %v", err)
+	}
+	var meta Metadata
+	for chunk := range stream.Stream {
+		if chunk.Error != nil {
+			t.Fatalf("stream error = %v", chunk.Error)
+		}
+		if chunk.Meta != nil {
+			meta = *chunk.Meta
+		}
+	}
+	if meta["finish_reason"] != FinishContentFilter || meta["block_reason"] != "SAFETY" {
+		t.Errorf("Unexpected stream metadata %v", meta)
+	}
+
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithModel("openai/gpt-4o"), WithBaseURL(server.URL)); err == nil {
+		t.Error("Expected an error for safety settings outside Gemini")
+	}
+}
@@ -0,0 +1,248 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// callHooks collects the client-side processing attached to a single call
+type callHooks struct {
+	meta     Metadata                // added to the response metadata
+	response []func(*Response)       // applied to complete responses, in order
+	checks   []func(*Response) error // applied to complete responses after the hooks, fail the call
+	filters  []textFilter            // applied to streamed text, in order
+	done     []func(Metadata)        // called with the provider metadata when the call finishes
+}
+
+// prepareMessages runs client-side checks and transformations on the message chain
+// and returns the messages to send together with the hooks for the response
+func (c *CommonClient) prepareMessages(ctx context.Context, messages []Message, cfg *CallConfig) ([]Message, *callHooks, error) {
+	hooks := &callHooks{meta: Metadata{}}
+
+	if cfg.Tenant != "" && cfg.TenantStore != nil {
+		if err := checkTenantBudget(*cfg); err != nil {
+			return nil, nil, err
+		}
+		hooks.done = append(hooks.done, func(meta Metadata) {
+			tokens := usageTokens(meta)
+			err := cfg.TenantStore.RecordUsage(context.WithoutCancel(ctx), cfg.Tenant, tokens)
+			// The answer is already delivered, so a failed record is reported to the logger only
+			if err != nil && cfg.Logger != nil {
+				cfg.Logger.Error("failed to record tenant usage", "tenant", cfg.Tenant, "tokens", tokens, "error", err)
+			}
+		})
+	}
+
+	if cfg.Sanitation != nil {
+		var count int
+		var err error
+		if messages, count, err = SanitizeMessages(messages, cfg.Sanitation...); err != nil {
+			return nil, nil, err
+		}
+		hooks.meta["sanitized"] = count
+	}
+
+	if cfg.InjectionGuard != "" {
+		score, err := c.guardMessages(ctx, messages, *cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		hooks.meta["injection_score"] = score
+		hooks.meta["injection_flagged"] = score >= cfg.injectionThreshold()
+	}
+
+	if cfg.ImageFetchLimit > 0 {
+		var err error
+		if messages, err = fetchImages(ctx, messages, *cfg); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if cfg.CurrentTime {
+		messages = appendSystem(messages, cfg, timeInstruction(timeNow()))
+	}
+	if cfg.Locale != "" {
+		text, err := localeInstruction(cfg.Locale)
+		if err != nil {
+			return nil, nil, err
+		}
+		messages = appendSystem(messages, cfg, text)
+	}
+
+	if cfg.AutoLanguage {
+		messages = appendSystem(messages, cfg, languageInstruction(messages))
+	}
+
+	if cfg.JSONRepair && cfg.jsonOutput() {
+		hooks.response = append(hooks.response, func(resp *Response) {
+			if text, repaired := RepairJSON(resp.Text); repaired {
+				resp.Text = text
+				if resp.Metadata == nil {
+					resp.Metadata = Metadata{}
+				}
+				resp.Metadata["json_repaired"] = true
+			}
+		})
+	}
+
+	if cfg.ConfidenceScore {
+		if logprobModel(*cfg) {
+			hooks.response = append(hooks.response, func(resp *Response) {
+				if score, ok := resp.Metadata["logprob_confidence"].(float64); ok {
+					resp.Confidence = score
+					resp.Metadata["confidence_method"] = ConfidenceLogprobs
+				}
+			})
+		} else {
+			messages = appendSystem(messages, cfg, confidenceInstruction)
+			hooks.response = append(hooks.response, func(resp *Response) {
+				if text, score, ok := parseConfidence(resp.Text); ok {
+					resp.Text, resp.Confidence = text, score
+					if resp.Metadata == nil {
+						resp.Metadata = Metadata{}
+					}
+					resp.Metadata["confidence_method"] = ConfidenceSelf
+				}
+			})
+			hooks.filters = append(hooks.filters, &confidenceFilter{})
+		}
+	}
+
+	if cfg.Scrubbing != nil {
+		scrubber, err := NewScrubber(cfg.Scrubbing...)
+		if err != nil {
+			return nil, nil, err
+		}
+		var count, n int
+		messages, count = scrubber.ScrubMessages(messages)
+		cfg.SystemMsg, n = scrubber.Scrub(cfg.SystemMsg)
+		hooks.meta["scrubbed"] = count + n
+	}
+
+	if cfg.PIIMasking != nil {
+		masker := NewPIIMasker(cfg.PIIMasking...)
+		messages = masker.MaskMessages(messages)
+		if cfg.SystemMsg != "" {
+			cfg.SystemMsg = masker.Mask(cfg.SystemMsg)
+		}
+		hooks.response = append(hooks.response, func(resp *Response) {
+			resp.Text = masker.Unmask(resp.Text)
+		})
+		hooks.filters = append(hooks.filters, masker.unmaskFilter())
+		hooks.meta["pii_masked"] = masker.Count()
+	}
+
+	if cfg.OutputFilter != "" {
+		filter, err := newOutputFilter(cfg.OutputFilter, cfg.OutputPatterns)
+		if err != nil {
+			return nil, nil, err
+		}
+		hooks.checks = append(hooks.checks, func(resp *Response) error {
+			text, err := filter.filter(resp.Text)
+			resp.Text = text
+			return err
+		})
+		hooks.filters = append(hooks.filters, filter)
+	}
+
+	if cfg.coalesce() {
+		messages = coalesceMessages(messages)
+	}
+
+	if cfg.jsonOutput() {
+		// Runs as the last check, so it sees the answer after repairs and filters
+		hooks.checks = append(hooks.checks, func(resp *Response) error {
+			if text := strings.TrimSpace(resp.Text); json.Valid([]byte(text)) {
+				resp.JSON = json.RawMessage(text)
+			}
+			return nil
+		})
+	}
+
+	if cfg.MaxTokensAuto {
+		n, ok, err := autoMaxTokens(messages, *cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			cfg.MaxTokens = &n
+		}
+	}
+
+	return messages, hooks, nil
+}
+
+// apply runs the response hooks and checks and merges hook metadata into the response
+func (h *callHooks) apply(resp *Response) error {
+	for _, fn := range h.done {
+		fn(resp.Metadata)
+	}
+	for _, fn := range h.response {
+		fn(resp)
+	}
+	for _, fn := range h.checks {
+		if err := fn(resp); err != nil {
+			return err
+		}
+	}
+	// Other answers get the same processing of the text, metadata hooks run once
+	for i := range resp.Choices {
+		if i == 0 {
+			resp.Choices[0].Text = resp.Text
+			continue
+		}
+		choice := &Response{Text: resp.Choices[i].Text, Metadata: Metadata{}}
+		for _, fn := range h.response {
+			fn(choice)
+		}
+		for _, fn := range h.checks {
+			if err := fn(choice); err != nil {
+				return err
+			}
+		}
+		resp.Choices[i].Text = choice.Text
+	}
+	if len(h.meta) > 0 {
+		if resp.Metadata == nil {
+			resp.Metadata = Metadata{}
+		}
+		for k, v := range h.meta {
+			resp.Metadata[k] = v
+		}
+	}
+	return nil
+}
+
+// wrap applies the stream filters and emits hook metadata before the streamed chunks
+func (h *callHooks) wrap(ctx context.Context, stream *StreamResponse) *StreamResponse {
+	if len(h.done) > 0 {
+		stream = observeStream(ctx, stream, func(meta Metadata) {
+			for _, fn := range h.done {
+				fn(meta)
+			}
+		})
+	}
+	if len(h.filters) > 0 {
+		stream = filterStream(ctx, stream, h.filters...)
+	}
+	if len(h.meta) > 0 {
+		stream = prependMeta(ctx, stream, h.meta)
+	}
+	return stream
+}
+
+// appendSystem adds an instruction to the effective system prompt, which is either
+// the WithSystemMessage value or the system message at the start of the chain
+func appendSystem(messages []Message, cfg *CallConfig, text string) []Message {
+	switch {
+	case cfg.SystemMsg != "":
+		cfg.SystemMsg += "\n\n" + text
+	case len(messages) > 0 && messages[0].Role == System:
+		messages = append([]Message{}, messages...)
+		messages[0].Content += "\n\n" + text
+	default:
+		cfg.SystemMsg = text
+	}
+	return messages
+}
@@ -5,37 +5,241 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 type RequestInit func(*http.Request)
 
+// maxPooledBuffer is the largest buffer returned to the pool, so a single huge request
+// doesn't keep its memory alive for the lifetime of the process
+const maxPooledBuffer = 4 << 20
+
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// pooledBody is a request body backed by a pooled buffer. The buffer is released after
+// the transport closed the body, every copy made by GetBody is closed and the request
+// returned, since redirects and retries on a reused connection read the body again
+// while the request is in progress.
+type pooledBody struct {
+	*bytes.Reader
+	buf  *bytes.Buffer
+	refs atomic.Int32
+	once sync.Once
+}
+
+func (b *pooledBody) Close() error {
+	b.once.Do(b.release)
+	return nil
+}
+
+func (b *pooledBody) release() {
+	if b.refs.Add(-1) == 0 && b.buf.Cap() <= maxPooledBuffer {
+		b.buf.Reset()
+		bufferPool.Put(b.buf)
+	}
+}
+
+// copy returns a new reader over the buffer, holding a reference until it is closed
+func (b *pooledBody) copy() io.ReadCloser {
+	b.refs.Add(1)
+	return &pooledCopy{Reader: bytes.NewReader(b.buf.Bytes()), body: b}
+}
+
+// pooledCopy is a body returned by GetBody
+type pooledCopy struct {
+	*bytes.Reader
+	body *pooledBody
+	once sync.Once
+}
+
+func (c *pooledCopy) Close() error {
+	c.once.Do(c.body.release)
+	return nil
+}
+
+// newJSONRequest creates a POST request with the body encoded as JSON into a pooled buffer.
+// The returned function must be called once the request has been sent.
+func newJSONRequest(ctx context.Context, url string, body any) (*http.Request, func(), error) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(body); err != nil {
+		bufferPool.Put(buf)
+		return nil, nil, err
+	}
+
+	data := buf.Bytes()
+	pb := &pooledBody{Reader: bytes.NewReader(data), buf: buf}
+	pb.refs.Store(2)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, pb)
+	if err != nil {
+		bufferPool.Put(buf)
+		return nil, nil, err
+	}
+	req.ContentLength = int64(len(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return pb.copy(), nil
+	}
+
+	// The transport always closes the body, even on errors
+	return req, pb.release, nil
+}
+
+// APIError is returned when a provider answers with a non-200 status. The details are parsed
+// from the error body of OpenAI-compatible, Anthropic and Gemini APIs, so callers can check
+// them with errors.As instead of matching the error text.
+type APIError struct {
+	StatusCode int    // HTTP status code
+	Provider   string // provider of the call, empty when a provider is used directly
+	Type       string // error type, e.g. "invalid_request_error", or the Gemini status, e.g. "RESOURCE_EXHAUSTED"
+	Code       string // error code when sent, e.g. "context_length_exceeded"
+	Message    string // message of the provider
+	Body       string // unparsed response body
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("status code: %d, body: %s", e.StatusCode, e.Body)
+	}
+
+	kind := e.Type
+	if e.Code != "" && e.Code != e.Type {
+		kind = strings.TrimPrefix(kind+"/"+e.Code, "/")
+	}
+	if kind == "" {
+		return fmt.Sprintf("status code: %d, %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("status code: %d, %s: %s", e.StatusCode, kind, e.Message)
+}
+
+// newAPIError parses an error body. The known shapes are
+//
+//	OpenAI:    {"error": {"message": "...", "type": "...", "code": "..."}}
+//	Anthropic: {"type": "error", "error": {"type": "...", "message": "..."}}
+//	Gemini:    {"error": {"code": 400, "message": "...", "status": "..."}}, sometimes in an array
+//	xAI:       {"code": "...", "error": "..."}
+func newAPIError(cfg CallConfig, status int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: status, Provider: cfg.provider, Body: string(body)}
+
+	data := bytes.TrimSpace(body)
+	if len(data) > 0 && data[0] == '[' {
+		var list []json.RawMessage
+		if json.Unmarshal(data, &list) != nil || len(list) == 0 {
+			return apiErr
+		}
+		data = list[0]
+	}
+
+	var envelope struct {
+		Error json.RawMessage `json:"error"`
+		Code  any             `json:"code"`
+	}
+	if json.Unmarshal(data, &envelope) != nil || len(envelope.Error) == 0 {
+		return apiErr
+	}
+	if envelope.Error[0] == '"' {
+		json.Unmarshal(envelope.Error, &apiErr.Message)
+		apiErr.Code, _ = envelope.Code.(string)
+		return apiErr
+	}
+
+	var detail struct {
+		Type    string `json:"type"`
+		Status  string `json:"status"`
+		Code    any    `json:"code"` // a string for OpenAI, the HTTP status for Gemini
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(envelope.Error, &detail) != nil {
+		return apiErr
+	}
+	apiErr.Type = detail.Type
+	if apiErr.Type == "" {
+		apiErr.Type = detail.Status
+	}
+	apiErr.Code, _ = detail.Code.(string)
+	apiErr.Message = detail.Message
+	return apiErr
+}
+
+// mutateBody passes the request body as a generic map to the request mutator of the call.
+// Numbers are kept as json.Number, so large integers survive the round trip.
+func mutateBody(cfg CallConfig, body any) (any, error) {
+	if cfg.RequestMutator == nil {
+		return body, nil
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var fields map[string]any
+	if err := dec.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("request body is not a JSON object: %w", err)
+	}
+
+	cfg.RequestMutator(cfg.provider, fields)
+	return fields, nil
+}
+
 // callHTTPAPI is a generic function that makes HTTP requests and decodes responses
-func callHTTPAPI(ctx context.Context, url string, init RequestInit, body any, responsePtr any) error {
-	jsonBody, err := json.Marshal(body)
+func callHTTPAPI(ctx context.Context, cfg CallConfig, url string, init RequestInit, body any, responsePtr any) error {
+	body, err := mutateBody(cfg, body)
 	if err != nil {
 		return err
 	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
-	req.Header.Set("Content-Type", "application/json")
+	req, done, err := newJSONRequest(ctx, url, body)
 	if err != nil {
 		return err
 	}
+	defer done()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", cfg.userAgent())
 
 	init(req)
 
-	resp, err := http.DefaultClient.Do(req)
+	if cfg.QuotaPacing {
+		if err := cfg.quota.pace(ctx, cfg.provider); err != nil {
+			return err
+		}
+	}
+	resp, err := cfg.providerClient().Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	cfg.quota.observe(cfg.provider, resp)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("status code: %d, body: %s", resp.StatusCode, string(body))
+		if cfg.RawResponse != nil {
+			*cfg.RawResponse = body
+		}
+		return newAPIError(cfg, resp.StatusCode, body)
+	}
+
+	if cfg.RawResponse != nil {
+		data, err := io.ReadAll(resp.Body)
+		*cfg.RawResponse = data
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, responsePtr); err != nil {
+			return fmt.Errorf("failed to decode response: %w, body: %s", err, string(data))
+		}
+		return nil
 	}
 
 	err = json.NewDecoder(resp.Body).Decode(responsePtr)
@@ -47,32 +251,58 @@ func callHTTPAPI(ctx context.Context, url string, init RequestInit, body any, re
 	return nil
 }
 
+// rawBody copies everything read from a response stream into the raw response of the call
+type rawBody struct {
+	io.ReadCloser
+	raw *[]byte
+}
+
+func (b rawBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	*b.raw = append(*b.raw, p[:n]...)
+	return n, err
+}
+
 // streamHTTPAPI makes streaming HTTP requests and returns the response body
-func streamHTTPAPI(ctx context.Context, url string, init RequestInit, body any) (io.ReadCloser, error) {
-	jsonBody, err := json.Marshal(body)
+func streamHTTPAPI(ctx context.Context, cfg CallConfig, url string, init RequestInit, body any) (io.ReadCloser, error) {
+	body, err := mutateBody(cfg, body)
 	if err != nil {
 		return nil, err
 	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
+	req, done, err := newJSONRequest(ctx, url, body)
 	if err != nil {
 		return nil, err
 	}
+	defer done()
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", cfg.userAgent())
 
 	init(req)
 
-	resp, err := http.DefaultClient.Do(req)
+	if cfg.QuotaPacing {
+		if err := cfg.quota.pace(ctx, cfg.provider); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := cfg.providerClient().Do(req)
 	if err != nil {
 		return nil, err
 	}
+	cfg.quota.observe(cfg.provider, resp)
 
 	if resp.StatusCode != http.StatusOK {
 		defer resp.Body.Close()
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("status code: %d, body: %s", resp.StatusCode, string(body))
+		if cfg.RawResponse != nil {
+			*cfg.RawResponse = body
+		}
+		return nil, newAPIError(cfg, resp.StatusCode, body)
 	}
 
+	if cfg.RawResponse != nil {
+		*cfg.RawResponse = nil
+		return rawBody{ReadCloser: resp.Body, raw: cfg.RawResponse}, nil
+	}
 	return resp.Body, nil
 }
 
@@ -82,41 +312,40 @@ type SSEMessage struct {
 	Data  []byte
 }
 
-var eventPrefix = []byte("event: ")
-var dataPrefix = []byte("data: ")
+var eventPrefix = []byte("event:")
+var dataPrefix = []byte("data:")
 var doneMarker = []byte("[DONE]")
-var emptyLine = []byte("")
 
-// parseSSEStream parses Server-Sent Events stream and calls handler for each complete message
+// maxSSELine limits the size of a single SSE line; the line buffer grows up to this size and is reused
+const maxSSELine = 16 << 20
+
+var sseBufferPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 64<<10)
+		return &buf
+	},
+}
+
+// parseSSEStream parses Server-Sent Events stream and calls handler for each complete message.
+// msg.Data is only valid until the handler returns.
 func parseSSEStream(respBody io.ReadCloser, handler func(SSEMessage) error) error {
 	defer respBody.Close()
 
+	lineBuf := sseBufferPool.Get().(*[]byte)
+	defer sseBufferPool.Put(lineBuf)
+
 	var buffer bytes.Buffer
-	reader := bufio.NewReader(respBody)
+	scanner := bufio.NewScanner(respBody)
+	scanner.Buffer(*lineBuf, maxSSELine)
 	var currentEvent string
 
-	for {
-		line, err := reader.ReadBytes('\n')
-		if err == io.EOF {
-			// Process any remaining data in buffer
-			if buffer.Len() > 0 {
-				msg := SSEMessage{Event: currentEvent, Data: buffer.Bytes()}
-				if err := handler(msg); err != nil {
-					return err
-				}
-			}
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("read error: %w", err)
-		}
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
 
-		// Check for double newline (message separator)
-		if bytes.Equal(bytes.TrimSpace(line), emptyLine) {
-			// End of message, process buffer contents if we have data
+		// Empty line is the message separator
+		if len(line) == 0 {
 			if buffer.Len() > 0 {
-				msg := SSEMessage{Event: currentEvent, Data: buffer.Bytes()}
-				if err := handler(msg); err != nil {
+				if err := handler(SSEMessage{Event: currentEvent, Data: buffer.Bytes()}); err != nil {
 					return err
 				}
 				buffer.Reset()
@@ -125,19 +354,38 @@ func parseSSEStream(respBody io.ReadCloser, handler func(SSEMessage) error) erro
 			continue
 		}
 
-		line = bytes.TrimSpace(line)
-		if len(line) == 0 {
-			continue
-		}
-
 		// Parse SSE fields
 		if bytes.HasPrefix(line, eventPrefix) {
-			currentEvent = string(bytes.TrimPrefix(line, eventPrefix))
+			currentEvent = string(bytes.TrimSpace(line[len(eventPrefix):]))
 		} else if bytes.HasPrefix(line, dataPrefix) {
-			data := bytes.TrimPrefix(line, dataPrefix)
-			buffer.Write(data)
+			buffer.Write(bytes.TrimSpace(line[len(dataPrefix):]))
 		}
 	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read error: %w", err)
+	}
 
+	// Process any remaining data in buffer
+	if buffer.Len() > 0 {
+		return handler(SSEMessage{Event: currentEvent, Data: buffer.Bytes()})
+	}
 	return nil
 }
+
+// errStreamDone stops parsing at the [DONE] marker of OpenAI-compatible streams
+var errStreamDone = errors.New("stream done")
+
+// parseSSEData calls handler with the data of each message of an OpenAI-compatible
+// stream, until the [DONE] marker or the end of the stream
+func parseSSEData(respBody io.ReadCloser, handler func(data []byte) error) error {
+	err := parseSSEStream(respBody, func(msg SSEMessage) error {
+		if bytes.Equal(msg.Data, doneMarker) {
+			return errStreamDone
+		}
+		return handler(msg.Data)
+	})
+	if err == errStreamDone {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,353 @@
+package echo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCallHTTPAPIBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/echo", http.StatusTemporaryRedirect)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(`{"text": ` + strings.TrimSpace(string(body)) + `}`))
+	}))
+	defer server.Close()
+
+	// The body must survive a redirect and pooled buffers must not leak between calls
+	for _, text := range []string{"first <request>", "second"} {
+		var resp struct{ Text string }
+		err := callHTTPAPI(context.Background(), CallConfig{}, server.URL+"/redirect", func(*http.Request) {}, text, &resp)
+		if err != nil {
+			t.Fatalf("callHTTPAPI() error = %v", err)
+		}
+		if resp.Text != text {
+			t.Errorf("Expected %q, got %q", text, resp.Text)
+		}
+	}
+}
+
+func TestPooledBodyCopy(t *testing.T) {
+	req, done, err := newJSONRequest(context.Background(), "http://localhost", "text")
+	if err != nil {
+		t.Fatalf("newJSONRequest() error = %v", err)
+	}
+	pb := req.Body.(*pooledBody)
+
+	// A copy made by GetBody keeps the buffer after the original body and the request are done
+	body, _ := req.GetBody()
+	req.Body.Close()
+	req.Body.Close()
+	done()
+	if pb.refs.Load() != 1 {
+		t.Fatalf("Expected the copy to hold the buffer, got %d references", pb.refs.Load())
+	}
+	if data, _ := io.ReadAll(body); string(data) != "\"text\"\n" {
+		t.Errorf("Unexpected copy %q", data)
+	}
+	body.Close()
+	body.Close()
+	if pb.refs.Load() != 0 {
+		t.Errorf("Expected the buffer to be released, got %d references", pb.refs.Load())
+	}
+}
+
+func BenchmarkNewJSONRequest(b *testing.B) {
+	messages := make([]OpenAIMessage, 200)
+	for i := range messages {
+		messages[i] = OpenAIMessage{Role: "user", Content: strings.Repeat("lorem ipsum ", 100)}
+	}
+	body := OpenAIRequest{Model: "gpt-4.1", Messages: messages}
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		req, done, err := newJSONRequest(ctx, "http://localhost", body)
+		if err != nil {
+			b.Fatal(err)
+		}
+		req.Body.Close()
+		done()
+	}
+}
+
+func TestParseSSEStream(t *testing.T) {
+	body := "event: start\ndata: {\"a\":\ndata: 1}\n\n: comment\ndata:{\"b\":2}\r\n\r\ndata: [DONE]\n\ndata: {\"c\":3}"
+
+	var got []string
+	err := parseSSEStream(io.NopCloser(strings.NewReader(body)), func(msg SSEMessage) error {
+		got = append(got, msg.Event+" "+string(msg.Data))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("parseSSEStream() error = %v", err)
+	}
+	want := []string{`start {"a":1}`, ` {"b":2}`, ` [DONE]`, ` {"c":3}`}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("parseSSEStream() = %q, want %q", got, want)
+	}
+
+	got = nil
+	err = parseSSEData(io.NopCloser(strings.NewReader(body)), func(data []byte) error {
+		got = append(got, string(data))
+		return nil
+	})
+	if err != nil || len(got) != 2 {
+		t.Errorf("parseSSEData() = %q, %v; expected to stop at [DONE]", got, err)
+	}
+}
+
+// openAIStreamBody builds an OpenAI-style stream with n content chunks
+func openAIStreamBody(n int) []byte {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		sb.WriteString(`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"token "}}]}` + "\n\n")
+	}
+	sb.WriteString(`data: {"choices":[],"usage":{"prompt_tokens":10,"completion_tokens":1000,"total_tokens":1010}}` + "\n\n")
+	sb.WriteString("data: [DONE]\n\n")
+	return []byte(sb.String())
+}
+
+// staticTransport answers every request with the same streaming body
+type staticTransport struct {
+	body []byte
+}
+
+func (t staticTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/event-stream"}},
+		Body:       io.NopCloser(bytes.NewReader(t.body)),
+		Request:    req,
+	}, nil
+}
+
+func BenchmarkParseSSEStream(b *testing.B) {
+	body := openAIStreamBody(1000)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		err := parseSSEStream(io.NopCloser(bytes.NewReader(body)), func(SSEMessage) error { return nil })
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkOpenAIStream(b *testing.B) {
+	body := openAIStreamBody(1000)
+	client, err := NewCommonClient(map[string]string{"openai": "key"},
+		WithModel("openai/gpt-4.1"),
+		WithHTTPClient(&http.Client{Transport: staticTransport{body: body}}),
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := context.Background()
+	messages := QuickMessage("Hello")
+
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		stream, err := client.StreamComplete(ctx, messages)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for chunk := range stream.Stream {
+			if chunk.Error != nil {
+				b.Fatal(chunk.Error)
+			}
+		}
+	}
+}
+
+func TestRequestMutator(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "ok"}}]}`))
+	}))
+	defer server.Close()
+
+	var provider string
+	client, _ := NewCommonClient(map[string]string{"openai": "key"},
+		WithModel("openai/gpt-4o-mini"),
+		WithBaseURL(server.URL),
+		WithRequestMutator(func(name string, body map[string]any) {
+			provider = name
+			body["service_tier"] = "flex"
+			delete(body, "temperature")
+		}),
+	)
+
+	if _, err := client.Complete(context.Background(), QuickMessage("Hi"), WithTemperature(0.5)); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if provider != "openai" {
+		t.Errorf("Expected the provider name, got %q", provider)
+	}
+	if got["service_tier"] != "flex" || got["model"] != "gpt-4o-mini" {
+		t.Errorf("Unexpected request body: %v", got)
+	}
+	if _, ok := got["temperature"]; ok {
+		t.Errorf("Expected temperature to be removed: %v", got)
+	}
+}
+
+func TestRawResponse(t *testing.T) {
+	const completion = `{"choices": [{"message": {"role": "assistant", "content": "ok"}}], "system_fingerprint": "fp_1"}`
+	const stream = "data: {\"choices\":[{\"delta\":{\"content\":\"ok\"}}]}\n\ndata: [DONE]\n\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["stream"] == true {
+			w.Write([]byte(stream))
+			return
+		}
+		w.Write([]byte(completion))
+	}))
+	defer server.Close()
+
+	client, _ := NewCommonClient(map[string]string{"openai": "key"},
+		WithModel("openai/gpt-4o-mini"),
+		WithBaseURL(server.URL),
+	)
+	ctx := context.Background()
+
+	var raw []byte
+	resp, err := client.Complete(ctx, QuickMessage("Hi"), WithRawResponse(&raw))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Text != "ok" || string(raw) != completion {
+		t.Errorf("Unexpected response %q, raw %q", resp.Text, raw)
+	}
+
+	streamResp, err := client.StreamComplete(ctx, QuickMessage("Hi"), WithRawResponse(&raw))
+	if err != nil {
+		t.Fatalf("StreamComplete() error = %v", err)
+	}
+	for chunk := range streamResp.Stream {
+		if chunk.Error != nil {
+			t.Fatalf("Stream error = %v", chunk.Error)
+		}
+	}
+	if string(raw) != stream {
+		t.Errorf("Unexpected raw stream %q", raw)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		typ     string
+		code    string
+		message string
+	}{
+		{"openai", `{"error": {"message": "too long", "type": "invalid_request_error", "param": "messages", "code": "context_length_exceeded"}}`,
+			"invalid_request_error", "context_length_exceeded", "too long"},
+		{"anthropic", `{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`,
+			"overloaded_error", "", "Overloaded"},
+		{"google", `[{"error": {"code": 429, "message": "Quota exceeded", "status": "RESOURCE_EXHAUSTED"}}]`,
+			"RESOURCE_EXHAUSTED", "", "Quota exceeded"},
+		{"xai", `{"code": "Client specified an invalid argument", "error": "Incorrect API key"}`,
+			"", "Client specified an invalid argument", "Incorrect API key"},
+		{"html", `<html>Bad Gateway</html>`, "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			cfg := CallConfig{provider: tt.name}
+			err := callHTTPAPI(context.Background(), cfg, server.URL, func(*http.Request) {}, struct{}{}, &struct{}{})
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Expected an *APIError, got %v", err)
+			}
+			if apiErr.StatusCode != http.StatusBadRequest || apiErr.Provider != tt.name || apiErr.Body != tt.body ||
+				apiErr.Type != tt.typ || apiErr.Code != tt.code || apiErr.Message != tt.message {
+				t.Errorf("Unexpected error: %+v", apiErr)
+			}
+		})
+	}
+
+	err := (&APIError{StatusCode: 400, Type: "invalid_request_error", Code: "context_length_exceeded", Message: "too long"}).Error()
+	if err != "status code: 400, invalid_request_error/context_length_exceeded: too long" {
+		t.Errorf("Unexpected error text: %q", err)
+	}
+}
+
+func TestUserAttribution(t *testing.T) {
+	var request map[string]any
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request, header = nil, r.Header
+		json.NewDecoder(r.Body).Decode(&request)
+		if r.Header.Get("anthropic-version") != "" {
+			w.Write([]byte(`{"content": [{"type": "text", "text": "ok"}]}`))
+			return
+		}
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "ok"}}]}`))
+	}))
+	defer server.Close()
+
+	keys := map[string]string{"openai": "key", "anthropic": "key", "xai": "key", "openrouter": "key"}
+	client, _ := NewCommonClient(keys, WithBaseURL(server.URL), WithUserID("user-42"),
+		WithRequestMetadata(map[string]string{"referer": "https://example.com", "title": "Example"}))
+	ctx := context.Background()
+
+	tests := []struct {
+		model string
+		user  func() any
+	}{
+		{"openai/gpt-4o", func() any { return request["user"] }},
+		{"xai/grok-3", func() any { return request["user"] }},
+		{"openrouter/openai/gpt-4o", func() any { return request["user"] }},
+		{"anthropic/claude-sonnet-4-5", func() any { return request["metadata"].(map[string]any)["user_id"] }},
+	}
+	for _, tt := range tests {
+		if _, err := client.Complete(ctx, QuickMessage("Hi"), WithModel(tt.model)); err != nil {
+			t.Fatalf("%s: Complete() error = %v", tt.model, err)
+		}
+		if user := tt.user(); user != "user-42" {
+			t.Errorf("%s: user %v", tt.model, user)
+		}
+	}
+
+	// OpenRouter gets the metadata as app attribution headers, OpenAI in the body
+	if header.Get("HTTP-Referer") != "" {
+		t.Errorf("Expected no attribution headers for Anthropic, got %v", header)
+	}
+	client.Complete(ctx, QuickMessage("Hi"), WithModel("openrouter/openai/gpt-4o"))
+	if header.Get("HTTP-Referer") != "https://example.com" || header.Get("X-Title") != "Example" || request["metadata"] != nil {
+		t.Errorf("Unexpected OpenRouter attribution: headers %v, metadata %v", header, request["metadata"])
+	}
+	client.Complete(ctx, QuickMessage("Hi"), WithModel("openai/gpt-4o"))
+	if metadata, _ := request["metadata"].(map[string]any); metadata["title"] != "Example" {
+		t.Errorf("Expected the OpenAI metadata in the body, got %v", request["metadata"])
+	}
+
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithModel("openai/gpt-4o"),
+		WithRequestMetadata(map[string]string{"note": strings.Repeat("x", 600)})); err == nil {
+		t.Error("Expected an error for a metadata value over the OpenAI limit")
+	}
+}
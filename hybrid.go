@@ -0,0 +1,125 @@
+package echo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 is a keyword index over documents, scored with Okapi BM25
+type BM25 struct {
+	IDs []string
+	K1  float64 // term frequency saturation, 1.2 by default
+	B   float64 // length normalization, 0.75 by default
+
+	terms  []map[string]int // term counts per document
+	lens   []int            // document lengths in terms
+	avgLen float64
+	df     map[string]int // number of documents containing the term
+}
+
+// NewBM25 indexes the documents, ids identify them in search results
+func NewBM25(ids []string, docs []string) (*BM25, error) {
+	if len(ids) != len(docs) {
+		return nil, fmt.Errorf("got %d ids for %d documents", len(ids), len(docs))
+	}
+
+	idx := &BM25{IDs: ids, K1: 1.2, B: 0.75, df: map[string]int{}}
+	total := 0
+	for _, doc := range docs {
+		words := searchTerms(doc)
+		counts := map[string]int{}
+		for _, w := range words {
+			counts[w]++
+		}
+		for w := range counts {
+			idx.df[w]++
+		}
+		idx.terms = append(idx.terms, counts)
+		idx.lens = append(idx.lens, len(words))
+		total += len(words)
+	}
+	if len(docs) > 0 {
+		idx.avgLen = float64(total) / float64(len(docs))
+	}
+	return idx, nil
+}
+
+// searchTerms splits text into lowercase words
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Search returns the k documents with the highest BM25 score for the query, best first.
+// Documents without any query term are not returned.
+func (idx *BM25) Search(query string, k int) []Match {
+	n := float64(len(idx.terms))
+	var matches []Match
+	queryTerms := searchTerms(query)
+	for i, counts := range idx.terms {
+		var score float64
+		for _, term := range queryTerms {
+			tf := float64(counts[term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(idx.df[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			lenNorm := 1 - idx.B + idx.B*float64(idx.lens[i])/idx.avgLen
+			score += idf * tf * (idx.K1 + 1) / (tf + idx.K1*lenNorm)
+		}
+		if score > 0 {
+			matches = append(matches, Match{ID: idx.IDs[i], Index: i, Score: float32(score)})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if k > 0 && k < len(matches) {
+		matches = matches[:k]
+	}
+	return matches
+}
+
+// RRFConstant is the usual k of reciprocal rank fusion, damping the weight of top ranks
+const RRFConstant = 60
+
+// FuseRRF merges ranked result lists with reciprocal rank fusion: each result scores
+// the sum of 1/(RRFConstant + rank) over the lists it appears in. Results are matched by ID;
+// Index is taken from the first list containing the result.
+func FuseRRF(k int, lists ...[]Match) []Match {
+	var fused []Match
+	pos := map[string]int{}
+	for _, list := range lists {
+		for rank, m := range list {
+			score := float32(1.0 / float64(RRFConstant+rank+1))
+			if i, ok := pos[m.ID]; ok {
+				fused[i].Score += score
+				continue
+			}
+			pos[m.ID] = len(fused)
+			fused = append(fused, Match{ID: m.ID, Index: m.Index, Score: score})
+		}
+	}
+
+	sort.SliceStable(fused, func(i, j int) bool { return fused[i].Score > fused[j].Score })
+	if k > 0 && k < len(fused) {
+		fused = fused[:k]
+	}
+	return fused
+}
+
+// HybridSearch ranks documents by both embedding similarity and keyword relevance,
+// fused with FuseRRF. The matrix and the BM25 index must use the same document IDs;
+// candidates are the top results of each ranking.
+func HybridSearch(dense *EmbeddingMatrix, keywords *BM25, query string, vector []float32, k int) ([]Match, error) {
+	candidates := max(k*4, 50)
+	denseMatches, err := dense.Search(vector, candidates)
+	if err != nil {
+		return nil, err
+	}
+	return FuseRRF(k, denseMatches, keywords.Search(query, candidates)), nil
+}
@@ -0,0 +1,65 @@
+package echo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBM25(t *testing.T) {
+	docs := []string{
+		"The quick brown fox jumps over the lazy dog",
+		"Error code E1234 means the disk is full",
+		"Foxes are small omnivorous mammals; a fox is quick",
+	}
+	idx, err := NewBM25([]string{"a", "b", "c"}, docs)
+	if err != nil {
+		t.Fatalf("NewBM25() error = %v", err)
+	}
+
+	matches := idx.Search("quick fox", 0)
+	if len(matches) != 2 || (matches[0].ID != "a" && matches[0].ID != "c") {
+		t.Fatalf("Unexpected matches %+v", matches)
+	}
+	if matches := idx.Search("e1234", 10); len(matches) != 1 || matches[0].ID != "b" {
+		t.Errorf("Expected exact keyword match, got %+v", matches)
+	}
+	if matches := idx.Search("unrelated", 10); len(matches) != 0 {
+		t.Errorf("Expected no matches, got %+v", matches)
+	}
+
+	if _, err := NewBM25([]string{"a"}, docs); err == nil {
+		t.Error("Expected error for mismatched ids")
+	}
+}
+
+func TestFuseRRF(t *testing.T) {
+	dense := []Match{{ID: "a", Index: 0}, {ID: "b", Index: 1}, {ID: "c", Index: 2}}
+	sparse := []Match{{ID: "c", Index: 2}, {ID: "d", Index: 3}}
+
+	fused := FuseRRF(3, dense, sparse)
+	if len(fused) != 3 || fused[0].ID != "c" || fused[1].ID != "a" {
+		t.Fatalf("Unexpected fusion %+v", fused)
+	}
+	if want := 1.0/63 + 1.0/61; math.Abs(float64(fused[0].Score)-want) > 1e-6 {
+		t.Errorf("Fused score = %v, want %v", fused[0].Score, want)
+	}
+}
+
+func TestHybridSearch(t *testing.T) {
+	ids := []string{"a", "b", "c"}
+	docs := []string{"invoice payment overdue", "error code E1234", "holiday schedule"}
+	vectors := make([][]float32, len(docs))
+	for i, doc := range docs {
+		vectors[i] = mockEmbedding(doc)
+	}
+	matrix, _ := NewEmbeddingMatrix(ids, vectors)
+	keywords, _ := NewBM25(ids, docs)
+
+	matches, err := HybridSearch(matrix, keywords, "E1234", mockEmbedding("what does error E1234 mean"), 2)
+	if err != nil {
+		t.Fatalf("HybridSearch() error = %v", err)
+	}
+	if len(matches) != 2 || matches[0].ID != "b" {
+		t.Errorf("Unexpected matches %+v", matches)
+	}
+}
@@ -0,0 +1,50 @@
+package echo
+
+import (
+	"context"
+	"iter"
+	"slices"
+)
+
+// Chunks returns the stream as an iterator for range loops. A failed stream yields its last
+// chunk together with the error and ends. Breaking out of the loop drains the rest of the
+// stream in the background, so the producer can finish.
+func (s *StreamResponse) Chunks() iter.Seq2[StreamChunk, error] {
+	return func(yield func(StreamChunk, error) bool) {
+		for chunk := range s.Stream {
+			if !yield(chunk, chunk.Error) {
+				go drain(s)
+				return
+			}
+			if chunk.Error != nil {
+				go drain(s)
+				return
+			}
+		}
+	}
+}
+
+// CompleteSeq is Complete for a message chain given as an iterator
+func CompleteSeq(ctx context.Context, client Client, messages iter.Seq[Message], opts ...CallOption) (*Response, error) {
+	return client.Complete(ctx, slices.Collect(messages), opts...)
+}
+
+// StreamSeq is StreamComplete for a message chain given as an iterator, returning the chunks
+// as an iterator. When the call fails, the error is the only element.
+//
+//	for chunk, err := range echo.StreamSeq(ctx, client, slices.Values(messages)) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Print(chunk.Data)
+//	}
+func StreamSeq(ctx context.Context, client Client, messages iter.Seq[Message], opts ...CallOption) iter.Seq2[StreamChunk, error] {
+	return func(yield func(StreamChunk, error) bool) {
+		stream, err := client.StreamComplete(ctx, slices.Collect(messages), opts...)
+		if err != nil {
+			yield(StreamChunk{Error: err}, err)
+			return
+		}
+		stream.Chunks()(yield)
+	}
+}
@@ -0,0 +1,43 @@
+package echo
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStreamSeq(t *testing.T) {
+	client, _ := NewCommonClient(nil, WithModel("mock/test"))
+	ctx := context.Background()
+	messages := slices.Values(QuickMessage("An answer that is streamed in several chunks"))
+
+	var text strings.Builder
+	for chunk, err := range StreamSeq(ctx, client, messages) {
+		if err != nil {
+			t.Fatalf("StreamSeq() error = %v", err)
+		}
+		text.WriteString(chunk.Data)
+	}
+	if text.String() != "[user]: An answer that is streamed in several chunks" {
+		t.Errorf("StreamSeq() text = %q", text.String())
+	}
+
+	// Breaking out early is allowed
+	for range StreamSeq(ctx, client, messages) {
+		break
+	}
+
+	var failed error
+	for _, err := range StreamSeq(ctx, client, slices.Values([]Message{})) {
+		failed = err
+	}
+	if failed == nil {
+		t.Error("Expected an error for an empty message chain")
+	}
+
+	resp, err := CompleteSeq(ctx, client, messages)
+	if err != nil || resp.Text != text.String() {
+		t.Errorf("CompleteSeq() = %v, %v", resp, err)
+	}
+}
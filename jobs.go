@@ -0,0 +1,119 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+)
+
+// ErrJobNotFound is returned by a JobStore for unknown and expired jobs
+var ErrJobNotFound = errors.New("job not found")
+
+// JobStore keeps the state of async jobs, so clients can poll them instead of waiting
+// for a callback. Implementations adapt Redis, SQL databases and similar; expired jobs
+// must not be returned, e.g. by saving them with a key TTL or an expiry column.
+type JobStore interface {
+	// Save creates or replaces the job
+	Save(ctx context.Context, job AsyncJob) error
+	// Get returns the job or ErrJobNotFound
+	Get(ctx context.Context, id string) (AsyncJob, error)
+}
+
+// MemoryJobStore is an in-process JobStore, for tests and single-binary deployments
+type MemoryJobStore struct {
+	ttl       time.Duration
+	mu        sync.Mutex
+	jobs      map[string]AsyncJob
+	lastSweep time.Time
+}
+
+// NewMemoryJobStore creates a store that drops jobs not updated for the ttl
+func NewMemoryJobStore(ttl time.Duration) *MemoryJobStore {
+	return &MemoryJobStore{ttl: ttl, jobs: map[string]AsyncJob{}, lastSweep: time.Now()}
+}
+
+// Save implements the JobStore interface
+func (s *MemoryJobStore) Save(ctx context.Context, job AsyncJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs[job.ID] = job
+	// Expired jobs are removed at most once per ttl
+	if now := time.Now(); now.Sub(s.lastSweep) >= s.ttl {
+		for id, job := range s.jobs {
+			if s.expired(job, now) {
+				delete(s.jobs, id)
+			}
+		}
+		s.lastSweep = now
+	}
+	return nil
+}
+
+// Get implements the JobStore interface
+func (s *MemoryJobStore) Get(ctx context.Context, id string) (AsyncJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok || s.expired(job, time.Now()) {
+		return AsyncJob{}, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// DeleteByUser implements the UserDataStore interface
+func (s *MemoryJobStore) DeleteByUser(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, job := range s.jobs {
+		if job.UserID == userID {
+			delete(s.jobs, id)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryJobStore) expired(job AsyncJob, now time.Time) bool {
+	return now.Sub(job.UpdatedAt) > s.ttl
+}
+
+// JobHandler serves the state of async jobs as JSON, for routes like "GET /v1/jobs/{id}".
+// The id is taken from the {id} path wildcard or, without one, from the last path segment.
+func JobHandler(store JobStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJobError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		id := r.PathValue("id")
+		if id == "" {
+			id = path.Base(r.URL.Path)
+		}
+		job, err := store.Get(r.Context(), id)
+		if errors.Is(err, ErrJobNotFound) {
+			writeJobError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeJobError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(job)
+	})
+}
+
+// writeJobError writes an error in the OpenAI error format
+func writeJobError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{"error": map[string]string{"message": message}})
+}
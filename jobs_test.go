@@ -0,0 +1,108 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingStore keeps every saved state of the jobs
+type recordingStore struct {
+	*MemoryJobStore
+	mu    sync.Mutex
+	saved []AsyncJob
+}
+
+func (s *recordingStore) Save(ctx context.Context, job AsyncJob) error {
+	s.mu.Lock()
+	s.saved = append(s.saved, job)
+	s.mu.Unlock()
+	return s.MemoryJobStore.Save(ctx, job)
+}
+
+func TestJobStorePolling(t *testing.T) {
+	defer func(interval time.Duration) { jobProgressInterval = interval }(jobProgressInterval)
+	jobProgressInterval = 0
+
+	store := &recordingStore{MemoryJobStore: NewMemoryJobStore(time.Minute)}
+	c, _ := NewCommonClient(nil, WithModel("mock/test"), WithJobStore(store))
+	client := c.(ProxyClient)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /v1/jobs/{id}", JobHandler(store))
+
+	req := &CompletionRequest{Messages: []OpenAIMessage{{Role: "user", Content: "Tell me a long story"}}}
+	id, err := client.ExecCompleteAsync(context.Background(), req, "")
+	if err != nil {
+		t.Fatalf("ExecCompleteAsync() error = %v", err)
+	}
+
+	var job AsyncJob
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/jobs/"+id, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		json.Unmarshal(rec.Body.Bytes(), &job)
+		if job.Status == JobCompleted || job.Status == JobFailed {
+			break
+		}
+	}
+
+	if job.Status != JobCompleted || job.Output != "[user]: Tell me a long story" {
+		t.Fatalf("Unexpected job %+v", job)
+	}
+	if job.Response == nil || job.Response.Choices[0].Message.Content != job.Output || job.Response.Choices[0].FinishReason != FinishStop {
+		t.Errorf("Unexpected response %+v", job.Response)
+	}
+
+	// Partial output is saved while the job runs
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	partial := false
+	for _, saved := range store.saved {
+		if saved.Status == JobRunning && saved.Output != "" && strings.HasPrefix(job.Output, saved.Output) {
+			partial = true
+		}
+	}
+	if store.saved[0].Status != JobPending || !partial {
+		t.Errorf("Expected pending and running states, got %+v", store.saved)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/jobs/unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown job, got %d", rec.Code)
+	}
+
+	if _, err := c.(ProxyClient).ExecCompleteAsync(context.Background(), req, "", WithJobStore(nil)); err == nil {
+		t.Error("Expected error without a callback URL and a job store")
+	}
+}
+
+func TestMemoryJobStoreTTL(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryJobStore(time.Hour)
+	store.Save(ctx, AsyncJob{ID: "old", Status: JobCompleted, UpdatedAt: time.Now().Add(-2 * time.Hour)})
+	store.Save(ctx, AsyncJob{ID: "new", Status: JobRunning, UpdatedAt: time.Now()})
+
+	if _, err := store.Get(ctx, "old"); err != ErrJobNotFound {
+		t.Errorf("Expected expired job to be not found, got %v", err)
+	}
+	if job, err := store.Get(ctx, "new"); err != nil || job.Status != JobRunning {
+		t.Errorf("Get() = %+v, %v", job, err)
+	}
+
+	// The sweep removes expired jobs from memory
+	store.lastSweep = time.Now().Add(-2 * time.Hour)
+	store.Save(ctx, AsyncJob{ID: "next", UpdatedAt: time.Now()})
+	if _, ok := store.jobs["old"]; ok || len(store.jobs) != 2 {
+		t.Errorf("Expected the expired job to be removed, got %d jobs", len(store.jobs))
+	}
+}
@@ -0,0 +1,91 @@
+package judge
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mkozhukh/echo"
+)
+
+// scripted answers every call with the next canned response
+type scripted struct {
+	echo.Client
+	answers []string
+	prompts []string
+	systems []string
+}
+
+func (s *scripted) Complete(ctx context.Context, messages []echo.Message, opts ...echo.CallOption) (*echo.Response, error) {
+	s.prompts = append(s.prompts, messages[len(messages)-1].Content)
+	var cfg echo.CallConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	s.systems = append(s.systems, cfg.SystemMsg)
+	text := s.answers[0]
+	s.answers = s.answers[1:]
+	return &echo.Response{Text: text}, nil
+}
+
+func TestCompare(t *testing.T) {
+	ctx := context.Background()
+	client := &scripted{answers: []string{
+		`{"winner": "a", "reason": "A is correct"}`,
+		`{"winner": "b", "reason": "B is correct"}`,
+	}}
+	verdict, err := Compare(ctx, client, "2+2?", "4", "5")
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if verdict.Winner != WinnerA || verdict.Reason != "A is correct" {
+		t.Errorf("Compare() = %+v", verdict)
+	}
+	if !strings.Contains(client.prompts[1], "Answer A:\n5") {
+		t.Errorf("Expected the answers swapped in the second call: %q", client.prompts[1])
+	}
+
+	// Position-dependent verdicts are a tie
+	client.answers = []string{`{"winner": "a", "reason": "first"}`, `{"winner": "a", "reason": "first"}`}
+	verdict, err = Compare(ctx, client, "2+2?", "4", "four")
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if verdict.Winner != Tie {
+		t.Errorf("Expected a tie for inconsistent verdicts, got %+v", verdict)
+	}
+}
+
+func TestScoreAndGrade(t *testing.T) {
+	ctx := context.Background()
+	client := &scripted{answers: []string{
+		`{"score": 7, "reasons": ["correct", "concise"]}`,
+		`{"safe": false, "severity": "high", "categories": ["violence"], "reason": "threat"}`,
+	}}
+
+	score, err := Score(ctx, client, "2+2?", "4", "Correct and concise")
+	if err != nil {
+		t.Fatalf("Score() error = %v", err)
+	}
+	if score.Score != 5 || len(score.Reasons) != 2 {
+		t.Errorf("Score() = %+v", score)
+	}
+
+	safety, err := Grade(ctx, client, "I will hurt you")
+	if err != nil {
+		t.Fatalf("Grade() error = %v", err)
+	}
+	if safety.Safe || safety.Severity != SeverityHigh || safety.Categories[0] != "violence" {
+		t.Errorf("Grade() = %+v", safety)
+	}
+}
+
+func TestSystemMessage(t *testing.T) {
+	client := &scripted{answers: []string{`{"score": 4, "reasons": ["correct"]}`}}
+	if _, err := Score(context.Background(), client, "2+2?", "4", "Correct", echo.WithSystemMessage("You are helpful")); err != nil {
+		t.Fatalf("Score() error = %v", err)
+	}
+	if client.systems[0] != "You are helpful\n\n"+scoreInstruction || !strings.Contains(client.prompts[0], "Answer:\n4") {
+		t.Errorf("Unexpected prompt: %q, %q", client.systems[0], client.prompts[0])
+	}
+}
@@ -0,0 +1,133 @@
+package echo
+
+import (
+	"strings"
+	"unicode"
+)
+
+// languageNames maps the codes returned by DetectLanguage to English names
+var languageNames = map[string]string{
+	"en": "English", "es": "Spanish", "fr": "French", "de": "German",
+	"it": "Italian", "pt": "Portuguese", "nl": "Dutch", "pl": "Polish",
+	"ru": "Russian", "uk": "Ukrainian", "el": "Greek", "ar": "Arabic",
+	"he": "Hebrew", "hi": "Hindi", "th": "Thai", "zh": "Chinese",
+	"ja": "Japanese", "ko": "Korean",
+}
+
+// stopWords holds frequent words used to tell Latin-script languages apart
+var stopWords = map[string][]string{
+	"en": {"the", "and", "is", "are", "you", "to", "of", "what", "with", "this", "that", "have", "for", "not", "my", "it"},
+	"es": {"el", "la", "los", "las", "que", "y", "es", "en", "por", "para", "con", "una", "no", "mi", "cómo", "qué"},
+	"fr": {"le", "la", "les", "et", "est", "je", "vous", "une", "des", "pas", "pour", "dans", "que", "mon", "avec", "ce"},
+	"de": {"der", "die", "das", "und", "ist", "ich", "nicht", "sie", "ein", "eine", "mit", "zu", "mein", "wie", "auf", "es"},
+	"it": {"il", "che", "di", "e", "è", "non", "per", "una", "sono", "con", "mi", "come", "gli", "della", "questo", "ho"},
+	"pt": {"o", "que", "e", "é", "não", "de", "uma", "para", "com", "os", "meu", "como", "você", "em", "do", "está"},
+	"nl": {"de", "het", "een", "en", "is", "ik", "niet", "van", "je", "dat", "met", "op", "mijn", "zijn", "wat", "hoe"},
+	"pl": {"i", "w", "nie", "się", "jest", "to", "na", "że", "jak", "mój", "czy", "do", "co", "ale", "mam", "z"},
+}
+
+// DetectLanguage returns the ISO 639-1 code of the language of the text,
+// or an empty string when the language can't be determined.
+// It is a cheap heuristic based on scripts and common words, not a model call.
+func DetectLanguage(text string) string {
+	counts := map[string]int{}
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			counts["ja"]++
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Han, r):
+			counts["zh"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["cyrillic"]++
+			if strings.ContainsRune("іїєґІЇЄҐ", r) {
+				counts["uk"]++
+			}
+		case unicode.Is(unicode.Greek, r):
+			counts["el"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Hebrew, r):
+			counts["he"]++
+		case unicode.Is(unicode.Devanagari, r):
+			counts["hi"]++
+		case unicode.Is(unicode.Thai, r):
+			counts["th"]++
+		case unicode.Is(unicode.Latin, r):
+			counts["latin"]++
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+
+	// Japanese text mixes kana with Han characters
+	if counts["ja"] > 0 {
+		return "ja"
+	}
+
+	script, best := "", 0
+	for name, n := range counts {
+		if name == "uk" {
+			continue
+		}
+		if n > best || (n == best && name < script) {
+			script, best = name, n
+		}
+	}
+
+	switch script {
+	case "cyrillic":
+		if counts["uk"] > 0 {
+			return "uk"
+		}
+		return "ru"
+	case "latin":
+		return detectLatinLanguage(text)
+	}
+	return script
+}
+
+// detectLatinLanguage picks the Latin-script language with the most stop word hits
+func detectLatinLanguage(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+
+	lang, best := "", 0
+	for code, list := range stopWords {
+		hits := 0
+		for _, w := range words {
+			for _, sw := range list {
+				if w == sw {
+					hits++
+					break
+				}
+			}
+		}
+		if hits > best || (hits == best && hits > 0 && code < lang) {
+			lang, best = code, hits
+		}
+	}
+	return lang
+}
+
+// languageInstruction builds the system instruction used by WithAutoLanguage
+func languageInstruction(messages []Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != User {
+			continue
+		}
+		if name, ok := languageNames[DetectLanguage(messages[i].Content)]; ok {
+			return "Respond in " + name + ", the language the user writes in."
+		}
+		break
+	}
+	return "Respond in the same language as the user's last message."
+}
@@ -0,0 +1,82 @@
+package echo
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"What is the status of my order?", "en"},
+		{"¿Dónde está mi pedido? No lo he recibido", "es"},
+		{"Je ne trouve pas ma commande, pouvez-vous m'aider?", "fr"},
+		{"Ich habe meine Bestellung nicht bekommen, wie ist der Status?", "de"},
+		{"Где мой заказ?", "ru"},
+		{"Де моє замовлення? Я його ще не отримав", "uk"},
+		{"注文はどこですか", "ja"},
+		{"我的订单在哪里", "zh"},
+		{"내 주문은 어디에 있나요", "ko"},
+		{"12345 !!!", ""},
+	}
+
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.text); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestAutoLanguage(t *testing.T) {
+	client, err := NewCommonClient(nil, WithModel("mock/test"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	messages := []Message{
+		{Role: System, Content: "You are a support bot"},
+		{Role: User, Content: "Где мой заказ?"},
+	}
+	resp, err := client.Complete(context.Background(), messages, WithAutoLanguage())
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if !strings.Contains(resp.Text, "You are a support bot\n\nRespond in Russian") {
+		t.Errorf("Expected language instruction in system prompt, got %q", resp.Text)
+	}
+	if messages[0].Content != "You are a support bot" {
+		t.Errorf("Caller messages were modified: %q", messages[0].Content)
+	}
+}
+
+func TestTimeAndLocale(t *testing.T) {
+	defer func(now func() time.Time) { timeNow = now }(timeNow)
+	timeNow = func() time.Time { return time.Date(2026, 3, 5, 14, 30, 0, 0, time.FixedZone("CET", 3600)) }
+
+	client, err := NewCommonClient(nil, WithModel("mock/test"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	messages := []Message{
+		{Role: System, Content: "You are a travel agent"},
+		{Role: User, Content: "Which day is it?"},
+	}
+	resp, err := client.Complete(context.Background(), messages, WithCurrentTime(), WithLocale("de-AT"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	for _, want := range []string{"2026-03-05T14:30:00+01:00", "Thursday, March 5, 2026, 14:30 CET", "User locale: de-AT, German."} {
+		if !strings.Contains(resp.Text, want) {
+			t.Errorf("Expected %q in system prompt, got %q", want, resp.Text)
+		}
+	}
+
+	if _, err := client.Complete(context.Background(), messages, WithLocale("de AT")); err == nil {
+		t.Error("Expected an error for an invalid locale tag")
+	}
+}
@@ -2,10 +2,22 @@ package echo
 
 import (
 	"context"
+	"encoding/json"
+	"log/slog"
 	"net/http"
+	"slices"
 )
 
-// Client is the main interface for LLM operations
+// Version is the current version of the echo library
+const Version = "0.1.0"
+
+// UserAgent is sent with every request made by the library.
+// Applications may replace it globally, or extend it per client with WithUserAgent.
+var UserAgent = "echo/" + Version
+
+// Client is the main interface for LLM operations.
+// It is safe for concurrent use by multiple goroutines, including SetProvider
+// calls made while requests are in flight (e.g. to rotate API keys).
 type Client interface {
 	// SetProvider sets a provider for the client
 	SetProvider(name string, provider Provider)
@@ -15,8 +27,24 @@ type Client interface {
 	StreamComplete(ctx context.Context, messages []Message, opts ...CallOption) (*StreamResponse, error)
 	// GetEmbeddings calculates embeddings for the given text
 	GetEmbeddings(ctx context.Context, text string, opts ...CallOption) (*EmbeddingResponse, error)
+	// GetMessageEmbeddings calculates embeddings for a message chain rendered to canonical text
+	GetMessageEmbeddings(ctx context.Context, messages []Message, opts ...CallOption) (*EmbeddingResponse, error)
+	// GetMultimodalEmbeddings calculates a single embedding for mixed text and image content
+	GetMultimodalEmbeddings(ctx context.Context, parts []Part, opts ...CallOption) (*EmbeddingResponse, error)
 	// ReRank reranks documents based on relevance to query
 	ReRank(ctx context.Context, query string, documents []string, opts ...CallOption) (*RerankResponse, error)
+	// Classify assigns one of the provided labels to the text
+	Classify(ctx context.Context, text string, labels []string, opts ...CallOption) (*ClassifyResult, error)
+	// Summarize condenses the text, splitting inputs that are too long for a single call
+	Summarize(ctx context.Context, text string, opts ...CallOption) (*Response, error)
+	// Translate translates the text into the target language, preserving formatting
+	Translate(ctx context.Context, text string, targetLang string, opts ...CallOption) (*Response, error)
+	// Validate checks that a completion request can be built, without sending it
+	Validate(messages []Message, opts ...CallOption) error
+	// RecordFeedback saves a rating of a recorded response, identified by its call ID
+	RecordFeedback(ctx context.Context, callID string, rating int, comment string, opts ...CallOption) error
+	// QuotaStatus returns the rate limit state last reported by the provider
+	QuotaStatus(provider string) (QuotaStatus, bool)
 }
 
 // ProxyClient extends Client with HTTP proxy capabilities for building LLM proxies
@@ -29,6 +57,13 @@ type ProxyClient interface {
 	ExecComplete(ctx context.Context, req *CompletionRequest, opts ...CallOption) (*CompletionResponse, error)
 	// WriteComplete writes a completion response to the response writer
 	WriteComplete(w http.ResponseWriter, resp *CompletionResponse, opts ...CallOption) error
+	// ExecCompleteAsync executes a completion request in the background, POSTs the result
+	// to the callback URL and/or keeps it in the job store, and returns the job ID immediately
+	ExecCompleteAsync(ctx context.Context, req *CompletionRequest, callbackURL string, opts ...CallOption) (string, error)
+	// ExecCompleteStream executes a completion request and returns the response as a stream
+	ExecCompleteStream(ctx context.Context, req *CompletionRequest, opts ...CallOption) (*StreamResponse, error)
+	// WriteCompleteStream writes a streamed completion to the response writer as server-sent events
+	WriteCompleteStream(w http.ResponseWriter, model string, stream *StreamResponse, opts ...CallOption) error
 	// ParseEmbedding parses an embedding request from HTTP request
 	ParseEmbedding(req *http.Request, opts ...CallOption) (*EmbeddingRequest, error)
 	// ExecEmbedding executes an embedding request and returns a UnifiedEmbeddingResponse
@@ -45,16 +80,41 @@ type ProxyClient interface {
 
 type Metadata = map[string]any
 
+// Finish reasons, reported by all providers in the "finish_reason" metadata key
+const (
+	FinishStop          = "stop"           // the model completed the answer or reached a stop sequence
+	FinishLength        = "length"         // the answer was cut at the max tokens limit
+	FinishContentFilter = "content_filter" // the answer was blocked by the provider's safety filters
+	FinishToolCalls     = "tool_calls"     // the model asked to call a tool
+)
+
 // Response represents the LLM response
 type Response struct {
-	Text     string   `json:"text"`
-	Metadata Metadata `json:"metadata,omitempty"`
+	Text       string          `json:"text"`
+	Thinking   string          `json:"thinking,omitempty"`   // reasoning of the model when WithThinking is used
+	Model      string          `json:"model,omitempty"`      // model that answered as reported by the provider, e.g. a dated snapshot or an OpenRouter fallback
+	Provider   string          `json:"provider,omitempty"`   // provider the call was sent to
+	Audio      []byte          `json:"audio,omitempty"`      // spoken response when WithAudioOutput is used
+	Confidence float64         `json:"confidence,omitempty"` // 0..1, estimated when WithConfidenceScore is used
+	ToolCalls  []ToolCall      `json:"tool_calls,omitempty"` // functions the model asked to call, see WithTools
+	JSON       json.RawMessage `json:"json,omitempty"`       // the answer when structured output is requested and it is valid JSON
+	Choices    []Choice        `json:"choices,omitempty"`    // all answers when WithN asks for several, Text is the first
+	Metadata   Metadata        `json:"metadata,omitempty"`
+}
+
+// Choice is one of the answers of a call made with WithN
+type Choice struct {
+	Text         string     `json:"text"`
+	ToolCalls    []ToolCall `json:"tool_calls,omitempty"`
+	FinishReason string     `json:"finish_reason,omitempty"`
 }
 
 type StreamChunk struct {
-	Data  string
-	Meta  *Metadata // Set on first chunk if available
-	Error error     // Set on error or completion
+	Data     string
+	Thinking string    // Piece of the reasoning when WithThinking is used, sent in chunks without Data
+	Audio    []byte    // Piece of the spoken response when WithAudioOutput is used
+	Meta     *Metadata // Set on first chunk if available
+	Error    error     // Set on error or completion
 }
 
 type StreamResponse struct {
@@ -163,11 +223,141 @@ type CallConfig struct {
 	EndPoint string
 
 	Temperature      *float32
+	TopP             *float32 // nucleus sampling, 0..1
+	TopK             *int     // sample from the k most likely tokens; Anthropic, Google and OpenRouter
+	FrequencyPenalty *float32 // -2..2, penalizes tokens by how often they appeared; OpenAI, Google and xAI
+	PresencePenalty  *float32 // -2..2, penalizes tokens that appeared at all; OpenAI, Google and xAI
+	Seed             *int     // best-effort deterministic sampling; OpenAI, Google and xAI
+	N                int      // number of answers generated by one call, returned in Response.Choices
 	MaxTokens        *int
+	MaxTokensAuto    bool     // MaxTokens is the output budget left in the context window, see WithMaxTokensAuto
+	StopSequences    []string // output ends before the first of these; enforced client-side for all providers
 	SystemMsg        string
 	StructuredOutput *StructuredOutputConfig
-	ReasoningEffort  string // "low", "medium", "high" - controls thinking/reasoning level
-	StoreData        *bool  // xAI: set to false to disable server-side storage (default: false)
+	JSONMode         bool     // any JSON object, for providers with a JSON mode; a schema takes precedence
+	JSONRepair       bool     // complete structured output that was cut short
+	Coalesce         *bool    // merge adjacent messages of the same role; on by default for Anthropic
+	Sanitation       []string // rules applied to the message chain before the call; nil disables sanitation
+	ReasoningEffort  string   // "low", "medium", "high" - controls thinking/reasoning level
+	ThinkingBudget   int      // tokens the model may spend on reasoning, returned in Response.Thinking
+	StrictOptions    bool     // fail instead of dropping options the model doesn't support
+	StoreData        *bool    // xAI: set to false to disable server-side storage (default: false)
+	AnthropicBeta    []string // Anthropic: extra beta features for the anthropic-beta header
+	UserAgent        string   // application name appended to the library User-Agent
+
+	RequestMutator func(provider string, body map[string]any) // edits request bodies before they are sent
+	RawResponse    *[]byte                                    // receives the unprocessed provider response
+
+	RetrySchedule func(attempt int) []CallOption // per-attempt options of the typed and Edit retry loops
+
+	Logger *slog.Logger // receives warnings, e.g. about models scheduled for shutdown
+
+	ScoreNormalization string // rerank score normalization: "minmax" or "softmax"
+
+	EmbeddingDimensions int                  // embeddings are truncated to this width and renormalized
+	EmbeddingProjection *EmbeddingProjection // embeddings are projected with this matrix and renormalized
+
+	TargetTokens int    // Summarize: desired length of the summary
+	Style        string // Summarize: output style, e.g. "bullets" or "paragraph"
+
+	Glossary map[string]string // Translate: fixed translations for source terms
+
+	EditFormat string // Edit: EditSearchReplace or EditUnifiedDiff
+
+	CitationCheck     string  // VerifyCitations: CitationEmbedding or CitationJudge
+	CitationThreshold float64 // VerifyCitations: similarity at which an embedding check passes
+
+	InjectionGuard      string  // GuardBlock or GuardFlag, empty disables the guard
+	InjectionThreshold  float64 // score at which a message counts as an injection attempt
+	InjectionGuardModel string  // optional model used to double-check user input
+
+	ImageFetchLimit int64 // when set, image URLs are downloaded and sent inline, up to this many bytes each
+
+	AutoLanguage bool   // instruct the model to answer in the language of the user
+	CurrentTime  bool   // add the current date, time and timezone to the system prompt
+	Locale       string // BCP 47 tag of the user locale added to the system prompt
+
+	ConfidenceScore bool // estimate Response.Confidence from logprobs or a self-assessment
+
+	AudioOutput *AudioOutputConfig // request a spoken response in addition to text
+
+	Tools      []Tool // functions the model may call, requests are returned in Response.ToolCalls
+	ToolChoice string // ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired or the name of a tool
+
+	LogitBias map[string]float64 // OpenAI: token ID to bias, -100 (ban) to 100 (force)
+
+	SafetySettings []SafetySetting // Gemini: blocking threshold per harm category
+
+	ModelVariant string   // OpenRouter: model suffix such as "online" or "nitro"
+	Transforms   []string // OpenRouter: prompt transforms such as "middle-out"; empty disables the defaults
+
+	AutoContinue int // max number of segments joined when the answer is cut at the token limit
+
+	APIKey string // overrides the provider key for a single call
+
+	UserID          string            // end user of the call, for provider abuse detection and usage reports
+	RequestMetadata map[string]string // tags of the call: OpenAI metadata, OpenRouter "referer" and "title" attribution
+
+	HTTPClient *http.Client    // client used for provider requests, created by NewClient when not set
+	Transport  TransportConfig // connection pool settings for the client created by NewClient
+
+	FaultRate  float64  // share of provider requests that fail on purpose, for resilience testing
+	FaultKinds []string // faults injected, all kinds when empty
+
+	QuotaPacing bool // delay calls while the provider reports its rate limit quota exhausted or nearly so
+
+	PIIMasking []string // names to mask in addition to emails and phones; nil disables masking
+	Scrubbing  []string // patterns to redact in addition to paths and internal hosts; nil disables scrubbing
+
+	OutputFilter   string   // FilterRedact or FilterAbort, empty disables the output filter
+	OutputPatterns []string // banned output, as regular expressions
+
+	ResponseStore ResponseStore // responses are saved here, streams even when the consumer disconnects
+	CallID        string        // identifies the call in the response store, generated when empty
+
+	CallbackSecret string   // key for the HMAC signature of async job callbacks
+	JobStore       JobStore // keeps the state of async jobs for polling
+
+	Tenant      string            // tenant the call is made for
+	TenantStore TenantConfigStore // per-tenant keys, allowed models and budgets
+
+	provider     string        // provider name resolved for the call
+	tenantConfig *TenantConfig // settings of the tenant, loaded for the call
+	quota        *quotaTracker // rate limit state of the client's providers
+}
+
+// userAgent returns the User-Agent header value for the call
+func (cfg CallConfig) userAgent() string {
+	if cfg.UserAgent == "" {
+		return UserAgent
+	}
+	return UserAgent + " " + cfg.UserAgent
+}
+
+// jsonOutput reports whether the answer is requested as JSON
+func (cfg CallConfig) jsonOutput() bool {
+	return cfg.StructuredOutput != nil || cfg.JSONMode
+}
+
+// sampling reports whether any sampling parameter other than temperature, or a seed, is set
+func (cfg CallConfig) sampling() bool {
+	return cfg.TopP != nil || cfg.TopK != nil || cfg.FrequencyPenalty != nil || cfg.PresencePenalty != nil || cfg.Seed != nil
+}
+
+// coalesce reports whether adjacent messages of the same role are merged for the call
+func (cfg CallConfig) coalesce() bool {
+	if cfg.Coalesce != nil {
+		return *cfg.Coalesce
+	}
+	return cfg.provider == "anthropic"
+}
+
+// apiKey returns the per-call key set with WithAPIKey, or the provider key
+func (cfg CallConfig) apiKey(providerKey string) string {
+	if cfg.APIKey != "" {
+		return cfg.APIKey
+	}
+	return providerKey
 }
 
 func WithTemperature(temp float32) CallOption {
@@ -176,24 +366,115 @@ func WithTemperature(temp float32) CallOption {
 	}
 }
 
+// WithTopP limits sampling to the most likely tokens whose probabilities add up to p (0..1).
+// Vendors advise changing either temperature or top p, not both.
+func WithTopP(p float32) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.TopP = &p
+	}
+}
+
+// WithTopK limits sampling to the k most likely tokens. Supported by Anthropic, Google and
+// OpenRouter; OpenAI and xAI have no top k and calls fail there.
+func WithTopK(k int) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.TopK = &k
+	}
+}
+
+// WithFrequencyPenalty lowers the likelihood of tokens by how often they already appeared
+// (-2..2, positive values reduce repetition). Supported by OpenAI, OpenRouter, Google and xAI;
+// Anthropic has no penalties and calls fail there.
+func WithFrequencyPenalty(penalty float32) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.FrequencyPenalty = &penalty
+	}
+}
+
+// WithPresencePenalty lowers the likelihood of tokens that already appeared, however often
+// (-2..2, positive values favor new topics). Supported by OpenAI, OpenRouter, Google and xAI;
+// Anthropic has no penalties and calls fail there.
+func WithPresencePenalty(penalty float32) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.PresencePenalty = &penalty
+	}
+}
+
+// WithSeed asks for deterministic sampling: calls with the same seed and parameters should
+// return the same answer. It is best effort; OpenAI and xAI report a "system_fingerprint" in
+// the metadata that changes with the backend configuration, and answers are reproducible only
+// while it stays the same. Supported by OpenAI, OpenRouter, Google and xAI; calls to Anthropic fail.
+func WithSeed(seed int) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.Seed = &seed
+	}
+}
+
+// WithN generates n answers in one call, e.g. to rank samples, instead of n separate calls;
+// prompt tokens are paid once. All answers are in Response.Choices, Response.Text is the first one.
+// Supported by OpenAI, OpenRouter, Google and xAI, not in streaming calls nor with WithAutoContinue;
+// calls to Anthropic fail.
+func WithN(n int) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.N = n
+	}
+}
+
 func WithMaxTokens(tokens int) CallOption {
 	return func(cfg *CallConfig) {
 		cfg.MaxTokens = &tokens
 	}
 }
 
+// WithMaxTokensAuto sets max tokens to the output budget left in the context window after the
+// prompt, capped by the output limit of the model and by WithMaxTokens when set. The prompt size
+// is estimated with EstimateMessagesTokens; calls whose prompt doesn't fit fail before reaching
+// the provider. Models missing from the capability table keep the provider default.
+func WithMaxTokensAuto() CallOption {
+	return func(cfg *CallConfig) {
+		cfg.MaxTokensAuto = true
+	}
+}
+
+// WithStopSequences ends the answer before the first occurrence of any of the sequences.
+// Anthropic and Google stop natively; for other providers the answer is trimmed client-side,
+// in Complete and StreamComplete alike, and finish_reason is set to FinishStop.
+func WithStopSequences(sequences ...string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.StopSequences = sequences
+	}
+}
+
 func WithSystemMessage(msg string) CallOption {
 	return func(cfg *CallConfig) {
 		cfg.SystemMsg = msg
 	}
 }
 
+// WithSystemInstructions appends instructions to the system prompt instead of replacing it,
+// so a prompt set with WithSystemMessage on the client or the call is kept. Task helpers
+// such as Extract and Translate pass their instructions this way; put it after WithSystemMessage.
+func WithSystemInstructions(text string) CallOption {
+	return func(cfg *CallConfig) {
+		if cfg.SystemMsg != "" {
+			cfg.SystemMsg += "\n\n" + text
+		} else {
+			cfg.SystemMsg = text
+		}
+	}
+}
+
 func WithModel(model string) CallOption {
 	return func(cfg *CallConfig) {
 		cfg.Model = model
 	}
 }
 
+// WithModelRef sets the model of the call, the same as WithModel(ref.String())
+func WithModelRef(ref ModelRef) CallOption {
+	return WithModel(ref.String())
+}
+
 func WithBaseURL(url string) CallOption {
 	return func(cfg *CallConfig) {
 		cfg.BaseURL = url
@@ -218,17 +499,210 @@ func WithStructuredOutput(name string, schema any) CallOption {
 	}
 }
 
+// WithTools declares functions the model may call. Instead of answering, the model may
+// return calls in Response.ToolCalls; send the results back as ToolResultMessage
+// messages after the agent message holding the calls. Tools are supported by
+// OpenAI, Anthropic, Google and xAI in Complete.
+func WithTools(tools ...Tool) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.Tools = tools
+	}
+}
+
+// WithCoalescing merges adjacent user messages, and adjacent agent messages, into one before
+// the call. Anthropic rejects chains where a role repeats, so it is on by default there;
+// pass false to send the chain as is.
+func WithCoalescing(enabled bool) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.Coalesce = &enabled
+	}
+}
+
+// WithToolChoice controls whether the model calls tools: ToolChoiceAuto (the default),
+// ToolChoiceNone, ToolChoiceRequired, or the name of a declared tool that must be called
+func WithToolChoice(choice string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.ToolChoice = choice
+	}
+}
+
+// WithSanitation cleans the message chain before the call with the given rules, all of them
+// when none are given: SanitizeEmpty drops messages without content, SanitizePrefill trims
+// trailing whitespace of a final agent message (Anthropic rejects such prefills) and
+// SanitizeUnicode repairs invalid UTF-8 and drops control and zero-width characters.
+// The number of messages changed or dropped is reported as "sanitized" metadata.
+func WithSanitation(rules ...string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.Sanitation = append([]string{}, rules...)
+	}
+}
+
+// WithResponseSchema requests JSON output matching the schema, a JSON Schema as map[string]any
+// (see SchemaOf). OpenAI and xAI use strict structured outputs, Google a response schema and
+// Anthropic its native output format, or a forced tool call on models without one.
+// The answer is available as Response.JSON.
+func WithResponseSchema(schema any) CallOption {
+	return WithStructuredOutput("response", schema)
+}
+
+// WithJSONMode asks for a JSON object without enforcing a schema: response_format json_object
+// for OpenAI and xAI, the application/json response type for Google. OpenAI requires the word
+// "JSON" somewhere in the messages. Anthropic has no JSON mode, calls fail there; use
+// WithResponseSchema instead.
+func WithJSONMode() CallOption {
+	return func(cfg *CallConfig) {
+		cfg.JSONMode = true
+	}
+}
+
+// WithLogitBias changes the likelihood of tokens, keyed by token ID of the model tokenizer
+// (e.g. "1734"); -100 bans a token and 100 makes it the only choice. Supported by OpenAI and
+// OpenAI-compatible endpoints (OpenRouter); Anthropic, Google and xAI have no logit bias and
+// calls fail there.
+func WithLogitBias(bias map[string]float64) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.LogitBias = bias
+	}
+}
+
+// WithSafetySettings sets the thresholds at which Gemini blocks harmful content, per harm category.
+// Blocked prompts and answers return a response with finish reason FinishContentFilter, the
+// "block_reason" of a refused prompt and the "safety_ratings" in the metadata, instead of an error.
+// Calls to other providers fail.
+func WithSafetySettings(settings ...SafetySetting) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.SafetySettings = settings
+	}
+}
+
+// WithModelVariant selects an OpenRouter variant of the model, the same as the ":variant"
+// suffix of the model name: "online" adds web search results, "nitro" routes to the fastest
+// providers, "floor" to the cheapest, "free" to the free tier. Calls to other providers fail.
+func WithModelVariant(variant string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.ModelVariant = variant
+	}
+}
+
+// WithTransforms sets the OpenRouter prompt transforms. "middle-out" drops messages from the
+// middle of the conversation when the prompt exceeds the context window of the model; without
+// arguments the transforms OpenRouter applies by default are disabled. Calls to other providers fail.
+func WithTransforms(transforms ...string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.Transforms = append([]string{}, transforms...)
+	}
+}
+
+// WithUserID identifies the end user of the call to the provider, so abuse detection and
+// usage dashboards can tell users apart. It is sent as OpenAI, OpenRouter and xAI "user" and
+// Anthropic "metadata.user_id"; Gemini has no such field. Use an opaque ID or a hash, never
+// names or emails.
+func WithUserID(id string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.UserID = id
+	}
+}
+
+// WithRequestMetadata tags the call for the reports of the provider. OpenAI stores the pairs as
+// the "metadata" of the completion (up to 16, keys up to 64 and values up to 512 characters).
+// OpenRouter attributes the traffic to an app by the "referer" (app URL) and "title" (app name)
+// keys. Other providers ignore the tags; use WithUserID for the end user.
+func WithRequestMetadata(metadata map[string]string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.RequestMetadata = metadata
+	}
+}
+
+// WithRequestMutator sets provider-specific fields the library doesn't model, e.g. beta
+// parameters or vendor extensions. The function gets the provider name and the request body
+// as built for the call, decoded into a map (numbers are json.Number), and edits it in place;
+// the map is sent as the request. It applies to every request of the call, including embeddings.
+func WithRequestMutator(fn func(provider string, body map[string]any)) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.RequestMutator = fn
+	}
+}
+
+// WithRawResponse stores the unprocessed body of the provider response in raw: the JSON of
+// a completion, or the SSE transcript of a stream once it is consumed. Error responses are
+// captured too. When a call makes several requests (retries, continuations), raw holds the last one.
+func WithRawResponse(raw *[]byte) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.RawResponse = raw
+	}
+}
+
+// WithQuotaPacing adapts the pace of calls to the rate limit headers of the provider: calls wait
+// for the reset while the request or token quota is exhausted or after a 429 answer, and are
+// spread over the time left until the reset when less than a tenth of the request quota remains.
+func WithQuotaPacing() CallOption {
+	return func(cfg *CallConfig) {
+		cfg.QuotaPacing = true
+	}
+}
+
+// WithJSONRepair repairs structured output that is not valid JSON, typically because the
+// answer was cut at the token limit: unterminated strings and open brackets are closed and
+// an incomplete trailing fragment is dropped. Repaired responses have "json_repaired" metadata.
+func WithJSONRepair() CallOption {
+	return func(cfg *CallConfig) {
+		cfg.JSONRepair = true
+	}
+}
+
+// WithRetrySchedule adjusts the options of each attempt made by the retry loops of Extract,
+// Generate, TypedClient and Edit, e.g. raising the temperature when the model keeps
+// returning invalid output. The schedule is called with the attempt number, starting at 1, and
+// its options are applied after the call options; returning nil stops retrying (the first
+// attempt is always made). See TemperatureSchedule.
+func WithRetrySchedule(fn func(attempt int) []CallOption) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.RetrySchedule = fn
+	}
+}
+
+// WithLogger sets the logger for library warnings, such as a call to a model that its vendor
+// is going to shut down; the warning names the shutdown date and the suggested replacement
+func WithLogger(logger *slog.Logger) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.Logger = logger
+	}
+}
+
 // WithReasoningEffort controls the thinking/reasoning level for models that support it.
-// Valid values: "low", "medium", "high"
-// - OpenAI: uses reasoning_effort parameter (for o1 models)
+// Valid values: "low", "medium", "high"; gpt-5 also accepts "minimal", gpt-5.2 "none" and "xhigh"
+// - OpenAI: uses reasoning_effort parameter (o-series and gpt-5 models)
 // - Anthropic: uses output_config.effort (claude-opus-4-5)
-// - Google: uses thinkingConfig.thinkingLevel (gemini-3 models)
+// - Google: uses thinkingConfig.thinkingLevel (gemini-3 models, "low" or "high")
+// - xAI: uses reasoning_effort parameter (grok-3-mini, "low" or "high")
+//
+// Known models without an effort setting (e.g. gpt-4o) get the call without it,
+// or fail with WithStrictOptions; levels a model doesn't accept fail the call.
 func WithReasoningEffort(effort string) CallOption {
 	return func(cfg *CallConfig) {
 		cfg.ReasoningEffort = effort
 	}
 }
 
+// WithStrictOptions makes calls fail when an option is not supported by the model, instead of
+// sending the call without it. It applies to WithReasoningEffort.
+func WithStrictOptions() CallOption {
+	return func(cfg *CallConfig) {
+		cfg.StrictOptions = true
+	}
+}
+
+// WithThinking lets the model reason before answering, spending up to budgetTokens on it.
+// The reasoning is returned in Response.Thinking and streamed in StreamChunk.Thinking,
+// separately from the answer.
+// - Anthropic: extended thinking; the budget is at least 1024 tokens and below max tokens
+// - Google: thinkingConfig.thinkingBudget with thought summaries (gemini-2.5 models)
+func WithThinking(budgetTokens int) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.ThinkingBudget = budgetTokens
+	}
+}
+
 // WithStoreData controls whether the provider stores conversation data on the server.
 // Currently only supported by xAI (Grok) - set to false to disable server-side storage.
 // Default is false for xAI to prioritize privacy.
@@ -237,3 +711,302 @@ func WithStoreData(store bool) CallOption {
 		cfg.StoreData = &store
 	}
 }
+
+// WithAutoContinue continues answers cut at the max tokens limit (finish_reason "length")
+// with follow-up requests, joining up to maxSegments segments into a single answer.
+// Works with Complete and StreamComplete, token counts in the metadata are summed.
+func WithAutoContinue(maxSegments int) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.AutoContinue = maxSegments
+	}
+}
+
+// WithAnthropicBeta enables Anthropic beta features (e.g. AnthropicBetaContext1M) by adding them
+// to the anthropic-beta header. Can be set per client and per call, the features are combined.
+func WithAnthropicBeta(features ...string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.AnthropicBeta = append(slices.Clip(cfg.AnthropicBeta), features...)
+	}
+}
+
+// WithUserAgent appends an application identifier (e.g. "myapp/1.2") to the
+// User-Agent header sent to providers. Can be set per client or per call.
+func WithUserAgent(app string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.UserAgent = app
+	}
+}
+
+// Rerank score normalization methods
+const (
+	NormalizeMinMax  = "minmax"
+	NormalizeSoftmax = "softmax"
+)
+
+// WithNormalizedScores normalizes rerank scores so thresholds are portable between backends.
+// Valid values: NormalizeMinMax (scores scaled to 0..1, the default when no method is given)
+// and NormalizeSoftmax (scores sum to 1).
+func WithNormalizedScores(method ...string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.ScoreNormalization = NormalizeMinMax
+		if len(method) > 0 {
+			cfg.ScoreNormalization = method[0]
+		}
+	}
+}
+
+// WithEmbeddingDimensions reduces embeddings to n dimensions by keeping the leading values
+// and renormalizing to unit length, the Matryoshka convention. Embeddings of different models
+// can then be stored in a fixed-width vector column; for models not trained for truncation
+// use WithEmbeddingProjection.
+func WithEmbeddingDimensions(n int) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.EmbeddingDimensions = n
+	}
+}
+
+// WithEmbeddingProjection reduces embeddings with a projection matrix, e.g. PCA components
+// fitted on embeddings of the model; the result is renormalized to unit length
+func WithEmbeddingProjection(projection *EmbeddingProjection) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.EmbeddingProjection = projection
+	}
+}
+
+// WithTargetTokens sets the desired length of a summary in tokens
+func WithTargetTokens(tokens int) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.TargetTokens = tokens
+	}
+}
+
+// WithStyle sets the output style of a summary, e.g. "bullets", "paragraph" or "tldr"
+func WithStyle(style string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.Style = style
+	}
+}
+
+// WithGlossary sets fixed translations for terms used by Translate.
+// Keys are source terms, values are their required translations.
+func WithGlossary(glossary map[string]string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.Glossary = glossary
+	}
+}
+
+// WithEditFormat selects the patch format requested by Edit: EditSearchReplace (default)
+// or EditUnifiedDiff
+func WithEditFormat(format string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.EditFormat = format
+	}
+}
+
+// WithCitationCheck sets how VerifyCitations checks a sentence against its sources:
+// CitationEmbedding (default) or CitationJudge
+func WithCitationCheck(method string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.CitationCheck = method
+	}
+}
+
+// WithCitationThreshold sets the similarity at which a sentence counts as supported
+// by a cited source in embedding checks, 0.7 by default
+func WithCitationThreshold(threshold float64) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.CitationThreshold = threshold
+	}
+}
+
+// WithInjectionGuard scores user messages for prompt-injection patterns before the call.
+// In GuardBlock mode a suspicious message fails the call with *InjectionError,
+// in GuardFlag mode the call proceeds and metadata gets "injection_score" and "injection_flagged".
+// A threshold of 0 uses the default of 0.5; an empty mode disables the guard.
+func WithInjectionGuard(mode string, threshold float64) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.InjectionGuard = mode
+		cfg.InjectionThreshold = threshold
+	}
+}
+
+// WithInjectionGuardModel adds a model classification (ideally a cheap model)
+// on top of the heuristic injection checks
+func WithInjectionGuardModel(model string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.InjectionGuardModel = model
+	}
+}
+
+// WithPIIMasking replaces emails, phone numbers and the given names in outgoing messages
+// with placeholders, and restores the original values in the response.
+// The number of masked values is reported in metadata as "pii_masked".
+func WithPIIMasking(names ...string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.PIIMasking = append([]string{}, names...)
+	}
+}
+
+// WithScrubbing removes absolute file paths, internal hostnames, private IP addresses and
+// internal URLs from outgoing messages, together with text matching the given regular
+// expressions. Replacements are counted in the "scrubbed" metadata key.
+func WithScrubbing(patterns ...string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.Scrubbing = append([]string{}, patterns...)
+	}
+}
+
+// WithOutputFilter scans the output, complete and streamed, for the patterns (regular expressions;
+// plain strings without special characters match literally), e.g. internal codenames or secrets.
+// In FilterRedact mode matches are replaced with [FILTERED], in FilterAbort mode the call fails
+// with *OutputFilterError; a stream ends with the error before the matching text is sent.
+func WithOutputFilter(mode string, patterns ...string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.OutputFilter = mode
+		cfg.OutputPatterns = append([]string{}, patterns...)
+	}
+}
+
+// WithAutoLanguage instructs the model to respond in the language of the last user message,
+// as detected by DetectLanguage
+func WithAutoLanguage() CallOption {
+	return func(cfg *CallConfig) {
+		cfg.AutoLanguage = true
+	}
+}
+
+// WithCurrentTime adds the current date, time and timezone of the local clock to the system prompt,
+// so the model doesn't assume the date of its training data
+func WithCurrentTime() CallOption {
+	return func(cfg *CallConfig) {
+		cfg.CurrentTime = true
+	}
+}
+
+// WithLocale adds the locale of the user, a BCP 47 tag such as "de-AT", to the system prompt,
+// so dates, numbers and currencies are formatted accordingly
+func WithLocale(tag string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.Locale = tag
+	}
+}
+
+// WithConfidenceScore sets Response.Confidence, the estimated probability that the answer
+// is correct, so low-confidence answers can be routed to a human. OpenAI chat models report
+// the mean token probability (logprobs); other models are asked for a calibrated self-assessment,
+// which is removed from the answer text. The method is stored in "confidence_method" metadata.
+// Streams only have the self-assessment line removed, the score is reported by Complete.
+func WithConfidenceScore() CallOption {
+	return func(cfg *CallConfig) {
+		cfg.ConfidenceScore = true
+	}
+}
+
+// WithImageFetch downloads images referenced by URL and sends them as inline data,
+// so URLs work with providers that accept only inline images (Gemini).
+// Images larger than maxBytes are rejected; 0 uses a 20MB limit.
+func WithImageFetch(maxBytes int64) CallOption {
+	return func(cfg *CallConfig) {
+		if maxBytes <= 0 {
+			maxBytes = defaultImageFetchLimit
+		}
+		cfg.ImageFetchLimit = maxBytes
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for provider requests
+func WithHTTPClient(client *http.Client) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.HTTPClient = client
+	}
+}
+
+// WithFaultInjection makes the given share of provider requests (0..1) fail on purpose, to test
+// the resilience paths of an application in staging: FaultLatency delays the request, FaultRateLimit
+// answers 429, FaultTruncate cuts the response body and FaultMalformed breaks its JSON. Without
+// kinds, all of them are injected. Never use it in production.
+func WithFaultInjection(rate float64, kinds ...string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.FaultRate = rate
+		cfg.FaultKinds = append([]string{}, kinds...)
+	}
+}
+
+// WithTransport tunes the connection pool of the HTTP client created by NewClient.
+// It has effect only as a client option and is ignored when WithHTTPClient is used.
+func WithTransport(tc TransportConfig) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.Transport = tc
+	}
+}
+
+// WithAPIKey overrides the provider API key for a call, e.g. to bill a tenant's own key.
+// The key applies to whichever provider the call resolves to.
+func WithAPIKey(key string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.APIKey = key
+	}
+}
+
+// WithStreamPersistence saves every response, with its usage metadata, to the store; streamed
+// responses once the stream ends. When the consumer disconnects mid-stream the answer is still read
+// to the end and saved with Disconnected set, so billing and audit don't lose abandoned requests.
+// Each record has a call ID, returned in the "call_id" metadata key for RecordFeedback.
+func WithStreamPersistence(store ResponseStore) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.ResponseStore = store
+	}
+}
+
+// WithCallID sets the ID of the call in the response store, e.g. the ID of a chat message,
+// instead of a generated one
+func WithCallID(id string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.CallID = id
+	}
+}
+
+// WithCallbackSecret sets the key used to sign the callbacks of ExecCompleteAsync,
+// receivers check the X-Echo-Signature header with VerifyCallback
+func WithCallbackSecret(secret string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.CallbackSecret = secret
+	}
+}
+
+// WithJobStore sets the store of ExecCompleteAsync jobs, serve it with JobHandler
+// to let clients poll the status, partial output and result of a job
+func WithJobStore(store JobStore) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.JobStore = store
+	}
+}
+
+// WithTenant marks the call as made on behalf of the tenant; keys, allowed models
+// and budgets come from the store set with WithTenantStore
+func WithTenant(id string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.Tenant = id
+	}
+}
+
+// WithTenantStore sets the store consulted for calls made with WithTenant
+func WithTenantStore(store TenantConfigStore) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.TenantStore = store
+	}
+}
+
+// AudioOutputConfig selects the voice and encoding of spoken responses
+type AudioOutputConfig struct {
+	Voice  string // e.g. "alloy"
+	Format string // "wav", "mp3", "opus", "flac" or "pcm16"; streaming requires "pcm16"
+}
+
+// WithAudioOutput asks audio-capable models (OpenAI gpt-4o-audio) to answer with speech.
+// The audio is returned in Response.Audio or StreamChunk.Audio, the transcript as text.
+func WithAudioOutput(voice, format string) CallOption {
+	return func(cfg *CallConfig) {
+		cfg.AudioOutput = &AudioOutputConfig{Voice: voice, Format: format}
+	}
+}
@@ -0,0 +1,196 @@
+package echo
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"unsafe"
+)
+
+// matrixMagic starts every embedding matrix file
+const matrixMagic = "ECHOEMB1"
+
+// matrixHeaderSize is the size of the header: magic, row count and dimension.
+// It keeps the float data 4-byte aligned in memory-mapped files.
+const matrixHeaderSize = 16
+
+// EmbeddingMatrix is a set of embeddings of the same dimension stored as one
+// row-major float32 slice, with an ID for every row
+type EmbeddingMatrix struct {
+	IDs  []string
+	Dim  int
+	Data []float32
+
+	unmap func() error
+}
+
+// Match is a row of the matrix found by Search
+type Match struct {
+	ID    string
+	Index int
+	Score float32 // cosine similarity
+}
+
+// NewEmbeddingMatrix packs the vectors into a matrix
+func NewEmbeddingMatrix(ids []string, vectors [][]float32) (*EmbeddingMatrix, error) {
+	if len(ids) != len(vectors) {
+		return nil, fmt.Errorf("got %d ids for %d vectors", len(ids), len(vectors))
+	}
+
+	m := &EmbeddingMatrix{IDs: ids}
+	if len(vectors) == 0 {
+		return m, nil
+	}
+
+	m.Dim = len(vectors[0])
+	if m.Dim == 0 {
+		return nil, fmt.Errorf("vectors have no dimensions")
+	}
+	m.Data = make([]float32, 0, len(vectors)*m.Dim)
+	for i, v := range vectors {
+		if len(v) != m.Dim {
+			return nil, fmt.Errorf("vector %d has dimension %d, expected %d", i, len(v), m.Dim)
+		}
+		m.Data = append(m.Data, v...)
+	}
+	return m, nil
+}
+
+// Len returns the number of rows
+func (m *EmbeddingMatrix) Len() int {
+	return len(m.IDs)
+}
+
+// Row returns the vector of the i-th row; the slice shares memory with the matrix
+func (m *EmbeddingMatrix) Row(i int) []float32 {
+	return m.Data[i*m.Dim : (i+1)*m.Dim : (i+1)*m.Dim]
+}
+
+// Search returns the k rows most similar to the query by cosine similarity
+func (m *EmbeddingMatrix) Search(query []float32, k int) ([]Match, error) {
+	if len(query) != m.Dim {
+		return nil, fmt.Errorf("query has dimension %d, expected %d", len(query), m.Dim)
+	}
+
+	qnorm := norm(query)
+	matches := make([]Match, 0, m.Len())
+	for i := 0; i < m.Len(); i++ {
+		row := m.Row(i)
+		var dot float32
+		for j, v := range row {
+			dot += v * query[j]
+		}
+		score := float32(0)
+		if d := qnorm * norm(row); d > 0 {
+			score = dot / d
+		}
+		matches = append(matches, Match{ID: m.IDs[i], Index: i, Score: score})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if k > 0 && k < len(matches) {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+func norm(v []float32) float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return float32(math.Sqrt(sum))
+}
+
+// Save writes the matrix to path as little-endian float32 values after a short header,
+// and the row IDs to path + ".idx" as a JSON array
+func (m *EmbeddingMatrix) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	header := make([]byte, matrixHeaderSize)
+	copy(header, matrixMagic)
+	binary.LittleEndian.PutUint32(header[8:], uint32(m.Len()))
+	binary.LittleEndian.PutUint32(header[12:], uint32(m.Dim))
+	if _, err := f.Write(header); err != nil {
+		return err
+	}
+
+	if err := binary.Write(f, binary.LittleEndian, m.Data); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	ids, err := json.Marshal(m.IDs)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path+".idx", ids, 0o644)
+}
+
+// LoadEmbeddingMatrix opens a matrix written by Save. Where supported the file is
+// memory-mapped instead of being read, so call Close when the matrix is no longer used.
+func LoadEmbeddingMatrix(path string) (*EmbeddingMatrix, error) {
+	idx, err := os.ReadFile(path + ".idx")
+	if err != nil {
+		return nil, err
+	}
+	m := &EmbeddingMatrix{}
+	if err := json.Unmarshal(idx, &m.IDs); err != nil {
+		return nil, fmt.Errorf("invalid index file: %w", err)
+	}
+
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < matrixHeaderSize || string(data[:8]) != matrixMagic {
+		unmap()
+		return nil, fmt.Errorf("%s is not an embedding matrix", path)
+	}
+
+	rows := int(binary.LittleEndian.Uint32(data[8:]))
+	m.Dim = int(binary.LittleEndian.Uint32(data[12:]))
+	body := data[matrixHeaderSize:]
+	if rows != len(m.IDs) || (rows > 0 && m.Dim <= 0) || len(body) != rows*m.Dim*4 {
+		unmap()
+		return nil, fmt.Errorf("matrix %s doesn't match its index", path)
+	}
+
+	if len(body) > 0 {
+		if nativeLittleEndian() {
+			m.Data = unsafe.Slice((*float32)(unsafe.Pointer(&body[0])), rows*m.Dim)
+		} else {
+			m.Data = make([]float32, rows*m.Dim)
+			for i := range m.Data {
+				m.Data[i] = math.Float32frombits(binary.LittleEndian.Uint32(body[i*4:]))
+			}
+		}
+	}
+	m.unmap = unmap
+	return m, nil
+}
+
+// Close releases the memory-mapped file; the matrix must not be used afterwards
+func (m *EmbeddingMatrix) Close() error {
+	if m.unmap == nil {
+		return nil
+	}
+	err := m.unmap()
+	m.unmap = nil
+	m.Data = nil
+	return err
+}
+
+func nativeLittleEndian() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}
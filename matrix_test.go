@@ -0,0 +1,56 @@
+package echo
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestEmbeddingMatrix(t *testing.T) {
+	m, err := NewEmbeddingMatrix([]string{"a", "b", "c"}, [][]float32{
+		{1, 0, 0},
+		{0, 1, 0},
+		{0.7, 0.7, 0},
+	})
+	if err != nil {
+		t.Fatalf("NewEmbeddingMatrix() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "docs.emb")
+	if err := m.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadEmbeddingMatrix(path)
+	if err != nil {
+		t.Fatalf("LoadEmbeddingMatrix() error = %v", err)
+	}
+	defer loaded.Close()
+
+	if loaded.Len() != 3 || loaded.Dim != 3 || loaded.Row(2)[1] != 0.7 {
+		t.Errorf("Loaded matrix differs: %+v", loaded)
+	}
+
+	matches, err := loaded.Search([]float32{1, 0.1, 0}, 2)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(matches) != 2 || matches[0].ID != "a" || matches[1].ID != "c" {
+		t.Errorf("Search() = %+v", matches)
+	}
+
+	if _, err := NewEmbeddingMatrix([]string{"a", "b"}, [][]float32{{1, 2}, {1}}); err == nil {
+		t.Error("Expected error for vectors of different dimensions")
+	}
+	if _, err := NewEmbeddingMatrix([]string{"a"}, [][]float32{{}}); err == nil {
+		t.Error("Expected error for vectors without dimensions")
+	}
+
+	// a header with rows but no dimensions is rejected instead of read
+	empty := filepath.Join(t.TempDir(), "empty.emb")
+	if err := (&EmbeddingMatrix{IDs: []string{"a"}}).Save(empty); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := LoadEmbeddingMatrix(empty); err == nil {
+		t.Error("Expected error for a matrix without dimensions")
+	}
+}
@@ -0,0 +1,79 @@
+package echo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// defaultImageFetchLimit is the size limit used by WithImageFetch(0)
+const defaultImageFetchLimit = 20 << 20
+
+// fetchImages returns a copy of the messages with image URLs replaced by inline data
+func fetchImages(ctx context.Context, messages []Message, cfg CallConfig) ([]Message, error) {
+	result := make([]Message, len(messages))
+	for i, msg := range messages {
+		result[i] = msg
+		if len(msg.Parts) == 0 {
+			continue
+		}
+
+		parts := make([]Part, len(msg.Parts))
+		for j, part := range msg.Parts {
+			parts[j] = part
+			if part.Type != PartImage || part.URL == "" || len(part.Data) > 0 || strings.HasPrefix(part.URL, "gs://") {
+				continue
+			}
+
+			fetched, err := fetchImage(ctx, part.URL, cfg)
+			if err != nil {
+				return nil, err
+			}
+			parts[j] = fetched
+		}
+		result[i].Parts = parts
+	}
+	return result, nil
+}
+
+// fetchImage downloads an image, validating its size and content type
+func fetchImage(ctx context.Context, url string, cfg CallConfig) (Part, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Part{}, fmt.Errorf("failed to create image request: %w", err)
+	}
+	req.Header.Set("User-Agent", cfg.userAgent())
+
+	resp, err := cfg.httpClient().Do(req)
+	if err != nil {
+		return Part{}, fmt.Errorf("failed to fetch image %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Part{}, fmt.Errorf("failed to fetch image %s: status %d", url, resp.StatusCode)
+	}
+	if resp.ContentLength > cfg.ImageFetchLimit {
+		return Part{}, fmt.Errorf("image %s is too large: %d bytes, limit is %d", url, resp.ContentLength, cfg.ImageFetchLimit)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, cfg.ImageFetchLimit+1))
+	if err != nil {
+		return Part{}, fmt.Errorf("failed to read image %s: %w", url, err)
+	}
+	if int64(len(data)) > cfg.ImageFetchLimit {
+		return Part{}, fmt.Errorf("image %s is too large, limit is %d bytes", url, cfg.ImageFetchLimit)
+	}
+
+	mimeType := strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0])
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return Part{}, fmt.Errorf("%s is not an image: content type %s", url, mimeType)
+	}
+
+	return ImagePart(data, mimeType), nil
+}
@@ -0,0 +1,241 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// pngHeader is enough for content type sniffing
+var pngHeader = []byte("\x89PNG\r\n\x1a\n0000")
+
+func TestImageFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cat.png":
+			w.Write(pngHeader)
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewCommonClient(nil, WithModel("mock/test"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	ctx := context.Background()
+	message := func(url string) []Message {
+		return []Message{{Role: User, Content: "What is this?", Parts: []Part{ImageURLPart(url)}}}
+	}
+
+	resp, err := client.Complete(ctx, message(server.URL+"/cat.png"), WithImageFetch(0))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Text != "[user]: What is this? [image image/png 12 bytes]" {
+		t.Errorf("Expected inline image, got %q", resp.Text)
+	}
+
+	if _, err := client.Complete(ctx, message(server.URL+"/cat.png"), WithImageFetch(4)); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("Expected size limit error, got %v", err)
+	}
+	if _, err := client.Complete(ctx, message(server.URL+"/page.html"), WithImageFetch(0)); err == nil || !strings.Contains(err.Error(), "not an image") {
+		t.Errorf("Expected content type error, got %v", err)
+	}
+}
+
+func TestImageRequests(t *testing.T) {
+	messages := []Message{{Role: User, Content: "Describe", Parts: []Part{
+		ImagePart(pngHeader, "image/png"),
+		ImageURLPart("https://example.com/cat.jpg"),
+	}}}
+
+	openaiReq, err := prepareOpenAIRequest(messages, false, CallConfig{Model: "gpt"})
+	if err != nil {
+		t.Fatalf("prepareOpenAIRequest() error = %v", err)
+	}
+	data, _ := json.Marshal(openaiReq.Messages[0])
+	if !strings.Contains(string(data), `{"type":"text","text":"Describe"}`) ||
+		!strings.Contains(string(data), `"image_url":{"url":"data:image/png;base64,`) ||
+		!strings.Contains(string(data), `"image_url":{"url":"https://example.com/cat.jpg"}`) {
+		t.Errorf("Unexpected OpenAI message: %s", data)
+	}
+
+	var decoded OpenAIMessage
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Content != "Describe" || len(decoded.Parts) != 3 {
+		t.Errorf("Failed to decode OpenAI message: %+v, %v", decoded, err)
+	}
+
+	anthropicReq, err := prepareAnthropicRequest(messages, false, CallConfig{Model: "claude"})
+	if err != nil {
+		t.Fatalf("prepareAnthropicRequest() error = %v", err)
+	}
+	data, _ = json.Marshal(anthropicReq.Messages[0])
+	if !strings.Contains(string(data), `"source":{"type":"base64","media_type":"image/png"`) ||
+		!strings.Contains(string(data), `"source":{"type":"url","url":"https://example.com/cat.jpg"}`) {
+		t.Errorf("Unexpected Anthropic message: %s", data)
+	}
+
+	if _, err := prepareGoogleRequest(messages, CallConfig{Model: "gemini"}); err == nil || !strings.Contains(err.Error(), "WithImageFetch") {
+		t.Errorf("Expected Gemini to require fetching for remote URLs, got %v", err)
+	}
+}
+
+func TestOpenAIAudio(t *testing.T) {
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&request)
+		if request["stream"] == true {
+			w.Write([]byte(`data: {"choices":[{"delta":{"audio":{"id":"a1","data":"AAE=","transcript":"Hel"}}}]}` + "\n\n" +
+				`data: {"choices":[{"delta":{"audio":{"data":"AgM=","transcript":"lo"}}}]}` + "\n\ndata: [DONE]\n\n"))
+			return
+		}
+		w.Write([]byte(`{"choices": [{"message": {"content": null, "audio": {"id": "a1", "data": "AAECAw==", "transcript": "Hello"}}}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewCommonClient(map[string]string{"openai": "key"},
+		WithModel("openai/gpt-4o-audio-preview"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	ctx := context.Background()
+	messages := []Message{{Role: User, Parts: []Part{AudioPart([]byte("RIFF"), "audio/wav")}}}
+
+	resp, err := client.Complete(ctx, messages, WithAudioOutput("verse", ""))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Text != "Hello" || len(resp.Audio) != 4 {
+		t.Errorf("Unexpected response: %q, %v", resp.Text, resp.Audio)
+	}
+	data, _ := json.Marshal(request)
+	if !strings.Contains(string(data), `"modalities":["text","audio"]`) ||
+		!strings.Contains(string(data), `"audio":{"format":"wav","voice":"verse"}`) ||
+		!strings.Contains(string(data), `"input_audio":{"data":"UklGRg==","format":"wav"}`) {
+		t.Errorf("Unexpected request: %s", data)
+	}
+
+	stream, err := client.StreamComplete(ctx, messages, WithAudioOutput("verse", ""))
+	if err != nil {
+		t.Fatalf("StreamComplete() error = %v", err)
+	}
+	var text string
+	var audio []byte
+	for chunk := range stream.Stream {
+		if chunk.Error != nil {
+			t.Fatalf("Stream error = %v", chunk.Error)
+		}
+		text += chunk.Data
+		audio = append(audio, chunk.Audio...)
+	}
+	if text != "Hello" || len(audio) != 4 {
+		t.Errorf("Unexpected stream: %q, %v", text, audio)
+	}
+	if request["audio"].(map[string]any)["format"] != "pcm16" {
+		t.Errorf("Expected pcm16 format for streaming, got %v", request["audio"])
+	}
+}
+
+func TestAudioRequests(t *testing.T) {
+	wav := []byte("RIFF\x24\x00\x00\x00WAVEfmt ")
+	mp3 := []byte("\xff\xfb\x90\x64\x00\x00")
+	if p := AudioPart(wav, ""); p.MimeType != "audio/wav" {
+		t.Errorf("Expected wav to be detected, got %q", p.MimeType)
+	}
+	if p := AudioPart(mp3, ""); p.MimeType != "audio/mpeg" {
+		t.Errorf("Expected mp3 to be detected, got %q", p.MimeType)
+	}
+
+	messages := []Message{{Role: User, Content: "Transcribe", Parts: []Part{AudioPart(mp3, "")}}}
+
+	openaiReq, err := prepareOpenAIRequest(messages, false, CallConfig{Model: "gpt-4o-audio-preview"})
+	if err != nil {
+		t.Fatalf("prepareOpenAIRequest() error = %v", err)
+	}
+	data, _ := json.Marshal(openaiReq.Messages[0])
+	if !strings.Contains(string(data), `"input_audio":{"data":"//uQZAAA","format":"mp3"}`) {
+		t.Errorf("Unexpected OpenAI message: %s", data)
+	}
+
+	googleReq, err := prepareGoogleRequest(messages, CallConfig{Model: "gemini-2.5-flash"})
+	if err != nil {
+		t.Fatalf("prepareGoogleRequest() error = %v", err)
+	}
+	data, _ = json.Marshal(googleReq.Contents[0])
+	if !strings.Contains(string(data), `"inlineData":{"mimeType":"audio/mpeg","data":"//uQZAAA"}`) {
+		t.Errorf("Unexpected Gemini content: %s", data)
+	}
+
+	ogg := []Message{{Role: User, Parts: []Part{AudioPart([]byte("OggS"), "audio/ogg")}}}
+	if _, err := prepareOpenAIRequest(ogg, false, CallConfig{Model: "gpt-4o-audio-preview"}); err == nil || !strings.Contains(err.Error(), "unsupported audio format") {
+		t.Errorf("Expected OpenAI to reject ogg audio, got %v", err)
+	}
+	if _, err := prepareAnthropicRequest(messages, false, CallConfig{Model: "claude"}); err == nil {
+		t.Error("Expected Anthropic to reject audio parts")
+	}
+}
+
+func TestDocumentRequests(t *testing.T) {
+	pdf := []byte("%PDF-1.7")
+	messages := []Message{{Role: User, Content: "Summarize", Parts: []Part{
+		DocumentPart(pdf, ""),
+		DocumentPart([]byte("notes"), "text/plain"),
+	}}}
+
+	anthropicReq, err := prepareAnthropicRequest(messages, false, CallConfig{Model: "claude"})
+	if err != nil {
+		t.Fatalf("prepareAnthropicRequest() error = %v", err)
+	}
+	data, _ := json.Marshal(anthropicReq.Messages[0])
+	if !strings.Contains(string(data), `{"type":"document","source":{"type":"base64","media_type":"application/pdf","data":"JVBERi0xLjc="}}`) ||
+		!strings.Contains(string(data), `{"type":"document","source":{"type":"text","media_type":"text/plain","data":"notes"}}`) {
+		t.Errorf("Unexpected Anthropic message: %s", data)
+	}
+
+	remote := []Message{{Role: User, Parts: []Part{DocumentURLPart("https://example.com/paper.pdf")}}}
+	anthropicReq, err = prepareAnthropicRequest(remote, false, CallConfig{Model: "claude"})
+	if err != nil {
+		t.Fatalf("prepareAnthropicRequest() error = %v", err)
+	}
+	data, _ = json.Marshal(anthropicReq.Messages[0])
+	if !strings.Contains(string(data), `{"type":"document","source":{"type":"url","url":"https://example.com/paper.pdf"}}`) {
+		t.Errorf("Unexpected Anthropic message: %s", data)
+	}
+
+	googleReq, err := prepareGoogleRequest(messages[:1], CallConfig{Model: "gemini-2.5-flash"})
+	if err != nil {
+		t.Fatalf("prepareGoogleRequest() error = %v", err)
+	}
+	data, _ = json.Marshal(googleReq.Contents[0])
+	if !strings.Contains(string(data), `"inlineData":{"mimeType":"application/pdf","data":"JVBERi0xLjc="}`) {
+		t.Errorf("Unexpected Gemini content: %s", data)
+	}
+	uploaded := []Message{{Role: User, Parts: []Part{DocumentURLPart("gs://bucket/paper.pdf")}}}
+	googleReq, err = prepareGoogleRequest(uploaded, CallConfig{Model: "gemini-2.5-flash"})
+	if err != nil {
+		t.Fatalf("prepareGoogleRequest() error = %v", err)
+	}
+	data, _ = json.Marshal(googleReq.Contents[0])
+	if !strings.Contains(string(data), `"fileData":{"mimeType":"application/pdf","fileUri":"gs://bucket/paper.pdf"}`) {
+		t.Errorf("Unexpected Gemini content: %s", data)
+	}
+	if _, err := prepareGoogleRequest(remote, CallConfig{Model: "gemini-2.5-flash"}); err == nil {
+		t.Error("Expected Gemini to reject remote document URLs")
+	}
+
+	if _, err := prepareOpenAIRequest(messages, false, CallConfig{Model: "gpt"}); err == nil || !strings.Contains(err.Error(), "document parts are not supported") {
+		t.Errorf("Expected OpenAI to reject documents, got %v", err)
+	}
+	docx := []Message{{Role: User, Parts: []Part{DocumentPart([]byte("PK"), "application/msword")}}}
+	if _, err := prepareAnthropicRequest(docx, false, CallConfig{Model: "claude"}); err == nil || !strings.Contains(err.Error(), "unsupported document type") {
+		t.Errorf("Expected Anthropic to reject Word documents, got %v", err)
+	}
+}
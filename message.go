@@ -1,19 +1,147 @@
 package echo
 
 import (
+	"encoding/base64"
 	"fmt"
+	"net/http"
+	"slices"
 	"strings"
+	"time"
 )
 
 const (
-	System = "system"
-	Agent  = "agent"
-	User   = "user"
+	System     = "system"
+	Agent      = "agent"
+	User       = "user"
+	ToolResult = "tool" // output of a tool call, see ToolResultMessage
 )
 
 type Message struct {
-	Content string
-	Role    string
+	Content    string
+	Role       string
+	Parts      []Part     // media attached to a user message, sent after Content
+	ToolCalls  []ToolCall // tool calls requested in an agent message
+	ToolCallID string     // call answered by a ToolResult message
+}
+
+// Part types
+const (
+	PartText  = "text"
+	PartImage = "image"
+	PartVideo = "video"
+	PartAudio = "audio"
+
+	PartDocument = "document"
+)
+
+// Part is a single piece of multimodal content
+type Part struct {
+	Type     string
+	Text     string // content of text parts
+	Data     []byte // raw binary content of media parts
+	MimeType string // media type of Data, e.g. "image/png"
+	URL      string // remote location of the media, used instead of Data
+
+	Video *VideoOptions // sampling settings of video parts
+}
+
+// VideoOptions limits the part of a video sent to the model and its sampling rate
+type VideoOptions struct {
+	Start time.Duration // clip start, 0 for the beginning
+	End   time.Duration // clip end, 0 for the end of the video
+	FPS   float64       // frames sampled per second, 0 for the provider default
+}
+
+// TextPart creates a text content part
+func TextPart(text string) Part {
+	return Part{Type: PartText, Text: text}
+}
+
+// ImagePart creates an image content part from raw bytes
+func ImagePart(data []byte, mimeType string) Part {
+	return Part{Type: PartImage, Data: data, MimeType: mimeType}
+}
+
+// ImageURLPart creates an image content part referencing a remote image
+func ImageURLPart(url string) Part {
+	return Part{Type: PartImage, URL: url}
+}
+
+// AudioPart creates an audio content part from raw bytes, e.g. "audio/wav" or "audio/mpeg".
+// An empty mime type is detected from the data.
+func AudioPart(data []byte, mimeType string) Part {
+	if mimeType == "" {
+		mimeType = audioMimeType(data)
+	}
+	return Part{Type: PartAudio, Data: data, MimeType: mimeType}
+}
+
+// audioMimeType detects the format of audio data, using the names providers accept
+func audioMimeType(data []byte) string {
+	switch mimeType := http.DetectContentType(data); mimeType {
+	case "audio/wave":
+		return "audio/wav"
+	case "application/octet-stream":
+		// MPEG frames without an ID3 tag start with a sync word
+		if len(data) > 1 && data[0] == 0xff && data[1]&0xe0 == 0xe0 {
+			return "audio/mpeg"
+		}
+		return mimeType
+	default:
+		return mimeType
+	}
+}
+
+// DocumentPart creates a document content part from raw bytes, e.g. "application/pdf".
+// An empty mime type defaults to PDF.
+func DocumentPart(data []byte, mimeType string) Part {
+	if mimeType == "" {
+		mimeType = "application/pdf"
+	}
+	return Part{Type: PartDocument, Data: data, MimeType: mimeType}
+}
+
+// DocumentURLPart creates a document content part referencing a remote or uploaded PDF
+func DocumentURLPart(url string) Part {
+	return Part{Type: PartDocument, URL: url, MimeType: "application/pdf"}
+}
+
+// VideoPart creates a video content part from raw bytes
+func VideoPart(data []byte, mimeType string) Part {
+	return Part{Type: PartVideo, Data: data, MimeType: mimeType}
+}
+
+// VideoURLPart creates a video content part referencing an uploaded file or a YouTube URL
+func VideoURLPart(url string) Part {
+	return Part{Type: PartVideo, URL: url}
+}
+
+// WithClip returns a copy of the video part limited to the given interval
+func (p Part) WithClip(start, end time.Duration) Part {
+	opts := p.videoOptions()
+	opts.Start, opts.End = start, end
+	p.Video = &opts
+	return p
+}
+
+// WithFPS returns a copy of the video part sampled at the given frame rate
+func (p Part) WithFPS(fps float64) Part {
+	opts := p.videoOptions()
+	opts.FPS = fps
+	p.Video = &opts
+	return p
+}
+
+func (p Part) videoOptions() VideoOptions {
+	if p.Video == nil {
+		return VideoOptions{}
+	}
+	return *p.Video
+}
+
+// dataURL returns the part content encoded as a data: URL
+func (p Part) dataURL() string {
+	return "data:" + p.MimeType + ";base64," + base64.StdEncoding.EncodeToString(p.Data)
 }
 
 // QuickMessage creates a simple user message chain for backward compatibility
@@ -26,11 +154,52 @@ func QuickMessage(message string) []Message {
 	}
 }
 
+// RenderMessages renders a message chain to a canonical plain-text form.
+// The output does not depend on the provider, so it can be used to embed
+// or index conversations consistently.
+func RenderMessages(messages []Message) string {
+	var sb strings.Builder
+	for i, msg := range messages {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(msg.Role)
+		sb.WriteString(": ")
+		sb.WriteString(strings.TrimSpace(msg.Content))
+	}
+	return sb.String()
+}
+
+// coalesceMessages merges adjacent user or agent messages into one: contents are joined
+// with a blank line, parts and tool calls are kept in order. Tool results stay separate.
+func coalesceMessages(messages []Message) []Message {
+	out := make([]Message, 0, len(messages))
+	for _, msg := range messages {
+		n := len(out)
+		if n == 0 || msg.Role != out[n-1].Role || (msg.Role != User && msg.Role != Agent) {
+			out = append(out, msg)
+			continue
+		}
+
+		last := &out[n-1]
+		switch {
+		case last.Content == "":
+			last.Content = msg.Content
+		case msg.Content != "":
+			last.Content += "\n\n" + msg.Content
+		}
+		last.Parts = append(slices.Clip(last.Parts), msg.Parts...)
+		last.ToolCalls = append(slices.Clip(last.ToolCalls), msg.ToolCalls...)
+	}
+	return out
+}
+
 // validateMessages validates the message chain according to the rules:
 // - Must not be empty
 // - System message (if present) must be first
 // - Only one system message allowed
-// - Roles must be valid (system, user, agent)
+// - Roles must be valid (system, user, agent, tool)
+// - Tool calls must be answered by the tool results that follow them, see validateToolCalls
 func validateMessages(messages []Message) error {
 	if len(messages) == 0 {
 		return fmt.Errorf("message chain cannot be empty")
@@ -41,7 +210,7 @@ func validateMessages(messages []Message) error {
 	for i, msg := range messages {
 		// Validate role
 		switch msg.Role {
-		case System, User, Agent:
+		case System, User, Agent, ToolResult:
 			// Valid roles
 		default:
 			return fmt.Errorf("invalid role '%s' at position %d", msg.Role, i)
@@ -59,6 +228,17 @@ func validateMessages(messages []Message) error {
 		} else {
 			userMessageSeen = true
 		}
+
+		if len(msg.Parts) > 0 && msg.Role != User {
+			return fmt.Errorf("content parts are only supported in user messages, got '%s' at position %d", msg.Role, i)
+		}
+		if len(msg.ToolCalls) > 0 && msg.Role != Agent {
+			return fmt.Errorf("tool calls are only supported in agent messages, got '%s' at position %d", msg.Role, i)
+		}
+	}
+
+	if err := validateToolCalls(messages); err != nil {
+		return err
 	}
 
 	if !userMessageSeen {
@@ -67,6 +247,60 @@ func validateMessages(messages []Message) error {
 	return nil
 }
 
+// validateToolCalls checks the ordering all providers require: the tool calls of an agent
+// message are answered by tool results right after it, one result per call, before any
+// other message
+func validateToolCalls(messages []Message) error {
+	type pendingCall struct {
+		id    string
+		index int
+	}
+	var pending []pendingCall
+	answered := map[string]bool{}
+
+	unanswered := func(at int) error {
+		call := pending[0]
+		if at == len(messages) {
+			return fmt.Errorf("tool call '%s' at position %d has no result", call.id, call.index)
+		}
+		return fmt.Errorf("tool call '%s' at position %d has no result before position %d", call.id, call.index, at)
+	}
+
+	for i, msg := range messages {
+		if msg.Role == ToolResult {
+			j := slices.IndexFunc(pending, func(c pendingCall) bool { return c.id == msg.ToolCallID })
+			switch {
+			case j >= 0:
+				pending = slices.Delete(pending, j, j+1)
+				answered[msg.ToolCallID] = true
+			case answered[msg.ToolCallID]:
+				return fmt.Errorf("tool call '%s' has a second result at position %d", msg.ToolCallID, i)
+			default:
+				return fmt.Errorf("tool result at position %d doesn't answer a preceding tool call", i)
+			}
+			continue
+		}
+
+		if len(pending) > 0 {
+			return unanswered(i)
+		}
+		for _, call := range msg.ToolCalls {
+			if call.ID == "" {
+				return fmt.Errorf("tool call '%s' at position %d has no ID", call.Name, i)
+			}
+			if answered[call.ID] || slices.ContainsFunc(pending, func(c pendingCall) bool { return c.id == call.ID }) {
+				return fmt.Errorf("tool call ID '%s' at position %d is not unique", call.ID, i)
+			}
+			pending = append(pending, pendingCall{call.ID, i})
+		}
+	}
+
+	if len(pending) > 0 {
+		return unanswered(len(messages))
+	}
+	return nil
+}
+
 // TemplateMessage parses a template string into a message chain.
 // The template format uses @role: markers to separate messages.
 // Example:
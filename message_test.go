@@ -1,6 +1,7 @@
 package echo
 
 import (
+	"context"
 	"fmt"
 	"testing"
 )
@@ -114,3 +115,47 @@ Can you explain why?
 	// agent: 2+2 equals 4.
 	// user: Can you explain why?
 }
+
+func TestRenderMessages(t *testing.T) {
+	messages := []Message{
+		{Role: System, Content: "Be brief"},
+		{Role: User, Content: "  Hello \n"},
+		{Role: Agent, Content: "Hi"},
+	}
+
+	expected := "system: Be brief\n\nuser: Hello\n\nagent: Hi"
+	if got := RenderMessages(messages); got != expected {
+		t.Errorf("RenderMessages() = %q, want %q", got, expected)
+	}
+}
+
+func TestCoalesceMessages(t *testing.T) {
+	messages := []Message{
+		{Role: System, Content: "Be brief"},
+		{Role: User, Content: "Hello"},
+		{Role: User, Content: "Look at this", Parts: []Part{ImageURLPart("https://example.com/a.png")}},
+		{Role: Agent, Content: "A cat"},
+	}
+
+	provider := &scriptedProvider{answers: []string{"ok", "ok", "ok"}}
+	client, _ := NewClient(WithModel("anthropic/claude-haiku-4-5"))
+	client.SetProvider("anthropic", provider)
+	client.SetProvider("openai", provider)
+	ctx := context.Background()
+
+	client.Complete(ctx, messages)
+	sent := provider.calls[0]
+	if len(sent) != 3 || sent[1].Content != "Hello\n\nLook at this" || len(sent[1].Parts) != 1 {
+		t.Errorf("Unexpected coalesced messages %+v", sent)
+	}
+	if len(messages[1].Parts) != 0 {
+		t.Error("Expected the original chain to be unchanged")
+	}
+
+	// Off by default for other providers, and on request for Anthropic
+	client.Complete(ctx, messages, WithModel("openai/gpt-5"))
+	client.Complete(ctx, messages, WithCoalescing(false))
+	if len(provider.calls[1]) != 4 || len(provider.calls[2]) != 4 {
+		t.Errorf("Expected the chain sent as is, got %d and %d messages", len(provider.calls[1]), len(provider.calls[2]))
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"math"
 	"net/http"
 	"strings"
 )
@@ -29,11 +31,28 @@ func (p *MockProvider) getMessages(messages []Message, cfg CallConfig) string {
 			combinedContent.WriteString("\n")
 		}
 		combinedContent.WriteString(fmt.Sprintf("[%s]: %s", msg.Role, msg.Content))
+		for _, part := range msg.Parts {
+			if part.Type == PartText {
+				combinedContent.WriteString(" " + part.Text)
+			} else if len(part.Data) > 0 {
+				combinedContent.WriteString(fmt.Sprintf(" [%s %s %d bytes]", part.Type, part.MimeType, len(part.Data)))
+			} else {
+				combinedContent.WriteString(fmt.Sprintf(" [%s %s]", part.Type, part.URL))
+			}
+		}
 	}
 
 	return combinedContent.String()
 }
 
+// validateCompletion implements the provider interface
+func (p *MockProvider) validateCompletion(messages []Message, cfg CallConfig) error {
+	if err := validateMessages(messages); err != nil {
+		return fmt.Errorf("invalid message chain: %w", err)
+	}
+	return nil
+}
+
 // call implements the provider interface for mock testing
 func (p *MockProvider) call(ctx context.Context, messages []Message, cfg CallConfig) (*Response, error) {
 	// Validate messages
@@ -49,14 +68,22 @@ func (p *MockProvider) call(ctx context.Context, messages []Message, cfg CallCon
 			cfg.StructuredOutput.Name)
 	}
 
-	return &Response{
+	resp := &Response{
 		Text: responseText,
 		Metadata: Metadata{
 			"mock":              true,
 			"message_count":     len(messages),
 			"structured_output": cfg.StructuredOutput != nil,
+			"finish_reason":     FinishStop,
 		},
-	}, nil
+	}
+	// Every choice gets the same answer
+	if cfg.N > 1 {
+		for range cfg.N {
+			resp.Choices = append(resp.Choices, Choice{Text: responseText, FinishReason: FinishStop})
+		}
+	}
+	return resp, nil
 }
 
 // streamCall implements the provider interface for mock streaming
@@ -72,14 +99,18 @@ func (p *MockProvider) streamCall(ctx context.Context, messages []Message, cfg C
 	// Start goroutine to simulate streaming
 	go func() {
 		defer close(ch)
+		out := chunkSender{ctx: ctx, ch: ch}
 
 		// Send metadata in first chunk
-		ch <- StreamChunk{
+		if out.send(StreamChunk{
 			Meta: &Metadata{
 				"mock":              true,
 				"message_count":     len(messages),
 				"structured_output": cfg.StructuredOutput != nil,
+				"finish_reason":     FinishStop,
 			},
+		}) != nil {
+			return
 		}
 
 		// Simulate streaming by sending the combined content in chunks
@@ -98,15 +129,17 @@ func (p *MockProvider) streamCall(ctx context.Context, messages []Message, cfg C
 				end = len(content)
 			}
 
-			ch <- StreamChunk{
+			if out.send(StreamChunk{
 				Data: content[i:end],
+			}) != nil {
+				return
 			}
 		}
 
 		// Send completion signal
-		ch <- StreamChunk{
+		out.send(StreamChunk{
 			Error: nil, // nil error indicates completion
-		}
+		})
 	}()
 
 	return &StreamResponse{
@@ -114,14 +147,89 @@ func (p *MockProvider) streamCall(ctx context.Context, messages []Message, cfg C
 	}, nil
 }
 
+// mockEmbeddingSize is the dimension of vectors returned by the mock provider
+const mockEmbeddingSize = 64
+
+// mockEmbedding builds a deterministic bag-of-words vector for the text,
+// so texts sharing words produce similar embeddings
+func mockEmbedding(text string) []float32 {
+	vec := make([]float32, mockEmbeddingSize)
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		h := fnv.New32a()
+		h.Write([]byte(word))
+		vec[h.Sum32()%mockEmbeddingSize] += 1
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v * v)
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range vec {
+			vec[i] = float32(float64(vec[i]) / norm)
+		}
+	}
+	return vec
+}
+
 // getEmbeddings implements the provider interface for mock embeddings
 func (p *MockProvider) getEmbeddings(ctx context.Context, text string, cfg CallConfig) (*EmbeddingResponse, error) {
-	return nil, fmt.Errorf("not implemented")
+	return &EmbeddingResponse{
+		Embedding: mockEmbedding(text),
+		Metadata: Metadata{
+			"mock": true,
+		},
+	}, nil
+}
+
+// getMultimodalEmbeddings implements the provider interface for mock multimodal embeddings
+// Media parts contribute a token with their type, so results stay deterministic
+func (p *MockProvider) getMultimodalEmbeddings(ctx context.Context, parts []Part, cfg CallConfig) (*EmbeddingResponse, error) {
+	words := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part.Type == PartText {
+			words = append(words, part.Text)
+		} else {
+			words = append(words, "["+part.Type+"]")
+		}
+	}
+
+	return &EmbeddingResponse{
+		Embedding: mockEmbedding(strings.Join(words, " ")),
+		Metadata: Metadata{
+			"mock": true,
+		},
+	}, nil
 }
 
 // reRank implements the provider interface for mock reranking
+// The score of a document is the share of query words it contains
 func (p *MockProvider) reRank(ctx context.Context, query string, documents []string, cfg CallConfig) (*RerankResponse, error) {
-	return nil, fmt.Errorf("not implemented")
+	queryWords := strings.Fields(strings.ToLower(query))
+	scores := make([]float32, len(documents))
+	for i, doc := range documents {
+		docWords := map[string]bool{}
+		for _, w := range strings.Fields(strings.ToLower(doc)) {
+			docWords[w] = true
+		}
+		matched := 0
+		for _, w := range queryWords {
+			if docWords[w] {
+				matched++
+			}
+		}
+		if len(queryWords) > 0 {
+			scores[i] = float32(matched) / float32(len(queryWords))
+		}
+	}
+
+	return &RerankResponse{
+		Scores: scores,
+		Metadata: Metadata{
+			"mock": true,
+		},
+	}, nil
 }
 
 // parseCompletionRequest parses an HTTP request into a CompletionRequest
@@ -206,6 +314,13 @@ func (p *MockProvider) writeCompletionResponse(w http.ResponseWriter, resp *Comp
 	return err
 }
 
+// writeCompletionStream writes the streamed text as is
+func (p *MockProvider) writeCompletionStream(w http.ResponseWriter, model string, stream *StreamResponse) error {
+	w.Header().Set("Content-Type", "plain/text")
+	_, err := stream.WriteTo(w)
+	return err
+}
+
 // writeEmbeddingResponse writes a UnifiedEmbeddingResponse as JSON to the HTTP response writer
 func (p *MockProvider) writeEmbeddingResponse(w http.ResponseWriter, resp *UnifiedEmbeddingResponse) error {
 	w.Header().Set("Content-Type", "application/json")
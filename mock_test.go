@@ -2,7 +2,9 @@ package echo
 
 import (
 	"context"
+	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -157,3 +159,146 @@ func TestMockClient_InvalidMessages(t *testing.T) {
 		t.Errorf("Expected error for system message not first")
 	}
 }
+
+func TestMockClient_MessageEmbeddings(t *testing.T) {
+	client, err := NewCommonClient(nil, WithModel("mock/test"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	ctx := context.Background()
+
+	messages := []Message{
+		{Role: User, Content: "Hello"},
+		{Role: Agent, Content: "Hi there!"},
+	}
+
+	resp, err := client.GetMessageEmbeddings(ctx, messages)
+	if err != nil {
+		t.Fatalf("GetMessageEmbeddings() error = %v", err)
+	}
+
+	direct, err := client.GetEmbeddings(ctx, RenderMessages(messages))
+	if err != nil {
+		t.Fatalf("GetEmbeddings() error = %v", err)
+	}
+
+	if len(resp.Embedding) != len(direct.Embedding) {
+		t.Fatalf("Expected %d dimensions, got %d", len(direct.Embedding), len(resp.Embedding))
+	}
+	for i := range resp.Embedding {
+		if resp.Embedding[i] != direct.Embedding[i] {
+			t.Fatalf("Embeddings differ at index %d", i)
+		}
+	}
+
+	if _, err := client.GetMessageEmbeddings(ctx, nil); err == nil {
+		t.Errorf("Expected error for empty messages")
+	}
+}
+
+func TestMockClient_NormalizedScores(t *testing.T) {
+	client, err := NewCommonClient(nil, WithModel("mock/test"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	ctx := context.Background()
+
+	documents := []string{"red apple", "green apple pie", "blue sky"}
+
+	resp, err := client.ReRank(ctx, "apple pie", documents, WithNormalizedScores(NormalizeMinMax))
+	if err != nil {
+		t.Fatalf("ReRank() error = %v", err)
+	}
+	if resp.Scores[1] != 1 || resp.Scores[2] != 0 {
+		t.Errorf("Unexpected minmax scores: %v", resp.Scores)
+	}
+
+	resp, err = client.ReRank(ctx, "apple pie", documents, WithNormalizedScores(NormalizeSoftmax))
+	if err != nil {
+		t.Fatalf("ReRank() error = %v", err)
+	}
+	var sum float32
+	for _, s := range resp.Scores {
+		sum += s
+	}
+	if sum < 0.999 || sum > 1.001 {
+		t.Errorf("Expected softmax scores to sum to 1, got %v", sum)
+	}
+	if resp.Scores[1] <= resp.Scores[0] {
+		t.Errorf("Expected softmax to keep ordering: %v", resp.Scores)
+	}
+
+	// Min-max is the default method
+	resp, err = client.ReRank(ctx, "apple pie", documents, WithNormalizedScores())
+	if err != nil || resp.Scores[1] != 1 || resp.Metadata["score_normalization"] != NormalizeMinMax {
+		t.Errorf("Unexpected default normalization: %v, %v", resp, err)
+	}
+
+	// An unknown method fails before the rerank request
+	provider := &rerankCounter{}
+	client.SetProvider("mock", provider)
+	if _, err := client.ReRank(ctx, "apple", documents, WithNormalizedScores("unknown")); err == nil {
+		t.Errorf("Expected error for unknown normalization")
+	}
+	if provider.calls != 0 {
+		t.Errorf("Expected no rerank request, got %d", provider.calls)
+	}
+}
+
+// rerankCounter counts rerank requests
+type rerankCounter struct {
+	MockProvider
+	calls int
+}
+
+func (p *rerankCounter) reRank(ctx context.Context, query string, documents []string, cfg CallConfig) (*RerankResponse, error) {
+	p.calls++
+	return p.MockProvider.reRank(ctx, query, documents, cfg)
+}
+
+func TestStreamWriteTo(t *testing.T) {
+	client, err := NewCommonClient(nil, WithModel("mock/test"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	stream, err := client.StreamComplete(context.Background(), QuickMessage("Hello world"))
+	if err != nil {
+		t.Fatalf("StreamComplete() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	n, err := stream.WriteTo(rec)
+	if err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if rec.Body.String() != "[user]: Hello world" || n != int64(rec.Body.Len()) {
+		t.Errorf("WriteTo() wrote %d bytes: %q", n, rec.Body.String())
+	}
+	if !rec.Flushed {
+		t.Error("Expected the writer to be flushed")
+	}
+}
+
+func TestConcurrentSetProvider(t *testing.T) {
+	client, err := NewCommonClient(nil, WithModel("mock/test"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			client.SetProvider("mock", &MockProvider{})
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := client.Complete(context.Background(), QuickMessage("Hi")); err != nil {
+				t.Errorf("Complete() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,121 @@
+package echo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ModelRef identifies a model by the parts of a "provider/model@endpoint" string
+type ModelRef struct {
+	Provider string // registered provider name, e.g. "openai" or "openrouter"
+	Model    string // model name as the provider knows it, may contain slashes and "@"
+	Endpoint string // routing for OpenRouter, comma-separated upstream providers; empty for the default
+}
+
+// ParseModelRef parses a "provider/model@endpoint" string, see ModelRef.Parse
+func ParseModelRef(s string) (ModelRef, error) {
+	var ref ModelRef
+	err := ref.Parse(s)
+	return ref, err
+}
+
+// Parse reads a "provider/model@endpoint" string:
+//   - the provider is the text before the first slash, the model name everything after it,
+//     so OpenRouter names keep their vendor ("openrouter/meta-llama/llama-3.3-70b-instruct")
+//   - the endpoint follows the first single "@"; "@@" stands for an "@" in the model name
+//     ("custom/claude-sonnet-4-5@@20250929" is the model "claude-sonnet-4-5@20250929")
+//   - a ":variant" suffix belongs to the model, also when written after the endpoint
+//     ("openrouter/openai/gpt-5@azure:online" is the model "openai/gpt-5:online")
+//
+// Aliases are not resolved, the provider is not checked; see Validate.
+func (r *ModelRef) Parse(s string) error {
+	invalid := fmt.Errorf("invalid model format: %s. Expected provider/model-name@endpoint", s)
+	provider, rest, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok || provider == "" {
+		return invalid
+	}
+
+	// Remove endpoint suffix if present (handled in CallConfig.EndPoint)
+	model, endpoint, hasEndpoint := splitEndpoint(rest)
+	if hasEndpoint {
+		if colon := strings.Index(endpoint, ":"); colon != -1 {
+			model += endpoint[colon:]
+			endpoint = endpoint[:colon]
+		}
+		if endpoint == "" || strings.Contains(endpoint, "@") {
+			return invalid
+		}
+	}
+	if model == "" {
+		return invalid
+	}
+
+	*r = ModelRef{Provider: provider, Model: model, Endpoint: endpoint}
+	return nil
+}
+
+// String returns the reference in the format read by Parse, escaping "@" in the model name
+func (r ModelRef) String() string {
+	s := r.Provider + "/" + strings.ReplaceAll(r.Model, "@", "@@")
+	if r.Endpoint != "" {
+		s += "@" + r.Endpoint
+	}
+	return s
+}
+
+// Validate checks that the provider is one of the built-in providers and the model is set.
+// Providers added with SetProvider are known to their client only, calls check them
+// when the model is resolved.
+func (r ModelRef) Validate() error {
+	if _, ok := knownProviders[r.Provider]; !ok {
+		return fmt.Errorf("unknown provider: %s", r.Provider)
+	}
+	if r.Model == "" {
+		return fmt.Errorf("no model name for provider %s", r.Provider)
+	}
+	if strings.ContainsAny(r.Endpoint, "@:") {
+		return fmt.Errorf("invalid endpoint %q", r.Endpoint)
+	}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, so references can be used in JSON
+// configuration and with flag.TextVar
+func (r ModelRef) MarshalText() ([]byte, error) {
+	if r == (ModelRef{}) {
+		return nil, nil
+	}
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, resolving aliases such as "openai/best"
+func (r *ModelRef) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*r = ModelRef{}
+		return nil
+	}
+	return r.Parse(resolveAlias(string(text)))
+}
+
+// modelRef returns the resolved model of the call, without the endpoint
+func (cfg CallConfig) modelRef() ModelRef {
+	return ModelRef{Provider: cfg.provider, Model: cfg.Model}
+}
+
+// splitEndpoint splits a model name at the first single "@", unescaping "@@" in the name
+func splitEndpoint(name string) (model, endpoint string, ok bool) {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] != '@' {
+			b.WriteByte(name[i])
+			continue
+		}
+		if i+1 < len(name) && name[i+1] == '@' {
+			b.WriteByte('@')
+			i++
+			continue
+		}
+		return b.String(), name[i+1:], true
+	}
+	return b.String(), "", false
+}
@@ -0,0 +1,71 @@
+package echo
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseModelRef(t *testing.T) {
+	tests := []struct {
+		input string
+		ref   ModelRef
+	}{
+		{"openai/gpt-5", ModelRef{"openai", "gpt-5", ""}},
+		{" openai/gpt-5 ", ModelRef{"openai", "gpt-5", ""}},
+		{"openai/gpt-5@azure", ModelRef{"openai", "gpt-5", "azure"}},
+		{"openrouter/openai/gpt-5", ModelRef{"openrouter", "openai/gpt-5", ""}},
+		{"openrouter/meta-llama/llama-3.3-70b-instruct", ModelRef{"openrouter", "meta-llama/llama-3.3-70b-instruct", ""}},
+		{"openrouter/meta-llama/llama-3.3-70b:nitro", ModelRef{"openrouter", "meta-llama/llama-3.3-70b:nitro", ""}},
+		{"openrouter/openai/gpt-5:online@azure,openai", ModelRef{"openrouter", "openai/gpt-5:online", "azure,openai"}},
+		{"openrouter/openai/gpt-5@azure:online", ModelRef{"openrouter", "openai/gpt-5:online", "azure"}},
+		{"custom/claude-sonnet-4-5@@20250929", ModelRef{"custom", "claude-sonnet-4-5@20250929", ""}},
+		{"custom/claude-sonnet-4-5@@20250929@us-east5", ModelRef{"custom", "claude-sonnet-4-5@20250929", "us-east5"}},
+		{"custom/a@@@@b", ModelRef{"custom", "a@@b", ""}},
+	}
+	for _, tt := range tests {
+		ref, err := ParseModelRef(tt.input)
+		if err != nil || ref != tt.ref {
+			t.Errorf("ParseModelRef(%q) = %+v, %v", tt.input, ref, err)
+			continue
+		}
+		// String writes a form that parses back to the same reference
+		if back, err := ParseModelRef(ref.String()); err != nil || back != ref {
+			t.Errorf("ParseModelRef(%q) = %+v, %v", ref.String(), back, err)
+		}
+	}
+
+	for _, input := range []string{"", "gpt-5", "/gpt-5", "openai/", "openai/@azure", "openai/gpt-5@", "openai/gpt-5@azure@aws"} {
+		if _, err := ParseModelRef(input); err == nil {
+			t.Errorf("ParseModelRef(%q) expected an error", input)
+		}
+	}
+}
+
+func TestModelRefValidate(t *testing.T) {
+	if err := (ModelRef{Provider: "openrouter", Model: "openai/gpt-5", Endpoint: "azure"}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	for _, ref := range []ModelRef{{"custom", "gpt-5", ""}, {"openai", "", ""}, {"openai", "gpt-5", "azure:online"}} {
+		if err := ref.Validate(); err == nil {
+			t.Errorf("%+v: expected a validation error", ref)
+		}
+	}
+}
+
+func TestModelRefText(t *testing.T) {
+	var config struct {
+		Model ModelRef `json:"model"`
+	}
+	if err := json.Unmarshal([]byte(`{"model": "openai/best"}`), &config); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if config.Model.Provider != "openai" || config.Model.Model == "best" {
+		t.Errorf("Expected the alias to be resolved, got %+v", config.Model)
+	}
+
+	config.Model = ModelRef{Provider: "custom", Model: "claude@2025", Endpoint: "eu"}
+	data, _ := json.Marshal(config)
+	if string(data) != `{"model":"custom/claude@@2025@eu"}` {
+		t.Errorf("Unexpected JSON: %s", data)
+	}
+}
@@ -0,0 +1,206 @@
+package echo
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ModelInfo describes the token limits, reasoning settings and prices of a model
+type ModelInfo struct {
+	ContextWindow     int // tokens of input and output the model accepts
+	LongContextWindow int // context window with the long-context beta enabled, 0 if not available
+	MaxOutputTokens   int // largest output the model can produce, 0 if unknown
+
+	ReasoningEfforts []string // values accepted by WithReasoningEffort, nil if the model has no effort setting
+
+	InputPrice  float64 // USD per million input tokens at the standard list price, 0 if unknown
+	OutputPrice float64 // USD per million output tokens at the standard list price, 0 if unknown
+}
+
+// Cost estimates the price of a call in USD from its token usage at list prices.
+// Cache, batch and long-context rates are not applied, so cached prompts are overestimated.
+// Returns false when the prices of the model are unknown.
+func (m ModelInfo) Cost(u Usage) (float64, bool) {
+	if m.InputPrice == 0 && m.OutputPrice == 0 {
+		return 0, false
+	}
+	return (float64(u.PromptTokens)*m.InputPrice + float64(u.CompletionTokens)*m.OutputPrice) / 1e6, true
+}
+
+// Reasoning effort levels of the model families
+var (
+	effortsLowHigh       = []string{"low", "high"}
+	effortsLowMediumHigh = []string{"low", "medium", "high"}
+	effortsGPT5          = []string{"minimal", "low", "medium", "high"}
+	effortsGPT52         = []string{"none", "low", "medium", "high", "xhigh"}
+)
+
+// models is the capability table, keyed by "provider/model".
+// Dated snapshots match their base entry, e.g. claude-sonnet-4-5-20250929.
+var models = map[string]ModelInfo{
+	"anthropic/claude-opus-4-5":   {ContextWindow: 200_000, MaxOutputTokens: 64_000, ReasoningEfforts: effortsLowMediumHigh, InputPrice: 5, OutputPrice: 25},
+	"anthropic/claude-opus-4-1":   {ContextWindow: 200_000, MaxOutputTokens: 32_000, InputPrice: 15, OutputPrice: 75},
+	"anthropic/claude-opus-4":     {ContextWindow: 200_000, MaxOutputTokens: 32_000, InputPrice: 15, OutputPrice: 75},
+	"anthropic/claude-sonnet-4-5": {ContextWindow: 200_000, LongContextWindow: 1_000_000, MaxOutputTokens: 64_000, InputPrice: 3, OutputPrice: 15},
+	"anthropic/claude-sonnet-4":   {ContextWindow: 200_000, LongContextWindow: 1_000_000, MaxOutputTokens: 64_000, InputPrice: 3, OutputPrice: 15},
+	"anthropic/claude-haiku-4-5":  {ContextWindow: 200_000, MaxOutputTokens: 64_000, InputPrice: 1, OutputPrice: 5},
+	"anthropic/claude-3-7-sonnet": {ContextWindow: 200_000, MaxOutputTokens: 64_000, InputPrice: 3, OutputPrice: 15},
+	"anthropic/claude-3-5-haiku":  {ContextWindow: 200_000, MaxOutputTokens: 8_192, InputPrice: 0.8, OutputPrice: 4},
+	"anthropic/claude-3-haiku":    {ContextWindow: 200_000, MaxOutputTokens: 4_096, InputPrice: 0.25, OutputPrice: 1.25},
+
+	"openai/gpt-5.2":      {ContextWindow: 400_000, MaxOutputTokens: 128_000, ReasoningEfforts: effortsGPT52, InputPrice: 1.75, OutputPrice: 14},
+	"openai/gpt-5":        {ContextWindow: 400_000, MaxOutputTokens: 128_000, ReasoningEfforts: effortsGPT5, InputPrice: 1.25, OutputPrice: 10},
+	"openai/gpt-5-mini":   {ContextWindow: 400_000, MaxOutputTokens: 128_000, ReasoningEfforts: effortsGPT5, InputPrice: 0.25, OutputPrice: 2},
+	"openai/gpt-5-nano":   {ContextWindow: 400_000, MaxOutputTokens: 128_000, ReasoningEfforts: effortsGPT5, InputPrice: 0.05, OutputPrice: 0.4},
+	"openai/o3":           {ContextWindow: 200_000, MaxOutputTokens: 100_000, ReasoningEfforts: effortsLowMediumHigh, InputPrice: 2, OutputPrice: 8},
+	"openai/o4-mini":      {ContextWindow: 200_000, MaxOutputTokens: 100_000, ReasoningEfforts: effortsLowMediumHigh, InputPrice: 1.1, OutputPrice: 4.4},
+	"openai/gpt-4.1":      {ContextWindow: 1_047_576, MaxOutputTokens: 32_768, InputPrice: 2, OutputPrice: 8},
+	"openai/gpt-4.1-mini": {ContextWindow: 1_047_576, MaxOutputTokens: 32_768, InputPrice: 0.4, OutputPrice: 1.6},
+	"openai/gpt-4.1-nano": {ContextWindow: 1_047_576, MaxOutputTokens: 32_768, InputPrice: 0.1, OutputPrice: 0.4},
+	"openai/gpt-4o":       {ContextWindow: 128_000, MaxOutputTokens: 16_384, InputPrice: 2.5, OutputPrice: 10},
+	"openai/gpt-4o-mini":  {ContextWindow: 128_000, MaxOutputTokens: 16_384, InputPrice: 0.15, OutputPrice: 0.6},
+	"openai/gpt-4o-audio": {ContextWindow: 128_000, MaxOutputTokens: 16_384, InputPrice: 2.5, OutputPrice: 10},
+
+	"google/gemini-3-pro":          {ContextWindow: 1_048_576, MaxOutputTokens: 65_536, ReasoningEfforts: effortsLowHigh, InputPrice: 2, OutputPrice: 12},
+	"google/gemini-2.5-pro":        {ContextWindow: 1_048_576, MaxOutputTokens: 65_536, InputPrice: 1.25, OutputPrice: 10},
+	"google/gemini-2.5-flash":      {ContextWindow: 1_048_576, MaxOutputTokens: 65_536, InputPrice: 0.3, OutputPrice: 2.5},
+	"google/gemini-2.5-flash-lite": {ContextWindow: 1_048_576, MaxOutputTokens: 65_536, InputPrice: 0.1, OutputPrice: 0.4},
+	"google/gemini-2.0-flash":      {ContextWindow: 1_048_576, MaxOutputTokens: 8_192, InputPrice: 0.1, OutputPrice: 0.4},
+
+	"xai/grok-3-mini":   {ContextWindow: 131_072, ReasoningEfforts: effortsLowHigh, InputPrice: 0.3, OutputPrice: 0.5},
+	"xai/grok-4-0709":   {ContextWindow: 256_000, InputPrice: 3, OutputPrice: 15},
+	"xai/grok-4-1-fast": {ContextWindow: 2_000_000, InputPrice: 0.2, OutputPrice: 0.5},
+}
+
+// autoMaxTokens returns the output budget left in the context window of the model after
+// the prompt, capped by the output limit of the model and the requested max tokens.
+// The prompt size is an estimate, so a tenth of it is kept in reserve.
+func autoMaxTokens(messages []Message, cfg CallConfig) (int, bool, error) {
+	info, ok := LookupModel(cfg.modelRef().String())
+	if !ok || info.ContextWindow == 0 {
+		return 0, false, nil
+	}
+
+	window := contextWindow(info, cfg)
+	prompt := EstimateMessagesTokens(messages) + EstimateTokens(cfg.SystemMsg)
+	budget := window - prompt - prompt/10
+	if budget <= 0 {
+		return 0, false, fmt.Errorf("prompt of about %d tokens exceeds the context window of %d tokens", prompt, window)
+	}
+
+	if info.MaxOutputTokens > 0 {
+		budget = min(budget, info.MaxOutputTokens)
+	}
+	if cfg.MaxTokens != nil {
+		budget = min(budget, *cfg.MaxTokens)
+	}
+	return budget, true, nil
+}
+
+// contextWindow returns the context window of the model for the call, the long one
+// when the long-context beta is enabled
+func contextWindow(info ModelInfo, cfg CallConfig) int {
+	if info.LongContextWindow > 0 && slices.Contains(cfg.AnthropicBeta, AnthropicBetaContext1M) {
+		return info.LongContextWindow
+	}
+	return info.ContextWindow
+}
+
+// checkReasoningEffort drops the reasoning effort for models of the table without an effort
+// setting, or fails for them with WithStrictOptions, and rejects levels the model doesn't accept.
+// Models missing from the table get the effort as given.
+func checkReasoningEffort(cfg *CallConfig) error {
+	if cfg.ReasoningEffort == "" {
+		return nil
+	}
+	model := cfg.modelRef().String()
+	info, ok := LookupModel(model)
+	switch {
+	case !ok:
+		return nil
+	case info.ReasoningEfforts == nil && cfg.StrictOptions:
+		return fmt.Errorf("%s does not support reasoning effort", model)
+	case info.ReasoningEfforts == nil:
+		cfg.ReasoningEffort = ""
+	case !slices.Contains(info.ReasoningEfforts, cfg.ReasoningEffort):
+		return fmt.Errorf("reasoning effort %q is not supported by %s, use one of %s", cfg.ReasoningEffort, model, strings.Join(info.ReasoningEfforts, ", "))
+	}
+	return nil
+}
+
+// Deprecation describes a model scheduled for shutdown by its vendor
+type Deprecation struct {
+	Shutdown    time.Time // date the model stops serving requests
+	Replacement string    // suggested model, as "provider/model"
+}
+
+// deprecations is the table of announced model retirements, keyed like models
+var deprecations = map[string]Deprecation{
+	"anthropic/claude-3-opus":     {Shutdown: date(2026, 1, 5), Replacement: "anthropic/claude-opus-4-5"},
+	"anthropic/claude-3-5-sonnet": {Shutdown: date(2025, 10, 22), Replacement: "anthropic/claude-sonnet-4-5"},
+	"anthropic/claude-3-sonnet":   {Shutdown: date(2025, 7, 21), Replacement: "anthropic/claude-sonnet-4-5"},
+
+	"openai/gpt-4.5-preview": {Shutdown: date(2025, 7, 14), Replacement: "openai/gpt-4.1"},
+
+	"google/gemini-1.5-pro":   {Shutdown: date(2025, 9, 24), Replacement: "google/gemini-2.5-pro"},
+	"google/gemini-1.5-flash": {Shutdown: date(2025, 9, 24), Replacement: "google/gemini-2.5-flash"},
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// LookupModel returns the limits of a model given as "provider/model" or as an alias.
+// Models that are not in the table, or are newer than it, return false.
+func LookupModel(model string) (ModelInfo, bool) {
+	return matchModel(models, model)
+}
+
+// LookupDeprecation returns the scheduled shutdown of a model given as "provider/model"
+// or as an alias, false when none is announced
+func LookupDeprecation(model string) (Deprecation, bool) {
+	return matchModel(deprecations, model)
+}
+
+// matchModel finds the table entry of a model, ignoring the endpoint, date and variant suffixes
+func matchModel[T any](table map[string]T, model string) (T, bool) {
+	model, _, _ = splitEndpoint(resolveAlias(model))
+
+	// The longest matching entry wins, so gpt-5.2 is not taken for gpt-5
+	var info T
+	var matched string
+	for name, m := range table {
+		if len(name) > len(matched) && (model == name || strings.HasPrefix(model, name+"-") || strings.HasPrefix(model, name+".") || strings.HasPrefix(model, name+":")) {
+			info, matched = m, name
+		}
+	}
+	return info, matched != ""
+}
+
+// warnedDeprecations remembers the models already reported to each logger
+var warnedDeprecations sync.Map
+
+// warnDeprecated logs a warning, once per logger, when the model is scheduled for shutdown
+func warnDeprecated(logger *slog.Logger, model string) {
+	d, ok := LookupDeprecation(model)
+	if !ok {
+		return
+	}
+	key := struct {
+		logger *slog.Logger
+		model  string
+	}{logger, model}
+	if _, seen := warnedDeprecations.LoadOrStore(key, true); seen {
+		return
+	}
+
+	msg := "model is scheduled for shutdown"
+	if !d.Shutdown.After(time.Now()) {
+		msg = "model has been shut down"
+	}
+	logger.Warn(msg, "model", model, "shutdown", d.Shutdown.Format(time.DateOnly), "replacement", d.Replacement)
+}
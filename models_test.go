@@ -0,0 +1,221 @@
+package echo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLookupModel(t *testing.T) {
+	tests := []struct {
+		model     string
+		found     bool
+		maxOutput int
+	}{
+		{"anthropic/claude-sonnet-4-5", true, 64_000},
+		{"anthropic/claude-sonnet-4-5-20250929", true, 64_000},
+		{"anthropic/claude-3-haiku-20240307", true, 4_096},
+		{"anthropic/light", true, 64_000},
+		{"openai/gpt-5.2@eu", true, 128_000},
+		{"openai/gpt-4o-mini", true, 16_384},
+		{"openai/gpt-50", false, 0},
+		{"mock/any", false, 0},
+	}
+
+	for _, tt := range tests {
+		info, ok := LookupModel(tt.model)
+		if ok != tt.found || info.MaxOutputTokens != tt.maxOutput {
+			t.Errorf("LookupModel(%q) = %+v, %v", tt.model, info, ok)
+		}
+	}
+}
+
+func TestModelCost(t *testing.T) {
+	usage := Usage{PromptTokens: 1_000_000, CompletionTokens: 100_000}
+
+	// Aliases of smaller models don't inherit the prices of the larger ones
+	tests := []struct {
+		model string
+		cost  float64
+		known bool
+	}{
+		{"anthropic/claude-sonnet-4-5", 4.5, true},
+		{"openai/gpt-5", 2.25, true},
+		{"openai/gpt-5-mini", 0.45, true},
+		{"openai/light", 0.09, true},
+		{"mock/any", 0, false},
+	}
+	for _, tt := range tests {
+		info, _ := LookupModel(tt.model)
+		cost, ok := info.Cost(usage)
+		if ok != tt.known || math.Abs(cost-tt.cost) > 1e-9 {
+			t.Errorf("Cost(%q) = %v, %v, want %v", tt.model, cost, ok, tt.cost)
+		}
+	}
+}
+
+func TestAliasEnvironmentOverride(t *testing.T) {
+	t.Setenv("ECHO_ALIAS_OPENAI_BEST", "anthropic/light")
+	t.Setenv("ECHO_ALIAS_BEST", "mock/override")
+
+	if got := resolveAlias("openai/best"); got != "anthropic/claude-haiku-4-5" {
+		t.Errorf("resolveAlias(openai/best) = %q", got)
+	}
+	if got := resolveAlias("google/best"); got != "google/gemini-2.5-pro" {
+		t.Errorf("resolveAlias(google/best) = %q", got)
+	}
+
+	client, err := NewCommonClient(nil, WithModel("best"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	resp, err := client.Complete(context.Background(), QuickMessage("Hi"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Text == "" {
+		t.Error("Expected a response from the mock provider")
+	}
+}
+
+func TestDeprecationWarning(t *testing.T) {
+	d, ok := LookupDeprecation("anthropic/claude-3-5-sonnet-20241022")
+	if !ok || d.Replacement != "anthropic/claude-sonnet-4-5" {
+		t.Errorf("LookupDeprecation() = %+v, %v", d, ok)
+	}
+	if _, ok := LookupDeprecation("anthropic/claude-sonnet-4-5"); ok {
+		t.Error("Expected no deprecation for a current model")
+	}
+
+	deprecations["mock/old"] = Deprecation{Shutdown: date(2099, 1, 1), Replacement: "mock/new"}
+	defer delete(deprecations, "mock/old")
+
+	var buf bytes.Buffer
+	client, _ := NewCommonClient(nil, WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+	for range 2 {
+		if _, err := client.Complete(context.Background(), QuickMessage("Hi"), WithModel("mock/old")); err != nil {
+			t.Fatalf("Complete() error = %v", err)
+		}
+	}
+	if out := buf.String(); strings.Count(out, "level=WARN") != 1 || !strings.Contains(out, "shutdown=2099-01-01 replacement=mock/new") {
+		t.Errorf("Expected a single deprecation warning, got %q", out)
+	}
+}
+
+func TestAnthropicBeta(t *testing.T) {
+	var beta string
+	var maxTokens int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		beta = r.Header.Get("anthropic-beta")
+		var body AnthropicRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		maxTokens = body.MaxTokens
+		w.Write([]byte(`{"content": [{"type": "text", "text": "ok"}]}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewCommonClient(map[string]string{"anthropic": "key"},
+		WithModel("anthropic/claude-sonnet-4-5"),
+		WithBaseURL(server.URL),
+		WithAnthropicBeta(AnthropicBetaContext1M),
+	)
+
+	ctx := context.Background()
+	if _, err := client.Complete(ctx, QuickMessage("Hi")); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if beta != AnthropicBetaContext1M || maxTokens != 64_000 {
+		t.Errorf("Unexpected request: beta %q, max_tokens %d", beta, maxTokens)
+	}
+
+	models["anthropic/claude-small"] = ModelInfo{ContextWindow: 100_000, MaxOutputTokens: 1024, ReasoningEfforts: effortsLowMediumHigh}
+	defer delete(models, "anthropic/claude-small")
+
+	_, err := client.Complete(ctx, QuickMessage("Hi"),
+		WithModel("anthropic/claude-small-1"),
+		WithReasoningEffort("low"),
+		WithAnthropicBeta("prompt-caching-2024-07-31", AnthropicBetaContext1M),
+	)
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if beta != "effort-2025-11-24,context-1m-2025-08-07,prompt-caching-2024-07-31" {
+		t.Errorf("Unexpected beta header: %q", beta)
+	}
+	if maxTokens != 1024 {
+		t.Errorf("Expected the default to fit the model limit, got %d", maxTokens)
+	}
+
+	// Unknown models fall back to 4096
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithModel("anthropic/claude-next")); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if maxTokens != 4096 {
+		t.Errorf("Expected the fallback default, got %d", maxTokens)
+	}
+
+	// The automatic budget is what is left of the context window after the prompt
+	long := QuickMessage(strings.Repeat("abcd", 80_000))
+	if _, err := client.Complete(ctx, long, WithModel("anthropic/claude-small"), WithMaxTokensAuto()); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if maxTokens != 1024 {
+		t.Errorf("Expected the budget capped by the output limit, got %d", maxTokens)
+	}
+	models["anthropic/claude-small"] = ModelInfo{ContextWindow: 100_000, MaxOutputTokens: 64_000}
+	if _, err := client.Complete(ctx, long, WithModel("anthropic/claude-small"), WithMaxTokensAuto()); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if want := 100_000 - 80_004 - 8_000; maxTokens != want {
+		t.Errorf("Expected the remaining budget %d, got %d", want, maxTokens)
+	}
+	if _, err := client.Complete(ctx, QuickMessage(strings.Repeat("abcd", 100_000)), WithModel("anthropic/claude-small"), WithMaxTokensAuto()); err == nil {
+		t.Error("Expected an error for a prompt larger than the context window")
+	}
+}
+
+func TestReasoningEffort(t *testing.T) {
+	var effort any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		effort = body["reasoning_effort"]
+		w.Write([]byte(`{"choices": [{"message": {"content": "ok"}}]}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewCommonClient(map[string]string{"openai": "key"}, WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	tests := []struct {
+		model  string
+		effort string
+		strict bool
+		sent   any
+		valid  bool
+	}{
+		{"openai/o4-mini", "high", false, "high", true},
+		{"openai/gpt-5-mini", "minimal", false, "minimal", true},
+		{"openai/o3", "minimal", false, nil, false},
+		{"openai/gpt-4o", "high", false, nil, true},
+		{"openai/gpt-4o", "high", true, nil, false},
+		{"openai/gpt-next", "high", true, "high", true},
+	}
+	for _, tt := range tests {
+		effort = nil
+		opts := []CallOption{WithModel(tt.model), WithReasoningEffort(tt.effort)}
+		if tt.strict {
+			opts = append(opts, WithStrictOptions())
+		}
+		_, err := client.Complete(ctx, QuickMessage("Hi"), opts...)
+		if (err == nil) != tt.valid || effort != tt.sent {
+			t.Errorf("%s %s (strict %v): sent %v, error %v", tt.model, tt.effort, tt.strict, effort, err)
+		}
+	}
+}
@@ -1,13 +1,13 @@
 package echo
 
 import (
-	"bufio"
-	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
+	"maps"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -25,15 +25,72 @@ type OpenAIError struct {
 type OpenAIRequest struct {
 	Model         string          `json:"model"`
 	Temperature   *float32        `json:"temperature,omitempty"`
+	TopP          *float32        `json:"top_p,omitempty"`
+	TopK          *int            `json:"top_k,omitempty"` // OpenRouter only
+	FreqPenalty   *float32        `json:"frequency_penalty,omitempty"`
+	PresPenalty   *float32        `json:"presence_penalty,omitempty"`
+	Seed          *int            `json:"seed,omitempty"`
+	N             int             `json:"n,omitempty"`
 	MaxTokens     *int            `json:"max_completion_tokens,omitempty"`
 	Messages      []OpenAIMessage `json:"messages"`
 	Stream        bool            `json:"stream,omitempty"`
 	StreamOptions *struct {
 		IncludeUsage bool `json:"include_usage"`
 	} `json:"stream_options,omitempty"`
-	Provider        *OpenRouterProvider   `json:"provider,omitempty"`
-	ResponseFormat  *OpenAIResponseFormat `json:"response_format,omitempty"`
-	ReasoningEffort string                `json:"reasoning_effort,omitempty"`
+	Provider        *OpenRouterProvider    `json:"provider,omitempty"`
+	ResponseFormat  *OpenAIResponseFormat  `json:"response_format,omitempty"`
+	ReasoningEffort string                 `json:"reasoning_effort,omitempty"`
+	Modalities      []string               `json:"modalities,omitempty"`
+	Audio           *OpenAIAudioConfig     `json:"audio,omitempty"`
+	Logprobs        bool                   `json:"logprobs,omitempty"`
+	Tools           []OpenAITool           `json:"tools,omitempty"`
+	ToolChoice      any                    `json:"tool_choice,omitempty"` // "auto", "none", "required" or a function
+	LogitBias       map[string]float64     `json:"logit_bias,omitempty"`
+	Usage           *OpenRouterUsageOption `json:"usage,omitempty"`
+	Transforms      *[]string              `json:"transforms,omitempty"` // OpenRouter, an empty list disables the default transforms
+	User            string                 `json:"user,omitempty"`
+	Metadata        map[string]string      `json:"metadata,omitempty"` // OpenAI only
+}
+
+// OpenRouterUsageOption asks OpenRouter to include the cost in the usage of the response
+type OpenRouterUsageOption struct {
+	Include bool `json:"include"`
+}
+
+// OpenAITool declares a function the model may call
+type OpenAITool struct {
+	Type     string         `json:"type"` // "function"
+	Function OpenAIFunction `json:"function"`
+}
+
+// OpenAIFunction is the name and JSON schema of a callable function
+type OpenAIFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+// OpenAIToolCall is a function call requested in an assistant message
+type OpenAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"` // "function"
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"` // JSON encoded as a string
+	} `json:"function"`
+}
+
+// OpenAIAudioConfig selects the voice and format of audio output
+type OpenAIAudioConfig struct {
+	Voice  string `json:"voice"`
+	Format string `json:"format"`
+}
+
+// OpenAIAudio is the audio part of a response message or stream delta
+type OpenAIAudio struct {
+	ID         string `json:"id,omitempty"`
+	Data       string `json:"data,omitempty"` // base64 encoded
+	Transcript string `json:"transcript,omitempty"`
 }
 
 // OpenAIResponseFormat specifies the format for model output
@@ -51,22 +108,279 @@ type OpenAIJSONSchemaConfig struct {
 
 // OpenAIMessage represents a message in OpenAI format
 type OpenAIMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string              `json:"role"`
+	Content    string              `json:"content"`
+	Parts      []OpenAIContentPart `json:"-"` // when set, sent as the content array instead of Content
+	ToolCalls  []OpenAIToolCall    `json:"tool_calls,omitempty"`
+	ToolCallID string              `json:"tool_call_id,omitempty"` // call answered by a "tool" message
+}
+
+// OpenAIContentPart is a single item of an array message content
+type OpenAIContentPart struct {
+	Type       string            `json:"type"`
+	Text       string            `json:"text,omitempty"`
+	ImageURL   *OpenAIImageURL   `json:"image_url,omitempty"`
+	InputAudio *OpenAIInputAudio `json:"input_audio,omitempty"`
+}
+
+// OpenAIInputAudio holds base64 encoded audio of a user message
+type OpenAIInputAudio struct {
+	Data   string `json:"data"`
+	Format string `json:"format"` // "wav" or "mp3"
+}
+
+// OpenAIImageURL references an image by URL or data: URL
+type OpenAIImageURL struct {
+	URL string `json:"url"`
+}
+
+// MarshalJSON writes the content as a string or as an array of parts
+func (m OpenAIMessage) MarshalJSON() ([]byte, error) {
+	var content any = m.Content
+	if len(m.Parts) > 0 {
+		content = m.Parts
+	}
+	return json.Marshal(struct {
+		Role       string           `json:"role"`
+		Content    any              `json:"content"`
+		ToolCalls  []OpenAIToolCall `json:"tool_calls,omitempty"`
+		ToolCallID string           `json:"tool_call_id,omitempty"`
+	}{m.Role, content, m.ToolCalls, m.ToolCallID})
+}
+
+// UnmarshalJSON accepts both string and array content; text of array parts is joined into Content
+func (m *OpenAIMessage) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Role       string           `json:"role"`
+		Content    json.RawMessage  `json:"content"`
+		ToolCalls  []OpenAIToolCall `json:"tool_calls"`
+		ToolCallID string           `json:"tool_call_id"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	m.Role = raw.Role
+	m.Content = ""
+	m.Parts = nil
+	m.ToolCalls = raw.ToolCalls
+	m.ToolCallID = raw.ToolCallID
+	if len(raw.Content) == 0 || string(raw.Content) == "null" {
+		return nil
+	}
+	if raw.Content[0] == '"' {
+		return json.Unmarshal(raw.Content, &m.Content)
+	}
+
+	if err := json.Unmarshal(raw.Content, &m.Parts); err != nil {
+		return err
+	}
+	var texts []string
+	for _, part := range m.Parts {
+		if part.Type == "text" {
+			texts = append(texts, part.Text)
+		}
+	}
+	m.Content = strings.Join(texts, "\n")
+	return nil
+}
+
+// checkOpenAIMetadata verifies the limits OpenAI puts on completion metadata
+func checkOpenAIMetadata(metadata map[string]string) error {
+	if len(metadata) > 16 {
+		return fmt.Errorf("request metadata has %d pairs, OpenAI accepts up to 16", len(metadata))
+	}
+	for k, v := range metadata {
+		if len(k) > 64 || len(v) > 512 {
+			return fmt.Errorf("request metadata %q is too long, OpenAI accepts keys up to 64 and values up to 512 characters", k)
+		}
+	}
+	return nil
+}
+
+// checkLogitBias verifies that keys are token IDs and biases are within -100..100
+func checkLogitBias(bias map[string]float64) error {
+	for token, value := range bias {
+		if _, err := strconv.Atoi(token); err != nil {
+			return fmt.Errorf("logit bias key %q is not a token ID", token)
+		}
+		if value < -100 || value > 100 {
+			return fmt.Errorf("logit bias of token %s is out of the -100..100 range", token)
+		}
+	}
+	return nil
+}
+
+// openAIAudioFormat maps a mime type to the input_audio format name
+func openAIAudioFormat(mimeType string) (string, error) {
+	switch mimeType {
+	case "audio/mpeg", "audio/mp3":
+		return "mp3", nil
+	case "audio/wav", "audio/wave", "audio/x-wav":
+		return "wav", nil
+	default:
+		return "", fmt.Errorf("unsupported audio format %q, OpenAI accepts wav and mp3", mimeType)
+	}
+}
+
+// toOpenAIMessage converts a message to OpenAI format, using array content when it has media parts
+func toOpenAIMessage(role string, msg Message) (OpenAIMessage, error) {
+	out := OpenAIMessage{Role: role, Content: msg.Content}
+	if len(msg.Parts) == 0 {
+		return out, nil
+	}
+
+	if msg.Content != "" {
+		out.Parts = append(out.Parts, OpenAIContentPart{Type: "text", Text: msg.Content})
+	}
+	for _, part := range msg.Parts {
+		switch {
+		case part.Type == PartText:
+			out.Parts = append(out.Parts, OpenAIContentPart{Type: "text", Text: part.Text})
+		case part.Type == PartAudio:
+			if len(part.Data) == 0 {
+				return out, fmt.Errorf("audio parts must contain inline data")
+			}
+			format, err := openAIAudioFormat(part.MimeType)
+			if err != nil {
+				return out, err
+			}
+			out.Parts = append(out.Parts, OpenAIContentPart{Type: "input_audio", InputAudio: &OpenAIInputAudio{
+				Data:   base64.StdEncoding.EncodeToString(part.Data),
+				Format: format,
+			}})
+		case part.Type == PartDocument:
+			return out, fmt.Errorf("document parts are not supported, use an Anthropic or Google model")
+		case part.Type != PartImage:
+			return out, fmt.Errorf("%s parts are not supported", part.Type)
+		case len(part.Data) > 0:
+			out.Parts = append(out.Parts, OpenAIContentPart{Type: "image_url", ImageURL: &OpenAIImageURL{URL: part.dataURL()}})
+		case part.URL != "":
+			out.Parts = append(out.Parts, OpenAIContentPart{Type: "image_url", ImageURL: &OpenAIImageURL{URL: part.URL}})
+		default:
+			return out, fmt.Errorf("empty %s part", part.Type)
+		}
+	}
+	return out, nil
+}
+
+// toOpenAIAgentMessage converts an agent message with its tool calls to an assistant message
+func toOpenAIAgentMessage(msg Message) OpenAIMessage {
+	out := OpenAIMessage{Role: "assistant", Content: msg.Content}
+	for _, call := range msg.ToolCalls {
+		tc := OpenAIToolCall{ID: call.ID, Type: "function"}
+		tc.Function.Name = call.Name
+		tc.Function.Arguments = string(toolArguments(call))
+		out.ToolCalls = append(out.ToolCalls, tc)
+	}
+	return out
+}
+
+// openAITools converts tool declarations to OpenAI format
+func openAITools(tools []Tool) []OpenAITool {
+	var out []OpenAITool
+	for _, t := range tools {
+		out = append(out, OpenAITool{Type: "function", Function: OpenAIFunction{
+			Name:        t.Name,
+			Description: t.Description,
+			Parameters:  t.Parameters,
+		}})
+	}
+	return out
+}
+
+// openAIToolChoice converts the tool choice to a mode string or a named function
+func openAIToolChoice(choice string) any {
+	switch choice {
+	case "":
+		return nil
+	case ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired:
+		return choice
+	}
+	return map[string]any{"type": "function", "function": map[string]string{"name": choice}}
+}
+
+// fromOpenAIToolCalls converts the tool calls of a response message
+func fromOpenAIToolCalls(calls []OpenAIToolCall) []ToolCall {
+	var out []ToolCall
+	for _, call := range calls {
+		out = append(out, ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: json.RawMessage(call.Function.Arguments),
+		})
+	}
+	return out
 }
 
 type OpenAIResponse struct {
-	Error   *OpenAIError `json:"error,omitempty"`
-	Choices []struct {
+	Error             *OpenAIError `json:"error,omitempty"`
+	ID                string       `json:"id,omitempty"`
+	Model             string       `json:"model,omitempty"`
+	Provider          string       `json:"provider,omitempty"` // OpenRouter: upstream provider that served the call
+	SystemFingerprint string       `json:"system_fingerprint,omitempty"`
+	Choices           []struct {
 		Message struct {
-			Content string `json:"content"`
+			Content   string           `json:"content"`
+			Audio     *OpenAIAudio     `json:"audio,omitempty"`
+			ToolCalls []OpenAIToolCall `json:"tool_calls,omitempty"`
 		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+		Logprobs     *struct {
+			Content []struct {
+				Logprob float64 `json:"logprob"`
+			} `json:"content"`
+		} `json:"logprobs,omitempty"`
 	} `json:"choices"`
-	Usage *struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage,omitempty"`
+	Usage *OpenAIUsage `json:"usage,omitempty"`
+}
+
+// OpenAIUsage is the token usage of a completion. OpenRouter adds the cost of the call
+// and counts tokens with the tokenizer of the upstream model.
+type OpenAIUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+	PromptTokensDetails *struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details,omitempty"`
+	CompletionTokensDetails *struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details,omitempty"`
+	Cost *float64 `json:"cost,omitempty"` // OpenRouter: credits spent on the call, in USD
+}
+
+// metadata returns the usage as response metadata
+func (u *OpenAIUsage) metadata() Metadata {
+	meta := Metadata{
+		"total_tokens":      u.TotalTokens,
+		"prompt_tokens":     u.PromptTokens,
+		"completion_tokens": u.CompletionTokens,
+	}
+	if d := u.PromptTokensDetails; d != nil && d.CachedTokens > 0 {
+		meta["cached_tokens"] = d.CachedTokens
+	}
+	if d := u.CompletionTokensDetails; d != nil && d.ReasoningTokens > 0 {
+		meta["reasoning_tokens"] = d.ReasoningTokens
+	}
+	if u.Cost != nil {
+		meta["cost"] = *u.Cost
+	}
+	return meta
+}
+
+// openRouterMeta adds the upstream provider and the generation ID of OpenRouter calls,
+// the ID is the key of OpenAIProvider.Generation
+func openRouterMeta(meta Metadata, cfg CallConfig, id, provider string) {
+	if cfg.provider != "openrouter" {
+		return
+	}
+	if id != "" {
+		meta["generation_id"] = id
+	}
+	if provider != "" {
+		meta["upstream_provider"] = provider
+	}
 }
 
 // OpenAIProvider is a stateless provider for OpenAI API
@@ -90,7 +404,6 @@ func prepareOpenAIRequest(messages []Message, streaming bool, cfg CallConfig) (O
 
 	// Convert messages to OpenAI format
 	openaiMessages := []OpenAIMessage{}
-	systemMessageProcessed := false
 
 	for _, msg := range messages {
 		switch msg.Role {
@@ -102,42 +415,50 @@ func prepareOpenAIRequest(messages []Message, streaming bool, cfg CallConfig) (O
 					Content: msg.Content,
 				})
 			}
-			systemMessageProcessed = true
 		case User:
-			openaiMessages = append(openaiMessages, OpenAIMessage{
-				Role:    "user",
-				Content: msg.Content,
-			})
+			userMsg, err := toOpenAIMessage("user", msg)
+			if err != nil {
+				return OpenAIRequest{}, err
+			}
+			openaiMessages = append(openaiMessages, userMsg)
 		case Agent:
+			openaiMessages = append(openaiMessages, toOpenAIAgentMessage(msg))
+		case ToolResult:
 			openaiMessages = append(openaiMessages, OpenAIMessage{
-				Role:    "assistant",
-				Content: msg.Content,
+				Role:       "tool",
+				Content:    msg.Content,
+				ToolCallID: msg.ToolCallID,
 			})
 		}
 	}
 
-	// Handle WithSystemMessage option
+	// Handle WithSystemMessage option, the chain system message was skipped above
 	if cfg.SystemMsg != "" {
-		// Insert system message at the beginning
 		systemMsg := OpenAIMessage{
 			Role:    "system",
 			Content: cfg.SystemMsg,
 		}
-		if systemMessageProcessed {
-			// Replace the first message (which should be system)
-			openaiMessages = append([]OpenAIMessage{systemMsg}, openaiMessages[1:]...)
-		} else {
-			// Add system message at the beginning
-			openaiMessages = append([]OpenAIMessage{systemMsg}, openaiMessages...)
-		}
+		openaiMessages = append([]OpenAIMessage{systemMsg}, openaiMessages...)
 	}
 
 	req := OpenAIRequest{
 		Model:       cfg.Model,
 		Temperature: cfg.Temperature,
+		TopP:        cfg.TopP,
+		FreqPenalty: cfg.FrequencyPenalty,
+		PresPenalty: cfg.PresencePenalty,
+		Seed:        cfg.Seed,
+		N:           cfg.N,
 		MaxTokens:   cfg.MaxTokens,
 		Messages:    openaiMessages,
 		Stream:      streaming,
+		Tools:       openAITools(cfg.Tools),
+		ToolChoice:  openAIToolChoice(cfg.ToolChoice),
+	}
+
+	// Token probabilities for WithConfidenceScore
+	if cfg.ConfidenceScore && !streaming && logprobModel(cfg) {
+		req.Logprobs = true
 	}
 
 	// Add stream options for usage stats when streaming
@@ -151,7 +472,12 @@ func prepareOpenAIRequest(messages []Message, streaming bool, cfg CallConfig) (O
 
 	// Add provider field if EndPoint is set (for openrouter compatibility)
 	if cfg.EndPoint != "" {
-		order := strings.Split(cfg.EndPoint, ",")
+		var order []string
+		for _, name := range strings.Split(cfg.EndPoint, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				order = append(order, name)
+			}
+		}
 		req.Provider = &OpenRouterProvider{
 			Only:           order,
 			Order:          order,
@@ -171,14 +497,73 @@ func prepareOpenAIRequest(messages []Message, streaming bool, cfg CallConfig) (O
 		}
 	}
 
+	// Loose JSON output when no schema is given
+	if cfg.JSONMode && cfg.StructuredOutput == nil {
+		req.ResponseFormat = &OpenAIResponseFormat{Type: "json_object"}
+	}
+
+	if len(cfg.LogitBias) > 0 {
+		if err := checkLogitBias(cfg.LogitBias); err != nil {
+			return OpenAIRequest{}, err
+		}
+		req.LogitBias = cfg.LogitBias
+	}
+
+	if cfg.ThinkingBudget > 0 {
+		return OpenAIRequest{}, fmt.Errorf("thinking budget is not supported by OpenAI, use WithReasoningEffort")
+	}
+	if cfg.TopK != nil && cfg.provider != "openrouter" {
+		return OpenAIRequest{}, fmt.Errorf("top k is not supported by OpenAI, use WithTopP")
+	}
+
+	// Attribution of the call, OpenRouter takes the metadata as headers
+	req.User = cfg.UserID
+	if len(cfg.RequestMetadata) > 0 && cfg.provider != "openrouter" {
+		if err := checkOpenAIMetadata(cfg.RequestMetadata); err != nil {
+			return OpenAIRequest{}, err
+		}
+		req.Metadata = cfg.RequestMetadata
+	}
+
+	// OpenRouter reports the cost of the call in the usage
+	if cfg.provider == "openrouter" {
+		req.Usage = &OpenRouterUsageOption{Include: true}
+		req.TopK = cfg.TopK
+		req.Model = openRouterModel(cfg.Model, cfg.ModelVariant)
+		if cfg.Transforms != nil {
+			req.Transforms = &cfg.Transforms
+		}
+	}
+
 	// Add reasoning effort if configured (for o1 models)
 	if cfg.ReasoningEffort != "" {
 		req.ReasoningEffort = cfg.ReasoningEffort
 	}
 
+	// Request spoken output from audio models
+	if cfg.AudioOutput != nil {
+		req.Modalities = []string{"text", "audio"}
+		req.Audio = &OpenAIAudioConfig{Voice: cfg.AudioOutput.Voice, Format: cfg.AudioOutput.Format}
+		if req.Audio.Voice == "" {
+			req.Audio.Voice = "alloy"
+		}
+		if req.Audio.Format == "" {
+			req.Audio.Format = "wav"
+			if streaming {
+				req.Audio.Format = "pcm16"
+			}
+		}
+	}
+
 	return req, nil
 }
 
+// validateCompletion implements the provider interface
+func (p *OpenAIProvider) validateCompletion(messages []Message, cfg CallConfig) error {
+	_, err := prepareOpenAIRequest(messages, false, cfg)
+	return err
+}
+
 // call implements the provider interface for OpenAI
 func (p *OpenAIProvider) call(ctx context.Context, messages []Message, cfg CallConfig) (*Response, error) {
 	body, err := prepareOpenAIRequest(messages, false, cfg)
@@ -193,8 +578,9 @@ func (p *OpenAIProvider) call(ctx context.Context, messages []Message, cfg CallC
 	}
 
 	resp := OpenAIResponse{}
-	err = callHTTPAPI(ctx, baseURL, func(req *http.Request) {
-		req.Header.Set("Authorization", "Bearer "+p.Key)
+	err = callHTTPAPI(ctx, cfg, baseURL, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+cfg.apiKey(p.Key))
+		openRouterHeaders(req, cfg)
 	}, body, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("OpenAI API call failed: %w", err)
@@ -211,16 +597,51 @@ func (p *OpenAIProvider) call(ctx context.Context, messages []Message, cfg CallC
 	}
 
 	response := &Response{
-		Text: resp.Choices[0].Message.Content,
+		Text:      resp.Choices[0].Message.Content,
+		Model:     resp.Model,
+		ToolCalls: fromOpenAIToolCalls(resp.Choices[0].Message.ToolCalls),
+	}
+
+	// Audio models return the text as a transcript of the spoken answer
+	if audio := resp.Choices[0].Message.Audio; audio != nil {
+		data, err := base64.StdEncoding.DecodeString(audio.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode audio: %w", err)
+		}
+		response.Audio = data
+		if response.Text == "" {
+			response.Text = audio.Transcript
+		}
+	}
+
+	response.Metadata = Metadata{
+		"finish_reason": resp.Choices[0].FinishReason,
+	}
+	if len(resp.Choices) > 1 {
+		for _, c := range resp.Choices {
+			response.Choices = append(response.Choices, Choice{
+				Text:         c.Message.Content,
+				ToolCalls:    fromOpenAIToolCalls(c.Message.ToolCalls),
+				FinishReason: c.FinishReason,
+			})
+		}
 	}
 
 	// Add metadata if usage information is available
 	if resp.Usage != nil {
-		response.Metadata = Metadata{
-			"total_tokens":      resp.Usage.TotalTokens,
-			"prompt_tokens":     resp.Usage.PromptTokens,
-			"completion_tokens": resp.Usage.CompletionTokens,
+		maps.Copy(response.Metadata, resp.Usage.metadata())
+	}
+	openRouterMeta(response.Metadata, cfg, resp.ID, resp.Provider)
+	if resp.SystemFingerprint != "" {
+		response.Metadata["system_fingerprint"] = resp.SystemFingerprint
+	}
+
+	if lp := resp.Choices[0].Logprobs; lp != nil && len(lp.Content) > 0 {
+		logprobs := make([]float64, len(lp.Content))
+		for i, token := range lp.Content {
+			logprobs[i] = token.Logprob
 		}
+		response.Metadata["logprob_confidence"] = logprobConfidence(logprobs)
 	}
 
 	return response, nil
@@ -228,16 +649,17 @@ func (p *OpenAIProvider) call(ctx context.Context, messages []Message, cfg CallC
 
 // Streaming response structures
 type OpenAIStreamResponse struct {
-	Choices []struct {
+	ID                string `json:"id,omitempty"`
+	Provider          string `json:"provider,omitempty"`
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+	Choices           []struct {
 		Delta struct {
-			Content string `json:"content"`
+			Content string       `json:"content"`
+			Audio   *OpenAIAudio `json:"audio,omitempty"`
 		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
-	Usage *struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage,omitempty"`
+	Usage *OpenAIUsage `json:"usage,omitempty"`
 }
 
 // streamCall implements the provider interface for OpenAI streaming
@@ -254,8 +676,9 @@ func (p *OpenAIProvider) streamCall(ctx context.Context, messages []Message, cfg
 	}
 
 	// Get streaming response
-	respBody, err := streamHTTPAPI(ctx, baseURL, func(req *http.Request) {
-		req.Header.Set("Authorization", "Bearer "+p.Key)
+	respBody, err := streamHTTPAPI(ctx, cfg, baseURL, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+cfg.apiKey(p.Key))
+		openRouterHeaders(req, cfg)
 	}, body)
 	if err != nil {
 		return nil, fmt.Errorf("OpenAI streaming API call failed: %w", err)
@@ -267,62 +690,58 @@ func (p *OpenAIProvider) streamCall(ctx context.Context, messages []Message, cfg
 	// Start goroutine to process stream
 	go func() {
 		defer close(ch)
-		defer respBody.Close()
-
-		reader := bufio.NewReader(respBody)
-		for {
-			line, err := reader.ReadBytes('\n')
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				ch <- StreamChunk{Error: fmt.Errorf("read error: %w", err)}
-				return
-			}
-
-			// Skip empty lines
-			line = bytes.TrimSpace(line)
-			if len(line) == 0 {
-				continue
-			}
-
-			// Check for SSE data prefix
-			if !bytes.HasPrefix(line, dataPrefix) {
-				continue
-			}
+		defer closeOnDone(ctx, respBody)()
+		out := chunkSender{ctx: ctx, ch: ch}
 
-			// Remove "data: " prefix
-			data := bytes.TrimPrefix(line, dataPrefix)
-
-			// Check for end of stream
-			if bytes.Equal(data, doneMarker) {
-				return
-			}
-
-			// Parse JSON
+		err := parseSSEData(respBody, func(data []byte) error {
 			var streamResp OpenAIStreamResponse
 			if err := json.Unmarshal(data, &streamResp); err != nil {
-				ch <- StreamChunk{Error: fmt.Errorf("json parse error: %w", err)}
-				return
+				return fmt.Errorf("json parse error: %w", err)
+			}
+
+			// The last chunk with a choice carries the finish reason
+			if len(streamResp.Choices) > 0 && streamResp.Choices[0].FinishReason != "" {
+				meta := Metadata{"finish_reason": streamResp.Choices[0].FinishReason}
+				if err := out.send(StreamChunk{Meta: &meta}); err != nil {
+					return err
+				}
 			}
 
 			// Check if this is a usage chunk (has usage data but no choices)
 			if streamResp.Usage != nil && len(streamResp.Choices) == 0 {
 				// Send metadata chunk
-				meta := Metadata{
-					"total_tokens":      streamResp.Usage.TotalTokens,
-					"prompt_tokens":     streamResp.Usage.PromptTokens,
-					"completion_tokens": streamResp.Usage.CompletionTokens,
+				meta := streamResp.Usage.metadata()
+				openRouterMeta(meta, cfg, streamResp.ID, streamResp.Provider)
+				if streamResp.SystemFingerprint != "" {
+					meta["system_fingerprint"] = streamResp.SystemFingerprint
 				}
-				ch <- StreamChunk{
+				return out.send(StreamChunk{
 					Meta: &meta,
+				})
+			} else if len(streamResp.Choices) > 0 && streamResp.Choices[0].Delta.Audio != nil {
+				// Audio chunk, the transcript goes along as text
+				audio := streamResp.Choices[0].Delta.Audio
+				data, err := base64.StdEncoding.DecodeString(audio.Data)
+				if err != nil {
+					return fmt.Errorf("failed to decode audio: %w", err)
+				}
+				if len(data) > 0 || audio.Transcript != "" {
+					return out.send(StreamChunk{
+						Data:  audio.Transcript,
+						Audio: data,
+					})
 				}
 			} else if len(streamResp.Choices) > 0 && streamResp.Choices[0].Delta.Content != "" {
 				// Normal content chunk
-				ch <- StreamChunk{
+				return out.send(StreamChunk{
 					Data: streamResp.Choices[0].Delta.Content,
-				}
+				})
 			}
+			return nil
+		})
+
+		if err != nil {
+			out.fail(err)
 		}
 	}()
 
@@ -367,8 +786,8 @@ func (p *OpenAIProvider) getEmbeddings(ctx context.Context, text string, cfg Cal
 	}
 
 	resp := OpenAIEmbeddingResponse{}
-	err := callHTTPAPI(ctx, baseURL, func(req *http.Request) {
-		req.Header.Set("Authorization", "Bearer "+p.Key)
+	err := callHTTPAPI(ctx, cfg, baseURL, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+cfg.apiKey(p.Key))
 	}, body, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("OpenAI embedding API call failed: %w", err)
@@ -399,6 +818,12 @@ func (p *OpenAIProvider) getEmbeddings(ctx context.Context, text string, cfg Cal
 	return response, nil
 }
 
+// getMultimodalEmbeddings implements the provider interface for OpenAI
+// Note: OpenAI does not support multimodal embeddings
+func (p *OpenAIProvider) getMultimodalEmbeddings(ctx context.Context, parts []Part, cfg CallConfig) (*EmbeddingResponse, error) {
+	return nil, fmt.Errorf("OpenAI does not support multimodal embeddings")
+}
+
 // reRank implements the provider interface for OpenAI
 // Note: OpenAI does not currently support reranking API
 func (p *OpenAIProvider) reRank(ctx context.Context, query string, documents []string, cfg CallConfig) (*RerankResponse, error) {
@@ -451,8 +876,8 @@ func (p *OpenAIProvider) buildCompletionRequest(ctx context.Context, req *Comple
 
 	// Make the API call
 	var openaiResp OpenAIResponse
-	err := callHTTPAPI(ctx, baseURL, func(httpReq *http.Request) {
-		httpReq.Header.Set("Authorization", "Bearer "+p.Key)
+	err := callHTTPAPI(ctx, cfg, baseURL, func(httpReq *http.Request) {
+		httpReq.Header.Set("Authorization", "Bearer "+cfg.apiKey(p.Key))
 	}, openaiReq, &openaiResp)
 	if err != nil {
 		return nil, fmt.Errorf("OpenAI API call failed: %w", err)
@@ -484,7 +909,7 @@ func (p *OpenAIProvider) buildCompletionRequest(ctx context.Context, req *Comple
 		completionResp.Choices[i].Index = i
 		completionResp.Choices[i].Message.Role = "assistant"
 		completionResp.Choices[i].Message.Content = choice.Message.Content
-		completionResp.Choices[i].FinishReason = "stop"
+		completionResp.Choices[i].FinishReason = choice.FinishReason
 	}
 
 	// Copy usage if available
@@ -523,8 +948,8 @@ func (p *OpenAIProvider) buildEmbeddingRequest(ctx context.Context, req *Embeddi
 	}
 
 	var openaiResp OpenAIEmbeddingResponse
-	err := callHTTPAPI(ctx, baseURL, func(httpReq *http.Request) {
-		httpReq.Header.Set("Authorization", "Bearer "+p.Key)
+	err := callHTTPAPI(ctx, cfg, baseURL, func(httpReq *http.Request) {
+		httpReq.Header.Set("Authorization", "Bearer "+cfg.apiKey(p.Key))
 	}, body, &openaiResp)
 	if err != nil {
 		return nil, fmt.Errorf("OpenAI embedding API call failed: %w", err)
@@ -579,6 +1004,11 @@ func (p *OpenAIProvider) writeCompletionResponse(w http.ResponseWriter, resp *Co
 	return json.NewEncoder(w).Encode(resp)
 }
 
+// writeCompletionStream writes a streamed completion as OpenAI chunk events
+func (p *OpenAIProvider) writeCompletionStream(w http.ResponseWriter, model string, stream *StreamResponse) error {
+	return writeOpenAIStream(w, model, stream)
+}
+
 // writeEmbeddingResponse writes a UnifiedEmbeddingResponse as JSON to the HTTP response writer
 func (p *OpenAIProvider) writeEmbeddingResponse(w http.ResponseWriter, resp *UnifiedEmbeddingResponse) error {
 	w.Header().Set("Content-Type", "application/json")
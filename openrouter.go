@@ -0,0 +1,99 @@
+package echo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// OpenRouterGeneration is the accounting record OpenRouter keeps for a call
+type OpenRouterGeneration struct {
+	ID                     string  `json:"id"`
+	Model                  string  `json:"model"`
+	ProviderName           string  `json:"provider_name"` // upstream provider that served the call
+	TotalCost              float64 `json:"total_cost"`    // in USD
+	TokensPrompt           int     `json:"tokens_prompt"`
+	TokensCompletion       int     `json:"tokens_completion"`
+	NativeTokensPrompt     int     `json:"native_tokens_prompt"` // counted with the tokenizer of the upstream model
+	NativeTokensCompletion int     `json:"native_tokens_completion"`
+	NativeTokensReasoning  int     `json:"native_tokens_reasoning"`
+	Latency                int     `json:"latency"`         // milliseconds until the first token
+	GenerationTime         int     `json:"generation_time"` // milliseconds
+	FinishReason           string  `json:"finish_reason"`
+}
+
+// Generation fetches the OpenRouter record of a call by the "generation_id" metadata of its response:
+//
+//	gen, err := provider.Generation(ctx, resp.Metadata["generation_id"].(string))
+//
+// OpenRouter stores the record shortly after the call ends. WithBaseURL overrides the API host.
+func (p *OpenAIProvider) Generation(ctx context.Context, id string, opts ...CallOption) (*OpenRouterGeneration, error) {
+	cfg := CallConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	base := strings.TrimSuffix(cfg.BaseURL, "/")
+	if base == "" {
+		base = "https://openrouter.ai"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", base+"/api/v1/generation?id="+url.QueryEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", cfg.userAgent())
+	req.Header.Set("Authorization", "Bearer "+cfg.apiKey(p.Key))
+
+	var result struct {
+		Data OpenRouterGeneration `json:"data"`
+	}
+	if err := doFileRequest(cfg, req, &result); err != nil {
+		return nil, fmt.Errorf("failed to get generation %s: %w", id, err)
+	}
+	return &result.Data, nil
+}
+
+// checkOpenRouterOptions rejects OpenRouter options in calls to other providers
+func checkOpenRouterOptions(cfg CallConfig) error {
+	if cfg.provider == "openrouter" {
+		return nil
+	}
+	if cfg.ModelVariant != "" {
+		return fmt.Errorf("model variant %q is supported by OpenRouter only, not %s", cfg.ModelVariant, cfg.provider)
+	}
+	if cfg.Transforms != nil {
+		return fmt.Errorf("transforms are supported by OpenRouter only, not %s", cfg.provider)
+	}
+	return nil
+}
+
+// openRouterModel sets the variant suffix of the model name, replacing the one given in the name
+func openRouterModel(model, variant string) string {
+	variant = strings.TrimPrefix(variant, ":")
+	if variant == "" {
+		return model
+	}
+	if _, current, ok := strings.Cut(model, ":"); ok {
+		if current == variant {
+			return model
+		}
+		model = model[:len(model)-len(current)-1]
+	}
+	return model + ":" + variant
+}
+
+// openRouterHeaders attributes OpenRouter calls to the app named by the "referer" and
+// "title" request metadata
+func openRouterHeaders(req *http.Request, cfg CallConfig) {
+	if cfg.provider != "openrouter" {
+		return
+	}
+	if referer := cfg.RequestMetadata["referer"]; referer != "" {
+		req.Header.Set("HTTP-Referer", referer)
+	}
+	if title := cfg.RequestMetadata["title"]; title != "" {
+		req.Header.Set("X-Title", title)
+	}
+}
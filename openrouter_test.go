@@ -0,0 +1,142 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenRouterUsage(t *testing.T) {
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = nil
+		json.NewDecoder(r.Body).Decode(&request)
+		usage := `"usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15, "cost": 0.0021, ` +
+			`"prompt_tokens_details": {"cached_tokens": 4}, "completion_tokens_details": {"reasoning_tokens": 2}}`
+		if request["stream"] == true {
+			w.Write([]byte(`data: {"id": "gen-2", "provider": "Anthropic", "choices": [{"delta": {"content": "ok"}}]}` + "\n\n"))
+			w.Write([]byte(`data: {"id": "gen-2", "provider": "Anthropic", "choices": [], ` + usage + "}\n\n"))
+			w.Write([]byte("data: [DONE]\n\n"))
+			return
+		}
+		w.Write([]byte(`{"id": "gen-1", "provider": "Anthropic", "model": "anthropic/claude-sonnet-4.5",
+			"choices": [{"message": {"role": "assistant", "content": "ok"}}], ` + usage + "}"))
+	}))
+	defer server.Close()
+
+	client, _ := NewCommonClient(map[string]string{"openrouter": "key"}, WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	resp, err := client.Complete(ctx, QuickMessage("Hi"), WithModel("openrouter/anthropic/claude-sonnet-4.5"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if include, _ := request["usage"].(map[string]any)["include"].(bool); !include {
+		t.Errorf("Expected usage accounting in the request, got %v", request["usage"])
+	}
+	u := resp.Usage()
+	if u.Cost != 0.0021 || u.CachedTokens != 4 || u.TotalTokens != 15 || resp.Metadata["reasoning_tokens"] != 2 {
+		t.Errorf("Unexpected usage: %+v, metadata %v", u, resp.Metadata)
+	}
+	if resp.Metadata["generation_id"] != "gen-1" || resp.Metadata["upstream_provider"] != "Anthropic" {
+		t.Errorf("Unexpected generation metadata: %v", resp.Metadata)
+	}
+
+	stream, err := client.StreamComplete(ctx, QuickMessage("Hi"), WithModel("openrouter/anthropic/claude-sonnet-4.5"))
+	if err != nil {
+		t.Fatalf("StreamComplete() error = %v", err)
+	}
+	var meta Metadata
+	for chunk := range stream.Stream {
+		if chunk.Meta != nil {
+			meta = *chunk.Meta
+		}
+	}
+	if meta["cost"] != 0.0021 || meta["generation_id"] != "gen-2" || meta["upstream_provider"] != "Anthropic" {
+		t.Errorf("Unexpected stream metadata: %v", meta)
+	}
+
+	// other OpenAI-compatible providers don't get the OpenRouter fields
+	client, _ = NewCommonClient(map[string]string{"openai": "key"}, WithBaseURL(server.URL))
+	resp, err = client.Complete(ctx, QuickMessage("Hi"), WithModel("openai/gpt-4o-mini"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if _, ok := request["usage"]; ok {
+		t.Errorf("Expected no usage option for OpenAI, got %v", request["usage"])
+	}
+	if _, ok := resp.Metadata["generation_id"]; ok {
+		t.Errorf("Expected no generation id for OpenAI, got %v", resp.Metadata)
+	}
+}
+
+func TestOpenRouterGeneration(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/generation" || r.URL.Query().Get("id") != "gen-1" || r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"message": "Generation not found", "code": 404}}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"id": "gen-1", "provider_name": "Anthropic", "total_cost": 0.0021,
+			"native_tokens_prompt": 12, "native_tokens_completion": 6, "finish_reason": "stop"}}`))
+	}))
+	defer server.Close()
+
+	p := &OpenAIProvider{Key: "key"}
+	gen, err := p.Generation(context.Background(), "gen-1", WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("Generation() error = %v", err)
+	}
+	if gen.ProviderName != "Anthropic" || gen.TotalCost != 0.0021 || gen.NativeTokensPrompt != 12 {
+		t.Errorf("Unexpected generation: %+v", gen)
+	}
+
+	if _, err := p.Generation(context.Background(), "gen-2", WithBaseURL(server.URL)); err == nil {
+		t.Error("Expected an error for an unknown generation")
+	}
+}
+
+func TestOpenRouterRouting(t *testing.T) {
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = nil
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "ok"}}]}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewCommonClient(map[string]string{"openrouter": "key", "openai": "key"}, WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	_, err := client.Complete(ctx, QuickMessage("Hi"), WithModel("openrouter/openai/gpt-5:nitro"),
+		WithModelVariant("online"), WithTransforms("middle-out"), WithEndPoint("azure, openai"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if request["model"] != "openai/gpt-5:online" {
+		t.Errorf("Expected the variant to replace the suffix, got %v", request["model"])
+	}
+	if transforms, _ := request["transforms"].([]any); len(transforms) != 1 || transforms[0] != "middle-out" {
+		t.Errorf("Unexpected transforms: %v", request["transforms"])
+	}
+	if only, _ := request["provider"].(map[string]any)["only"].([]any); len(only) != 2 || only[1] != "openai" {
+		t.Errorf("Expected the endpoint option to route the call, got %v", request["provider"])
+	}
+
+	// no arguments disable the default transforms
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithModel("openrouter/openai/gpt-5"), WithTransforms()); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if transforms, ok := request["transforms"].([]any); !ok || len(transforms) != 0 {
+		t.Errorf("Expected an empty transforms list, got %v", request["transforms"])
+	}
+
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithModel("openai/gpt-5"), WithTransforms("middle-out")); err == nil {
+		t.Error("Expected an error for transforms outside OpenRouter")
+	}
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithModel("openai/gpt-5"), WithModelVariant("nitro")); err == nil {
+		t.Error("Expected an error for a model variant outside OpenRouter")
+	}
+}
@@ -0,0 +1,95 @@
+package echo
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// Output filter modes
+const (
+	FilterRedact = "redact" // replace matches with [FILTERED]
+	FilterAbort  = "abort"  // fail the call with an *OutputFilterError
+)
+
+// outputFilterHoldback is how much streamed text is held back, so matches split
+// between chunks are still found
+const outputFilterHoldback = 128
+
+// OutputFilterError is returned when the output matches a pattern of an abort filter
+type OutputFilterError struct {
+	Pattern string // the pattern that matched
+}
+
+func (e *OutputFilterError) Error() string {
+	return fmt.Sprintf("output matched the filter pattern %q", e.Pattern)
+}
+
+// outputFilter redacts or rejects banned strings in complete and streamed output
+type outputFilter struct {
+	mode     string
+	patterns []*regexp.Regexp
+	pending  string
+}
+
+func newOutputFilter(mode string, patterns []string) (*outputFilter, error) {
+	if mode != FilterRedact && mode != FilterAbort {
+		return nil, fmt.Errorf("unknown output filter mode: %s", mode)
+	}
+	f := &outputFilter{mode: mode}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output filter pattern %q: %w", p, err)
+		}
+		f.patterns = append(f.patterns, re)
+	}
+	return f, nil
+}
+
+// filter redacts the text or returns an error when it matches in abort mode
+func (f *outputFilter) filter(text string) (string, error) {
+	for _, re := range f.patterns {
+		if !re.MatchString(text) {
+			continue
+		}
+		if f.mode == FilterAbort {
+			return "", &OutputFilterError{Pattern: re.String()}
+		}
+		text = re.ReplaceAllString(text, "[FILTERED]")
+	}
+	return text, nil
+}
+
+// push releases the text that can't be part of a match continuing in the next chunks
+func (f *outputFilter) push(text string) (string, error) {
+	f.pending += text
+	cut := len(f.pending) - outputFilterHoldback
+	for _, re := range f.patterns {
+		for _, loc := range re.FindAllStringIndex(f.pending, -1) {
+			switch {
+			case loc[1] == len(f.pending):
+				// The match may go on in the next chunk
+				cut = min(cut, loc[0])
+			case loc[0] < cut && loc[1] > cut:
+				cut = loc[1]
+			}
+		}
+	}
+	for cut > 0 && !utf8.RuneStart(f.pending[cut]) {
+		cut--
+	}
+	if cut <= 0 {
+		return "", nil
+	}
+
+	out := f.pending[:cut]
+	f.pending = f.pending[cut:]
+	return f.filter(out)
+}
+
+func (f *outputFilter) flush() (string, error) {
+	out := f.pending
+	f.pending = ""
+	return f.filter(out)
+}
@@ -0,0 +1,62 @@
+package echo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestOutputFilterStream(t *testing.T) {
+	f, err := newOutputFilter(FilterRedact, []string{"Project Falcon", `sk-[a-z0-9]+`})
+	if err != nil {
+		t.Fatalf("newOutputFilter() error = %v", err)
+	}
+
+	// Matches split between chunks are still found
+	text := strings.Repeat("filler text ", 20) + "Project Falcon uses key sk-abc123 and " + strings.Repeat("more text ", 20)
+	var out strings.Builder
+	for i := 0; i < len(text); i += 7 {
+		chunk, err := f.push(text[i:min(i+7, len(text))])
+		if err != nil {
+			t.Fatalf("push() error = %v", err)
+		}
+		out.WriteString(chunk)
+	}
+	tail, _ := f.flush()
+	out.WriteString(tail)
+
+	want := strings.Replace(strings.Replace(text, "Project Falcon", "[FILTERED]", 1), "sk-abc123", "[FILTERED]", 1)
+	if out.String() != want {
+		t.Errorf("Filtered stream = %q", out.String())
+	}
+}
+
+func TestWithOutputFilter(t *testing.T) {
+	client, _ := NewCommonClient(nil, WithModel("mock/test"))
+	ctx := context.Background()
+	messages := QuickMessage("The codename is Falcon")
+
+	resp, err := client.Complete(ctx, messages, WithOutputFilter(FilterRedact, "Falcon"))
+	if err != nil || resp.Text != "[user]: The codename is [FILTERED]" {
+		t.Errorf("Complete() = %v, %v", resp, err)
+	}
+
+	var filterErr *OutputFilterError
+	if _, err := client.Complete(ctx, messages, WithOutputFilter(FilterAbort, "Falcon")); !errors.As(err, &filterErr) {
+		t.Errorf("Expected *OutputFilterError, got %v", err)
+	}
+
+	stream, err := client.StreamComplete(ctx, messages, WithOutputFilter(FilterAbort, "Falcon"))
+	if err != nil {
+		t.Fatalf("StreamComplete() error = %v", err)
+	}
+	var sb strings.Builder
+	if _, err := stream.WriteTo(&sb); !errors.As(err, &filterErr) || strings.Contains(sb.String(), "Falcon") {
+		t.Errorf("Expected the stream to abort before the match, got %q, %v", sb.String(), err)
+	}
+
+	if _, err := client.Complete(ctx, messages, WithOutputFilter("hide", "x")); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
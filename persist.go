@@ -0,0 +1,130 @@
+package echo
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"strings"
+	"time"
+)
+
+// ResponseRecord is a response saved by WithStreamPersistence
+type ResponseRecord struct {
+	CallID       string    `json:"call_id"` // links feedback to the response, see RecordFeedback
+	Model        string    `json:"model"`
+	UserID       string    `json:"user_id,omitempty"`      // end user set by WithUserID, see DeleteUserData
+	Response     *Response `json:"response"`               // complete text and metadata, including token usage
+	Error        string    `json:"error,omitempty"`        // set when the provider call failed
+	Disconnected bool      `json:"disconnected,omitempty"` // the consumer stopped reading before the end
+	Started      time.Time `json:"started"`
+	Finished     time.Time `json:"finished"`
+	Sealed       []byte    `json:"sealed,omitempty"` // encrypted Response and Error, see StoreCipher
+}
+
+// ResponseStore keeps responses for billing and audit
+type ResponseStore interface {
+	SaveResponse(ctx context.Context, record ResponseRecord) error
+}
+
+// newRecord starts the record of a call, generating the call ID when WithCallID is not set
+func newRecord(cfg *CallConfig) (ResponseRecord, error) {
+	if cfg.CallID == "" {
+		id, err := randomID("call_")
+		if err != nil {
+			return ResponseRecord{}, err
+		}
+		cfg.CallID = id
+	}
+	return ResponseRecord{CallID: cfg.CallID, Model: cfg.modelRef().String(), UserID: cfg.UserID, Started: time.Now()}, nil
+}
+
+// recordCall makes the call and saves its response, or its error, to the store.
+// The call ID is returned in the "call_id" metadata key.
+func recordCall(ctx context.Context, cfg *CallConfig, call func() (*Response, error)) (*Response, error) {
+	record, err := newRecord(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := call()
+	record.Finished = time.Now()
+	if err != nil {
+		record.Error = err.Error()
+	} else {
+		if resp.Metadata == nil {
+			resp.Metadata = Metadata{}
+		}
+		resp.Metadata["call_id"] = record.CallID
+		record.Response = resp
+	}
+	saveRecord(ctx, cfg.ResponseStore, cfg.Logger, record)
+	return resp, err
+}
+
+// saveRecord saves the record to the store. The answer is already delivered at this point,
+// so a failed save is reported to the logger only.
+func saveRecord(ctx context.Context, store ResponseStore, logger *slog.Logger, record ResponseRecord) {
+	err := store.SaveResponse(context.WithoutCancel(ctx), record)
+	if err != nil && logger != nil {
+		logger.Error("failed to save response", "call_id", record.CallID, "error", err)
+	}
+}
+
+// persistStream saves the stream to the store once it ends, while the returned stream
+// delivers the same chunks to the consumer, after a chunk with the "call_id" metadata.
+// The source stream must not depend on ctx: when the consumer goes away, it is read to
+// the end and saved all the same.
+func persistStream(ctx context.Context, stream *StreamResponse, cfg *CallConfig) (*StreamResponse, error) {
+	record, err := newRecord(cfg)
+	if err != nil {
+		drain(stream)
+		return nil, err
+	}
+	store, logger := cfg.ResponseStore, cfg.Logger
+	readers := stream.Tee(2)
+	disconnected := make(chan bool, 1)
+	model, provider := cfg.Model, cfg.provider
+
+	go func() {
+		meta := Metadata{}
+		var text, thinking strings.Builder
+		for chunk := range readers[1].Stream {
+			if chunk.Error != nil {
+				record.Error = chunk.Error.Error()
+				continue
+			}
+			if chunk.Meta != nil {
+				maps.Copy(meta, *chunk.Meta)
+			}
+			text.WriteString(chunk.Data)
+			thinking.WriteString(chunk.Thinking)
+		}
+
+		record.Response = &Response{Text: text.String(), Thinking: thinking.String(), Model: model, Provider: provider, Metadata: meta}
+		// Saved once the consumer got the whole stream or went away
+		record.Disconnected = <-disconnected
+		record.Finished = time.Now()
+		saveRecord(ctx, store, logger, record)
+	}()
+
+	ch := make(chan StreamChunk)
+	go func() {
+		defer close(ch)
+		out := chunkSender{ctx: ctx, ch: ch}
+		meta := Metadata{"call_id": record.CallID}
+		if out.send(StreamChunk{Meta: &meta}) != nil {
+			disconnected <- true
+			drain(readers[0])
+			return
+		}
+		for chunk := range readers[0].Stream {
+			if out.send(chunk) != nil {
+				disconnected <- true
+				drain(readers[0])
+				return
+			}
+		}
+		disconnected <- false
+	}()
+	return &StreamResponse{Stream: ch}, nil
+}
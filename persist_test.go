@@ -0,0 +1,104 @@
+package echo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+type chanResponseStore chan ResponseRecord
+
+func (s chanResponseStore) SaveResponse(ctx context.Context, record ResponseRecord) error {
+	s <- record
+	return nil
+}
+
+// brokenResponseStore fails to save every record
+type brokenResponseStore struct{}
+
+func (brokenResponseStore) SaveResponse(ctx context.Context, record ResponseRecord) error {
+	return errors.New("store is offline")
+}
+
+func TestPersistenceError(t *testing.T) {
+	var buf bytes.Buffer
+	client, _ := NewCommonClient(nil, WithModel("mock/test"), WithStreamPersistence(brokenResponseStore{}),
+		WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+	ctx := context.Background()
+
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithCallID("call_1")); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "level=ERROR") || !strings.Contains(out, "call_id=call_1") ||
+		!strings.Contains(out, "store is offline") {
+		t.Errorf("Expected the save error in the log, got %q", out)
+	}
+
+	// A stream is saved in the background once it ends
+	logs := make(chan string, 1)
+	logger := slog.New(slog.NewTextHandler(logWriter(logs), nil))
+	stream, err := client.StreamComplete(ctx, QuickMessage("Hi"), WithCallID("call_2"), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("StreamComplete() error = %v", err)
+	}
+	for range stream.Stream {
+	}
+	select {
+	case out := <-logs:
+		if !strings.Contains(out, "call_id=call_2") || !strings.Contains(out, "store is offline") {
+			t.Errorf("Expected the save error in the log, got %q", out)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the stream save error to be logged")
+	}
+}
+
+// logWriter sends every log line to the channel
+type logWriter chan string
+
+func (w logWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func TestStreamPersistence(t *testing.T) {
+	store := make(chanResponseStore, 1)
+	client, _ := NewCommonClient(nil, WithModel("mock/test"), WithStreamPersistence(store))
+	want := "[user]: An answer that is streamed in several chunks"
+
+	// Complete stream
+	stream, err := client.StreamComplete(context.Background(), QuickMessage("An answer that is streamed in several chunks"))
+	if err != nil {
+		t.Fatalf("StreamComplete() error = %v", err)
+	}
+	drain(stream)
+	record := <-store
+	if record.Response.Text != want || record.Disconnected || record.Model != "mock/test" {
+		t.Errorf("Unexpected record %+v", record)
+	}
+	if record.Response.Metadata["finish_reason"] != FinishStop {
+		t.Errorf("Expected the final metadata, got %v", record.Response.Metadata)
+	}
+
+	// The consumer leaves after the first chunk
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err = client.StreamComplete(ctx, QuickMessage("An answer that is streamed in several chunks"))
+	if err != nil {
+		t.Fatalf("StreamComplete() error = %v", err)
+	}
+	<-stream.Stream
+	cancel()
+
+	select {
+	case record = <-store:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Abandoned stream was not saved")
+	}
+	if record.Response.Text != want || !record.Disconnected {
+		t.Errorf("Unexpected record of the abandoned stream %+v", record)
+	}
+}
@@ -0,0 +1,146 @@
+package echo
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phonePattern = regexp.MustCompile(`\+?\(?\b\d[\d\s().-]{5,}\d\b`)
+
+	// Numbers that have the digits and separators of a phone number but are not one
+	datePattern = regexp.MustCompile(`^(?:\d{4}[-./]\d{1,2}[-./]\d{1,2}|\d{1,2}[-./]\d{1,2}[-./]\d{2,4})$`)
+	dottedIP    = regexp.MustCompile(`^\d{1,3}(?:\.\d{1,3}){3}$`)
+)
+
+// isPhone checks the structure of a phone number candidate: 7 to 15 digits, written with
+// a leading "+" or split into groups by separators, and not a date or an IP address
+func isPhone(s string) bool {
+	digits := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	if digits < 7 || digits > 15 {
+		return false
+	}
+	if !strings.HasPrefix(s, "+") && digits == len(s) {
+		return false
+	}
+	return !datePattern.MatchString(s) && !dottedIP.MatchString(s)
+}
+
+// maxPlaceholderLen is the longest placeholder the stream filter waits for
+const maxPlaceholderLen = 16
+
+// PIIMasker replaces personal data with placeholders like <EMAIL_1>
+// and restores it later. The same value always maps to the same placeholder.
+type PIIMasker struct {
+	names    []string
+	values   map[string]string // placeholder -> original
+	masks    map[string]string // original -> placeholder
+	counters map[string]int
+}
+
+// NewPIIMasker creates a masker for emails, phone numbers and the given names
+func NewPIIMasker(names ...string) *PIIMasker {
+	sorted := append([]string{}, names...)
+	// Longer names first, so "Anna Smith" wins over "Anna"
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	return &PIIMasker{
+		names:    sorted,
+		values:   map[string]string{},
+		masks:    map[string]string{},
+		counters: map[string]int{},
+	}
+}
+
+// Mask replaces personal data in the text with placeholders
+func (m *PIIMasker) Mask(text string) string {
+	text = emailPattern.ReplaceAllStringFunc(text, func(s string) string { return m.placeholder("EMAIL", s) })
+	text = phonePattern.ReplaceAllStringFunc(text, func(s string) string {
+		if !isPhone(s) {
+			return s
+		}
+		return m.placeholder("PHONE", s)
+	})
+	for _, name := range m.names {
+		if name == "" {
+			continue
+		}
+		re := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+		text = re.ReplaceAllStringFunc(text, func(s string) string { return m.placeholder("NAME", s) })
+	}
+	return text
+}
+
+// MaskMessages returns a copy of the messages with personal data masked
+func (m *PIIMasker) MaskMessages(messages []Message) []Message {
+	masked := make([]Message, len(messages))
+	for i, msg := range messages {
+		masked[i] = msg
+		masked[i].Content = m.Mask(msg.Content)
+	}
+	return masked
+}
+
+// Unmask restores the original values of all placeholders in the text
+func (m *PIIMasker) Unmask(text string) string {
+	if len(m.values) == 0 {
+		return text
+	}
+	pairs := make([]string, 0, len(m.values)*2)
+	for placeholder, value := range m.values {
+		pairs = append(pairs, placeholder, value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// Count returns the number of distinct masked values
+func (m *PIIMasker) Count() int {
+	return len(m.values)
+}
+
+func (m *PIIMasker) placeholder(kind, value string) string {
+	if p, ok := m.masks[value]; ok {
+		return p
+	}
+	m.counters[kind]++
+	p := fmt.Sprintf("<%s_%d>", kind, m.counters[kind])
+	m.masks[value] = p
+	m.values[p] = value
+	return p
+}
+
+// unmaskFilter restores placeholders in streamed text, holding back
+// a possibly incomplete placeholder at the end of a chunk
+func (m *PIIMasker) unmaskFilter() textFilter {
+	return &piiStreamFilter{masker: m}
+}
+
+type piiStreamFilter struct {
+	masker  *PIIMasker
+	pending string
+}
+
+func (f *piiStreamFilter) push(text string) (string, error) {
+	text = f.pending + text
+	f.pending = ""
+
+	if i := strings.LastIndex(text, "<"); i >= 0 && !strings.Contains(text[i:], ">") && len(text)-i < maxPlaceholderLen {
+		f.pending = text[i:]
+		text = text[:i]
+	}
+	return f.masker.Unmask(text), nil
+}
+
+func (f *piiStreamFilter) flush() (string, error) {
+	text := f.masker.Unmask(f.pending)
+	f.pending = ""
+	return text, nil
+}
@@ -0,0 +1,72 @@
+package echo
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPIIMasker(t *testing.T) {
+	m := NewPIIMasker("Anna", "Anna Smith")
+
+	masked := m.Mask("Anna Smith (anna@example.com, +1 555 123 4567) wrote to anna@example.com. Anna agreed.")
+	expected := "<NAME_1> (<EMAIL_1>, <PHONE_1>) wrote to <EMAIL_1>. <NAME_2> agreed."
+	if masked != expected {
+		t.Errorf("Mask() = %q, want %q", masked, expected)
+	}
+
+	if got := m.Unmask("Reply to <EMAIL_1>, cc <NAME_1>"); got != "Reply to anna@example.com, cc Anna Smith" {
+		t.Errorf("Unmask() = %q", got)
+	}
+	if m.Count() != 4 {
+		t.Errorf("Count() = %d, want 4", m.Count())
+	}
+
+	phones := []string{"+1 555 123 4567", "(555) 123-4567", "555.123.4567", "+4930123456"}
+	for _, phone := range phones {
+		if masked := NewPIIMasker().Mask("call " + phone); masked != "call <PHONE_1>" {
+			t.Errorf("Mask(%q) = %q", phone, masked)
+		}
+	}
+	for _, text := range []string{"due 2024-01-15", "on 15.01.2024", "host 192.168.0.1", "order 1234567890", "ticket 12345"} {
+		if masked := NewPIIMasker().Mask(text); masked != text {
+			t.Errorf("Mask(%q) = %q, expected no phone", text, masked)
+		}
+	}
+}
+
+func TestPIIMaskingStream(t *testing.T) {
+	client, err := NewCommonClient(nil, WithModel("mock/test"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	ctx := context.Background()
+	messages := QuickMessage("Contact john.doe@example.com or call Bob")
+
+	// The mock provider echoes masked input, which must be restored in the response
+	resp, err := client.Complete(ctx, messages, WithPIIMasking("Bob"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Text != "[user]: Contact john.doe@example.com or call Bob" {
+		t.Errorf("Complete() = %q", resp.Text)
+	}
+	if resp.Metadata["pii_masked"] != 2 {
+		t.Errorf("Expected 2 masked values, got %v", resp.Metadata["pii_masked"])
+	}
+
+	stream, err := client.StreamComplete(ctx, messages, WithPIIMasking("Bob"))
+	if err != nil {
+		t.Fatalf("StreamComplete() error = %v", err)
+	}
+	var sb strings.Builder
+	for chunk := range stream.Stream {
+		if chunk.Error != nil {
+			t.Fatalf("Stream error = %v", chunk.Error)
+		}
+		sb.WriteString(chunk.Data)
+	}
+	if sb.String() != "[user]: Contact john.doe@example.com or call Bob" {
+		t.Errorf("StreamComplete() = %q", sb.String())
+	}
+}
@@ -0,0 +1,138 @@
+package echo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Step is a single stage of a pipeline, it transforms the input payload
+// using the client and returns the payload for the next stage
+type Step[In, Out any] func(ctx context.Context, client Client, input In) (Out, error)
+
+// Chain composes steps into a single step that runs them in order,
+// feeding the output of each step into the next one
+func Chain[T any](steps ...Step[T, T]) Step[T, T] {
+	return func(ctx context.Context, client Client, input T) (T, error) {
+		var err error
+		for i, step := range steps {
+			input, err = step(ctx, client, input)
+			if err != nil {
+				var zero T
+				return zero, fmt.Errorf("pipeline step %d: %w", i+1, err)
+			}
+		}
+		return input, nil
+	}
+}
+
+// Then composes two steps whose payload types differ, e.g. texts embedded after a summary:
+//
+//	pipeline := echo.Then(echo.SummarizeStep(), echo.EmbedStep())
+func Then[A, B, C any](first Step[A, B], second Step[B, C]) Step[A, C] {
+	return func(ctx context.Context, client Client, input A) (C, error) {
+		mid, err := first(ctx, client, input)
+		if err != nil {
+			var zero C
+			return zero, err
+		}
+		return second(ctx, client, mid)
+	}
+}
+
+// PromptStep creates a step that sends the prompt to the model and returns the response text.
+// The {input} placeholder in the prompt is replaced with the step input;
+// if there is no placeholder, the input is appended after the prompt.
+func PromptStep(prompt string, opts ...CallOption) Step[string, string] {
+	return func(ctx context.Context, client Client, input string) (string, error) {
+		var text string
+		if strings.Contains(prompt, "{input}") {
+			text = strings.ReplaceAll(prompt, "{input}", input)
+		} else {
+			text = prompt + "\n\n" + input
+		}
+
+		resp, err := client.Complete(ctx, QuickMessage(text), opts...)
+		if err != nil {
+			return "", err
+		}
+		return resp.Text, nil
+	}
+}
+
+// SummarizeStep creates a step that summarizes the input
+func SummarizeStep(opts ...CallOption) Step[string, string] {
+	return func(ctx context.Context, client Client, input string) (string, error) {
+		resp, err := client.Summarize(ctx, input, opts...)
+		if err != nil {
+			return "", err
+		}
+		return resp.Text, nil
+	}
+}
+
+// TranslateStep creates a step that translates the input into the target language
+func TranslateStep(targetLang string, opts ...CallOption) Step[string, string] {
+	return func(ctx context.Context, client Client, input string) (string, error) {
+		resp, err := client.Translate(ctx, input, targetLang, opts...)
+		if err != nil {
+			return "", err
+		}
+		return resp.Text, nil
+	}
+}
+
+// ClassifyStep creates a step that replaces the input with one of the labels
+func ClassifyStep(labels []string, opts ...CallOption) Step[string, string] {
+	return func(ctx context.Context, client Client, input string) (string, error) {
+		result, err := client.Classify(ctx, input, labels, opts...)
+		if err != nil {
+			return "", err
+		}
+		return result.Label, nil
+	}
+}
+
+// EmbedStep creates a step that returns the embedding of the input
+func EmbedStep(opts ...CallOption) Step[string, []float32] {
+	return func(ctx context.Context, client Client, input string) ([]float32, error) {
+		resp, err := client.GetEmbeddings(ctx, input, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Embedding, nil
+	}
+}
+
+// RerankStep creates a step that orders the input documents by relevance to the query,
+// the most relevant first
+func RerankStep(query string, opts ...CallOption) Step[[]string, []string] {
+	return func(ctx context.Context, client Client, documents []string) ([]string, error) {
+		resp, err := client.ReRank(ctx, query, documents, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Scores) != len(documents) {
+			return nil, fmt.Errorf("got %d rerank scores for %d documents", len(resp.Scores), len(documents))
+		}
+		order := make([]int, len(documents))
+		for i := range order {
+			order[i] = i
+		}
+		slices.SortStableFunc(order, func(a, b int) int {
+			switch {
+			case resp.Scores[a] > resp.Scores[b]:
+				return -1
+			case resp.Scores[a] < resp.Scores[b]:
+				return 1
+			}
+			return 0
+		})
+		ranked := make([]string, len(documents))
+		for i, j := range order {
+			ranked[i] = documents[j]
+		}
+		return ranked, nil
+	}
+}
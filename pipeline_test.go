@@ -0,0 +1,60 @@
+package echo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	client, err := NewCommonClient(nil, WithModel("mock/test"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	ctx := context.Background()
+
+	upper := func(ctx context.Context, client Client, input string) (string, error) {
+		return strings.ToUpper(input), nil
+	}
+
+	pipeline := Chain(PromptStep("Echo: {input}"), upper)
+	out, err := pipeline(ctx, client, "hello")
+	if err != nil {
+		t.Fatalf("pipeline error = %v", err)
+	}
+	if out != "[USER]: ECHO: HELLO" {
+		t.Errorf("pipeline output = %q", out)
+	}
+
+	failing := func(ctx context.Context, client Client, input string) (string, error) {
+		return "", fmt.Errorf("boom")
+	}
+	if _, err := Chain(upper, failing)(ctx, client, "x"); err == nil || !strings.Contains(err.Error(), "step 2") {
+		t.Errorf("Expected error from step 2, got %v", err)
+	}
+}
+
+func TestTypedSteps(t *testing.T) {
+	client, _ := NewCommonClient(nil, WithModel("mock/test"))
+	ctx := context.Background()
+
+	embed := Then(PromptStep("Echo: {input}"), EmbedStep())
+	vector, err := embed(ctx, client, "hello")
+	if err != nil || len(vector) == 0 {
+		t.Fatalf("embedding pipeline = %v, %v", vector, err)
+	}
+
+	count := func(ctx context.Context, client Client, docs []string) (int, error) {
+		return len(docs), nil
+	}
+	pipeline := Then(Chain(RerankStep("red apple")), count)
+	if n, err := pipeline(ctx, client, []string{"a pear", "red apple"}); err != nil || n != 2 {
+		t.Errorf("rerank pipeline = %d, %v", n, err)
+	}
+
+	ranked, err := RerankStep("red apple")(ctx, client, []string{"a pear", "green apple", "red apple"})
+	if err != nil || ranked[0] != "red apple" || ranked[2] != "a pear" {
+		t.Errorf("RerankStep() = %v, %v", ranked, err)
+	}
+}
@@ -0,0 +1,214 @@
+package echo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Template formats of prompt files
+const (
+	PromptFString  = "f-string" // {variable}, with {{ and }} for literal braces
+	PromptMustache = "mustache" // {{variable}}
+)
+
+// PromptTemplate is a message chain with {variable} placeholders, loaded from a prompt file
+type PromptTemplate struct {
+	Messages       []Message
+	InputVariables []string
+	Format         string // PromptFString or PromptMustache
+}
+
+// LoadPromptFile reads a JSON prompt file, see ParsePrompt
+func LoadPromptFile(path string) (*PromptTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrompt(data)
+}
+
+// ParsePrompt parses a prompt in the JSON formats used by LangChain and LlamaIndex:
+// a chat prompt with "messages" (or "message_templates") given as {"role", "content"}
+// objects or [role, content] pairs, or a single "template" string sent as a user message.
+// "input_variables" and "template_format" are optional; missing variables are collected
+// from the templates.
+func ParsePrompt(data []byte) (*PromptTemplate, error) {
+	var file struct {
+		Messages         []json.RawMessage `json:"messages"`
+		MessageTemplates []json.RawMessage `json:"message_templates"`
+		Template         string            `json:"template"`
+		InputVariables   []string          `json:"input_variables"`
+		TemplateFormat   string            `json:"template_format"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse prompt: %w", err)
+	}
+
+	t := &PromptTemplate{InputVariables: file.InputVariables, Format: file.TemplateFormat}
+	switch t.Format {
+	case "":
+		t.Format = PromptFString
+	case PromptFString, PromptMustache:
+	default:
+		return nil, fmt.Errorf("unsupported template format: %s", t.Format)
+	}
+
+	raw := append(file.Messages, file.MessageTemplates...)
+	for i, item := range raw {
+		msg, err := parsePromptMessage(item)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		t.Messages = append(t.Messages, msg)
+	}
+	if len(t.Messages) == 0 {
+		if file.Template == "" {
+			return nil, fmt.Errorf("prompt has neither messages nor a template")
+		}
+		t.Messages = []Message{{Role: User, Content: file.Template}}
+	}
+
+	used := map[string]bool{}
+	for _, msg := range t.Messages {
+		for _, name := range t.variables(msg.Content) {
+			if !used[name] && len(file.InputVariables) == 0 {
+				t.InputVariables = append(t.InputVariables, name)
+			}
+			used[name] = true
+		}
+	}
+	return t, nil
+}
+
+// parsePromptMessage reads a message given as an object or as a [role, content] pair
+func parsePromptMessage(data json.RawMessage) (Message, error) {
+	var role, content string
+	var pair []string
+	if err := json.Unmarshal(data, &pair); err == nil {
+		if len(pair) != 2 {
+			return Message{}, fmt.Errorf("expected a [role, content] pair")
+		}
+		role, content = pair[0], pair[1]
+	} else {
+		var obj struct {
+			Role    string `json:"role"`
+			Type    string `json:"type"` // LangChain message type: "system", "human", "ai"
+			Content string `json:"content"`
+		}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return Message{}, err
+		}
+		role, content = obj.Role, obj.Content
+		if role == "" {
+			role = obj.Type
+		}
+	}
+
+	switch role {
+	case "system":
+		return Message{Role: System, Content: content}, nil
+	case "user", "human":
+		return Message{Role: User, Content: content}, nil
+	case "assistant", "ai", "agent":
+		return Message{Role: Agent, Content: content}, nil
+	}
+	return Message{}, fmt.Errorf("unsupported role %q", role)
+}
+
+// Render returns the messages with the variables substituted.
+// All input variables must be provided, values are formatted with fmt.Sprint.
+func (t *PromptTemplate) Render(vars map[string]any) ([]Message, error) {
+	for _, name := range t.InputVariables {
+		if _, ok := vars[name]; !ok {
+			return nil, fmt.Errorf("missing prompt variable: %s", name)
+		}
+	}
+
+	out := make([]Message, len(t.Messages))
+	for i, msg := range t.Messages {
+		content, err := t.render(msg.Content, vars)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = Message{Role: msg.Role, Content: content}
+	}
+	return out, nil
+}
+
+// Template returns the prompt in the TemplateMessage format, with placeholders kept
+func (t *PromptTemplate) Template() string {
+	var sb strings.Builder
+	for i, msg := range t.Messages {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("@" + msg.Role + ":\n" + msg.Content + "\n")
+	}
+	return sb.String()
+}
+
+// variables returns the placeholder names of the text in order of appearance
+func (t *PromptTemplate) variables(text string) []string {
+	var names []string
+	t.scan(text, func(literal string) {}, func(name string) {
+		names = append(names, name)
+	})
+	return names
+}
+
+// render substitutes the placeholders of the text
+func (t *PromptTemplate) render(text string, vars map[string]any) (string, error) {
+	var sb strings.Builder
+	var missing string
+	t.scan(text, func(literal string) {
+		sb.WriteString(literal)
+	}, func(name string) {
+		value, ok := vars[name]
+		if !ok && missing == "" {
+			missing = name
+		}
+		sb.WriteString(fmt.Sprint(value))
+	})
+	if missing != "" {
+		return "", fmt.Errorf("missing prompt variable: %s", missing)
+	}
+	return sb.String(), nil
+}
+
+// scan splits the text into literal pieces and placeholders
+func (t *PromptTemplate) scan(text string, literal func(string), variable func(string)) {
+	openTag, closeTag := "{", "}"
+	if t.Format == PromptMustache {
+		openTag, closeTag = "{{", "}}"
+	}
+
+	for text != "" {
+		i := strings.IndexAny(text, "{}")
+		if i == -1 {
+			literal(text)
+			return
+		}
+		literal(text[:i])
+		text = text[i:]
+
+		// Escaped braces of f-strings
+		if t.Format != PromptMustache && (strings.HasPrefix(text, "{{") || strings.HasPrefix(text, "}}")) {
+			literal(text[:1])
+			text = text[2:]
+			continue
+		}
+		if strings.HasPrefix(text, openTag) {
+			if end := strings.Index(text, closeTag); end != -1 {
+				if name := strings.TrimSpace(text[len(openTag):end]); name != "" && !strings.ContainsAny(name, "{} \n") {
+					variable(name)
+					text = text[end+len(closeTag):]
+					continue
+				}
+			}
+		}
+		literal(text[:1])
+		text = text[1:]
+	}
+}
@@ -0,0 +1,58 @@
+package echo
+
+import "testing"
+
+func TestParsePrompt(t *testing.T) {
+	prompt, err := ParsePrompt([]byte(`{
+		"_type": "chat",
+		"input_variables": ["topic", "style"],
+		"messages": [
+			["system", "You write {style} poems. Answer in JSON like {{\"poem\": \"...\"}}."],
+			{"role": "human", "content": "Write about {topic}."}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("ParsePrompt() error = %v", err)
+	}
+
+	messages, err := prompt.Render(map[string]any{"topic": "the sea", "style": "short"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if len(messages) != 2 || messages[0].Role != System || messages[1].Role != User {
+		t.Fatalf("Unexpected messages: %+v", messages)
+	}
+	if messages[0].Content != `You write short poems. Answer in JSON like {"poem": "..."}.` || messages[1].Content != "Write about the sea." {
+		t.Errorf("Unexpected content: %q, %q", messages[0].Content, messages[1].Content)
+	}
+
+	if _, err := prompt.Render(map[string]any{"topic": "x"}); err == nil {
+		t.Error("Expected an error for a missing variable")
+	}
+}
+
+func TestParsePromptMustache(t *testing.T) {
+	prompt, err := ParsePrompt([]byte(`{
+		"template_format": "mustache",
+		"message_templates": [{"role": "user", "content": "Hello {{ name }}, you have {{count}} {messages}"}]
+	}`))
+	if err != nil {
+		t.Fatalf("ParsePrompt() error = %v", err)
+	}
+	if len(prompt.InputVariables) != 2 || prompt.InputVariables[0] != "name" || prompt.InputVariables[1] != "count" {
+		t.Errorf("Unexpected variables: %v", prompt.InputVariables)
+	}
+
+	messages, err := prompt.Render(map[string]any{"name": "Ann", "count": 3})
+	if err != nil || messages[0].Content != "Hello Ann, you have 3 {messages}" {
+		t.Errorf("Render() = %+v, %v", messages, err)
+	}
+	if prompt.Template() != "@user:\nHello {{ name }}, you have {{count}} {messages}\n" {
+		t.Errorf("Unexpected template: %q", prompt.Template())
+	}
+
+	single, err := ParsePrompt([]byte(`{"_type": "prompt", "template": "Tell me about {topic}"}`))
+	if err != nil || len(single.Messages) != 1 || single.InputVariables[0] != "topic" {
+		t.Errorf("Unexpected single template prompt: %+v, %v", single, err)
+	}
+}
@@ -0,0 +1,165 @@
+package echo
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// quotaLowShare is the share of the request limit below which paced calls are spread
+// over the time left until the quota resets
+const quotaLowShare = 10
+
+// QuotaStatus is the rate limit state of a provider, as reported by the headers of its last response.
+// Limits and remaining counts are -1 when the provider doesn't report them.
+type QuotaStatus struct {
+	RequestsLimit     int
+	RequestsRemaining int
+	RequestsReset     time.Time // when the request quota is restored
+	TokensLimit       int
+	TokensRemaining   int
+	TokensReset       time.Time // when the token quota is restored
+	RetryAfter        time.Time // set by a 429 answer, no calls should be made before it
+	Updated           time.Time
+}
+
+// quotaTracker keeps the quota status of each provider of a client
+type quotaTracker struct {
+	mu     sync.Mutex
+	status map[string]QuotaStatus
+}
+
+func newQuotaTracker() *quotaTracker {
+	return &quotaTracker{status: map[string]QuotaStatus{}}
+}
+
+// get returns the last observed status of the provider
+func (q *quotaTracker) get(provider string) (QuotaStatus, bool) {
+	if q == nil {
+		return QuotaStatus{}, false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	s, ok := q.status[provider]
+	return s, ok
+}
+
+// observe reads the rate limit headers of a response. OpenAI and xAI send
+// x-ratelimit-{limit,remaining,reset}-{requests,tokens} with resets as durations,
+// Anthropic sends anthropic-ratelimit-{requests,tokens}-{limit,remaining,reset} with RFC 3339 times.
+func (q *quotaTracker) observe(provider string, resp *http.Response) {
+	if q == nil || provider == "" {
+		return
+	}
+	now := timeNow()
+	h := resp.Header
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	s, ok := q.status[provider]
+	if !ok {
+		s = QuotaStatus{RequestsLimit: -1, RequestsRemaining: -1, TokensLimit: -1, TokensRemaining: -1}
+	}
+
+	found := false
+	for _, kind := range []string{"requests", "tokens"} {
+		limit, remaining, reset := h.Get("x-ratelimit-limit-"+kind), h.Get("x-ratelimit-remaining-"+kind), h.Get("x-ratelimit-reset-"+kind)
+		if remaining == "" {
+			prefix := "anthropic-ratelimit-" + kind + "-"
+			limit, remaining, reset = h.Get(prefix+"limit"), h.Get(prefix+"remaining"), h.Get(prefix+"reset")
+		}
+		if remaining == "" {
+			continue
+		}
+
+		found = true
+		l, r, t := quotaNumber(limit), quotaNumber(remaining), quotaReset(reset, now)
+		if kind == "requests" {
+			s.RequestsLimit, s.RequestsRemaining, s.RequestsReset = l, r, t
+		} else {
+			s.TokensLimit, s.TokensRemaining, s.TokensReset = l, r, t
+		}
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		found = true
+		s.RetryAfter = quotaReset(h.Get("Retry-After"), now)
+		if s.RetryAfter.IsZero() {
+			s.RetryAfter = now.Add(time.Second)
+		}
+	}
+
+	if found {
+		s.Updated = now
+		q.status[provider] = s
+	}
+}
+
+// pace delays a call while the provider quota is exhausted, and spreads calls over the time
+// left until the reset when few requests remain
+func (q *quotaTracker) pace(ctx context.Context, provider string) error {
+	s, ok := q.get(provider)
+	if !ok {
+		return nil
+	}
+	now := timeNow()
+
+	var wait time.Duration
+	if s.RetryAfter.After(now) {
+		wait = s.RetryAfter.Sub(now)
+	}
+	if s.TokensRemaining == 0 && s.TokensReset.After(now) {
+		wait = max(wait, s.TokensReset.Sub(now))
+	}
+	if s.RequestsRemaining >= 0 && s.RequestsReset.After(now) {
+		left := s.RequestsReset.Sub(now)
+		switch {
+		case s.RequestsRemaining == 0:
+			wait = max(wait, left)
+		case s.RequestsLimit > 0 && s.RequestsRemaining*quotaLowShare < s.RequestsLimit:
+			wait = max(wait, left/time.Duration(s.RequestsRemaining+1))
+		}
+	}
+
+	if wait <= 0 {
+		return nil
+	}
+	return sleep(ctx, wait)
+}
+
+func quotaNumber(value string) int {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// quotaReset parses a reset time given as a duration ("6m0s"), an RFC 3339 time,
+// a number of seconds or an HTTP date
+func quotaReset(value string, now time.Time) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(d)
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	if secs, err := strconv.ParseFloat(value, 64); err == nil {
+		return now.Add(time.Duration(secs * float64(time.Second)))
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+// QuotaStatus returns the rate limit state of the provider observed by this client,
+// false when none of its responses reported one yet
+func (c *CommonClient) QuotaStatus(provider string) (QuotaStatus, bool) {
+	return c.baseConfig.quota.get(provider)
+}
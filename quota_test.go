@@ -0,0 +1,88 @@
+package echo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQuotaStatus(t *testing.T) {
+	var headers map[string]string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range headers {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(status)
+		if r.Header.Get("anthropic-version") != "" {
+			w.Write([]byte(`{"content": [{"type": "text", "text": "ok"}]}`))
+			return
+		}
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "ok"}}]}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewCommonClient(map[string]string{"openai": "key", "anthropic": "key"}, WithBaseURL(server.URL))
+	ctx := context.Background()
+	now := time.Now()
+
+	if _, ok := client.QuotaStatus("openai"); ok {
+		t.Error("Expected no quota status before the first call")
+	}
+
+	headers = map[string]string{
+		"x-ratelimit-limit-requests":     "500",
+		"x-ratelimit-remaining-requests": "499",
+		"x-ratelimit-reset-requests":     "120ms",
+		"x-ratelimit-limit-tokens":       "30000",
+		"x-ratelimit-remaining-tokens":   "29000",
+		"x-ratelimit-reset-tokens":       "6m0s",
+	}
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithModel("openai/gpt-4o-mini")); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	s, ok := client.QuotaStatus("openai")
+	if !ok || s.RequestsLimit != 500 || s.RequestsRemaining != 499 || s.TokensRemaining != 29000 ||
+		s.TokensReset.Sub(now) < 6*time.Minute || s.TokensReset.Sub(now) > 7*time.Minute {
+		t.Errorf("Unexpected OpenAI quota: %+v", s)
+	}
+
+	reset := now.Add(time.Hour).UTC().Truncate(time.Second)
+	headers = map[string]string{
+		"anthropic-ratelimit-requests-limit":     "50",
+		"anthropic-ratelimit-requests-remaining": "0",
+		"anthropic-ratelimit-requests-reset":     reset.Format(time.RFC3339),
+	}
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithModel("anthropic/claude-sonnet-4-5")); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	s, _ = client.QuotaStatus("anthropic")
+	if s.RequestsLimit != 50 || s.RequestsRemaining != 0 || !s.RequestsReset.Equal(reset) || s.TokensLimit != -1 {
+		t.Errorf("Unexpected Anthropic quota: %+v", s)
+	}
+
+	// Paced calls wait for the reset of an exhausted quota
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err := client.Complete(short, QuickMessage("Hi"), WithModel("anthropic/claude-sonnet-4-5"), WithQuotaPacing())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the paced call to wait for the reset, got %v", err)
+	}
+	headers = nil
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithModel("openai/gpt-4o-mini"), WithQuotaPacing()); err != nil {
+		t.Errorf("Expected calls with quota left to proceed, got %v", err)
+	}
+
+	headers = map[string]string{"Retry-After": "30"}
+	status = http.StatusTooManyRequests
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithModel("openai/gpt-4o-mini")); err == nil {
+		t.Fatal("Expected a rate limit error")
+	}
+	s, _ = client.QuotaStatus("openai")
+	if d := s.RetryAfter.Sub(now); d < 30*time.Second || d > time.Minute || s.RequestsRemaining != 499 {
+		t.Errorf("Unexpected quota after 429: %+v", s)
+	}
+}
@@ -0,0 +1,101 @@
+package echo
+
+import (
+	"context"
+	"fmt"
+)
+
+// defaultRegressionThreshold is the score below which a replayed case counts as a regression
+const defaultRegressionThreshold = 0.8
+
+// RegressionCase is a production prompt with the output recorded for it
+type RegressionCase struct {
+	Name     string    `json:"name,omitempty"`
+	Messages []Message `json:"messages"`
+	Output   string    `json:"output"`
+}
+
+// RegressionOptions configures DetectRegressions. At least one of Embedding and Judge must be set.
+type RegressionOptions struct {
+	Call      []CallOption // the candidate: a new model or prompt version, e.g. WithModel("openai/gpt-5")
+	Embedding []CallOption // embedding model options, e.g. WithModel("voyage/voyage-4"); nil skips the similarity score
+	Judge     []CallOption // judge model options, e.g. WithModel("anthropic/best"); nil skips the judge score
+	Threshold float64      // score below which a case regressed, 0.8 by default
+}
+
+// RegressionResult compares the new output of a case with the recorded one
+type RegressionResult struct {
+	Case       RegressionCase `json:"case"`
+	Output     string         `json:"output"`
+	Similarity float64        `json:"similarity,omitempty"` // cosine similarity of the output embeddings
+	Judge      float64        `json:"judge,omitempty"`      // judge score between 0 and 1
+	Regressed  bool           `json:"regressed"`
+}
+
+// RegressionReport is the outcome of DetectRegressions
+type RegressionReport struct {
+	Results        []RegressionResult `json:"results"`
+	Regressions    int                `json:"regressions"`
+	MeanSimilarity float64            `json:"mean_similarity,omitempty"`
+	MeanJudge      float64            `json:"mean_judge,omitempty"`
+}
+
+// regressionJudgement is the structured answer of the judge
+type regressionJudgement struct {
+	Score int `json:"score" description:"0 to 10: how well the new answer matches the reference in meaning, correctness and completeness"`
+}
+
+// DetectRegressions replays recorded prompts with the candidate options and scores each new
+// output against the recorded one: by embedding similarity, by a judge model, or both.
+// A case regressed when any of its scores is below the threshold.
+func DetectRegressions(ctx context.Context, client Client, cases []RegressionCase, opts RegressionOptions) (*RegressionReport, error) {
+	if opts.Embedding == nil && opts.Judge == nil {
+		return nil, fmt.Errorf("regression detection requires embedding or judge options")
+	}
+	threshold := opts.Threshold
+	if threshold <= 0 {
+		threshold = defaultRegressionThreshold
+	}
+
+	report := &RegressionReport{}
+	for i, tc := range cases {
+		resp, err := client.Complete(ctx, tc.Messages, opts.Call...)
+		if err != nil {
+			return nil, fmt.Errorf("case %d: %w", i, err)
+		}
+		result := RegressionResult{Case: tc, Output: resp.Text}
+
+		if opts.Embedding != nil {
+			recorded, err := client.GetEmbeddings(ctx, tc.Output, opts.Embedding...)
+			if err != nil {
+				return nil, fmt.Errorf("case %d: %w", i, err)
+			}
+			replayed, err := client.GetEmbeddings(ctx, resp.Text, opts.Embedding...)
+			if err != nil {
+				return nil, fmt.Errorf("case %d: %w", i, err)
+			}
+			result.Similarity = cosine(recorded.Embedding, replayed.Embedding)
+			result.Regressed = result.Similarity < threshold
+			report.MeanSimilarity += result.Similarity / float64(len(cases))
+		}
+
+		if opts.Judge != nil {
+			prompt := "Compare a new answer to a conversation with the reference answer that was accepted before.\n\n" +
+				"Conversation:\n" + RenderMessages(tc.Messages) + "\n\nReference answer:\n" + tc.Output +
+				"\n\nNew answer:\n" + resp.Text
+			judgement, err := completeTyped[regressionJudgement](ctx, client, QuickMessage(prompt), opts.Judge...)
+			if err != nil {
+				return nil, fmt.Errorf("case %d: %w", i, err)
+			}
+			result.Judge = float64(min(max(judgement.Score, 0), 10)) / 10
+			result.Regressed = result.Regressed || result.Judge < threshold
+			report.MeanJudge += result.Judge / float64(len(cases))
+		}
+
+		if result.Regressed {
+			report.Regressions++
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
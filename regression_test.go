@@ -0,0 +1,40 @@
+package echo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDetectRegressions(t *testing.T) {
+	provider := &scriptedProvider{answers: []string{
+		"Paris is the capital of France", `{"score": 9}`,
+		"I cannot help with that", `{"score": 2}`,
+	}}
+	client, _ := NewClient(WithModel("mock/any"))
+	client.SetProvider("mock", provider)
+
+	cases := []RegressionCase{
+		{Messages: QuickMessage("Capital of France?"), Output: "Paris is the capital of France"},
+		{Messages: QuickMessage("Capital of Spain?"), Output: "Madrid is the capital of Spain"},
+	}
+	report, err := DetectRegressions(context.Background(), client, cases, RegressionOptions{
+		Call:      []CallOption{WithModel("mock/candidate")},
+		Embedding: []CallOption{WithModel("mock/embed")},
+		Judge:     []CallOption{WithModel("mock/judge")},
+	})
+	if err != nil {
+		t.Fatalf("DetectRegressions() error = %v", err)
+	}
+
+	first, second := report.Results[0], report.Results[1]
+	if first.Regressed || first.Similarity < 0.99 || first.Judge != 0.9 {
+		t.Errorf("Unexpected result of an unchanged answer %+v", first)
+	}
+	if !second.Regressed || second.Judge != 0.2 || report.Regressions != 1 {
+		t.Errorf("Expected a regression, got %+v", second)
+	}
+
+	if _, err := DetectRegressions(context.Background(), client, cases, RegressionOptions{}); err == nil {
+		t.Error("Expected an error without a scoring method")
+	}
+}
@@ -0,0 +1,101 @@
+package echo
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// RepairJSON completes JSON that was cut short, as structured output is when the answer
+// reaches the token limit: an unterminated string is closed, an incomplete trailing
+// fragment (a key without value, a partial number or literal) is dropped and open arrays
+// and objects are closed. Returns the repaired text and whether a repair was made; text
+// that is already valid is returned as is, text that cannot be repaired too.
+func RepairJSON(text string) (string, bool) {
+	trimmed := strings.TrimSpace(text)
+	if json.Valid([]byte(trimmed)) {
+		return text, false
+	}
+	if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+		if code, ok := ExtractFirstCode(trimmed, ""); ok {
+			trimmed = strings.TrimSpace(code)
+			if json.Valid([]byte(trimmed)) {
+				return trimmed, true
+			}
+		}
+	}
+
+	// Positions after complete values and opened containers, where the text can be
+	// cut and closed, together with the brackets open at that point
+	type cut struct {
+		pos     int
+		closers string
+	}
+	var cuts []cut
+	var stack []byte
+	closers := func() string {
+		b := make([]byte, len(stack))
+		for i, open := range stack {
+			b[len(stack)-1-i] = open + 2 // '{'+2 is '}', '['+2 is ']'
+		}
+		return string(b)
+	}
+
+	inString, escaped := false, false
+	for i := 0; i < len(trimmed); i++ {
+		c := trimmed[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+				cuts = append(cuts, cut{i + 1, closers()})
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			stack = append(stack, c)
+			cuts = append(cuts, cut{i + 1, closers()})
+		case c == '}' || c == ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			cuts = append(cuts, cut{i + 1, closers()})
+		case jsonScalarChar(c):
+			// Numbers and literals count only when something follows them,
+			// a value at the very end may be cut short
+			if i+1 < len(trimmed) && !jsonScalarChar(trimmed[i+1]) {
+				cuts = append(cuts, cut{i + 1, closers()})
+			}
+		}
+	}
+
+	// Keep as much as possible: first the unterminated string, then earlier cut points
+	if inString {
+		partial := trimmed
+		if escaped {
+			partial = partial[:len(partial)-1]
+		}
+		if candidate := partial + `"` + closers(); json.Valid([]byte(candidate)) {
+			return candidate, true
+		}
+	}
+	for i := len(cuts) - 1; i >= 0; i-- {
+		candidate := strings.TrimSpace(trimmed[:cuts[i].pos]) + cuts[i].closers
+		if json.Valid([]byte(candidate)) {
+			return candidate, true
+		}
+	}
+	return text, false
+}
+
+// jsonScalarChar reports whether c can be part of a number or a literal
+func jsonScalarChar(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c == '.' || c == '-' || c == '+' || c == 'E'
+}
@@ -0,0 +1,44 @@
+package echo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+		ok   bool
+	}{
+		{`{"name": "Ann", "tags": ["a", "b`, `{"name": "Ann", "tags": ["a", "b"]}`, true},
+		{`{"name": "Ann", "age": 4`, `{"name": "Ann"}`, true},
+		{`{"name": "Ann", "age": 42, "city`, `{"name": "Ann", "age": 42}`, true},
+		{`{"name": "Ann", "active": tr`, `{"name": "Ann"}`, true},
+		{`{"items": [{"id": 1}, {"id": 2}, {"id"`, `{"items": [{"id": 1}, {"id": 2}, {}]}`, true},
+		{`{"quote": "say \`, `{"quote": "say "}`, true},
+		{"```json\n{\"a\": [1, 2]}\n```", `{"a": [1, 2]}`, true},
+		{`{"a": 1}`, `{"a": 1}`, false},
+		{`not json`, `not json`, false},
+	}
+	for _, tt := range tests {
+		got, ok := RepairJSON(tt.text)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("RepairJSON(%q) = %q, %v; want %q, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestJSONRepairOption(t *testing.T) {
+	provider := &scriptedProvider{answers: []string{`{"label": "spam", "confidence": 0.`, `{"label": "spam", "confidence": 0.`}}
+	client, _ := NewClient(WithModel("mock/test"))
+	client.SetProvider("mock", provider)
+
+	if _, err := client.Classify(context.Background(), "Buy now!", []string{"spam", "ham"}); err == nil {
+		t.Error("Expected truncated output to fail without repair")
+	}
+	result, err := client.Classify(context.Background(), "Buy now!", []string{"spam", "ham"}, WithJSONRepair())
+	if err != nil || result.Label != "spam" || result.Metadata["json_repaired"] != true {
+		t.Errorf("Classify() = %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,57 @@
+package echo
+
+import (
+	"fmt"
+	"math"
+)
+
+// normalizeScores rescales rerank scores in place using the given method
+// checkScoreNormalization rejects an unknown method before the rerank request is made
+func checkScoreNormalization(cfg CallConfig) error {
+	switch cfg.ScoreNormalization {
+	case "", NormalizeMinMax, NormalizeSoftmax:
+		return nil
+	}
+	return fmt.Errorf("unknown score normalization: %s", cfg.ScoreNormalization)
+}
+
+func normalizeScores(scores []float32, method string) error {
+	if len(scores) == 0 {
+		return nil
+	}
+
+	switch method {
+	case NormalizeMinMax:
+		lo, hi := scores[0], scores[0]
+		for _, s := range scores {
+			lo = min(lo, s)
+			hi = max(hi, s)
+		}
+		for i, s := range scores {
+			if hi == lo {
+				scores[i] = 1
+			} else {
+				scores[i] = (s - lo) / (hi - lo)
+			}
+		}
+	case NormalizeSoftmax:
+		// Subtract the max score for numerical stability
+		hi := scores[0]
+		for _, s := range scores {
+			hi = max(hi, s)
+		}
+		var sum float64
+		exps := make([]float64, len(scores))
+		for i, s := range scores {
+			exps[i] = math.Exp(float64(s - hi))
+			sum += exps[i]
+		}
+		for i := range scores {
+			scores[i] = float32(exps[i] / sum)
+		}
+	default:
+		return fmt.Errorf("unknown score normalization: %s", method)
+	}
+
+	return nil
+}
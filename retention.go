@@ -0,0 +1,151 @@
+package echo
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// UserDataStore is implemented by stores that can erase the records of an end user,
+// as identified by WithUserID, e.g. to serve a GDPR deletion request
+type UserDataStore interface {
+	DeleteByUser(ctx context.Context, userID string) error
+}
+
+// RetentionPolicy limits how long and how many records a store keeps
+type RetentionPolicy struct {
+	MaxAge     time.Duration // records older than this are deleted, 0 keeps them
+	MaxPerUser int           // only the newest records of each user are kept, 0 keeps all
+}
+
+// RetentionStore is implemented by stores that enforce a retention policy
+type RetentionStore interface {
+	ApplyRetention(ctx context.Context, policy RetentionPolicy) error
+}
+
+// DeleteUserData erases the records of the user from every store implementing UserDataStore.
+// Other stores, and wrappers around them returning errors.ErrUnsupported, are skipped;
+// all stores are tried and their errors joined.
+func DeleteUserData(ctx context.Context, userID string, stores ...any) error {
+	if userID == "" {
+		return errors.New("user ID is required")
+	}
+	var errs []error
+	for _, store := range stores {
+		if s, ok := store.(UserDataStore); ok {
+			if err := s.DeleteByUser(ctx, userID); err != nil && !errors.Is(err, errors.ErrUnsupported) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ApplyRetention enforces the policy on every store implementing RetentionStore, skipping
+// the others as DeleteUserData does. Call it periodically, e.g. once a day.
+func ApplyRetention(ctx context.Context, policy RetentionPolicy, stores ...any) error {
+	var errs []error
+	for _, store := range stores {
+		if s, ok := store.(RetentionStore); ok {
+			if err := s.ApplyRetention(ctx, policy); err != nil && !errors.Is(err, errors.ErrUnsupported) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MemoryResponseStore is an in-process ResponseStore with feedback, user deletion and
+// retention support, for tests and single-binary deployments
+type MemoryResponseStore struct {
+	mu       sync.Mutex
+	records  []ResponseRecord
+	feedback []Feedback
+}
+
+// NewMemoryResponseStore creates an empty store
+func NewMemoryResponseStore() *MemoryResponseStore {
+	return &MemoryResponseStore{}
+}
+
+// SaveResponse implements the ResponseStore interface
+func (s *MemoryResponseStore) SaveResponse(ctx context.Context, record ResponseRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+// SaveFeedback implements the FeedbackStore interface
+func (s *MemoryResponseStore) SaveFeedback(ctx context.Context, feedback Feedback) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.feedback = append(s.feedback, feedback)
+	return nil
+}
+
+// Records returns the saved responses in the order they were saved
+func (s *MemoryResponseStore) Records() []ResponseRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.records)
+}
+
+// Feedback returns the saved feedback in the order it was saved
+func (s *MemoryResponseStore) Feedback() []Feedback {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.feedback)
+}
+
+// DeleteByUser implements the UserDataStore interface.
+// The feedback on the deleted responses is deleted with them.
+func (s *MemoryResponseStore) DeleteByUser(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteRecords(func(r ResponseRecord) bool { return r.UserID == userID })
+	s.feedback = slices.DeleteFunc(s.feedback, func(f Feedback) bool { return f.UserID == userID })
+	return nil
+}
+
+// ApplyRetention implements the RetentionStore interface
+func (s *MemoryResponseStore) ApplyRetention(ctx context.Context, policy RetentionPolicy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if policy.MaxAge > 0 {
+		cutoff := time.Now().Add(-policy.MaxAge)
+		s.deleteRecords(func(r ResponseRecord) bool { return r.Finished.Before(cutoff) })
+		s.feedback = slices.DeleteFunc(s.feedback, func(f Feedback) bool { return f.Created.Before(cutoff) })
+	}
+	if policy.MaxPerUser > 0 {
+		// Records are appended as they finish, so the oldest are deleted first
+		count := map[string]int{}
+		for _, r := range s.records {
+			count[r.UserID]++
+		}
+		s.deleteRecords(func(r ResponseRecord) bool {
+			if r.UserID == "" || count[r.UserID] <= policy.MaxPerUser {
+				return false
+			}
+			count[r.UserID]--
+			return true
+		})
+	}
+	return nil
+}
+
+// deleteRecords removes the matching records and the feedback on them
+func (s *MemoryResponseStore) deleteRecords(match func(ResponseRecord) bool) {
+	deleted := map[string]bool{}
+	s.records = slices.DeleteFunc(s.records, func(r ResponseRecord) bool {
+		if match(r) {
+			deleted[r.CallID] = true
+			return true
+		}
+		return false
+	})
+	s.feedback = slices.DeleteFunc(s.feedback, func(f Feedback) bool { return deleted[f.CallID] })
+}
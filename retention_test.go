@@ -0,0 +1,71 @@
+package echo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeleteUserData(t *testing.T) {
+	responses := NewMemoryResponseStore()
+	jobs := NewMemoryJobStore(time.Hour)
+	sc, _ := NewStoreCipher(bytes.Repeat([]byte{1}, 32))
+	client, _ := NewCommonClient(nil, WithModel("mock/test"), WithStreamPersistence(sc.ResponseStore(responses)))
+	ctx := context.Background()
+
+	for _, user := range []string{"ann", "bob"} {
+		resp, err := client.Complete(ctx, QuickMessage("Hi"), WithUserID(user))
+		if err != nil {
+			t.Fatalf("Complete() error = %v", err)
+		}
+		client.RecordFeedback(ctx, resp.Metadata["call_id"].(string), 1, "", WithUserID(user))
+		jobs.Save(ctx, AsyncJob{ID: "job_" + user, UserID: user, UpdatedAt: time.Now()})
+	}
+	if records := responses.Records(); len(records) != 2 || records[0].UserID != "ann" || responses.Feedback()[1].UserID != "bob" {
+		t.Fatalf("Expected records with user IDs, got %+v", records)
+	}
+
+	// Stores without deletion support are skipped
+	err := DeleteUserData(ctx, "ann", client, sc.ResponseStore(make(chanResponseStore)), sc.ResponseStore(responses), jobs)
+	if err != nil {
+		t.Fatalf("DeleteUserData() error = %v", err)
+	}
+	if records := responses.Records(); len(records) != 1 || records[0].UserID != "bob" || len(responses.Feedback()) != 1 {
+		t.Errorf("Expected only the records of bob, got %+v", records)
+	}
+	if _, err := jobs.Get(ctx, "job_ann"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Expected the job of ann to be deleted, got %v", err)
+	}
+	if _, err := jobs.Get(ctx, "job_bob"); err != nil {
+		t.Errorf("Expected the job of bob to be kept, got %v", err)
+	}
+
+	if err := DeleteUserData(ctx, "", responses); err == nil {
+		t.Error("Expected an error for an empty user ID")
+	}
+}
+
+func TestApplyRetention(t *testing.T) {
+	store := NewMemoryResponseStore()
+	ctx := context.Background()
+	now := time.Now()
+	for i, user := range []string{"ann", "ann", "bob", "ann", ""} {
+		age := time.Duration(5-i) * time.Hour
+		store.SaveResponse(ctx, ResponseRecord{CallID: string(rune('a' + i)), UserID: user, Finished: now.Add(-age)})
+		store.SaveFeedback(ctx, Feedback{CallID: string(rune('a' + i)), Created: now})
+	}
+
+	// The oldest record is too old, then ann keeps her newest record only
+	if err := ApplyRetention(ctx, RetentionPolicy{MaxAge: 4*time.Hour + time.Minute, MaxPerUser: 1}, store); err != nil {
+		t.Fatalf("ApplyRetention() error = %v", err)
+	}
+	var ids string
+	for _, r := range store.Records() {
+		ids += r.CallID
+	}
+	if ids != "cde" || len(store.Feedback()) != 3 {
+		t.Errorf("Expected records c, d and e with their feedback, got %q, %d feedback", ids, len(store.Feedback()))
+	}
+}
@@ -0,0 +1,28 @@
+package echo
+
+import "slices"
+
+// TemperatureSchedule returns a retry schedule making one attempt per temperature,
+// e.g. TemperatureSchedule(0, 0.3, 0.7) for WithRetrySchedule
+func TemperatureSchedule(temps ...float32) func(attempt int) []CallOption {
+	return func(attempt int) []CallOption {
+		if attempt < 1 || attempt > len(temps) {
+			return nil
+		}
+		return []CallOption{WithTemperature(temps[attempt-1])}
+	}
+}
+
+// attemptOptions returns the options of an attempt of a retry loop and whether it should be made.
+// The schedule set by WithRetrySchedule decides when present, otherwise the loop makes maxAttempts calls.
+func attemptOptions(schedule func(int) []CallOption, opts []CallOption, attempt, maxAttempts int) ([]CallOption, bool) {
+	if schedule == nil {
+		return opts, attempt <= maxAttempts
+	}
+
+	extra := schedule(attempt)
+	if extra == nil && attempt > 1 {
+		return nil, false
+	}
+	return append(slices.Clip(opts), extra...), true
+}
@@ -0,0 +1,60 @@
+package echo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRetrySchedule(t *testing.T) {
+	provider := &scriptedProvider{answers: []string{"not json", "{\"name\":", `{"name": "Ann"}`}}
+	client, _ := NewClient(WithModel("mock/any"))
+	client.SetProvider("mock", provider)
+
+	type person struct {
+		Name string `json:"name"`
+	}
+	result, err := Extract[person](context.Background(), client, "Ann is here",
+		WithRetrySchedule(TemperatureSchedule(0, 0.3, 0.7)))
+	if err != nil || result.Name != "Ann" {
+		t.Fatalf("Extract() = %+v, %v", result, err)
+	}
+
+	want := []float32{0, 0.3, 0.7}
+	if len(provider.configs) != len(want) {
+		t.Fatalf("Expected %d attempts, got %d", len(want), len(provider.configs))
+	}
+	for i, cfg := range provider.configs {
+		if cfg.Temperature == nil || *cfg.Temperature != want[i] {
+			t.Errorf("Attempt %d: temperature = %v, want %v", i+1, cfg.Temperature, want[i])
+		}
+	}
+
+	// The schedule limits the number of attempts
+	provider.answers = []string{"bad", "bad"}
+	provider.configs = nil
+	if _, err := Extract[person](context.Background(), client, "x", WithRetrySchedule(TemperatureSchedule(0.2))); err == nil {
+		t.Error("Expected an error for invalid output")
+	}
+	if len(provider.configs) != 1 {
+		t.Errorf("Expected 1 attempt, got %d", len(provider.configs))
+	}
+}
+
+func TestClientRetrySchedule(t *testing.T) {
+	provider := &scriptedProvider{answers: []string{"bad", "bad", `{"name": "Ann"}`}}
+	client, _ := NewCommonClient(nil, WithModel("mock/any"), WithRetrySchedule(TemperatureSchedule(0.1, 0.5)))
+	client.SetProvider("mock", provider)
+
+	type person struct {
+		Name string `json:"name"`
+	}
+	if _, err := Generate[person](context.Background(), client, QuickMessage("Ann is here")); err == nil {
+		t.Error("Expected an error for invalid output")
+	}
+	if len(provider.configs) != 2 {
+		t.Fatalf("Expected 2 attempts from the client schedule, got %d", len(provider.configs))
+	}
+	if temp := provider.configs[1].Temperature; temp == nil || *temp != 0.5 {
+		t.Errorf("Attempt 2: temperature = %v, want 0.5", temp)
+	}
+}
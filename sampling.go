@@ -0,0 +1,27 @@
+package echo
+
+import "fmt"
+
+// checkSampling verifies that sampling parameters are within the ranges providers accept
+// and that the number of answers can be generated
+func checkSampling(cfg CallConfig) error {
+	if cfg.N < 0 {
+		return fmt.Errorf("number of answers must be positive, got %d", cfg.N)
+	}
+	if cfg.N > 1 && cfg.AutoContinue > 1 {
+		return fmt.Errorf("multiple answers can't be continued, remove WithAutoContinue or WithN")
+	}
+	if cfg.TopP != nil && (*cfg.TopP < 0 || *cfg.TopP > 1) {
+		return fmt.Errorf("top p %v is out of range 0..1", *cfg.TopP)
+	}
+	if cfg.TopK != nil && *cfg.TopK < 1 {
+		return fmt.Errorf("top k must be positive, got %d", *cfg.TopK)
+	}
+	if cfg.FrequencyPenalty != nil && (*cfg.FrequencyPenalty < -2 || *cfg.FrequencyPenalty > 2) {
+		return fmt.Errorf("frequency penalty %v is out of range -2..2", *cfg.FrequencyPenalty)
+	}
+	if cfg.PresencePenalty != nil && (*cfg.PresencePenalty < -2 || *cfg.PresencePenalty > 2) {
+		return fmt.Errorf("presence penalty %v is out of range -2..2", *cfg.PresencePenalty)
+	}
+	return nil
+}
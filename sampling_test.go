@@ -0,0 +1,190 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSampling(t *testing.T) {
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = nil
+		json.NewDecoder(r.Body).Decode(&request)
+		switch {
+		case r.Header.Get("anthropic-version") != "":
+			w.Write([]byte(`{"content": [{"type": "text", "text": "ok"}]}`))
+		case r.Header.Get("x-goog-api-key") != "":
+			w.Write([]byte(`{"candidates": [{"content": {"parts": [{"text": "ok"}]}, "finishReason": "STOP"}]}`))
+		default:
+			w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "ok"}}]}`))
+		}
+	}))
+	defer server.Close()
+
+	keys := map[string]string{"openai": "key", "anthropic": "key", "google": "key", "xai": "key", "openrouter": "key"}
+	client, _ := NewCommonClient(keys, WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	tests := []struct {
+		model   string
+		opts    []CallOption
+		request string
+	}{
+		{"openai/gpt-4o", []CallOption{WithTopP(0.9), WithFrequencyPenalty(0.5), WithPresencePenalty(-0.5)},
+			`{"frequency_penalty":0.5,"presence_penalty":-0.5,"top_p":0.9}`},
+		{"openrouter/meta-llama/llama-3.3-70b", []CallOption{WithTopP(0.9), WithTopK(40)},
+			`{"top_k":40,"top_p":0.9}`},
+		{"xai/grok-3", []CallOption{WithTopP(0.9), WithPresencePenalty(1)},
+			`{"presence_penalty":1,"top_p":0.9}`},
+		{"anthropic/claude-sonnet-4-5", []CallOption{WithTopP(0.9), WithTopK(40)},
+			`{"top_k":40,"top_p":0.9}`},
+		{"google/gemini-2.5-flash", []CallOption{WithTopP(0.9), WithTopK(40), WithFrequencyPenalty(0.5), WithPresencePenalty(0.5)},
+			`{"frequencyPenalty":0.5,"presencePenalty":0.5,"topK":40,"topP":0.9}`},
+	}
+	for _, tt := range tests {
+		if _, err := client.Complete(ctx, QuickMessage("Hi"), append(tt.opts, WithModel(tt.model))...); err != nil {
+			t.Fatalf("%s: Complete() error = %v", tt.model, err)
+		}
+		fields := request
+		if config, ok := request["generationConfig"].(map[string]any); ok {
+			fields = config
+		}
+		sampling := map[string]any{}
+		for _, key := range []string{"top_p", "top_k", "frequency_penalty", "presence_penalty", "topP", "topK", "frequencyPenalty", "presencePenalty"} {
+			if v, ok := fields[key]; ok {
+				sampling[key] = v
+			}
+		}
+		if data, _ := json.Marshal(sampling); string(data) != tt.request {
+			t.Errorf("%s: sampling %s, want %s", tt.model, data, tt.request)
+		}
+	}
+
+	failures := []struct {
+		model string
+		opt   CallOption
+	}{
+		{"openai/gpt-4o", WithTopK(40)},
+		{"xai/grok-3", WithTopK(40)},
+		{"anthropic/claude-sonnet-4-5", WithFrequencyPenalty(0.5)},
+		{"openai/gpt-4o", WithTopP(1.5)},
+		{"google/gemini-2.5-flash", WithPresencePenalty(3)},
+		{"google/gemini-2.5-flash", WithTopK(0)},
+	}
+	for _, tt := range failures {
+		if _, err := client.Complete(ctx, QuickMessage("Hi"), WithModel(tt.model), tt.opt); err == nil {
+			t.Errorf("%s: expected an error", tt.model)
+		}
+	}
+}
+
+func TestSeed(t *testing.T) {
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = nil
+		json.NewDecoder(r.Body).Decode(&request)
+		switch {
+		case r.Header.Get("x-goog-api-key") != "":
+			w.Write([]byte(`{"candidates": [{"content": {"parts": [{"text": "ok"}]}, "finishReason": "STOP"}]}`))
+		case request["stream"] == true:
+			w.Write([]byte(`data: {"system_fingerprint": "fp_1", "choices": [{"delta": {"content": "ok"}}]}` + "\n\n" +
+				`data: {"system_fingerprint": "fp_1", "choices": [], "usage": {"total_tokens": 3}}` + "\n\n" +
+				"data: [DONE]\n\n"))
+		default:
+			w.Write([]byte(`{"system_fingerprint": "fp_1", "choices": [{"message": {"role": "assistant", "content": "ok"}}]}`))
+		}
+	}))
+	defer server.Close()
+
+	keys := map[string]string{"openai": "key", "anthropic": "key", "google": "key", "xai": "key"}
+	client, _ := NewCommonClient(keys, WithBaseURL(server.URL), WithSeed(42))
+	ctx := context.Background()
+
+	for _, model := range []string{"openai/gpt-4o", "xai/grok-3"} {
+		resp, err := client.Complete(ctx, QuickMessage("Hi"), WithModel(model))
+		if err != nil {
+			t.Fatalf("%s: Complete() error = %v", model, err)
+		}
+		if request["seed"] != float64(42) || resp.Metadata["system_fingerprint"] != "fp_1" {
+			t.Errorf("%s: seed %v, metadata %v", model, request["seed"], resp.Metadata)
+		}
+
+		stream, err := client.StreamComplete(ctx, QuickMessage("Hi"), WithModel(model))
+		if err != nil {
+			t.Fatalf("%s: StreamComplete() error = %v", model, err)
+		}
+		var fingerprint any
+		for chunk := range stream.Stream {
+			if chunk.Meta != nil && (*chunk.Meta)["system_fingerprint"] != nil {
+				fingerprint = (*chunk.Meta)["system_fingerprint"]
+			}
+		}
+		if fingerprint != "fp_1" {
+			t.Errorf("%s: stream fingerprint %v", model, fingerprint)
+		}
+	}
+
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithModel("google/gemini-2.5-flash")); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if seed := request["generationConfig"].(map[string]any)["seed"]; seed != float64(42) {
+		t.Errorf("Expected the Gemini seed in the generation config, got %v", seed)
+	}
+
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithModel("anthropic/claude-sonnet-4-5")); err == nil {
+		t.Error("Expected an error for a seed on Anthropic")
+	}
+}
+
+func TestMultipleChoices(t *testing.T) {
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = nil
+		json.NewDecoder(r.Body).Decode(&request)
+		if r.Header.Get("x-goog-api-key") != "" {
+			w.Write([]byte(`{"candidates": [{"content": {"parts": [{"text": "one"}]}, "finishReason": "STOP"},
+				{"content": {"parts": [{"text": "two. END"}]}, "finishReason": "MAX_TOKENS"}]}`))
+			return
+		}
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "one"}, "finish_reason": "stop"},
+			{"message": {"role": "assistant", "content": "two. END"}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	keys := map[string]string{"openai": "key", "anthropic": "key", "google": "key"}
+	client, _ := NewCommonClient(keys, WithBaseURL(server.URL), WithN(2))
+	ctx := context.Background()
+
+	for _, model := range []string{"openai/gpt-4o", "google/gemini-2.5-flash"} {
+		resp, err := client.Complete(ctx, QuickMessage("Hi"), WithModel(model), WithStopSequences(" END"))
+		if err != nil {
+			t.Fatalf("%s: Complete() error = %v", model, err)
+		}
+		if request["n"] != float64(2) && request["generationConfig"].(map[string]any)["candidateCount"] != float64(2) {
+			t.Errorf("%s: expected 2 answers in the request, got %v", model, request)
+		}
+		if resp.Text != "one" || len(resp.Choices) != 2 || resp.Choices[0].Text != "one" ||
+			resp.Choices[1].Text != "two." || resp.Choices[1].FinishReason != FinishStop {
+			t.Errorf("%s: unexpected answers %q, %+v", model, resp.Text, resp.Choices)
+		}
+	}
+
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithModel("anthropic/claude-sonnet-4-5")); err == nil {
+		t.Error("Expected an error for multiple answers on Anthropic")
+	}
+	if _, err := client.StreamComplete(ctx, QuickMessage("Hi"), WithModel("openai/gpt-4o")); err == nil {
+		t.Error("Expected an error for multiple answers in a stream")
+	}
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithModel("openai/gpt-4o"), WithAutoContinue(3)); err == nil {
+		t.Error("Expected an error for continued multiple answers")
+	}
+
+	mock, _ := NewCommonClient(nil, WithModel("mock/any"), WithN(3))
+	resp, err := mock.Complete(ctx, QuickMessage("Hi"))
+	if err != nil || len(resp.Choices) != 3 || resp.Choices[2].Text != resp.Text {
+		t.Errorf("Unexpected mock answers %+v, %v", resp, err)
+	}
+}
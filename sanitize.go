@@ -0,0 +1,70 @@
+package echo
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Sanitation rules, see WithSanitation
+const (
+	SanitizeEmpty   = "empty"   // drop messages without content
+	SanitizePrefill = "prefill" // trim trailing whitespace of a final agent message (prefill)
+	SanitizeUnicode = "unicode" // repair invalid UTF-8, drop control and zero-width characters, use \n line ends
+)
+
+var sanitizeRules = []string{SanitizeEmpty, SanitizePrefill, SanitizeUnicode}
+
+// unicodeCleaner removes characters providers reject or silently mangle
+var unicodeCleaner = strings.NewReplacer("\r\n", "\n", "\r", "\n", "\u200b", "", "\ufeff", "")
+
+// SanitizeMessages applies the sanitation rules to a copy of the message chain, all rules
+// when none are given, and returns it together with the number of messages changed or dropped
+func SanitizeMessages(messages []Message, rules ...string) ([]Message, int, error) {
+	if len(rules) == 0 {
+		rules = sanitizeRules
+	}
+	for _, rule := range rules {
+		if !slices.Contains(sanitizeRules, rule) {
+			return nil, 0, fmt.Errorf("unknown sanitation rule: %s", rule)
+		}
+	}
+
+	out := make([]Message, 0, len(messages))
+	count := 0
+	for _, msg := range messages {
+		original := msg.Content
+		if slices.Contains(rules, SanitizeUnicode) {
+			msg.Content = cleanUnicode(msg.Content)
+		}
+		if slices.Contains(rules, SanitizeEmpty) && msg.Role != ToolResult &&
+			strings.TrimSpace(msg.Content) == "" && len(msg.Parts) == 0 && len(msg.ToolCalls) == 0 {
+			count++
+			continue
+		}
+		if msg.Content != original {
+			count++
+		}
+		out = append(out, msg)
+	}
+
+	if n := len(out); n > 0 && out[n-1].Role == Agent && slices.Contains(rules, SanitizePrefill) {
+		if trimmed := strings.TrimRight(out[n-1].Content, " \t\n"); trimmed != out[n-1].Content {
+			out[n-1].Content = trimmed
+			count++
+		}
+	}
+	return out, count, nil
+}
+
+// cleanUnicode replaces invalid UTF-8, drops control characters other than tabs and
+// line breaks, zero-width spaces and byte order marks, and converts line ends to \n
+func cleanUnicode(text string) string {
+	text = unicodeCleaner.Replace(strings.ToValidUTF8(text, "\ufffd"))
+	return strings.Map(func(r rune) rune {
+		if (r < 0x20 && r != '\n' && r != '\t') || r == 0x7f {
+			return -1
+		}
+		return r
+	}, text)
+}
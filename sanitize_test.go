@@ -0,0 +1,51 @@
+package echo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSanitizeMessages(t *testing.T) {
+	messages := []Message{
+		{Role: System, Content: "Be brief\r\n"},
+		{Role: User, Content: "Hi\u200b there\x00"},
+		{Role: Agent, Content: "  "},
+		{Role: User, Content: "Bad \xff byte"},
+		{Role: Agent, Content: "The answer is \n"},
+	}
+
+	out, count, err := SanitizeMessages(messages)
+	if err != nil {
+		t.Fatalf("SanitizeMessages() error = %v", err)
+	}
+	want := []string{"Be brief\n", "Hi there", "Bad \ufffd byte", "The answer is"}
+	if len(out) != len(want) || count != 5 {
+		t.Fatalf("SanitizeMessages() = %+v, %d", out, count)
+	}
+	for i, msg := range out {
+		if msg.Content != want[i] {
+			t.Errorf("Message %d = %q, want %q", i, msg.Content, want[i])
+		}
+	}
+	if messages[4].Content != "The answer is \n" {
+		t.Error("Expected the original chain to be unchanged")
+	}
+
+	// Only the requested rules apply
+	out, _, _ = SanitizeMessages(messages, SanitizePrefill)
+	if len(out) != 5 || out[4].Content != "The answer is" || out[1].Content != messages[1].Content {
+		t.Errorf("Unexpected prefill-only result %+v", out)
+	}
+	if _, _, err := SanitizeMessages(messages, "emoji"); err == nil {
+		t.Error("Expected an error for an unknown rule")
+	}
+
+	client, _ := NewCommonClient(nil, WithModel("mock/test"))
+	resp, err := client.Complete(context.Background(), messages[1:3], WithSanitation())
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Metadata["sanitized"] != 2 {
+		t.Errorf("Expected 2 sanitized messages, got %v", resp.Metadata["sanitized"])
+	}
+}
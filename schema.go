@@ -0,0 +1,146 @@
+package echo
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// SchemaOf derives a JSON Schema from the Go type of v.
+// Field names follow `json` tags, `description:"..."` tags document fields
+// and `enum:"a,b,c"` tags restrict string values. All fields are required,
+// as strict structured output modes demand; pointer fields are nullable.
+// []byte fields are base64 strings, as encoding/json expects; maps are not supported,
+// since strict modes accept objects with fixed properties only.
+func SchemaOf(v any) (map[string]any, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, fmt.Errorf("cannot derive schema from nil")
+	}
+	return SchemaOfType(t)
+}
+
+// SchemaOfType derives a JSON Schema from a reflect.Type, see SchemaOf
+func SchemaOfType(t reflect.Type) (map[string]any, error) {
+	return schemaForType(t, map[reflect.Type]bool{})
+}
+
+func schemaForType(t reflect.Type, visiting map[reflect.Type]bool) (map[string]any, error) {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	case rawMessageType:
+		return map[string]any{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema, err := schemaForType(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		if typ, ok := schema["type"].(string); ok {
+			schema["type"] = []string{typ, "null"}
+		}
+		return schema, nil
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.Slice, reflect.Array:
+		// encoding/json reads []byte from a base64 string
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}, nil
+		}
+		items, err := schemaForType(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		// Strict structured output allows objects with fixed properties only
+		return nil, fmt.Errorf("map type is not supported by structured output, use a slice of structs: %s", t)
+	case reflect.Interface:
+		return map[string]any{}, nil
+	case reflect.Struct:
+		return schemaForStruct(t, visiting)
+	}
+
+	return nil, fmt.Errorf("unsupported type: %s", t)
+}
+
+func schemaForStruct(t reflect.Type, visiting map[reflect.Type]bool) (map[string]any, error) {
+	if visiting[t] {
+		return nil, fmt.Errorf("recursive type is not supported: %s", t)
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	properties := map[string]any{}
+	required := []string{}
+
+	var addFields func(t reflect.Type) error
+	addFields = func(t reflect.Type) error {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+
+			// Embedded structs without a json name are flattened, as encoding/json does
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				if err := addFields(field.Type); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if !field.IsExported() {
+				continue
+			}
+
+			if name == "" {
+				name = field.Name
+			}
+
+			schema, err := schemaForType(field.Type, visiting)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			if desc := field.Tag.Get("description"); desc != "" {
+				schema["description"] = desc
+			}
+			if enum := field.Tag.Get("enum"); enum != "" {
+				schema["enum"] = strings.Split(enum, ",")
+			}
+
+			properties[name] = schema
+			required = append(required, name)
+		}
+		return nil
+	}
+
+	if err := addFields(t); err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}, nil
+}
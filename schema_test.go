@@ -0,0 +1,221 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type schemaAddress struct {
+	City string `json:"city"`
+}
+
+type schemaPerson struct {
+	schemaAddress
+	Name    string   `json:"name" description:"Full name"`
+	Age     *int     `json:"age,omitempty"`
+	Tags    []string `json:"tags"`
+	Mood    string   `json:"mood" enum:"happy,sad"`
+	Photo   []byte   `json:"photo"`
+	Skipped string   `json:"-"`
+	hidden  string
+}
+
+func TestSchemaOf(t *testing.T) {
+	schema, err := SchemaOf(schemaPerson{})
+	if err != nil {
+		t.Fatalf("SchemaOf() error = %v", err)
+	}
+
+	expected := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"city":  map[string]any{"type": "string"},
+			"name":  map[string]any{"type": "string", "description": "Full name"},
+			"age":   map[string]any{"type": []string{"integer", "null"}},
+			"tags":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"mood":  map[string]any{"type": "string", "enum": []string{"happy", "sad"}},
+			"photo": map[string]any{"type": "string", "contentEncoding": "base64"},
+		},
+		"required":             []string{"city", "name", "age", "tags", "mood", "photo"},
+		"additionalProperties": false,
+	}
+
+	if !reflect.DeepEqual(schema, expected) {
+		got, _ := json.MarshalIndent(schema, "", "  ")
+		t.Errorf("SchemaOf() = %s", got)
+	}
+}
+
+func TestSchemaOfUnsupported(t *testing.T) {
+	type recursive struct {
+		Next *recursive `json:"next"`
+	}
+	if _, err := SchemaOf(recursive{}); err == nil {
+		t.Errorf("Expected error for recursive type")
+	}
+	if _, err := SchemaOf(map[int]string{}); err == nil {
+		t.Errorf("Expected error for non-string map keys")
+	}
+	type labels struct {
+		Labels map[string]string `json:"labels"`
+	}
+	if _, err := SchemaOf(labels{}); err == nil {
+		t.Errorf("Expected error for map fields")
+	}
+}
+
+func TestExtract(t *testing.T) {
+	client, err := NewCommonClient(nil, WithModel("mock/test"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	// The mock provider returns a fixed JSON document for structured output
+	type mockResult struct {
+		MockResponse bool   `json:"mock_response"`
+		SchemaName   string `json:"schema_name"`
+	}
+	result, err := Extract[mockResult](context.Background(), client, "some text")
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if !result.MockResponse || result.SchemaName != "mockresult" {
+		t.Errorf("Extract() = %+v", result)
+	}
+
+	if _, err := Extract[string](context.Background(), client, "some text"); err == nil {
+		t.Errorf("Expected error for non-struct type")
+	}
+}
+
+func TestExtractSystemMessage(t *testing.T) {
+	var body OpenAIRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"choices": [{"message": {"content": "{\"name\": \"Ann\"}"}}]}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewCommonClient(map[string]string{"openai": "key"}, WithModel("openai/gpt-4.1"),
+		WithBaseURL(server.URL), WithSystemMessage("You are helpful"))
+	type person struct {
+		Name string `json:"name"`
+	}
+	result, err := Extract[person](context.Background(), client, "Ann is 30")
+	if err != nil || result.Name != "Ann" {
+		t.Fatalf("Extract() = %+v, %v", result, err)
+	}
+
+	// The client prompt is kept and the instructions are added to it, the text is sent as is
+	if len(body.Messages) != 2 || body.Messages[0].Role != "system" || body.Messages[1].Content != "Ann is 30" {
+		t.Fatalf("Unexpected messages: %+v", body.Messages)
+	}
+	if system := body.Messages[0].Content; system != "You are helpful\n\n"+extractInstruction {
+		t.Errorf("Unexpected system prompt: %q", system)
+	}
+
+	// WithSystemMessage replaces the chain system message without dropping the user message
+	client.Complete(context.Background(), []Message{{Role: System, Content: "Be brief"}, {Role: User, Content: "Hi"}})
+	if len(body.Messages) != 2 || body.Messages[0].Content != "You are helpful" || body.Messages[1].Content != "Hi" {
+		t.Errorf("Unexpected messages: %+v", body.Messages)
+	}
+}
+
+func TestTyped(t *testing.T) {
+	client, _ := NewCommonClient(nil, WithModel("mock/test"))
+
+	type verdict struct {
+		MockResponse bool   `json:"mock_response"`
+		SchemaName   string `json:"schema_name"`
+	}
+	typed := Typed[verdict](client, WithTemperature(0))
+	result, err := typed.Complete(context.Background(), QuickMessage("Is it done?"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if !result.MockResponse || result.SchemaName != "verdict" {
+		t.Errorf("Complete() = %+v", result)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	provider := &scriptedProvider{answers: []string{`{"city": "Par`, `{"city": "Paris"}`}}
+	client, _ := NewClient(WithModel("mock/test"))
+	client.SetProvider("mock", provider)
+
+	address, err := Generate[schemaAddress](context.Background(), client, QuickMessage("Where is the Louvre?"))
+	if err != nil || address.City != "Paris" {
+		t.Fatalf("Generate() = %+v, %v", address, err)
+	}
+	if len(provider.calls) != 2 || provider.configs[0].StructuredOutput.Name != "schemaaddress" {
+		t.Errorf("Expected a retry with structured output, got %d calls", len(provider.calls))
+	}
+}
+
+func TestResponseSchema(t *testing.T) {
+	var body AnthropicRequest
+	var beta string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		beta = r.Header.Get("anthropic-beta")
+		body = AnthropicRequest{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if len(body.Tools) > 0 {
+			w.Write([]byte(`{"content": [{"type": "tool_use", "id": "t1", "name": "response", "input": {"city": "Paris"}}],
+				"stop_reason": "tool_use"}`))
+			return
+		}
+		w.Write([]byte(`{"content": [{"type": "text", "text": "{\"city\": \"Rome\"}"}], "stop_reason": "end_turn"}`))
+	}))
+	defer server.Close()
+
+	schema, _ := SchemaOf(schemaAddress{})
+	client, _ := NewCommonClient(map[string]string{"anthropic": "key"}, WithBaseURL(server.URL), WithResponseSchema(schema))
+	ctx := context.Background()
+
+	// Models without native structured output get the schema through a forced tool
+	resp, err := client.Complete(ctx, QuickMessage("Capital of France?"), WithModel("anthropic/claude-3-5-haiku-20241022"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if body.OutputFormat != nil || body.ToolChoice == nil || body.ToolChoice.Name != "response" || beta != "" {
+		t.Errorf("Expected a forced schema tool, got %+v, beta %q", body, beta)
+	}
+	if string(resp.JSON) != `{"city": "Paris"}` || len(resp.ToolCalls) != 0 || resp.Metadata["finish_reason"] != FinishStop {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+
+	resp, err = client.Complete(ctx, QuickMessage("Capital of Italy?"), WithModel("anthropic/claude-sonnet-4-5"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if body.OutputFormat == nil || len(body.Tools) != 0 || string(resp.JSON) != `{"city": "Rome"}` {
+		t.Errorf("Expected native structured output, got %+v, %s", body, resp.JSON)
+	}
+}
+
+func TestJSONMode(t *testing.T) {
+	cfg := CallConfig{JSONMode: true}
+	messages := QuickMessage("List three colors as JSON")
+
+	openai, _ := prepareOpenAIRequest(messages, false, cfg)
+	if openai.ResponseFormat == nil || openai.ResponseFormat.Type != "json_object" {
+		t.Errorf("Unexpected OpenAI response format %+v", openai.ResponseFormat)
+	}
+	google, _ := prepareGoogleRequest(messages, cfg)
+	if gc := google.GenerationConfig; gc == nil || gc.ResponseMimeType != "application/json" || gc.ResponseSchema != nil {
+		t.Errorf("Unexpected Gemini config %+v", gc)
+	}
+	if _, err := prepareAnthropicRequest(messages, false, cfg); err == nil {
+		t.Error("Expected an error for Anthropic")
+	}
+
+	// A schema takes precedence
+	cfg.StructuredOutput = &StructuredOutputConfig{Name: "colors", Schema: map[string]any{"type": "object"}}
+	if openai, _ := prepareOpenAIRequest(messages, false, cfg); openai.ResponseFormat.Type != "json_schema" {
+		t.Errorf("Expected the schema format, got %+v", openai.ResponseFormat)
+	}
+}
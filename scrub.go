@@ -0,0 +1,142 @@
+package echo
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Default scrubber patterns. Paths and hosts must start after a separator, so parts
+// of public URLs and words like "and/or" are left alone.
+var (
+	urlPattern      = regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>()\x60]+`)
+	unixPathPattern = regexp.MustCompile(`(^|[\s"'(=\x60])((?:~|/(?:home|Users|root|var|etc|opt|srv|usr|tmp|mnt|data|private|Volumes))(?:/[\w.@+-]+)+/?)`)
+	winPathPattern  = regexp.MustCompile(`\b[A-Za-z]:\\(?:[^\\\s"'<>|]+\\)*[^\\\s"'<>|]*`)
+	hostPattern     = regexp.MustCompile(`(?i)\b(?:[a-z0-9-]+\.)+(?:internal|local|localdomain|corp|lan|intranet|home\.arpa)\b`)
+	ipPattern       = regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}(?::\d+)?\b`)
+)
+
+// internalSuffixes are the domain suffixes of hosts that are not reachable from the internet
+var internalSuffixes = []string{".internal", ".local", ".localdomain", ".corp", ".lan", ".intranet", ".home.arpa"}
+
+// Scrubber removes environment details from prompts: absolute file paths, internal
+// hostnames, private IP addresses and URLs pointing to them, and text matching
+// custom patterns. Unlike PIIMasker the values are not restored in the response.
+type Scrubber struct {
+	patterns []*regexp.Regexp
+}
+
+// NewScrubber creates a scrubber with the default rules and the given extra
+// regular expressions, whose matches are replaced with [REDACTED]
+func NewScrubber(patterns ...string) (*Scrubber, error) {
+	s := &Scrubber{}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scrub pattern %q: %w", p, err)
+		}
+		s.patterns = append(s.patterns, re)
+	}
+	return s, nil
+}
+
+// Scrub returns the text with environment details replaced and the number of replacements
+func (s *Scrubber) Scrub(text string) (string, int) {
+	count := 0
+	replace := func(re *regexp.Regexp, fn func(string) string) {
+		text = re.ReplaceAllStringFunc(text, func(m string) string {
+			out := fn(m)
+			if out != m {
+				count++
+			}
+			return out
+		})
+	}
+
+	for _, re := range s.patterns {
+		replace(re, func(string) string { return "[REDACTED]" })
+	}
+	replace(urlPattern, func(m string) string {
+		if internalURL(m) {
+			return "[INTERNAL_URL]"
+		}
+		return m
+	})
+	replace(unixPathPattern, func(m string) string {
+		sub := unixPathPattern.FindStringSubmatch(m)
+		return sub[1] + "[PATH]"
+	})
+	replace(winPathPattern, func(string) string { return "[PATH]" })
+	replace(hostPattern, func(string) string { return "[HOST]" })
+	replace(ipPattern, func(m string) string {
+		if privateIP(m) {
+			return "[HOST]"
+		}
+		return m
+	})
+	return text, count
+}
+
+// ScrubMessages returns a copy of the messages with the content and text parts scrubbed,
+// together with the number of replacements
+func (s *Scrubber) ScrubMessages(messages []Message) ([]Message, int) {
+	total := 0
+	scrubbed := make([]Message, len(messages))
+	for i, msg := range messages {
+		var n int
+		msg.Content, n = s.Scrub(msg.Content)
+		total += n
+		if len(msg.Parts) > 0 {
+			msg.Parts = append([]Part{}, msg.Parts...)
+			for j, part := range msg.Parts {
+				if part.Type == PartText {
+					msg.Parts[j].Text, n = s.Scrub(part.Text)
+					total += n
+				}
+			}
+		}
+		scrubbed[i] = msg
+	}
+	return scrubbed, total
+}
+
+// privateIP reports whether the dotted address, with an optional port, is a private or loopback IP
+func privateIP(addr string) bool {
+	host, _, ok := strings.Cut(addr, ":")
+	if !ok {
+		host = addr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsPrivate() || ip.IsLoopback())
+}
+
+// internalURL reports whether the URL points to localhost, a private address,
+// a single-label host or a host under an internal domain
+func internalURL(raw string) bool {
+	_, rest, _ := strings.Cut(raw, "://")
+	host := rest
+	if i := strings.IndexAny(host, "/?#"); i != -1 {
+		host = host[:i]
+	}
+	if i := strings.LastIndex(host, "@"); i != -1 {
+		host = host[i+1:]
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast()
+	}
+	if host == "" || !strings.Contains(host, ".") {
+		return true
+	}
+	for _, suffix := range internalSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
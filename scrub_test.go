@@ -0,0 +1,57 @@
+package echo
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestScrubber(t *testing.T) {
+	s, err := NewScrubber(`ACME-\d+`)
+	if err != nil {
+		t.Fatalf("NewScrubber() error = %v", err)
+	}
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"open /home/ann/work/app/main.go and fix it", "open [PATH] and fix it"},
+		{`config at C:\Users\ann\app.yaml`, "config at [PATH]"},
+		{"see http://build.corp/job/12 or https://go.dev/doc/home/x", "see [INTERNAL_URL] or https://go.dev/doc/home/x"},
+		{"call http://localhost:8080/api", "call [INTERNAL_URL]"},
+		{"db is db01.prod.internal at 10.0.3.7:5432", "db is [HOST] at [HOST]"},
+		{"ticket ACME-1234 and/or ~/notes.txt", "ticket [REDACTED] and/or [PATH]"},
+		{"nothing to see at https://example.com", "nothing to see at https://example.com"},
+		{"loopback 127.0.0.1 and public 8.8.8.8", "loopback [HOST] and public 8.8.8.8"},
+		{"costs $10.99, rate 10.5%", "costs $10.99, rate 10.5%"},
+		{"iOS 10.3 and version 127.5", "iOS 10.3 and version 127.5"},
+		{"release 10.2.1 on 192.168.0", "release 10.2.1 on 192.168.0"},
+		{"not an address 10.0.0.300", "not an address 10.0.0.300"},
+	}
+	for _, tt := range tests {
+		if got, _ := s.Scrub(tt.text); got != tt.want {
+			t.Errorf("Scrub(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	if _, err := NewScrubber("("); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
+
+func TestWithScrubbing(t *testing.T) {
+	client, err := NewCommonClient(nil, WithModel("mock/test"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	messages := QuickMessage("Why does /var/lib/app/data.db fail on 192.168.1.20?")
+	resp, err := client.Complete(context.Background(), messages, WithScrubbing())
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if !strings.Contains(resp.Text, "Why does [PATH] fail on [HOST]?") || resp.Metadata["scrubbed"] != 2 {
+		t.Errorf("Complete() = %q, %v", resp.Text, resp.Metadata["scrubbed"])
+	}
+}
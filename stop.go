@@ -0,0 +1,164 @@
+package echo
+
+import (
+	"context"
+	"strings"
+)
+
+// findStop returns the position of the earliest stop sequence in the text and the sequence, or -1
+func findStop(text string, sequences []string) (int, string) {
+	pos, found := -1, ""
+	for _, seq := range sequences {
+		if seq == "" {
+			continue
+		}
+		if i := strings.Index(text, seq); i != -1 && (pos == -1 || i < pos) {
+			pos, found = i, seq
+		}
+	}
+	return pos, found
+}
+
+// stopProvider enforces stop sequences on the output of a provider, for providers
+// that don't support them natively or ignore them for some models
+type stopProvider struct {
+	Provider
+}
+
+func (p stopProvider) call(ctx context.Context, messages []Message, cfg CallConfig) (*Response, error) {
+	resp, err := p.Provider.call(ctx, messages, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if pos, seq := findStop(resp.Text, cfg.StopSequences); pos != -1 {
+		resp.Text = resp.Text[:pos]
+		if resp.Metadata == nil {
+			resp.Metadata = Metadata{}
+		}
+		resp.Metadata["finish_reason"] = FinishStop
+		resp.Metadata["stop_sequence"] = seq
+	}
+	for i, choice := range resp.Choices {
+		if pos, _ := findStop(choice.Text, cfg.StopSequences); pos != -1 {
+			resp.Choices[i].Text = choice.Text[:pos]
+			resp.Choices[i].FinishReason = FinishStop
+		}
+	}
+	return resp, nil
+}
+
+func (p stopProvider) streamCall(ctx context.Context, messages []Message, cfg CallConfig) (*StreamResponse, error) {
+	stream, err := p.Provider.streamCall(ctx, messages, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return stopStream(ctx, stream, cfg.StopSequences), nil
+}
+
+// stopMatcher cuts streamed text at the first stop sequence. It holds back the tail
+// of the text that may be the start of a sequence split between chunks.
+type stopMatcher struct {
+	sequences []string
+	pending   string
+	stopped   string // the sequence that was found
+}
+
+// push returns the text that can be emitted
+func (m *stopMatcher) push(text string) string {
+	if m.stopped != "" {
+		return ""
+	}
+	m.pending += text
+	if pos, seq := findStop(m.pending, m.sequences); pos != -1 {
+		out := m.pending[:pos]
+		m.pending, m.stopped = "", seq
+		return out
+	}
+
+	keep := 0
+	for _, seq := range m.sequences {
+		for n := min(len(seq)-1, len(m.pending)); n > keep; n-- {
+			if strings.HasPrefix(seq, m.pending[len(m.pending)-n:]) {
+				keep = n
+				break
+			}
+		}
+	}
+	out := m.pending[:len(m.pending)-keep]
+	m.pending = m.pending[len(m.pending)-keep:]
+	return out
+}
+
+// flush returns the held back text at the end of the stream
+func (m *stopMatcher) flush() string {
+	out := m.pending
+	m.pending = ""
+	return out
+}
+
+// stopStream returns a stream cut at the first stop sequence. Chunks after the stop only
+// pass their metadata, with finish_reason replaced by FinishStop.
+func stopStream(ctx context.Context, stream *StreamResponse, sequences []string) *StreamResponse {
+	ch := make(chan StreamChunk)
+	go func() {
+		defer close(ch)
+		out := chunkSender{ctx: ctx, ch: ch}
+		m := &stopMatcher{sequences: sequences}
+
+		for chunk := range stream.Stream {
+			wasStopped := m.stopped != ""
+			hadData := chunk.Data != ""
+			if hadData {
+				chunk.Data = m.push(chunk.Data)
+			}
+			if chunk.Error != nil {
+				// The held back text was received before the error, it goes out first
+				if text := chunk.Data + m.flush(); text != "" {
+					chunk.Data = ""
+					if out.send(StreamChunk{Data: text}) != nil {
+						drain(stream)
+						return
+					}
+				}
+			}
+			if wasStopped {
+				chunk.Audio = nil
+				chunk.Meta = stoppedMeta(chunk.Meta)
+			}
+			empty := chunk.Data == "" && len(chunk.Audio) == 0 && chunk.Meta == nil && chunk.Error == nil
+			if !(empty && (hadData || wasStopped)) {
+				if out.send(chunk) != nil || chunk.Error != nil {
+					drain(stream)
+					return
+				}
+			}
+			if !wasStopped && m.stopped != "" {
+				meta := Metadata{"finish_reason": FinishStop, "stop_sequence": m.stopped}
+				if out.send(StreamChunk{Meta: &meta}) != nil {
+					drain(stream)
+					return
+				}
+			}
+		}
+
+		if text := m.flush(); text != "" {
+			out.send(StreamChunk{Data: text})
+		}
+	}()
+	return &StreamResponse{Stream: ch}
+}
+
+// stoppedMeta returns a copy of the metadata with the finish reason replaced by FinishStop
+func stoppedMeta(meta *Metadata) *Metadata {
+	if meta == nil {
+		return nil
+	}
+	copied := Metadata{}
+	for k, v := range *meta {
+		copied[k] = v
+	}
+	if _, ok := copied["finish_reason"]; ok {
+		copied["finish_reason"] = FinishStop
+	}
+	return &copied
+}
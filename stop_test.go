@@ -0,0 +1,89 @@
+package echo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStopSequences(t *testing.T) {
+	client, _ := NewCommonClient(nil, WithModel("mock/any"))
+	ctx := context.Background()
+	messages := QuickMessage("first line\nEND_OF_ANSWER and the rest")
+
+	resp, err := client.Complete(ctx, messages, WithStopSequences("END_OF_ANSWER", "missing"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Text != "[user]: first line\n" {
+		t.Errorf("Expected the answer cut at the stop sequence, got %q", resp.Text)
+	}
+	if resp.Metadata["finish_reason"] != FinishStop || resp.Metadata["stop_sequence"] != "END_OF_ANSWER" {
+		t.Errorf("Unexpected metadata: %v", resp.Metadata)
+	}
+
+	// The mock streams 10 characters at a time, so the sequence is split between chunks
+	stream, err := client.StreamComplete(ctx, messages, WithStopSequences("END_OF_ANSWER"))
+	if err != nil {
+		t.Fatalf("StreamComplete() error = %v", err)
+	}
+	var text strings.Builder
+	meta := Metadata{}
+	for chunk := range stream.Stream {
+		if chunk.Error != nil {
+			t.Fatalf("Stream error = %v", chunk.Error)
+		}
+		if chunk.Meta != nil {
+			for k, v := range *chunk.Meta {
+				meta[k] = v
+			}
+		}
+		text.WriteString(chunk.Data)
+	}
+	if text.String() != "[user]: first line\n" || meta["stop_sequence"] != "END_OF_ANSWER" {
+		t.Errorf("Unexpected stream result: %q, %v", text.String(), meta)
+	}
+}
+
+func TestStopMatcher(t *testing.T) {
+	m := &stopMatcher{sequences: []string{"</answer>"}}
+	var out strings.Builder
+	for _, chunk := range []string{"a <", "b> c </ans", "wer> d"} {
+		out.WriteString(m.push(chunk))
+	}
+	out.WriteString(m.flush())
+	if out.String() != "a <b> c " || m.stopped != "</answer>" {
+		t.Errorf("Unexpected output %q, stopped at %q", out.String(), m.stopped)
+	}
+
+	m = &stopMatcher{sequences: []string{"</answer>"}}
+	out.Reset()
+	for _, chunk := range []string{"no stop </an", "d more"} {
+		out.WriteString(m.push(chunk))
+	}
+	out.WriteString(m.flush())
+	if out.String() != "no stop </and more" || m.stopped != "" {
+		t.Errorf("Expected the full text, got %q", out.String())
+	}
+}
+
+func TestStopStreamError(t *testing.T) {
+	ch := make(chan StreamChunk, 3)
+	ch <- StreamChunk{Data: "partial </ans"}
+	ch <- StreamChunk{Error: fmt.Errorf("connection reset")}
+	close(ch)
+
+	var text strings.Builder
+	var err error
+	for chunk := range stopStream(context.Background(), &StreamResponse{Stream: ch}, []string{"</answer>"}).Stream {
+		if chunk.Error != nil {
+			err = chunk.Error
+			break
+		}
+		text.WriteString(chunk.Data)
+	}
+	if text.String() != "partial </ans" || err == nil {
+		t.Errorf("Expected the held back text before the error, got %q, %v", text.String(), err)
+	}
+}
@@ -0,0 +1,280 @@
+package echo
+
+import (
+	"context"
+	"io"
+	"maps"
+	"net/http"
+)
+
+// chunkSender delivers chunks to the stream consumer until the caller's context is done,
+// so stream goroutines don't block forever when the consumer goes away
+type chunkSender struct {
+	ctx context.Context
+	ch  chan<- StreamChunk
+}
+
+// send delivers the chunk or returns the context error
+func (s chunkSender) send(chunk StreamChunk) error {
+	select {
+	case s.ch <- chunk:
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+// fail reports a stream error; after cancellation the context error is reported
+// instead of the read error caused by the closed connection
+func (s chunkSender) fail(err error) {
+	if s.ctx.Err() != nil {
+		err = s.ctx.Err()
+	}
+	s.send(StreamChunk{Error: err})
+}
+
+// closeOnDone closes the response body when the context is done, which terminates
+// the connection and unblocks pending reads. The returned function stops watching.
+func closeOnDone(ctx context.Context, body io.Closer) func() bool {
+	return context.AfterFunc(ctx, func() { body.Close() })
+}
+
+// WriteTo writes the streamed text to w as it arrives, implementing io.WriterTo.
+// Writers with a Flush method (http.ResponseWriter, bufio.Writer) are flushed after every chunk,
+// so the text shows up immediately in terminals and HTTP responses.
+// The stream is consumed completely, even when writing fails.
+func (s *StreamResponse) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for chunk := range s.Stream {
+		if chunk.Error != nil {
+			go drain(s)
+			return total, chunk.Error
+		}
+		if chunk.Data == "" {
+			continue
+		}
+
+		n, err := io.WriteString(w, chunk.Data)
+		total += int64(n)
+		if err == nil {
+			err = flushWriter(w)
+		}
+		if err != nil {
+			go drain(s)
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// flushWriter flushes buffered writers
+func flushWriter(w io.Writer) error {
+	switch f := w.(type) {
+	case http.Flusher:
+		f.Flush()
+	case interface{ Flush() error }:
+		return f.Flush()
+	}
+	return nil
+}
+
+// textFilter transforms streamed text. It may hold back the tail of the text
+// until more data arrives, and releases it when the stream ends.
+type textFilter interface {
+	push(text string) (string, error)
+	flush() (string, error)
+}
+
+// filterStream returns a stream with the text of data chunks passed through the filters
+func filterStream(ctx context.Context, stream *StreamResponse, filters ...textFilter) *StreamResponse {
+	ch := make(chan StreamChunk)
+
+	go func() {
+		defer close(ch)
+		out := chunkSender{ctx: ctx, ch: ch}
+
+		for chunk := range stream.Stream {
+			if chunk.Data != "" {
+				text, err := pushFilters(filters, chunk.Data)
+				if err != nil {
+					out.send(StreamChunk{Error: err})
+					drain(stream)
+					return
+				}
+				chunk.Data = text
+				if text == "" && len(chunk.Audio) == 0 && chunk.Meta == nil && chunk.Error == nil {
+					continue
+				}
+			}
+			if chunk.Error != nil {
+				// The held back text was received before the error, it goes out first;
+				// text a filter rejects is dropped and the stream error is reported
+				if tail, err := flushFilters(filters); err == nil && chunk.Data+tail != "" {
+					text := chunk.Data + tail
+					chunk.Data = ""
+					if out.send(StreamChunk{Data: text}) != nil {
+						drain(stream)
+						return
+					}
+				}
+			}
+			if out.send(chunk) != nil || chunk.Error != nil {
+				drain(stream)
+				return
+			}
+		}
+
+		text, err := flushFilters(filters)
+		if err != nil {
+			out.send(StreamChunk{Error: err})
+			return
+		}
+		if text != "" {
+			out.send(StreamChunk{Data: text})
+		}
+	}()
+
+	return &StreamResponse{Stream: ch}
+}
+
+// pushFilters passes the text through all filters in order
+func pushFilters(filters []textFilter, text string) (string, error) {
+	var err error
+	for _, f := range filters {
+		if text, err = f.push(text); err != nil {
+			return "", err
+		}
+	}
+	return text, nil
+}
+
+// flushFilters releases held back text, passing the tail of each filter through the following ones
+func flushFilters(filters []textFilter) (string, error) {
+	text := ""
+	for i, f := range filters {
+		if i > 0 && text != "" {
+			var err error
+			if text, err = f.push(text); err != nil {
+				return "", err
+			}
+		}
+		tail, err := f.flush()
+		if err != nil {
+			return "", err
+		}
+		text += tail
+	}
+	return text, nil
+}
+
+// drain consumes the remaining chunks so the producer goroutine can finish
+func drain(stream *StreamResponse) {
+	for range stream.Stream {
+	}
+}
+
+// observeStream passes the chunks through and calls fn with the merged metadata
+// of all chunks once the stream ends
+func observeStream(ctx context.Context, stream *StreamResponse, fn func(Metadata)) *StreamResponse {
+	ch := make(chan StreamChunk)
+	go func() {
+		defer close(ch)
+		out := chunkSender{ctx: ctx, ch: ch}
+
+		meta := Metadata{}
+		defer func() { fn(meta) }()
+
+		for chunk := range stream.Stream {
+			if chunk.Meta != nil {
+				for k, v := range *chunk.Meta {
+					meta[k] = v
+				}
+			}
+			if out.send(chunk) != nil {
+				drain(stream)
+				return
+			}
+		}
+	}()
+	return &StreamResponse{Stream: ch}
+}
+
+// prependMeta returns a stream that emits a metadata chunk before the original chunks
+func prependMeta(ctx context.Context, stream *StreamResponse, meta Metadata) *StreamResponse {
+	ch := make(chan StreamChunk)
+	go func() {
+		defer close(ch)
+		out := chunkSender{ctx: ctx, ch: ch}
+
+		if out.send(StreamChunk{Meta: &meta}) != nil {
+			drain(stream)
+			return
+		}
+		for chunk := range stream.Stream {
+			if out.send(chunk) != nil {
+				drain(stream)
+				return
+			}
+		}
+	}()
+	return &StreamResponse{Stream: ch}
+}
+
+// Tee returns n independent readers of the stream. Every reader gets all chunks, each
+// with its own copy of the metadata; chunks are buffered per reader, so a slow reader
+// (e.g. the HTTP client) does not hold back the others (e.g. persistence). Every reader
+// must be read to the end, as WriteTo does even on write errors, to release its buffer.
+// The original stream must not be read after Tee.
+func (s *StreamResponse) Tee(n int) []*StreamResponse {
+	ins := make([]chan StreamChunk, n)
+	readers := make([]*StreamResponse, n)
+	for i := range ins {
+		ins[i] = make(chan StreamChunk, 16)
+		out := make(chan StreamChunk)
+		go bufferChunks(ins[i], out)
+		readers[i] = &StreamResponse{Stream: out}
+	}
+
+	go func() {
+		defer func() {
+			for _, in := range ins {
+				close(in)
+			}
+		}()
+		for chunk := range s.Stream {
+			for _, in := range ins {
+				if chunk.Meta != nil {
+					meta := maps.Clone(*chunk.Meta)
+					chunk.Meta = &meta
+				}
+				in <- chunk
+			}
+		}
+	}()
+	return readers
+}
+
+// bufferChunks forwards chunks from in to out through an unbounded queue
+func bufferChunks(in <-chan StreamChunk, out chan<- StreamChunk) {
+	defer close(out)
+	var queue []StreamChunk
+	for in != nil || len(queue) > 0 {
+		var send chan<- StreamChunk
+		var next StreamChunk
+		if len(queue) > 0 {
+			send, next = out, queue[0]
+		}
+
+		select {
+		case chunk, ok := <-in:
+			if !ok {
+				in = nil
+				continue
+			}
+			queue = append(queue, chunk)
+		case send <- next:
+			queue[0] = StreamChunk{}
+			queue = queue[1:]
+		}
+	}
+}
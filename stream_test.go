@@ -0,0 +1,120 @@
+package echo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamCancellation(t *testing.T) {
+	disconnected := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for {
+			select {
+			case <-r.Context().Done():
+				close(disconnected)
+				return
+			case <-time.After(10 * time.Millisecond):
+				w.Write([]byte(`data: {"choices":[{"delta":{"content":"tick "}}]}` + "\n\n"))
+				w.(http.Flusher).Flush()
+			}
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewCommonClient(map[string]string{"openai": "key"},
+		WithModel("openai/gpt-4.1"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.StreamComplete(ctx, QuickMessage("Count"), WithPIIMasking())
+	if err != nil {
+		t.Fatalf("StreamComplete() error = %v", err)
+	}
+
+	// Read a little, then stop consuming and cancel
+	for chunk := range stream.Stream {
+		if chunk.Data != "" {
+			break
+		}
+	}
+	cancel()
+
+	select {
+	case <-disconnected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Connection was not closed after cancellation")
+	}
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case _, ok := <-stream.Stream:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("Stream was not closed after cancellation")
+		}
+	}
+}
+
+func TestStreamTee(t *testing.T) {
+	client, _ := NewCommonClient(nil, WithModel("mock/test"))
+	stream, err := client.StreamComplete(context.Background(), QuickMessage("A message long enough for several chunks"))
+	if err != nil {
+		t.Fatalf("StreamComplete() error = %v", err)
+	}
+
+	readers := stream.Tee(2)
+
+	// The second reader is consumed only after the first one is done
+	var first strings.Builder
+	var meta *Metadata
+	for chunk := range readers[0].Stream {
+		first.WriteString(chunk.Data)
+		if chunk.Meta != nil {
+			meta = chunk.Meta
+		}
+	}
+	var second strings.Builder
+	for chunk := range readers[1].Stream {
+		second.WriteString(chunk.Data)
+		if chunk.Meta != nil && meta != nil && chunk.Meta == meta {
+			t.Error("Readers share the metadata map")
+		}
+	}
+
+	want := "[user]: A message long enough for several chunks"
+	if first.String() != want || second.String() != want {
+		t.Errorf("Readers got %q and %q", first.String(), second.String())
+	}
+}
+
+func TestFilterStreamError(t *testing.T) {
+	ch := make(chan StreamChunk, 3)
+	ch <- StreamChunk{Data: "The answer.\nPartial"}
+	ch <- StreamChunk{Data: " tail", Error: fmt.Errorf("connection reset")}
+	close(ch)
+
+	// The confidence filter holds back the last line, it is sent before the error
+	var text strings.Builder
+	var err error
+	for chunk := range filterStream(context.Background(), &StreamResponse{Stream: ch}, &confidenceFilter{}).Stream {
+		if chunk.Error != nil {
+			err = chunk.Error
+			break
+		}
+		text.WriteString(chunk.Data)
+	}
+	if text.String() != "The answer.\nPartial tail" || err == nil {
+		t.Errorf("Expected the held back text before the error, got %q, %v", text.String(), err)
+	}
+}
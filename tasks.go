@@ -0,0 +1,272 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ClassifyResult is the outcome of a classification
+type ClassifyResult struct {
+	Label      string   `json:"label"`
+	Confidence float64  `json:"confidence"`
+	Metadata   Metadata `json:"metadata,omitempty"`
+}
+
+// Classify implements the Client interface.
+// The output is constrained to the labels through a structured output enum.
+func (c *CommonClient) Classify(ctx context.Context, text string, labels []string, opts ...CallOption) (*ClassifyResult, error) {
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("at least one label is required")
+	}
+
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"label": map[string]any{
+				"type": "string",
+				"enum": labels,
+			},
+			"confidence": map[string]any{
+				"type":        "number",
+				"description": "Probability between 0 and 1 that the label is correct",
+			},
+		},
+		"required":             []string{"label", "confidence"},
+		"additionalProperties": false,
+	}
+
+	prompt := "Classify the text into exactly one of these labels: " + strings.Join(labels, ", ") +
+		".\nAlso estimate your confidence as a number between 0 and 1.\n\nText:\n" + text
+
+	callOpts := append([]CallOption{WithStructuredOutput("classification", schema)}, opts...)
+	resp, err := c.Complete(ctx, QuickMessage(prompt), callOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := parseClassification(resp.Text, labels)
+	if err != nil {
+		return nil, err
+	}
+	result.Metadata = resp.Metadata
+	return result, nil
+}
+
+// parseClassification decodes the model output and matches it against the labels
+func parseClassification(text string, labels []string) (*ClassifyResult, error) {
+	var result ClassifyResult
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse classification: %w", err)
+	}
+
+	for _, label := range labels {
+		if strings.EqualFold(strings.TrimSpace(result.Label), label) {
+			result.Label = label
+			result.Confidence = min(max(result.Confidence, 0), 1)
+			return &result, nil
+		}
+	}
+
+	return nil, fmt.Errorf("model returned unknown label: %q", result.Label)
+}
+
+// summaryChunkTokens is the maximum size of a text chunk summarized in a single call
+const summaryChunkTokens = 8000
+
+// Summarize implements the Client interface.
+// Texts longer than a single chunk are split, each chunk is summarized,
+// and the partial summaries are merged into the final one.
+func (c *CommonClient) Summarize(ctx context.Context, text string, opts ...CallOption) (*Response, error) {
+	cfg := c.baseConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("no text to summarize")
+	}
+
+	size := EstimateTokens(text)
+	chunks := SplitText(text, summaryChunkTokens)
+	for len(chunks) > 1 {
+		partials := make([]string, 0, len(chunks))
+		for _, chunk := range chunks {
+			resp, err := c.Complete(ctx, QuickMessage(summaryPrompt(chunk, 0, "", true)), opts...)
+			if err != nil {
+				return nil, err
+			}
+			partials = append(partials, resp.Text)
+		}
+
+		merged := strings.Join(partials, "\n\n")
+		if EstimateTokens(merged) >= size {
+			return nil, fmt.Errorf("partial summaries are not shorter than the source text")
+		}
+		size = EstimateTokens(merged)
+		chunks = SplitText(merged, summaryChunkTokens)
+	}
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("partial summaries are empty")
+	}
+
+	return c.Complete(ctx, QuickMessage(summaryPrompt(chunks[0], cfg.TargetTokens, cfg.Style, false)), opts...)
+}
+
+// summaryPrompt builds the prompt for a summarization call
+func summaryPrompt(text string, targetTokens int, style string, partial bool) string {
+	var sb strings.Builder
+	if partial {
+		sb.WriteString("Summarize this part of a longer document. Keep all key facts, names and numbers.")
+	} else {
+		sb.WriteString("Summarize the following text.")
+	}
+
+	switch style {
+	case "":
+	case "bullets":
+		sb.WriteString(" Format the summary as a bulleted list.")
+	case "paragraph":
+		sb.WriteString(" Format the summary as a single paragraph.")
+	case "tldr":
+		sb.WriteString(" Write a one-sentence TL;DR.")
+	default:
+		sb.WriteString(" Use this style: " + style + ".")
+	}
+
+	if targetTokens > 0 {
+		sb.WriteString(fmt.Sprintf(" Aim for about %d words.", targetTokens*3/4))
+	}
+
+	sb.WriteString(" Reply with the summary only.\n\nText:\n")
+	sb.WriteString(text)
+	return sb.String()
+}
+
+// Translate implements the Client interface.
+// Glossary terms are replaced with placeholders before the call, so the model
+// cannot alter them, and substituted with the fixed translations afterwards.
+func (c *CommonClient) Translate(ctx context.Context, text string, targetLang string, opts ...CallOption) (*Response, error) {
+	cfg := c.baseConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	protected, placeholders := protectGlossary(text, cfg.Glossary)
+
+	// An unknown model fails in Complete, the prompt falls back to the default one
+	provider := ""
+	if ref, err := c.resolveProviderAndModel(cfg.Model); err == nil {
+		provider = ref.Provider
+	}
+
+	instructions, source := translationPrompt(provider, targetLang, protected)
+	resp, err := c.Complete(ctx, []Message{{Role: User, Content: source}},
+		append(slices.Clip(opts), WithSystemInstructions(instructions))...)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for placeholder, term := range placeholders {
+		if !strings.Contains(resp.Text, placeholder) {
+			missing = append(missing, term)
+			continue
+		}
+		resp.Text = strings.ReplaceAll(resp.Text, placeholder, cfg.Glossary[term])
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		if resp.Metadata == nil {
+			resp.Metadata = Metadata{}
+		}
+		resp.Metadata["glossary_missing"] = missing
+	}
+
+	return resp, nil
+}
+
+// translationPrompt returns the system instructions and the user text of the translation prompt
+// in the form the provider follows best: Claude gets the text in XML tags, Gemini is told not
+// to add notes or alternatives
+func translationPrompt(provider, targetLang, text string) (string, string) {
+	instructions := "Translate the text into " + targetLang + ".\n" +
+		"Preserve the original formatting exactly: markdown, line breaks, lists, links and code blocks. " +
+		"Do not translate code, URLs or numbered placeholders in ⟦ ⟧ brackets, keep them unchanged.\n" +
+		"Reply with the translation only."
+
+	switch provider {
+	case "anthropic":
+		instructions += " The text is given inside <source> tags, do not include the tags in the reply."
+		text = "<source>\n" + text + "\n</source>"
+	case "google":
+		instructions += " Do not add notes, explanations or alternative translations."
+	}
+	return instructions, text
+}
+
+// protectGlossary replaces glossary terms in the text with numbered placeholders.
+// Terms match whole words in any letter case, in a single pass so placeholders are never
+// matched again; where terms overlap, the longest one starting first wins.
+func protectGlossary(text string, glossary map[string]string) (string, map[string]string) {
+	terms := make([]string, 0, len(glossary))
+	for term := range glossary {
+		if term != "" {
+			terms = append(terms, term)
+		}
+	}
+	placeholders := map[string]string{}
+	if len(terms) == 0 {
+		return text, placeholders
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if len(terms[i]) != len(terms[j]) {
+			return len(terms[i]) > len(terms[j])
+		}
+		return terms[i] < terms[j]
+	})
+
+	// One group per term, the alternation prefers the earlier, longer terms
+	groups := make([]string, len(terms))
+	for i, term := range terms {
+		groups[i] = "(" + glossaryPattern(term) + ")"
+	}
+	re := regexp.MustCompile("(?i)" + strings.Join(groups, "|"))
+
+	var sb strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+		i := 0
+		for m[2*(i+1)] < 0 {
+			i++
+		}
+		placeholder := fmt.Sprintf("⟦%d⟧", i+1)
+		placeholders[placeholder] = terms[i]
+		sb.WriteString(text[last:m[0]])
+		sb.WriteString(placeholder)
+		last = m[1]
+	}
+	sb.WriteString(text[last:])
+	return sb.String(), placeholders
+}
+
+// glossaryPattern matches the term as a whole word where it starts or ends
+// with a letter or digit ("C++" has no boundary after the pluses)
+func glossaryPattern(term string) string {
+	pattern := regexp.QuoteMeta(term)
+	if isWordByte(term[0]) {
+		pattern = `\b` + pattern
+	}
+	if isWordByte(term[len(term)-1]) {
+		pattern += `\b`
+	}
+	return pattern
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
@@ -0,0 +1,129 @@
+package echo
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParseClassification(t *testing.T) {
+	labels := []string{"positive", "negative"}
+
+	tests := []struct {
+		name       string
+		text       string
+		label      string
+		confidence float64
+		wantErr    bool
+	}{
+		{"exact", `{"label": "positive", "confidence": 0.9}`, "positive", 0.9, false},
+		{"case insensitive", `{"label": "Negative ", "confidence": 0.4}`, "negative", 0.4, false},
+		{"clamped", `{"label": "positive", "confidence": 7}`, "positive", 1, false},
+		{"unknown label", `{"label": "neutral", "confidence": 0.5}`, "", 0, true},
+		{"invalid json", `positive`, "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseClassification(tt.text, labels)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %+v", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseClassification() error = %v", err)
+			}
+			if result.Label != tt.label || result.Confidence != tt.confidence {
+				t.Errorf("parseClassification() = %+v", result)
+			}
+		})
+	}
+}
+
+func TestSummarizeNotConverging(t *testing.T) {
+	client, err := NewCommonClient(nil, WithModel("mock/test"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	// The mock provider echoes its input, so partial summaries never get shorter
+	text := strings.Repeat("A sentence that repeats. ", 4000)
+	if _, err := client.Summarize(context.Background(), text); err == nil {
+		t.Errorf("Expected error for non-converging summaries")
+	}
+
+	resp, err := client.Summarize(context.Background(), "Short text.", WithStyle("bullets"), WithTargetTokens(40))
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if !strings.Contains(resp.Text, "bulleted list") || !strings.Contains(resp.Text, "about 30 words") {
+		t.Errorf("Prompt does not reflect options: %q", resp.Text)
+	}
+
+	for _, blank := range []string{"", strings.Repeat(" ", 40000)} {
+		if _, err := client.Summarize(context.Background(), blank); err == nil {
+			t.Errorf("Expected error for a blank text of %d characters", len(blank))
+		}
+	}
+}
+
+func TestTranslateGlossary(t *testing.T) {
+	client, err := NewCommonClient(nil, WithModel("mock/test"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	glossary := map[string]string{
+		"Echo":        "Echo",
+		"Echo client": "Echo-Client",
+		"unused":      "nicht benutzt",
+	}
+
+	// The mock provider echoes the prompt, so placeholders come back unchanged
+	resp, err := client.Translate(context.Background(), "Use the **Echo client** with Echo.", "German", WithGlossary(glossary))
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if !strings.Contains(resp.Text, "Use the **Echo-Client** with Echo.") {
+		t.Errorf("Glossary terms not restored: %q", resp.Text)
+	}
+	if strings.Contains(resp.Text, "⟦1⟧") || strings.Contains(resp.Text, "⟦2⟧") {
+		t.Errorf("Placeholders left in output: %q", resp.Text)
+	}
+
+	// Terms match whole words in any case
+	protected, _ := protectGlossary("Google ships go, GO and C++.", map[string]string{"Go": "Go", "C++": "C++"})
+	if protected != "Google ships ⟦2⟧, ⟦2⟧ and ⟦1⟧." {
+		t.Errorf("protectGlossary() = %q", protected)
+	}
+
+	// Numeric terms don't match the numbers of earlier placeholders
+	protected, placeholders := protectGlossary("Plan 1 and plan 2", map[string]string{"1": "eins", "2": "zwei", "Plan": "Plan"})
+	if protected != "⟦1⟧ ⟦2⟧ and ⟦1⟧ ⟦3⟧" || len(placeholders) != 3 {
+		t.Errorf("protectGlossary() = %q, %v", protected, placeholders)
+	}
+
+	instructions, source := translationPrompt("anthropic", "German", "Hello")
+	if !strings.Contains(instructions, "<source>") || source != "<source>\nHello\n</source>" {
+		t.Errorf("Unexpected Anthropic prompt %q, %q", instructions, source)
+	}
+	if _, source := translationPrompt("openai", "German", "Hello"); source != "Hello" {
+		t.Errorf("Unexpected OpenAI prompt %q", source)
+	}
+}
+
+func TestTranslateSystemMessage(t *testing.T) {
+	client, _ := NewCommonClient(nil, WithModel("mock/test"), WithSystemMessage("You are helpful"))
+
+	// The mock provider echoes the prompt: the client prompt is kept, followed by the instructions
+	resp, err := client.Translate(context.Background(), "Good morning", "German")
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if !strings.Contains(resp.Text, "[system]: You are helpful\n\nTranslate the text into German.") ||
+		!strings.Contains(resp.Text, "[user]: Good morning") {
+		t.Errorf("Unexpected prompt: %q", resp.Text)
+	}
+}
@@ -0,0 +1,146 @@
+package echo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrBudgetExceeded is returned when a tenant has used up its token budget
+var ErrBudgetExceeded = errors.New("tenant token budget exceeded")
+
+// TenantConfig holds the settings of a single tenant
+type TenantConfig struct {
+	Keys          map[string]string // API keys by provider name; providers without a key use the client keys
+	AllowedModels []string          // "provider/model" or "provider/*"; empty allows all models
+	TokenBudget   int               // tokens left for completions; 0 means unlimited, negative means used up
+}
+
+// TenantConfigStore provides tenant settings to the client. It is consulted on every call
+// made with WithTenant, so implementations should be fast and safe for concurrent use.
+type TenantConfigStore interface {
+	// TenantConfig returns the settings of the tenant
+	TenantConfig(ctx context.Context, tenant string) (*TenantConfig, error)
+	// RecordUsage is called after each completion with the number of tokens it used
+	RecordUsage(ctx context.Context, tenant string, tokens int) error
+}
+
+// applyTenant applies the tenant key and model restrictions to the call
+func applyTenant(ctx context.Context, cfg *CallConfig) error {
+	if cfg.Tenant == "" || cfg.TenantStore == nil {
+		return nil
+	}
+
+	tc, err := cfg.TenantStore.TenantConfig(ctx, cfg.Tenant)
+	if err != nil {
+		return fmt.Errorf("failed to load config of tenant %s: %w", cfg.Tenant, err)
+	}
+
+	cfg.tenantConfig = tc
+
+	if !modelAllowed(tc.AllowedModels, cfg.provider, cfg.Model) {
+		return fmt.Errorf("model %s/%s is not allowed for tenant %s", cfg.provider, cfg.Model, cfg.Tenant)
+	}
+
+	// An explicit WithAPIKey is more specific than the tenant key
+	if key := tc.Keys[cfg.provider]; key != "" && cfg.APIKey == "" {
+		cfg.APIKey = key
+	}
+	return nil
+}
+
+// checkTenantBudget rejects completions of tenants without tokens left
+func checkTenantBudget(cfg CallConfig) error {
+	if cfg.tenantConfig != nil && cfg.tenantConfig.TokenBudget < 0 {
+		return fmt.Errorf("%w: %s", ErrBudgetExceeded, cfg.Tenant)
+	}
+	return nil
+}
+
+func modelAllowed(allowed []string, provider, model string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	full := provider + "/" + model
+	for _, pattern := range allowed {
+		if pattern == "*" || pattern == full || pattern == provider+"/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// usageTokens returns the total token count from response metadata of any provider
+func usageTokens(meta Metadata) int {
+	if n, ok := meta["total_tokens"].(int); ok {
+		return n
+	}
+	sum := 0
+	for _, key := range []string{"input_tokens", "output_tokens", "prompt_tokens", "completion_tokens",
+		"cache_creation_input_tokens", "cache_read_input_tokens"} {
+		if n, ok := meta[key].(int); ok {
+			sum += n
+		}
+	}
+	return sum
+}
+
+// MemoryTenantStore is an in-memory TenantConfigStore.
+// Budgets set with SetTenant are reduced by the recorded usage.
+type MemoryTenantStore struct {
+	mu      sync.RWMutex
+	tenants map[string]TenantConfig
+	used    map[string]int
+}
+
+// NewMemoryTenantStore creates an empty store
+func NewMemoryTenantStore() *MemoryTenantStore {
+	return &MemoryTenantStore{
+		tenants: map[string]TenantConfig{},
+		used:    map[string]int{},
+	}
+}
+
+// SetTenant adds or replaces the tenant settings and resets its usage
+func (s *MemoryTenantStore) SetTenant(tenant string, cfg TenantConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tenants[tenant] = cfg
+	s.used[tenant] = 0
+}
+
+// Usage returns the number of tokens used by the tenant
+func (s *MemoryTenantStore) Usage(tenant string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.used[tenant]
+}
+
+// TenantConfig implements TenantConfigStore.
+// TokenBudget of the result is the remaining budget, negative when it is used up.
+func (s *MemoryTenantStore) TenantConfig(ctx context.Context, tenant string) (*TenantConfig, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tc, ok := s.tenants[tenant]
+	if !ok {
+		return nil, fmt.Errorf("unknown tenant: %s", tenant)
+	}
+	if tc.TokenBudget > 0 {
+		tc.TokenBudget -= s.used[tenant]
+		if tc.TokenBudget <= 0 {
+			tc.TokenBudget = -1
+		}
+	}
+	tc.AllowedModels = append([]string(nil), tc.AllowedModels...)
+	return &tc, nil
+}
+
+// RecordUsage implements TenantConfigStore
+func (s *MemoryTenantStore) RecordUsage(ctx context.Context, tenant string, tokens int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used[tenant] += tokens
+	return nil
+}
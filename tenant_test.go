@@ -0,0 +1,98 @@
+package echo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTenantStore(t *testing.T) {
+	var auth []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), `"stream":true`) {
+			w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"ok\"}}]}\n\n" +
+				"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":5,\"total_tokens\":10}}\n\ndata: [DONE]\n\n"))
+			return
+		}
+		w.Write([]byte(`{"choices": [{"message": {"content": "ok"}}], "usage": {"prompt_tokens": 40, "completion_tokens": 20, "total_tokens": 60}}`))
+	}))
+	defer server.Close()
+
+	store := NewMemoryTenantStore()
+	store.SetTenant("acme", TenantConfig{
+		Keys:          map[string]string{"openai": "acme-key"},
+		AllowedModels: []string{"openai/gpt-4.1"},
+		TokenBudget:   100,
+	})
+
+	client, err := NewCommonClient(map[string]string{"openai": "shared-key", "anthropic": "shared-key"},
+		WithModel("openai/gpt-4.1"), WithBaseURL(server.URL), WithTenantStore(store))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithTenant("acme")); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if _, err := client.Complete(ctx, QuickMessage("Hi")); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if auth[0] != "Bearer acme-key" || auth[1] != "Bearer shared-key" {
+		t.Errorf("Unexpected keys: %v", auth)
+	}
+
+	_, err = client.Complete(ctx, QuickMessage("Hi"), WithTenant("acme"), WithModel("anthropic/claude-sonnet-4-5"))
+	if err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("Expected model restriction error, got %v", err)
+	}
+
+	// The stream records usage too and exhausts the budget
+	stream, err := client.StreamComplete(ctx, QuickMessage("Hi"), WithTenant("acme"))
+	if err != nil {
+		t.Fatalf("StreamComplete() error = %v", err)
+	}
+	for range stream.Stream {
+	}
+	if store.Usage("acme") != 70 {
+		t.Errorf("Expected 70 used tokens, got %d", store.Usage("acme"))
+	}
+
+	client.Complete(ctx, QuickMessage("Hi"), WithTenant("acme"))
+	if _, err := client.Complete(ctx, QuickMessage("Hi"), WithTenant("acme")); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Expected budget error, got %v", err)
+	}
+}
+
+// brokenUsageStore serves tenant configs but fails to record usage
+type brokenUsageStore struct {
+	*MemoryTenantStore
+}
+
+func (s brokenUsageStore) RecordUsage(ctx context.Context, tenant string, tokens int) error {
+	return errors.New("store is offline")
+}
+
+func TestTenantUsageError(t *testing.T) {
+	store := brokenUsageStore{NewMemoryTenantStore()}
+	store.SetTenant("acme", TenantConfig{})
+
+	var buf bytes.Buffer
+	client, _ := NewCommonClient(nil, WithModel("mock/any"), WithTenantStore(store),
+		WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+	if _, err := client.Complete(context.Background(), QuickMessage("Hi"), WithTenant("acme")); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "level=ERROR") || !strings.Contains(out, "tenant=acme") ||
+		!strings.Contains(out, "store is offline") {
+		t.Errorf("Expected the usage error in the log, got %q", out)
+	}
+}
@@ -0,0 +1,86 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestThinking(t *testing.T) {
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = nil
+		json.NewDecoder(r.Body).Decode(&request)
+		stream := request["stream"] == true || r.URL.Query().Get("alt") == "sse"
+		switch {
+		case r.Header.Get("anthropic-version") != "" && stream:
+			w.Write([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"thinking_delta\",\"thinking\":\"Two plus two\"}}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"signature_delta\",\"signature\":\"sig\"}}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"text_delta\",\"text\":\"4\"}}\n\n" +
+				"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"))
+		case r.Header.Get("anthropic-version") != "":
+			w.Write([]byte(`{"content": [{"type": "thinking", "thinking": "Two plus two", "signature": "sig"}, {"type": "text", "text": "4"}], "stop_reason": "end_turn"}`))
+		case stream:
+			w.Write([]byte(`data: {"candidates": [{"content": {"parts": [{"text": "Two plus two", "thought": true}]}}]}` + "\n\n" +
+				`data: {"candidates": [{"content": {"parts": [{"text": "4"}]}, "finishReason": "STOP"}]}` + "\n\n"))
+		default:
+			w.Write([]byte(`{"candidates": [{"content": {"parts": [{"text": "Two plus two", "thought": true}, {"text": "4"}]}, "finishReason": "STOP"}],
+				"usageMetadata": {"promptTokenCount": 5, "candidatesTokenCount": 1, "thoughtsTokenCount": 12, "totalTokenCount": 18}}`))
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewCommonClient(map[string]string{"anthropic": "key", "google": "key"})
+	ctx := context.Background()
+
+	tests := []struct {
+		model   string
+		baseURL string
+		config  string
+	}{
+		{"anthropic/claude-sonnet-4-5", server.URL, `{"budget_tokens":2048,"type":"enabled"}`},
+		{"google/gemini-2.5-flash", server.URL + "/models/gemini-2.5-flash:generateContent", `{"includeThoughts":true,"thinkingBudget":2048}`},
+	}
+	for _, tt := range tests {
+		opts := []CallOption{WithModel(tt.model), WithBaseURL(tt.baseURL), WithThinking(2048)}
+		resp, err := client.Complete(ctx, QuickMessage("2+2?"), opts...)
+		if err != nil {
+			t.Fatalf("%s: Complete() error = %v", tt.model, err)
+		}
+		if resp.Text != "4" || resp.Thinking != "Two plus two" {
+			t.Errorf("%s: unexpected response %q, thinking %q", tt.model, resp.Text, resp.Thinking)
+		}
+
+		config := request["thinking"]
+		if config == nil {
+			config = request["generationConfig"].(map[string]any)["thinkingConfig"]
+		}
+		if data, _ := json.Marshal(config); string(data) != tt.config {
+			t.Errorf("%s: unexpected thinking config %s", tt.model, data)
+		}
+
+		stream, err := client.StreamComplete(ctx, QuickMessage("2+2?"), opts...)
+		if err != nil {
+			t.Fatalf("%s: StreamComplete() error = %v", tt.model, err)
+		}
+		var text, thinking string
+		for chunk := range stream.Stream {
+			if chunk.Error != nil {
+				t.Fatalf("%s: stream error = %v", tt.model, chunk.Error)
+			}
+			if chunk.Data != "" && chunk.Thinking != "" {
+				t.Errorf("%s: expected thinking in separate chunks", tt.model)
+			}
+			text += chunk.Data
+			thinking += chunk.Thinking
+		}
+		if text != "4" || thinking != "Two plus two" {
+			t.Errorf("%s: unexpected stream %q, thinking %q", tt.model, text, thinking)
+		}
+		if tt.model == "google/gemini-2.5-flash" && resp.Metadata["thinking_tokens"] != 12 {
+			t.Errorf("Expected thinking tokens in the metadata, got %v", resp.Metadata)
+		}
+	}
+}
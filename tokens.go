@@ -0,0 +1,101 @@
+package echo
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// EstimateTokens returns an approximate token count for the text.
+// It uses the common heuristic of ~4 characters per token, which is close
+// enough for budgeting across providers without shipping their tokenizers.
+func EstimateTokens(text string) int {
+	n := utf8.RuneCountInString(text)
+	if n == 0 {
+		return 0
+	}
+	return (n + 3) / 4
+}
+
+// EstimateMessagesTokens returns an approximate token count for a message chain,
+// including a small per-message overhead for role markers
+func EstimateMessagesTokens(messages []Message) int {
+	total := 0
+	for _, msg := range messages {
+		total += EstimateTokens(msg.Content) + 4
+	}
+	return total
+}
+
+// SplitText splits the text into chunks of at most maxTokens estimated tokens.
+// It prefers paragraph boundaries, then sentence boundaries, and only cuts
+// inside a sentence when a single sentence exceeds the limit.
+func SplitText(text string, maxTokens int) []string {
+	if maxTokens <= 0 || EstimateTokens(text) <= maxTokens {
+		return []string{text}
+	}
+
+	var chunks []string
+	var current strings.Builder
+
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			chunks = append(chunks, s)
+		}
+		current.Reset()
+	}
+	add := func(piece, sep string) {
+		if current.Len() > 0 && EstimateTokens(current.String()+sep+piece) > maxTokens {
+			flush()
+		}
+		if current.Len() > 0 {
+			current.WriteString(sep)
+		}
+		current.WriteString(piece)
+	}
+
+	for _, para := range strings.Split(text, "\n\n") {
+		if EstimateTokens(para) <= maxTokens {
+			add(para, "\n\n")
+			continue
+		}
+		for _, sentence := range splitSentences(para) {
+			if EstimateTokens(sentence) <= maxTokens {
+				add(sentence, " ")
+				continue
+			}
+			// Hard cut of an oversized sentence
+			flush()
+			runes := []rune(sentence)
+			size := maxTokens * 4
+			for len(runes) > 0 {
+				end := min(size, len(runes))
+				chunks = append(chunks, string(runes[:end]))
+				runes = runes[end:]
+			}
+		}
+	}
+	flush()
+
+	return chunks
+}
+
+// splitSentences splits text after sentence-ending punctuation
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i, r := range text {
+		if r == '.' || r == '!' || r == '?' || r == '\n' {
+			next := i + utf8.RuneLen(r)
+			if next >= len(text) || text[next] == ' ' || text[next] == '\n' {
+				if s := strings.TrimSpace(text[start:next]); s != "" {
+					sentences = append(sentences, s)
+				}
+				start = next
+			}
+		}
+	}
+	if s := strings.TrimSpace(text[start:]); s != "" {
+		sentences = append(sentences, s)
+	}
+	return sentences
+}
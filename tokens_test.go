@@ -0,0 +1,47 @@
+package echo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	if got := EstimateTokens(""); got != 0 {
+		t.Errorf("EstimateTokens(\"\") = %d", got)
+	}
+	if got := EstimateTokens("abcdefgh"); got != 2 {
+		t.Errorf("EstimateTokens(8 chars) = %d, want 2", got)
+	}
+	if got := EstimateTokens("abcde"); got != 2 {
+		t.Errorf("EstimateTokens(5 chars) = %d, want 2", got)
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	short := "Just a short text."
+	if chunks := SplitText(short, 100); len(chunks) != 1 || chunks[0] != short {
+		t.Errorf("Short text should not be split: %q", chunks)
+	}
+
+	paragraphs := strings.Repeat("This is a sentence. Another one here.\n\n", 20)
+	chunks := SplitText(paragraphs, 30)
+	if len(chunks) < 2 {
+		t.Fatalf("Expected several chunks, got %d", len(chunks))
+	}
+	for _, chunk := range chunks {
+		if EstimateTokens(chunk) > 30 {
+			t.Errorf("Chunk exceeds limit: %d tokens", EstimateTokens(chunk))
+		}
+	}
+
+	long := strings.Repeat("x", 1000)
+	chunks = SplitText(long, 50)
+	if strings.Join(chunks, "") != long {
+		t.Errorf("Hard split lost content")
+	}
+	for _, chunk := range chunks {
+		if EstimateTokens(chunk) > 50 {
+			t.Errorf("Chunk exceeds limit: %d tokens", EstimateTokens(chunk))
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package echo
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// Tool choices, any other value is the name of a tool the model must call
+const (
+	ToolChoiceAuto     = "auto"     // the model decides, the provider default
+	ToolChoiceNone     = "none"     // the model must answer without calling tools
+	ToolChoiceRequired = "required" // the model must call at least one tool
+)
+
+// Tool describes a function the model may call, see WithTools
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]any // JSON Schema of the arguments, e.g. from SchemaOf
+}
+
+// ToolCall is a request of the model to call a tool
+type ToolCall struct {
+	ID        string          `json:"id"` // passed back in the result message
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"` // JSON object matching the tool parameters
+}
+
+// ToolCallsMessage creates the agent message of a response that requested tool calls,
+// it goes into the chain before the results
+func ToolCallsMessage(resp *Response) Message {
+	return Message{Role: Agent, Content: resp.Text, ToolCalls: resp.ToolCalls}
+}
+
+// ToolResultMessage creates a message with the output of a tool call
+func ToolResultMessage(callID, content string) Message {
+	return Message{Role: ToolResult, Content: content, ToolCallID: callID}
+}
+
+// checkToolChoice verifies that a tool named by WithToolChoice is declared
+func checkToolChoice(cfg CallConfig) error {
+	switch cfg.ToolChoice {
+	case "", ToolChoiceAuto, ToolChoiceNone:
+		return nil
+	case ToolChoiceRequired:
+		if len(cfg.Tools) == 0 {
+			return fmt.Errorf("tool choice %q requires tools", cfg.ToolChoice)
+		}
+		return nil
+	}
+	if !slices.ContainsFunc(cfg.Tools, func(t Tool) bool { return t.Name == cfg.ToolChoice }) {
+		return fmt.Errorf("tool choice %q is not a declared tool", cfg.ToolChoice)
+	}
+	return nil
+}
+
+// toolArguments returns the arguments of a call, an empty object when the model sent none
+func toolArguments(call ToolCall) json.RawMessage {
+	if len(call.Arguments) == 0 {
+		return json.RawMessage("{}")
+	}
+	return call.Arguments
+}
+
+// toolCallName finds the name of the tool whose call is answered by a result message
+func toolCallName(messages []Message, id string) string {
+	for _, msg := range messages {
+		for _, call := range msg.ToolCalls {
+			if call.ID == id {
+				return call.Name
+			}
+		}
+	}
+	return ""
+}
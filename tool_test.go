@@ -0,0 +1,179 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var weatherTool = Tool{
+	Name:        "get_weather",
+	Description: "Current weather for a city",
+	Parameters: map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"city": map[string]any{"type": "string"}},
+	},
+}
+
+// toolChain is a conversation where the model already asked for the weather
+func toolChain() []Message {
+	return []Message{
+		{Role: User, Content: "Weather in Paris?"},
+		{Role: Agent, ToolCalls: []ToolCall{{ID: "call_1", Name: "get_weather", Arguments: json.RawMessage(`{"city":"Paris"}`)}}},
+		ToolResultMessage("call_1", `{"temperature": 21}`),
+	}
+}
+
+func TestToolCalls(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider Provider
+		response string
+		request  []string // fragments of the request body
+	}{
+		{
+			"openai", &OpenAIProvider{},
+			`{"choices": [{"message": {"content": null, "tool_calls": [{"id": "call_2", "type": "function",
+				"function": {"name": "get_weather", "arguments": "{\"city\":\"Rome\"}"}}]}, "finish_reason": "tool_calls"}]}`,
+			[]string{
+				`"tools":[{"type":"function","function":{"name":"get_weather","description":"Current weather for a city"`,
+				`"tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]`,
+				`{"role":"tool","content":"{\"temperature\": 21}","tool_call_id":"call_1"}`,
+			},
+		},
+		{
+			"anthropic", &AnthropicProvider{},
+			`{"content": [{"type": "text", "text": "Checking"}, {"type": "tool_use", "id": "call_2", "name": "get_weather",
+				"input": {"city": "Rome"}}], "stop_reason": "tool_use"}`,
+			[]string{
+				`"tools":[{"name":"get_weather","description":"Current weather for a city","input_schema":`,
+				`{"type":"tool_use","id":"call_1","name":"get_weather","input":{"city":"Paris"}}`,
+				`{"role":"user","content":[{"type":"tool_result","tool_use_id":"call_1","content":"{\"temperature\": 21}"}]}`,
+			},
+		},
+		{
+			"google", &GoogleProvider{},
+			`{"candidates": [{"content": {"parts": [{"functionCall": {"id": "call_2", "name": "get_weather",
+				"args": {"city": "Rome"}}}]}, "finishReason": "STOP"}]}`,
+			[]string{
+				`"tools":[{"functionDeclarations":[{"name":"get_weather","description":"Current weather for a city"`,
+				`{"role":"model","parts":[{"functionCall":{"id":"call_1","name":"get_weather","args":{"city":"Paris"}}}]}`,
+				`{"functionResponse":{"id":"call_1","name":"get_weather","response":{"temperature":21}}}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				body = string(data)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			cfg := CallConfig{Model: "model", BaseURL: server.URL, Tools: []Tool{weatherTool}}
+			resp, err := tt.provider.call(context.Background(), toolChain(), cfg)
+			if err != nil {
+				t.Fatalf("call() error = %v", err)
+			}
+			for _, fragment := range tt.request {
+				if !strings.Contains(body, fragment) {
+					t.Errorf("Request misses %s\n%s", fragment, body)
+				}
+			}
+
+			if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "call_2" || resp.ToolCalls[0].Name != "get_weather" {
+				t.Fatalf("Unexpected tool calls: %+v", resp.ToolCalls)
+			}
+			var args struct{ City string }
+			if err := json.Unmarshal(resp.ToolCalls[0].Arguments, &args); err != nil || args.City != "Rome" {
+				t.Errorf("Unexpected arguments: %s", resp.ToolCalls[0].Arguments)
+			}
+			if resp.Metadata["finish_reason"] != FinishToolCalls {
+				t.Errorf("Expected finish reason %q, got %v", FinishToolCalls, resp.Metadata["finish_reason"])
+			}
+		})
+	}
+}
+
+func TestToolResultValidation(t *testing.T) {
+	messages := append(QuickMessage("Hi"), ToolResultMessage("call_9", "42"))
+	if err := validateMessages(messages); err == nil {
+		t.Error("Expected an error for a result without a call")
+	}
+	if err := validateMessages(toolChain()); err != nil {
+		t.Errorf("validateMessages() error = %v", err)
+	}
+
+	call := func(ids ...string) Message {
+		msg := Message{Role: Agent}
+		for _, id := range ids {
+			msg.ToolCalls = append(msg.ToolCalls, ToolCall{ID: id, Name: "get_weather"})
+		}
+		return msg
+	}
+	user := Message{Role: User, Content: "Hi"}
+	tests := []struct {
+		messages []Message
+		err      string
+	}{
+		{[]Message{user, call("a", "b"), ToolResultMessage("b", ""), ToolResultMessage("a", ""), user}, ""},
+		{[]Message{user, call("a", "b"), ToolResultMessage("a", ""), user}, "tool call 'b' at position 1 has no result before position 3"},
+		{[]Message{user, call("a")}, "tool call 'a' at position 1 has no result"},
+		{[]Message{user, call("a"), ToolResultMessage("a", ""), ToolResultMessage("a", "")}, "tool call 'a' has a second result at position 3"},
+		{[]Message{user, call("a"), ToolResultMessage("a", ""), call("a"), ToolResultMessage("a", "")}, "tool call ID 'a' at position 3 is not unique"},
+	}
+	for _, tt := range tests {
+		err := validateMessages(tt.messages)
+		if (err == nil) != (tt.err == "") || err != nil && err.Error() != tt.err {
+			t.Errorf("validateMessages() error = %v, want %q", err, tt.err)
+		}
+	}
+
+	client, _ := NewCommonClient(nil)
+	if _, err := client.StreamComplete(context.Background(), QuickMessage("Hi"), WithTools(weatherTool)); err == nil {
+		t.Error("Expected an error for tools in a streaming call")
+	}
+}
+
+func TestToolChoice(t *testing.T) {
+	cfg := CallConfig{Tools: []Tool{weatherTool}, ToolChoice: "get_weather"}
+	messages := QuickMessage("Weather in Paris?")
+
+	openai, _ := prepareOpenAIRequest(messages, false, cfg)
+	anthropic, _ := prepareAnthropicRequest(messages, false, cfg)
+	google, _ := prepareGoogleRequest(messages, cfg)
+	tests := []struct {
+		request any
+		want    string
+	}{
+		{openai, `"tool_choice":{"function":{"name":"get_weather"},"type":"function"}`},
+		{anthropic, `"tool_choice":{"type":"tool","name":"get_weather"}`},
+		{google, `"toolConfig":{"functionCallingConfig":{"mode":"ANY","allowedFunctionNames":["get_weather"]}}`},
+	}
+	for _, tt := range tests {
+		data, _ := json.Marshal(tt.request)
+		if !strings.Contains(string(data), tt.want) {
+			t.Errorf("Request misses %s\n%s", tt.want, data)
+		}
+	}
+
+	cfg.ToolChoice = ToolChoiceRequired
+	if req, _ := prepareAnthropicRequest(messages, false, cfg); req.ToolChoice.Type != "any" {
+		t.Errorf("Expected the any type for required, got %+v", req.ToolChoice)
+	}
+
+	client, _ := NewCommonClient(nil, WithModel("mock/test"))
+	if err := client.Validate(messages, WithTools(weatherTool), WithToolChoice("get_time")); err == nil {
+		t.Error("Expected an error for an undeclared tool")
+	}
+	if err := client.Validate(messages, WithToolChoice(ToolChoiceRequired)); err == nil {
+		t.Error("Expected an error for required without tools")
+	}
+}
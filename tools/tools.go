@@ -0,0 +1,301 @@
+// Package tools exposes Go functions to language models.
+//
+// A tool is a function with one of the signatures
+//
+//	func(ctx context.Context, args T) (R, error)
+//	func(args T) (R, error)
+//
+// where T is a struct describing the arguments. The JSON schema of the
+// arguments is derived from T with echo.SchemaOf, so field names follow
+// `json` tags and `description:"..."` tags document the parameters.
+// Go does not keep parameter names or doc comments at runtime, which is why
+// arguments are passed as a struct and the tool description is set with
+// the Description option.
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/mkozhukh/echo"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Tool is a registered function together with its schema
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]any // JSON Schema of the arguments
+	Destructive bool           // calls require approval, see Registry.SetApprover
+
+	fn      reflect.Value
+	argType reflect.Type
+	withCtx bool
+}
+
+// Option customizes a tool during registration
+type Option func(*Tool)
+
+// Name overrides the tool name derived from the function name
+func Name(name string) Option {
+	return func(t *Tool) {
+		t.Name = name
+	}
+}
+
+// Description sets the tool description shown to the model
+func Description(desc string) Option {
+	return func(t *Tool) {
+		t.Description = desc
+	}
+}
+
+// Destructive marks the tool as performing actions that need confirmation,
+// like sending email or deleting data
+func Destructive() Option {
+	return func(t *Tool) {
+		t.Destructive = true
+	}
+}
+
+// Approver decides whether a call of a destructive tool may proceed
+type Approver func(ctx context.Context, name string, args json.RawMessage) (bool, error)
+
+// ApprovalError is returned when a destructive tool call was not approved.
+// It carries the pending call, so it can be confirmed out of band and
+// resumed later with Registry.CallApproved.
+type ApprovalError struct {
+	Tool     string
+	Args     json.RawMessage
+	Rejected bool // true if the approver declined, false if no approver is set
+}
+
+func (e *ApprovalError) Error() string {
+	if e.Rejected {
+		return fmt.Sprintf("call of tool %s was rejected", e.Tool)
+	}
+	return fmt.Sprintf("call of tool %s requires approval", e.Tool)
+}
+
+// Registry holds a set of tools
+type Registry struct {
+	mu       sync.RWMutex
+	tools    map[string]*Tool
+	order    []string
+	approver Approver
+}
+
+// SetApprover sets the callback consulted before calling destructive tools.
+// Without an approver, such calls fail with an *ApprovalError.
+func (r *Registry) SetApprover(approver Approver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.approver = approver
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{tools: map[string]*Tool{}}
+}
+
+// Default is the registry used by the package-level functions
+var Default = NewRegistry()
+
+// Register adds a function to the default registry
+func Register(fn any, opts ...Option) error {
+	return Default.Register(fn, opts...)
+}
+
+// Register adds a function to the registry
+func (r *Registry) Register(fn any, opts ...Option) error {
+	tool, err := newTool(fn, opts...)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tools[tool.Name]; ok {
+		return fmt.Errorf("tool %q is already registered", tool.Name)
+	}
+	r.tools[tool.Name] = tool
+	r.order = append(r.order, tool.Name)
+	return nil
+}
+
+// Get returns a registered tool by name
+func (r *Registry) Get(name string) (*Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+// List returns all registered tools in registration order
+func (r *Registry) List() []*Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]*Tool, 0, len(r.order))
+	for _, name := range r.order {
+		list = append(list, r.tools[name])
+	}
+	return list
+}
+
+// Definitions returns the declarations of all registered tools, to pass to echo.WithTools
+func (r *Registry) Definitions() []echo.Tool {
+	var defs []echo.Tool
+	for _, tool := range r.List() {
+		defs = append(defs, tool.Definition())
+	}
+	return defs
+}
+
+// Call decodes the JSON arguments, invokes the named tool and returns its result
+// encoded as text: strings are returned as is, other values as JSON.
+// Destructive tools are called only after the approver confirms the call.
+func (r *Registry) Call(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	tool, ok := r.Get(name)
+	if !ok {
+		return "", fmt.Errorf("unknown tool: %s", name)
+	}
+
+	if tool.Destructive {
+		r.mu.RLock()
+		approver := r.approver
+		r.mu.RUnlock()
+
+		if approver == nil {
+			return "", &ApprovalError{Tool: name, Args: args}
+		}
+		approved, err := approver(ctx, name, args)
+		if err != nil {
+			return "", err
+		}
+		if !approved {
+			return "", &ApprovalError{Tool: name, Args: args, Rejected: true}
+		}
+	}
+
+	return tool.Call(ctx, args)
+}
+
+// CallApproved invokes the named tool skipping the approval check,
+// used to resume a call confirmed outside of the approver
+func (r *Registry) CallApproved(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	tool, ok := r.Get(name)
+	if !ok {
+		return "", fmt.Errorf("unknown tool: %s", name)
+	}
+	return tool.Call(ctx, args)
+}
+
+// Definition returns the declaration of the tool sent to the model
+func (t *Tool) Definition() echo.Tool {
+	return echo.Tool{Name: t.Name, Description: t.Description, Parameters: t.Parameters}
+}
+
+// Call decodes the JSON arguments and invokes the tool
+func (t *Tool) Call(ctx context.Context, args json.RawMessage) (string, error) {
+	argPtr := reflect.New(t.argType)
+	if len(args) > 0 && string(args) != "null" {
+		if err := json.Unmarshal(args, argPtr.Interface()); err != nil {
+			return "", fmt.Errorf("invalid arguments for tool %s: %w", t.Name, err)
+		}
+	}
+
+	in := []reflect.Value{argPtr.Elem()}
+	if t.withCtx {
+		in = append([]reflect.Value{reflect.ValueOf(ctx)}, in...)
+	}
+
+	out := t.fn.Call(in)
+	if err, _ := out[1].Interface().(error); err != nil {
+		return "", err
+	}
+
+	result := out[0].Interface()
+	if s, ok := result.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result of tool %s: %w", t.Name, err)
+	}
+	return string(data), nil
+}
+
+// newTool validates the function signature and builds the tool definition
+func newTool(fn any, opts ...Option) (*Tool, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return nil, fmt.Errorf("tool must be a function, got %T", fn)
+	}
+
+	ft := v.Type()
+	tool := &Tool{fn: v, Name: funcName(v)}
+
+	switch {
+	case ft.NumIn() == 2 && ft.In(0) == contextType:
+		tool.withCtx = true
+		tool.argType = ft.In(1)
+	case ft.NumIn() == 1:
+		tool.argType = ft.In(0)
+	default:
+		return nil, fmt.Errorf("tool must accept (context.Context, args) or (args), got %s", ft)
+	}
+	if tool.argType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("tool arguments must be a struct, got %s", tool.argType)
+	}
+	if ft.NumOut() != 2 || ft.Out(1) != errorType {
+		return nil, fmt.Errorf("tool must return (result, error), got %s", ft)
+	}
+
+	for _, opt := range opts {
+		opt(tool)
+	}
+	if tool.Name == "" {
+		return nil, fmt.Errorf("cannot derive a name for an anonymous function, use the Name option")
+	}
+
+	schema, err := echo.SchemaOfType(tool.argType)
+	if err != nil {
+		return nil, fmt.Errorf("tool %s: %w", tool.Name, err)
+	}
+	tool.Parameters = schema
+
+	return tool, nil
+}
+
+// closureName matches the compiler names of closures, like "main.func1" or "pkg.Outer.func1.2"
+var closureName = regexp.MustCompile(`\.func\d+(\.\d+)*$`)
+
+// funcName returns the short name of a named function, or "" for closures
+func funcName(v reflect.Value) string {
+	f := runtime.FuncForPC(v.Pointer())
+	if f == nil {
+		return ""
+	}
+	name := f.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	// Method values end with "-fm"
+	if closureName.MatchString(name) || strings.HasSuffix(name, "-fm") {
+		return ""
+	}
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type weatherArgs struct {
+	City string `json:"city" description:"City name"`
+	Unit string `json:"unit" enum:"celsius,fahrenheit"`
+}
+
+type weatherResult struct {
+	Temperature float64 `json:"temperature"`
+}
+
+func GetWeather(ctx context.Context, args weatherArgs) (weatherResult, error) {
+	if args.City == "" {
+		return weatherResult{}, fmt.Errorf("city is required")
+	}
+	return weatherResult{Temperature: 21.5}, nil
+}
+
+func funcInfo(ctx context.Context, args weatherArgs) (string, error) {
+	return args.City, nil
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(GetWeather, Description("Current weather")); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := r.Register(GetWeather); err == nil {
+		t.Errorf("Expected error for duplicate registration")
+	}
+
+	tool, ok := r.Get("GetWeather")
+	if !ok {
+		t.Fatalf("Tool not found")
+	}
+	if tool.Description != "Current weather" {
+		t.Errorf("Unexpected description: %q", tool.Description)
+	}
+	if defs := r.Definitions(); len(defs) != 1 || defs[0].Name != "GetWeather" {
+		t.Errorf("Unexpected definitions: %+v", defs)
+	}
+	props := tool.Parameters["properties"].(map[string]any)
+	if _, ok := props["city"]; !ok {
+		t.Errorf("Schema misses city: %v", tool.Parameters)
+	}
+
+	out, err := r.Call(context.Background(), "GetWeather", json.RawMessage(`{"city":"Paris","unit":"celsius"}`))
+	if err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if out != `{"temperature":21.5}` {
+		t.Errorf("Call() = %s", out)
+	}
+
+	if _, err := r.Call(context.Background(), "GetWeather", json.RawMessage(`{}`)); err == nil {
+		t.Errorf("Expected error from tool")
+	}
+	if _, err := r.Call(context.Background(), "missing", nil); err == nil {
+		t.Errorf("Expected error for unknown tool")
+	}
+}
+
+func TestRegisterInvalid(t *testing.T) {
+	r := NewRegistry()
+
+	if err := r.Register("not a function"); err == nil {
+		t.Errorf("Expected error for non-function")
+	}
+	if err := r.Register(func(s string) (string, error) { return s, nil }, Name("echo")); err == nil {
+		t.Errorf("Expected error for non-struct arguments")
+	}
+	if err := r.Register(func(a weatherArgs) string { return "" }, Name("noerr")); err == nil {
+		t.Errorf("Expected error for missing error result")
+	}
+	if err := r.Register(func(a weatherArgs) (string, error) { return "", nil }); err == nil {
+		t.Errorf("Expected error for anonymous function without name")
+	}
+	if err := r.Register(func(a weatherArgs) (string, error) { return "ok", nil }, Name("anon")); err != nil {
+		t.Errorf("Register() error = %v", err)
+	}
+
+	// named functions starting with "func" are not closures
+	if err := r.Register(funcInfo); err != nil {
+		t.Errorf("Register(funcInfo) error = %v", err)
+	}
+	nested := func() func(weatherArgs) (string, error) {
+		return func(a weatherArgs) (string, error) { return "", nil }
+	}()
+	if err := r.Register(nested); err == nil {
+		t.Errorf("Expected error for a nested closure without name")
+	}
+}
+
+type emailArgs struct {
+	To string `json:"to"`
+}
+
+func SendEmail(args emailArgs) (string, error) {
+	return "sent to " + args.To, nil
+}
+
+func TestDestructiveApproval(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(SendEmail, Destructive()); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	ctx := context.Background()
+	args := json.RawMessage(`{"to":"bob@example.com"}`)
+
+	_, err := r.Call(ctx, "SendEmail", args)
+	var approvalErr *ApprovalError
+	if !errors.As(err, &approvalErr) || approvalErr.Rejected {
+		t.Fatalf("Expected pending approval error, got %v", err)
+	}
+
+	out, err := r.CallApproved(ctx, approvalErr.Tool, approvalErr.Args)
+	if err != nil || out != "sent to bob@example.com" {
+		t.Errorf("CallApproved() = %q, %v", out, err)
+	}
+
+	r.SetApprover(func(ctx context.Context, name string, args json.RawMessage) (bool, error) {
+		return false, nil
+	})
+	if _, err := r.Call(ctx, "SendEmail", args); !errors.As(err, &approvalErr) || !approvalErr.Rejected {
+		t.Errorf("Expected rejected approval error, got %v", err)
+	}
+
+	r.SetApprover(func(ctx context.Context, name string, args json.RawMessage) (bool, error) {
+		return true, nil
+	})
+	if _, err := r.Call(ctx, "SendEmail", args); err != nil {
+		t.Errorf("Call() error = %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package echo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Training data formats of ExportTraining
+const (
+	TrainingOpenAI    = "openai"    // {"messages": [...]}, the OpenAI chat fine-tuning format
+	TrainingAnthropic = "anthropic" // {"system": "...", "messages": [...]}, as used for Claude fine-tuning on Bedrock
+)
+
+// TrainingOptions selects and formats the conversations written by ExportTraining
+type TrainingOptions struct {
+	Format    string                // TrainingOpenAI (default) or TrainingAnthropic
+	Tags      []string              // when set, only conversations with at least one of these tags are exported
+	MinRating int                   // when set, only conversations rated at least this are exported
+	Redact    func(Message) Message // applied to each message, e.g. RedactPII()
+}
+
+// ExportTraining writes the active branch of each conversation as a line of JSONL
+// fine-tuning data and returns the number of examples written. Conversations whose
+// active branch doesn't end with an answer are skipped, as are those filtered out
+// by tags or rating.
+func ExportTraining(w io.Writer, conversations []*Conversation, opts TrainingOptions) (int, error) {
+	var encode func([]Message) ([]byte, error)
+	switch opts.Format {
+	case "", TrainingOpenAI:
+		encode = func(messages []Message) ([]byte, error) {
+			data, err := ToOpenAIMessages(messages)
+			if err != nil {
+				return nil, err
+			}
+			return json.Marshal(struct {
+				Messages json.RawMessage `json:"messages"`
+			}{data})
+		}
+	case TrainingAnthropic:
+		encode = ToAnthropicMessages
+	default:
+		return 0, fmt.Errorf("unknown training format: %s", opts.Format)
+	}
+
+	written := 0
+	for i, conv := range conversations {
+		if !conv.trainable(opts) {
+			continue
+		}
+
+		messages := conv.Messages()
+		if opts.Redact != nil {
+			messages = ExportOptions{Redact: opts.Redact}.redacted(messages)
+		}
+		line, err := encode(messages)
+		if err != nil {
+			return written, fmt.Errorf("conversation %d: %w", i, err)
+		}
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, nil
+}
+
+// trainable reports whether the conversation passes the filters and ends with an answer
+func (c *Conversation) trainable(opts TrainingOptions) bool {
+	messages := c.Messages()
+	if len(messages) == 0 || messages[len(messages)-1].Role != Agent {
+		return false
+	}
+	if opts.MinRating != 0 && c.Rating < opts.MinRating {
+		return false
+	}
+	if len(opts.Tags) > 0 && !slices.ContainsFunc(opts.Tags, func(tag string) bool { return slices.Contains(c.Tags, tag) }) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package echo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExportTraining(t *testing.T) {
+	good := NewConversation(nil, []Message{
+		{Role: System, Content: "Be brief"},
+		{Role: User, Content: "Hi, I am ann@example.com"},
+		{Role: Agent, Content: "Hello"},
+	})
+	good.Tags, good.Rating = []string{"support"}, 5
+	low := NewConversation(nil, []Message{{Role: User, Content: "Hi"}, {Role: Agent, Content: "Meh"}})
+	low.Tags, low.Rating = []string{"support"}, 1
+	open := NewConversation(nil, []Message{{Role: User, Content: "Unanswered"}})
+	conversations := []*Conversation{good, low, open}
+
+	var buf bytes.Buffer
+	n, err := ExportTraining(&buf, conversations, TrainingOptions{Tags: []string{"support"}, MinRating: 4, Redact: RedactPII()})
+	if err != nil || n != 1 {
+		t.Fatalf("ExportTraining() = %d, %v", n, err)
+	}
+	want := `{"messages":[{"role":"system","content":"Be brief"},{"role":"user","content":"Hi, I am \u003cEMAIL_1\u003e"},{"role":"assistant","content":"Hello"}]}` + "\n"
+	if buf.String() != want {
+		t.Errorf("OpenAI format = %s", buf.String())
+	}
+
+	buf.Reset()
+	n, err = ExportTraining(&buf, conversations, TrainingOptions{Format: TrainingAnthropic})
+	if err != nil || n != 2 {
+		t.Fatalf("ExportTraining() = %d, %v", n, err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], `{"system":"Be brief","messages":[{"role":"user"`) {
+		t.Errorf("Anthropic format = %s", buf.String())
+	}
+
+	if _, err := ExportTraining(&buf, conversations, TrainingOptions{Format: "csv"}); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,213 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"time"
+)
+
+// ExecCompleteStream implements the ProxyClient interface.
+// The request is streamed from the provider of the call options, whatever format it was parsed from.
+func (c *CommonClient) ExecCompleteStream(ctx context.Context, req *CompletionRequest, opts ...CallOption) (*StreamResponse, error) {
+	messages, opts, err := completionCall(req, opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.StreamComplete(ctx, messages, opts...)
+}
+
+// WriteCompleteStream implements the ProxyClient interface.
+// The stream is written as server-sent events in the wire format of the provider of the call options.
+func (c *CommonClient) WriteCompleteStream(w http.ResponseWriter, model string, stream *StreamResponse, opts ...CallOption) error {
+	p, err := c.getProvider(opts...)
+	if err != nil {
+		go drain(stream)
+		return err
+	}
+	return p.writeCompletionStream(w, model, stream)
+}
+
+// sseWriter writes server-sent events, flushing each one; the first error stops further writes
+type sseWriter struct {
+	w   io.Writer
+	err error
+}
+
+func newSSEWriter(w http.ResponseWriter) *sseWriter {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	return &sseWriter{w: w}
+}
+
+// event writes the data as JSON, with an event name when it is not empty
+func (s *sseWriter) event(name string, data any) {
+	if s.err != nil {
+		return
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		s.err = err
+		return
+	}
+	if name != "" {
+		_, s.err = fmt.Fprintf(s.w, "event: %s\n", name)
+	}
+	if s.err == nil {
+		_, s.err = fmt.Fprintf(s.w, "data: %s\n\n", body)
+	}
+	if s.err == nil {
+		s.err = flushWriter(s.w)
+	}
+}
+
+// done writes the terminating data line of OpenAI streams
+func (s *sseWriter) done() {
+	if s.err == nil {
+		_, s.err = io.WriteString(s.w, "data: [DONE]\n\n")
+	}
+	if s.err == nil {
+		s.err = flushWriter(s.w)
+	}
+}
+
+// transcodeStream reads the stream, calling text for each piece of text, and returns the merged
+// metadata. The stream is consumed completely, even when fn fails.
+func transcodeStream(stream *StreamResponse, text func(string) error) (Metadata, error) {
+	meta := Metadata{}
+	for chunk := range stream.Stream {
+		if chunk.Error != nil {
+			go drain(stream)
+			return meta, chunk.Error
+		}
+		if chunk.Meta != nil {
+			maps.Copy(meta, *chunk.Meta)
+		}
+		if chunk.Data == "" {
+			continue
+		}
+		if err := text(chunk.Data); err != nil {
+			go drain(stream)
+			return meta, err
+		}
+	}
+	return meta, nil
+}
+
+// writeOpenAIStream writes the stream as OpenAI chat.completion.chunk events
+func writeOpenAIStream(w http.ResponseWriter, model string, stream *StreamResponse) error {
+	id, err := randomID("chatcmpl-")
+	if err != nil {
+		go drain(stream)
+		return err
+	}
+	created := time.Now().Unix()
+	sse := newSSEWriter(w)
+
+	chunk := func(delta map[string]string, finishReason any) map[string]any {
+		return map[string]any{
+			"id":      id,
+			"object":  "chat.completion.chunk",
+			"created": created,
+			"model":   model,
+			"choices": []map[string]any{{"index": 0, "delta": delta, "finish_reason": finishReason}},
+		}
+	}
+
+	sse.event("", chunk(map[string]string{"role": "assistant", "content": ""}, nil))
+	meta, err := transcodeStream(stream, func(text string) error {
+		sse.event("", chunk(map[string]string{"content": text}, nil))
+		return sse.err
+	})
+	if err != nil {
+		if sse.err == nil {
+			sse.event("", map[string]any{"error": map[string]string{"message": err.Error()}})
+		}
+		return err
+	}
+
+	finishReason, _ := meta["finish_reason"].(string)
+	if finishReason == "" {
+		finishReason = FinishStop
+	}
+	last := chunk(map[string]string{}, finishReason)
+	if usage := completionResponse(model, "", meta).Usage; usage != nil {
+		last["usage"] = usage
+	}
+	sse.event("", last)
+	sse.done()
+	return sse.err
+}
+
+// writeAnthropicStream writes the stream as Anthropic messages events
+func writeAnthropicStream(w http.ResponseWriter, model string, stream *StreamResponse) error {
+	id, err := randomID("msg_")
+	if err != nil {
+		go drain(stream)
+		return err
+	}
+	sse := newSSEWriter(w)
+
+	sse.event("message_start", map[string]any{
+		"type": "message_start",
+		"message": map[string]any{
+			"id": id, "type": "message", "role": "assistant", "model": model,
+			"content": []any{}, "stop_reason": nil, "stop_sequence": nil,
+			"usage": map[string]int{"input_tokens": 0, "output_tokens": 0},
+		},
+	})
+	sse.event("content_block_start", map[string]any{
+		"type": "content_block_start", "index": 0,
+		"content_block": map[string]string{"type": "text", "text": ""},
+	})
+	meta, err := transcodeStream(stream, func(text string) error {
+		sse.event("content_block_delta", map[string]any{
+			"type": "content_block_delta", "index": 0,
+			"delta": map[string]string{"type": "text_delta", "text": text},
+		})
+		return sse.err
+	})
+	if err != nil {
+		if sse.err == nil {
+			sse.event("error", map[string]any{
+				"type":  "error",
+				"error": map[string]string{"type": "api_error", "message": err.Error()},
+			})
+		}
+		return err
+	}
+
+	sse.event("content_block_stop", map[string]any{"type": "content_block_stop", "index": 0})
+	stopReason, stopSequence := anthropicStopReason(meta)
+	usage := map[string]int{"output_tokens": 0}
+	if u := completionResponse(model, "", meta).Usage; u != nil {
+		usage["input_tokens"], usage["output_tokens"] = u.PromptTokens, u.CompletionTokens
+	}
+	sse.event("message_delta", map[string]any{
+		"type":  "message_delta",
+		"delta": map[string]any{"stop_reason": stopReason, "stop_sequence": stopSequence},
+		"usage": usage,
+	})
+	sse.event("message_stop", map[string]string{"type": "message_stop"})
+	return sse.err
+}
+
+// anthropicStopReason converts the normalized finish reason back to the Anthropic stop reason
+func anthropicStopReason(meta Metadata) (string, any) {
+	finishReason, _ := meta["finish_reason"].(string)
+	switch finishReason {
+	case FinishLength:
+		return "max_tokens", nil
+	case FinishToolCalls:
+		return "tool_use", nil
+	case FinishContentFilter:
+		return "refusal", nil
+	}
+	if seq, ok := meta["stop_sequence"].(string); ok && seq != "" {
+		return "stop_sequence", seq
+	}
+	return "end_turn", nil
+}
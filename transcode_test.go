@@ -0,0 +1,121 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// sseEvents returns the event names and data lines of a server-sent events body
+func sseEvents(body string) ([]string, []string) {
+	var names, data []string
+	for _, line := range strings.Split(body, "\n") {
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			names = append(names, name)
+		}
+		if d, ok := strings.CutPrefix(line, "data: "); ok {
+			data = append(data, d)
+		}
+	}
+	return names, data
+}
+
+func TestStreamTranscoding(t *testing.T) {
+	c, _ := NewCommonClient(map[string]string{"openai": "key", "anthropic": "key"}, WithModel("mock/test"))
+	c.SetProvider("mock", &MockProvider{})
+	client := c.(ProxyClient)
+	req := &CompletionRequest{Model: "claude", Stream: true, Messages: []OpenAIMessage{{Role: "user", Content: "Hello from the other side"}}}
+
+	// Anthropic client, streamed from the mock backend
+	stream, err := client.ExecCompleteStream(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ExecCompleteStream() error = %v", err)
+	}
+	rec := httptest.NewRecorder()
+	if err := client.WriteCompleteStream(rec, "claude", stream, WithModel("anthropic/claude")); err != nil {
+		t.Fatalf("WriteCompleteStream() error = %v", err)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected event stream, got %q", ct)
+	}
+
+	names, data := sseEvents(rec.Body.String())
+	if names[0] != "message_start" || names[1] != "content_block_start" || names[len(names)-1] != "message_stop" {
+		t.Errorf("Unexpected events %v", names)
+	}
+	var text strings.Builder
+	var stopReason string
+	for _, d := range data {
+		var event struct {
+			Type  string `json:"type"`
+			Delta struct {
+				Text       string `json:"text"`
+				StopReason string `json:"stop_reason"`
+			} `json:"delta"`
+		}
+		json.Unmarshal([]byte(d), &event)
+		switch event.Type {
+		case "content_block_delta":
+			text.WriteString(event.Delta.Text)
+		case "message_delta":
+			stopReason = event.Delta.StopReason
+		}
+	}
+	if text.String() != "[user]: Hello from the other side" || stopReason != "end_turn" {
+		t.Errorf("Unexpected text %q and stop reason %q", text.String(), stopReason)
+	}
+
+	// OpenAI client
+	stream, _ = client.ExecCompleteStream(context.Background(), req)
+	rec = httptest.NewRecorder()
+	if err := client.WriteCompleteStream(rec, "gpt", stream, WithModel("openai/gpt")); err != nil {
+		t.Fatalf("WriteCompleteStream() error = %v", err)
+	}
+	_, data = sseEvents(rec.Body.String())
+	if data[len(data)-1] != "[DONE]" {
+		t.Errorf("Expected the stream to end with [DONE], got %q", data[len(data)-1])
+	}
+	text.Reset()
+	var finishReason string
+	for _, d := range data[:len(data)-1] {
+		var chunk struct {
+			Object  string `json:"object"`
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+				FinishReason *string `json:"finish_reason"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(d), &chunk); err != nil || chunk.Object != "chat.completion.chunk" {
+			t.Fatalf("Unexpected chunk %s", d)
+		}
+		text.WriteString(chunk.Choices[0].Delta.Content)
+		if chunk.Choices[0].FinishReason != nil {
+			finishReason = *chunk.Choices[0].FinishReason
+		}
+	}
+	if text.String() != "[user]: Hello from the other side" || finishReason != FinishStop {
+		t.Errorf("Unexpected text %q and finish reason %q", text.String(), finishReason)
+	}
+}
+
+func TestAnthropicStopReason(t *testing.T) {
+	tests := []struct {
+		meta Metadata
+		want string
+	}{
+		{Metadata{"finish_reason": FinishStop}, "end_turn"},
+		{Metadata{"finish_reason": FinishStop, "stop_sequence": "###"}, "stop_sequence"},
+		{Metadata{"finish_reason": FinishLength}, "max_tokens"},
+		{Metadata{"finish_reason": FinishToolCalls}, "tool_use"},
+		{Metadata{"finish_reason": FinishContentFilter}, "refusal"},
+	}
+	for _, tt := range tests {
+		if got, _ := anthropicStopReason(tt.meta); got != tt.want {
+			t.Errorf("anthropicStopReason(%v) = %q, want %q", tt.meta, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package echo
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportConfig tunes the connection pool of the HTTP client created for each echo client
+type TransportConfig struct {
+	MaxIdleConnsPerHost int           // idle connections kept per provider host, default 32
+	IdleConnTimeout     time.Duration // how long idle connections are kept, default 90s
+	DisableHTTP2        bool          // use HTTP/1.1 only
+}
+
+// DefaultTransportConfig is used when WithTransport is not set.
+// The standard library keeps only 2 idle connections per host, which makes
+// concurrent callers of the same provider reconnect all the time.
+var DefaultTransportConfig = TransportConfig{
+	MaxIdleConnsPerHost: 32,
+	IdleConnTimeout:     90 * time.Second,
+}
+
+// newHTTPClient creates an HTTP client with its own connection pool
+func newHTTPClient(tc TransportConfig) *http.Client {
+	if tc.MaxIdleConnsPerHost <= 0 {
+		tc.MaxIdleConnsPerHost = DefaultTransportConfig.MaxIdleConnsPerHost
+	}
+	if tc.IdleConnTimeout <= 0 {
+		tc.IdleConnTimeout = DefaultTransportConfig.IdleConnTimeout
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     !tc.DisableHTTP2,
+		MaxIdleConns:          tc.MaxIdleConnsPerHost * 4,
+		MaxIdleConnsPerHost:   tc.MaxIdleConnsPerHost,
+		IdleConnTimeout:       tc.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if tc.DisableHTTP2 {
+		// A non-nil empty map disables the automatic HTTP/2 upgrade
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return &http.Client{Transport: transport}
+}
+
+// httpClient returns the HTTP client used for provider requests
+func (cfg CallConfig) httpClient() *http.Client {
+	if cfg.HTTPClient != nil {
+		return cfg.HTTPClient
+	}
+	return http.DefaultClient
+}
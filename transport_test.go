@@ -0,0 +1,80 @@
+package echo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type countingTransport struct {
+	calls int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices": [{"message": {"content": "ok"}}]}`))
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	client, err := NewCommonClient(map[string]string{"openai": "key"},
+		WithModel("openai/gpt-4.1"),
+		WithBaseURL(server.URL),
+		WithHTTPClient(&http.Client{Transport: transport}),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	if _, err := client.Complete(context.Background(), QuickMessage("Hi")); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if transport.calls != 1 {
+		t.Errorf("Expected the custom HTTP client to be used, got %d calls", transport.calls)
+	}
+}
+
+func TestTransportConfig(t *testing.T) {
+	client, err := NewClient(WithTransport(TransportConfig{MaxIdleConnsPerHost: 100, DisableHTTP2: true}))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	transport := client.(*CommonClient).baseConfig.HTTPClient.Transport.(*http.Transport)
+	if transport.MaxIdleConnsPerHost != 100 || transport.IdleConnTimeout != 90*time.Second {
+		t.Errorf("Unexpected pool settings: %d, %v", transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+	if transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+		t.Error("Expected HTTP/2 to be disabled")
+	}
+}
+
+func TestWithAPIKey(t *testing.T) {
+	var auth []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"choices": [{"message": {"content": "ok"}}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewCommonClient(map[string]string{"openai": "default-key"},
+		WithModel("openai/gpt-4.1"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ctx := context.Background()
+	client.Complete(ctx, QuickMessage("Hi"), WithAPIKey("tenant-key"))
+	client.Complete(ctx, QuickMessage("Hi"))
+
+	if len(auth) != 2 || auth[0] != "Bearer tenant-key" || auth[1] != "Bearer default-key" {
+		t.Errorf("Unexpected keys: %v", auth)
+	}
+}
@@ -0,0 +1,44 @@
+package echo
+
+// Usage is the token usage of a call, with the metadata keys of all providers mapped to one set of fields
+type Usage struct {
+	PromptTokens     int // input tokens, including cached ones and those written to the cache
+	CompletionTokens int // output tokens
+	TotalTokens      int
+	CachedTokens     int     // part of the prompt served from the provider cache
+	Cost             float64 // price of the call in USD, reported by OpenRouter only
+}
+
+// Usage returns the token usage of the response. OpenAI, Gemini and xAI report
+// prompt/completion tokens, Anthropic input/output tokens with the cache reads
+// and writes counted apart, which are added to the prompt tokens here.
+func (r *Response) Usage() Usage {
+	var u Usage
+	u.PromptTokens, _ = metaInt(r.Metadata, "prompt_tokens", "input_tokens")
+	u.CompletionTokens, _ = metaInt(r.Metadata, "completion_tokens", "output_tokens")
+	u.CachedTokens, _ = metaInt(r.Metadata, "cached_tokens", "cache_read_input_tokens")
+	if _, ok := metaInt(r.Metadata, "prompt_tokens"); !ok {
+		written, _ := metaInt(r.Metadata, "cache_creation_input_tokens")
+		read, _ := metaInt(r.Metadata, "cache_read_input_tokens")
+		u.PromptTokens += written + read
+	}
+	u.Cost, _ = r.Metadata["cost"].(float64)
+	if total, ok := metaInt(r.Metadata, "total_tokens"); ok {
+		u.TotalTokens = total
+	} else {
+		u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	}
+	return u
+}
+
+// StopReason returns why the model stopped, one of the Finish constants for known reasons.
+// Responses without the normalized key are mapped from the Anthropic stop reason.
+func (r *Response) StopReason() string {
+	if reason, _ := r.Metadata["finish_reason"].(string); reason != "" {
+		return reason
+	}
+	if reason, _ := r.Metadata["stop_reason"].(string); reason != "" {
+		return anthropicFinishReason(reason)
+	}
+	return ""
+}
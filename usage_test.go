@@ -0,0 +1,111 @@
+package echo
+
+import (
+	"context"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseAccessors(t *testing.T) {
+	tests := []struct {
+		name   string
+		meta   Metadata
+		usage  Usage
+		reason string
+	}{
+		{"openai", Metadata{"finish_reason": "length", "prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15},
+			Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, FinishLength},
+		{"anthropic", Metadata{"stop_reason": "tool_use", "finish_reason": "tool_calls", "input_tokens": 7, "output_tokens": 3},
+			Usage{PromptTokens: 7, CompletionTokens: 3, TotalTokens: 10}, FinishToolCalls},
+		{"anthropic cache", Metadata{"input_tokens": 7, "output_tokens": 3, "cache_creation_input_tokens": 100, "cache_read_input_tokens": 900},
+			Usage{PromptTokens: 1007, CompletionTokens: 3, TotalTokens: 1010, CachedTokens: 900}, ""},
+		{"google", Metadata{"finish_reason": "stop", "prompt_tokens": 20, "completion_tokens": 2, "total_tokens": 22, "cached_tokens": 16},
+			Usage{PromptTokens: 20, CompletionTokens: 2, TotalTokens: 22, CachedTokens: 16}, FinishStop},
+		{"stored", Metadata{"stop_reason": "max_tokens", "input_tokens": 7.0, "output_tokens": 3.0},
+			Usage{PromptTokens: 7, CompletionTokens: 3, TotalTokens: 10}, FinishLength},
+		{"empty", nil, Usage{}, ""},
+	}
+
+	for _, tt := range tests {
+		resp := &Response{Metadata: tt.meta}
+		if u := resp.Usage(); u != tt.usage {
+			t.Errorf("%s: Usage() = %+v, want %+v", tt.name, u, tt.usage)
+		}
+		if reason := resp.StopReason(); reason != tt.reason {
+			t.Errorf("%s: StopReason() = %q, want %q", tt.name, reason, tt.reason)
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"model": "gpt-4o-mini-2024-07-18", "choices": [{"message": {"content": "ok"}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewCommonClient(map[string]string{"openai": "key"}, WithModel("openai/gpt-4o-mini"), WithBaseURL(server.URL))
+	resp, err := client.Complete(context.Background(), QuickMessage("Hi"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Model != "gpt-4o-mini-2024-07-18" || resp.Provider != "openai" || resp.StopReason() != FinishStop {
+		t.Errorf("Unexpected model %q, provider %q, stop reason %q", resp.Model, resp.Provider, resp.StopReason())
+	}
+
+	// Without a model in the response, the resolved model of the call is reported
+	mock, _ := NewCommonClient(nil, WithModel("mock/any"))
+	resp, err = mock.Complete(context.Background(), QuickMessage("Hi"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Model != "any" || resp.Provider != "mock" {
+		t.Errorf("Unexpected model %q, provider %q", resp.Model, resp.Provider)
+	}
+}
+
+func TestAnthropicCacheUsage(t *testing.T) {
+	usage := `{"input_tokens": 10, "output_tokens": 0, "cache_creation_input_tokens": 200, "cache_read_input_tokens": 1000}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), `"stream":true`) {
+			w.Write([]byte("event: message_start\ndata: {\"type\": \"message_start\", \"message\": {\"usage\": " + usage + "}}\n\n" +
+				"event: content_block_delta\ndata: {\"type\": \"content_block_delta\", \"delta\": {\"type\": \"text_delta\", \"text\": \"ok\"}}\n\n" +
+				"event: message_delta\ndata: {\"type\": \"message_delta\", \"delta\": {\"stop_reason\": \"end_turn\"}, \"usage\": {\"output_tokens\": 5}}\n\n" +
+				"event: message_stop\ndata: {\"type\": \"message_stop\"}\n\n"))
+			return
+		}
+		w.Write([]byte(`{"content": [{"type": "text", "text": "ok"}], "stop_reason": "end_turn",
+			"usage": {"input_tokens": 10, "output_tokens": 5, "cache_creation_input_tokens": 200, "cache_read_input_tokens": 1000}}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewCommonClient(map[string]string{"anthropic": "key"}, WithModel("anthropic/claude-sonnet-4-5"), WithBaseURL(server.URL))
+	want := Usage{PromptTokens: 1210, CompletionTokens: 5, TotalTokens: 1215, CachedTokens: 1000}
+
+	resp, err := client.Complete(context.Background(), QuickMessage("Hi"))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if u := resp.Usage(); u != want {
+		t.Errorf("Usage() = %+v, want %+v", u, want)
+	}
+
+	stream, err := client.StreamComplete(context.Background(), QuickMessage("Hi"))
+	if err != nil {
+		t.Fatalf("StreamComplete() error = %v", err)
+	}
+	meta := Metadata{}
+	for chunk := range stream.Stream {
+		if chunk.Error != nil {
+			t.Fatalf("Stream error = %v", chunk.Error)
+		}
+		if chunk.Meta != nil {
+			maps.Copy(meta, *chunk.Meta)
+		}
+	}
+	if u := (&Response{Metadata: meta}).Usage(); u != want {
+		t.Errorf("Stream usage = %+v, want %+v", u, want)
+	}
+}
@@ -0,0 +1,48 @@
+package echo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Validate implements the Client interface.
+// It resolves the model, prepares the messages as a call would (sanitation, locale, scrubbing,
+// output filters, coalescing, automatic max tokens), builds the provider request for them and
+// checks the estimated prompt size against the context window of the model and the requested
+// max tokens against its output limit, without sending anything. Checks that need I/O, such as
+// tenant configs, image downloads and injection guard models, are made when the call is sent.
+func (c *CommonClient) Validate(messages []Message, opts ...CallOption) error {
+	opts = append(slices.Clip(opts), func(cfg *CallConfig) {
+		cfg.TenantStore = nil
+		cfg.InjectionGuard = ""
+		cfg.ImageFetchLimit = 0
+	})
+	ctx := context.Background()
+	p, cfg, err := c.prepareCall(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	messages, _, err = c.prepareMessages(ctx, messages, &cfg)
+	if err != nil {
+		return err
+	}
+	if err := p.validateCompletion(messages, cfg); err != nil {
+		return err
+	}
+
+	info, ok := LookupModel(cfg.modelRef().String())
+	if !ok {
+		return nil
+	}
+	if cfg.MaxTokens != nil && info.MaxOutputTokens > 0 && *cfg.MaxTokens > info.MaxOutputTokens {
+		return fmt.Errorf("max tokens %d exceed the output limit of %s/%s, %d tokens", *cfg.MaxTokens, cfg.provider, cfg.Model, info.MaxOutputTokens)
+	}
+	if window := contextWindow(info, cfg); window > 0 {
+		prompt := EstimateMessagesTokens(messages) + EstimateTokens(cfg.SystemMsg)
+		if prompt > window {
+			return fmt.Errorf("prompt of about %d tokens exceeds the context window of %d tokens", prompt, window)
+		}
+	}
+	return nil
+}
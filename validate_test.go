@@ -0,0 +1,47 @@
+package echo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	client, _ := NewCommonClient(map[string]string{"openai": "key", "voyage": "key", "google": "key", "anthropic": "key"}, WithModel("openai/gpt-4o"))
+
+	tests := []struct {
+		name     string
+		messages []Message
+		opts     []CallOption
+		valid    bool
+	}{
+		{"valid", QuickMessage("Hi"), nil, true},
+		{"empty chain", nil, nil, false},
+		{"invalid role", []Message{{Role: "robot", Content: "Hi"}}, nil, false},
+		{"unknown provider", QuickMessage("Hi"), []CallOption{WithModel("nope/model")}, false},
+		{"no completions", QuickMessage("Hi"), []CallOption{WithModel("voyage/voyage-4")}, false},
+		{"unsupported media", []Message{{Role: User, Content: "Watch", Parts: []Part{VideoURLPart("https://example.com/a.mp4")}}}, nil, false},
+		{"max tokens over limit", QuickMessage("Hi"), []CallOption{WithMaxTokens(100_000)}, false},
+		{"prompt over window", QuickMessage(strings.Repeat("abcd", 130_000)), nil, false},
+		{"logit bias", QuickMessage("Hi"), []CallOption{WithLogitBias(map[string]float64{"1734": -100})}, true},
+		{"logit bias out of range", QuickMessage("Hi"), []CallOption{WithLogitBias(map[string]float64{"1734": -200})}, false},
+		{"logit bias by word", QuickMessage("Hi"), []CallOption{WithLogitBias(map[string]float64{"yes": 10})}, false},
+		{"logit bias unsupported", QuickMessage("Hi"), []CallOption{WithModel("google/gemini-2.5-flash"), WithLogitBias(map[string]float64{"1734": 5})}, false},
+		{"thinking", QuickMessage("Hi"), []CallOption{WithModel("anthropic/claude-sonnet-4-5"), WithThinking(2048)}, true},
+		{"thinking budget too small", QuickMessage("Hi"), []CallOption{WithModel("anthropic/claude-sonnet-4-5"), WithThinking(512)}, false},
+		{"thinking over max tokens", QuickMessage("Hi"), []CallOption{WithModel("anthropic/claude-sonnet-4-5"), WithThinking(4096), WithMaxTokens(4096)}, false},
+		{"thinking with temperature", QuickMessage("Hi"), []CallOption{WithModel("anthropic/claude-sonnet-4-5"), WithThinking(2048), WithTemperature(0.2)}, false},
+		{"thinking unsupported", QuickMessage("Hi"), []CallOption{WithThinking(2048)}, false},
+		{"locale", QuickMessage("Hi"), []CallOption{WithLocale("de-DE")}, true},
+		{"invalid locale", QuickMessage("Hi"), []CallOption{WithLocale("not a locale")}, false},
+		{"invalid scrub pattern", QuickMessage("Hi"), []CallOption{WithScrubbing("([")}, false},
+		{"unknown output filter", QuickMessage("Hi"), []CallOption{WithOutputFilter("hide")}, false},
+		{"max tokens auto", QuickMessage("Hi"), []CallOption{WithMaxTokensAuto()}, true},
+		{"max tokens auto without budget", QuickMessage(strings.Repeat("abcd", 120_000)), []CallOption{WithMaxTokensAuto()}, false},
+		{"sanitized to empty", []Message{{Role: User, Content: " "}}, []CallOption{WithSanitation(SanitizeEmpty)}, false},
+	}
+	for _, tt := range tests {
+		if err := client.Validate(tt.messages, tt.opts...); (err == nil) != tt.valid {
+			t.Errorf("%s: Validate() error = %v", tt.name, err)
+		}
+	}
+}
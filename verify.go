@@ -0,0 +1,204 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Citation check methods
+const (
+	CitationEmbedding = "embedding" // cosine similarity of the sentence and the cited sources
+	CitationJudge     = "judge"     // a model decides whether the sources support the sentence
+)
+
+// defaultCitationThreshold is the similarity at which an embedding check passes
+const defaultCitationThreshold = 0.7
+
+// CitationCheck is the verdict for a sentence of the answer
+type CitationCheck struct {
+	Sentence  string  `json:"sentence"`
+	Sources   []int   `json:"sources,omitempty"` // cited source numbers, 1-based as in the text
+	Supported bool    `json:"supported"`
+	Score     float64 `json:"score"` // best similarity to a cited source; 1 or 0 for the judge
+}
+
+// citationMarker matches citations like [1] and [2, 3]
+var citationMarker = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// VerifyCitations checks the cited sentences of an answer against the sources.
+// Sentences of the answer cite the sources as [1], [2, 3]; each cited sentence is checked
+// against its sources with the method set by WithCitationCheck, using the model of the call
+// options: an embedding model for CitationEmbedding, a chat model for CitationJudge.
+// The checks are attached to the response metadata as "citations" ([]CitationCheck), along with
+// "unsupported" (number of cited sentences the sources don't support) and "uncited" (sentences
+// without a citation). The response is returned with its text unchanged.
+func VerifyCitations(ctx context.Context, client Client, resp *Response, sources []string, opts ...CallOption) (*Response, error) {
+	cfg := clientConfig(client, opts)
+
+	var checks, cited []CitationCheck
+	uncited := 0
+	for _, sentence := range splitSentences(resp.Text) {
+		check := CitationCheck{Sentence: sentence}
+		for _, m := range citationMarker.FindAllStringSubmatch(sentence, -1) {
+			for _, n := range strings.Split(m[1], ",") {
+				num, _ := strconv.Atoi(strings.TrimSpace(n))
+				check.Sources = append(check.Sources, num)
+			}
+		}
+		if len(check.Sources) == 0 {
+			uncited++
+		}
+		checks = append(checks, check)
+	}
+	for _, check := range checks {
+		if len(check.Sources) > 0 {
+			cited = append(cited, check)
+		}
+	}
+
+	var err error
+	switch cfg.CitationCheck {
+	case "", CitationEmbedding:
+		err = checkCitationsByEmbedding(ctx, client, cited, sources, cfg, opts)
+	case CitationJudge:
+		err = checkCitationsByJudge(ctx, client, cited, sources, opts)
+	default:
+		err = fmt.Errorf("unknown citation check method: %s", cfg.CitationCheck)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	unsupported := 0
+	for i, j := 0, 0; i < len(checks); i++ {
+		if len(checks[i].Sources) == 0 {
+			continue
+		}
+		checks[i] = cited[j]
+		if !cited[j].Supported {
+			unsupported++
+		}
+		j++
+	}
+
+	if resp.Metadata == nil {
+		resp.Metadata = Metadata{}
+	}
+	resp.Metadata["citations"] = checks
+	resp.Metadata["unsupported"] = unsupported
+	resp.Metadata["uncited"] = uncited
+	return resp, nil
+}
+
+// citedSource returns the text of a 1-based source number
+func citedSource(sources []string, num int) (string, bool) {
+	if num < 1 || num > len(sources) {
+		return "", false
+	}
+	return sources[num-1], true
+}
+
+// checkCitationsByEmbedding compares the embedding of each sentence, with the citation
+// markers removed, to the embeddings of its cited sources
+func checkCitationsByEmbedding(ctx context.Context, client Client, checks []CitationCheck, sources []string, cfg CallConfig, opts []CallOption) error {
+	threshold := cfg.CitationThreshold
+	if threshold <= 0 {
+		threshold = defaultCitationThreshold
+	}
+
+	embedded := map[int][]float32{}
+	for i := range checks {
+		resp, err := client.GetEmbeddings(ctx, citationMarker.ReplaceAllString(checks[i].Sentence, ""), opts...)
+		if err != nil {
+			return err
+		}
+		for _, num := range checks[i].Sources {
+			text, ok := citedSource(sources, num)
+			if !ok {
+				continue
+			}
+			if _, ok := embedded[num]; !ok {
+				source, err := client.GetEmbeddings(ctx, text, opts...)
+				if err != nil {
+					return err
+				}
+				embedded[num] = source.Embedding
+			}
+			checks[i].Score = max(checks[i].Score, cosine(resp.Embedding, embedded[num]))
+		}
+		checks[i].Supported = checks[i].Score >= threshold
+	}
+	return nil
+}
+
+// cosine returns the cosine similarity of two vectors, 0 for vectors of different dimensions
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	if d := float64(norm(a)) * float64(norm(b)); d > 0 {
+		return dot / d
+	}
+	return 0
+}
+
+// checkCitationsByJudge asks the model which sentences are supported by their sources, in a single call
+func checkCitationsByJudge(ctx context.Context, client Client, checks []CitationCheck, sources []string, opts []CallOption) error {
+	if len(checks) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Decide for each numbered claim whether the sources it cites fully support it. " +
+		"A claim is unsupported when the cited sources don't state it or contradict it; don't use outside knowledge.\n\nSources:\n")
+	for i, source := range sources {
+		fmt.Fprintf(&sb, "[%d] %s\n", i+1, source)
+	}
+	sb.WriteString("\nClaims:\n")
+	for i, check := range checks {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, check.Sentence)
+	}
+
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"supported": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "boolean"},
+				"description": "One verdict per claim, in order",
+			},
+		},
+		"required":             []string{"supported"},
+		"additionalProperties": false,
+	}
+	callOpts := append([]CallOption{WithStructuredOutput("citations", schema)}, opts...)
+	resp, err := client.Complete(ctx, QuickMessage(sb.String()), callOpts...)
+	if err != nil {
+		return err
+	}
+
+	var verdict struct {
+		Supported []bool `json:"supported"`
+	}
+	if err := json.Unmarshal([]byte(resp.Text), &verdict); err != nil {
+		return fmt.Errorf("failed to parse citation verdict: %w", err)
+	}
+	if len(verdict.Supported) != len(checks) {
+		return fmt.Errorf("model returned %d verdicts for %d claims", len(verdict.Supported), len(checks))
+	}
+	for i, supported := range verdict.Supported {
+		checks[i].Supported = supported
+		if supported {
+			checks[i].Score = 1
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package echo
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+var verifySources = []string{
+	"The Eiffel Tower was completed in 1889 for the World Fair in Paris.",
+	"Mount Everest is the highest mountain above sea level.",
+}
+
+func TestVerifyCitationsEmbedding(t *testing.T) {
+	client, _ := NewCommonClient(nil, WithModel("mock/test"))
+	resp := &Response{Text: "The Eiffel Tower was completed in 1889 for the World Fair [1]. " +
+		"It is painted gold every year [2]. Paris is lovely."}
+
+	resp, err := VerifyCitations(context.Background(), client, resp, verifySources)
+	if err != nil {
+		t.Fatalf("VerifyCitations() error = %v", err)
+	}
+
+	checks := resp.Metadata["citations"].([]CitationCheck)
+	if len(checks) != 3 {
+		t.Fatalf("Expected 3 sentences, got %+v", checks)
+	}
+	if !checks[0].Supported || checks[1].Supported || len(checks[1].Sources) != 1 || checks[1].Sources[0] != 2 {
+		t.Errorf("Unexpected checks %+v", checks)
+	}
+	if resp.Metadata["unsupported"] != 1 || resp.Metadata["uncited"] != 1 {
+		t.Errorf("Unexpected counts %v, %v", resp.Metadata["unsupported"], resp.Metadata["uncited"])
+	}
+}
+
+func TestVerifyCitationsJudge(t *testing.T) {
+	provider := &scriptedProvider{answers: []string{`{"supported": [true, false]}`}}
+	client, _ := NewClient(WithModel("mock/judge"))
+	client.SetProvider("mock", provider)
+
+	resp := &Response{Text: "The tower was finished in 1889 [1]. Everest is in the Alps [1, 2]."}
+	resp, err := VerifyCitations(context.Background(), client, resp, verifySources, WithCitationCheck(CitationJudge))
+	if err != nil {
+		t.Fatalf("VerifyCitations() error = %v", err)
+	}
+
+	checks := resp.Metadata["citations"].([]CitationCheck)
+	if !checks[0].Supported || checks[1].Supported || len(checks[1].Sources) != 2 {
+		t.Errorf("Unexpected checks %+v", checks)
+	}
+	prompt := provider.calls[0][0].Content
+	if !strings.Contains(prompt, "[2] Mount Everest") || !strings.Contains(prompt, "2. Everest is in the Alps [1, 2].") {
+		t.Errorf("Unexpected judge prompt %q", prompt)
+	}
+
+	provider.answers = []string{`{"supported": [true]}`}
+	if _, err := VerifyCitations(context.Background(), client, resp, verifySources, WithCitationCheck(CitationJudge)); err == nil {
+		t.Error("Expected error for a wrong number of verdicts")
+	}
+}
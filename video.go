@@ -0,0 +1,73 @@
+package echo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimedText is a piece of a response bound to a moment of a video
+type TimedText struct {
+	At   time.Duration
+	Text string
+}
+
+// timestampLine matches lines like "01:23 - text", "[1:02:03] text" or "**00:15**: text"
+var timestampLine = regexp.MustCompile(`^\s*(?:[-*]\s+)?[\[(*]*((?:\d+:)?\d{1,2}:\d{2})[\])*]*\s*[-–:]?\s*(.*)$`)
+
+// DescribeVideo asks the model to describe what happens in the video and returns
+// the description split by timestamps. Video input is supported by Gemini models.
+func DescribeVideo(ctx context.Context, client Client, video Part, prompt string, opts ...CallOption) ([]TimedText, error) {
+	if prompt == "" {
+		prompt = "Describe the key events of the video."
+	}
+	messages := []Message{{
+		Role: User,
+		Content: prompt + "\n\nAnswer with one line per event in the format \"MM:SS - description\", " +
+			"using timestamps relative to the start of the video.",
+		Parts: []Part{video},
+	}}
+
+	resp, err := client.Complete(ctx, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	items := ParseTimestamps(resp.Text)
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no timestamps found in the response")
+	}
+	return items, nil
+}
+
+// ParseTimestamps splits a response into timestamped entries.
+// Lines without a leading MM:SS or HH:MM:SS timestamp are appended to the previous entry.
+func ParseTimestamps(text string) []TimedText {
+	var result []TimedText
+	for _, line := range strings.Split(text, "\n") {
+		if m := timestampLine.FindStringSubmatch(line); m != nil {
+			result = append(result, TimedText{At: parseClock(m[1]), Text: strings.TrimSpace(m[2])})
+			continue
+		}
+
+		line = strings.TrimSpace(line)
+		if line != "" && len(result) > 0 {
+			last := &result[len(result)-1]
+			last.Text = strings.TrimSpace(last.Text + "\n" + line)
+		}
+	}
+	return result
+}
+
+// parseClock converts "MM:SS" or "HH:MM:SS" to a duration
+func parseClock(clock string) time.Duration {
+	var total time.Duration
+	for _, field := range strings.Split(clock, ":") {
+		n, _ := strconv.Atoi(field)
+		total = total*60 + time.Duration(n)
+	}
+	return total * time.Second
+}
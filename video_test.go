@@ -0,0 +1,80 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimestamps(t *testing.T) {
+	text := "Here is what happens:\n00:05 - A dog enters\nand sits down\n[01:10] The dog barks\n- **1:02:03**: Credits"
+	got := ParseTimestamps(text)
+	want := []TimedText{
+		{At: 5 * time.Second, Text: "A dog enters\nand sits down"},
+		{At: 70 * time.Second, Text: "The dog barks"},
+		{At: time.Hour + 2*time.Minute + 3*time.Second, Text: "Credits"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ParseTimestamps() = %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("item %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestGeminiVideoRequest(t *testing.T) {
+	messages := []Message{{Role: User, Content: "Summarize", Parts: []Part{
+		VideoURLPart("https://www.youtube.com/watch?v=abc").WithClip(10*time.Second, 90*time.Second).WithFPS(0.5),
+	}}}
+
+	req, err := prepareGoogleRequest(messages, CallConfig{Model: "gemini"})
+	if err != nil {
+		t.Fatalf("prepareGoogleRequest() error = %v", err)
+	}
+	data, _ := json.Marshal(req.Contents[0].Parts[1])
+	expected := `{"fileData":{"fileUri":"https://www.youtube.com/watch?v=abc"},"videoMetadata":{"startOffset":"10s","endOffset":"90s","fps":0.5}}`
+	if string(data) != expected {
+		t.Errorf("Unexpected video part: %s", data)
+	}
+}
+
+func TestGeminiUploadFile(t *testing.T) {
+	interval := filePollInterval
+	filePollInterval = time.Millisecond
+	t.Cleanup(func() { filePollInterval = interval })
+	checks := 0
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/upload/v1beta/files":
+			if r.Header.Get("X-Goog-Upload-Header-Content-Type") != "video/mp4" {
+				t.Errorf("Unexpected upload headers: %v", r.Header)
+			}
+			w.Header().Set("X-Goog-Upload-URL", server.URL+"/session")
+		case r.URL.Path == "/session":
+			w.Write([]byte(`{"file": {"name": "files/v1", "uri": "https://generativelanguage.googleapis.com/v1beta/files/v1", "state": "PROCESSING"}}`))
+		case strings.HasSuffix(r.URL.Path, "/files/v1"):
+			checks++
+			w.Write([]byte(`{"name": "files/v1", "uri": "https://generativelanguage.googleapis.com/v1beta/files/v1", "state": "ACTIVE"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p := &GoogleProvider{Key: "test"}
+	file, err := p.UploadFile(context.Background(), []byte("video"), "video/mp4", WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("UploadFile() error = %v", err)
+	}
+	if file.State != "ACTIVE" || checks != 1 {
+		t.Errorf("Expected an active file after one check, got %+v after %d checks", file, checks)
+	}
+}
@@ -0,0 +1,251 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Box is an object bounding box with coordinates normalized to 0..1,
+// measured from the top-left corner of the image
+type Box struct {
+	Label string
+	XMin  float64
+	YMin  float64
+	XMax  float64
+	YMax  float64
+}
+
+// Point is an object location with coordinates normalized to 0..1
+type Point struct {
+	Label string
+	X     float64
+	Y     float64
+}
+
+// Pixels converts the box to pixel coordinates of an image with the given size
+func (b Box) Pixels(width, height int) (x0, y0, x1, y1 int) {
+	return int(math.Round(b.XMin * float64(width))), int(math.Round(b.YMin * float64(height))),
+		int(math.Round(b.XMax * float64(width))), int(math.Round(b.YMax * float64(height)))
+}
+
+// Pixels converts the point to pixel coordinates of an image with the given size
+func (p Point) Pixels(width, height int) (x, y int) {
+	return int(math.Round(p.X * float64(width))), int(math.Round(p.Y * float64(height)))
+}
+
+// visionScale is the coordinate range used in prompts; Gemini models are trained on 0..1000
+const visionScale = 1000
+
+// annotation is a single object in the JSON returned by vision models.
+// box_2d and point follow the Gemini convention: [ymin, xmin, ymax, xmax] and [y, x] in 0..1000.
+type annotation struct {
+	Label string    `json:"label" description:"short name of the object"`
+	Box2D []float64 `json:"box_2d,omitempty" description:"bounding box as [ymin, xmin, ymax, xmax] normalized to 0-1000"`
+	Point []float64 `json:"point,omitempty" description:"object center as [y, x] normalized to 0-1000"`
+
+	// Fields used by models that return explicit coordinates
+	XMin *float64 `json:"x_min,omitempty"`
+	YMin *float64 `json:"y_min,omitempty"`
+	XMax *float64 `json:"x_max,omitempty"`
+	YMax *float64 `json:"y_max,omitempty"`
+	X    *float64 `json:"x,omitempty"`
+	Y    *float64 `json:"y,omitempty"`
+}
+
+type boxAnnotations struct {
+	Objects []struct {
+		Label string    `json:"label" description:"short name of the object"`
+		Box2D []float64 `json:"box_2d" description:"bounding box as [ymin, xmin, ymax, xmax] normalized to 0-1000"`
+	} `json:"objects"`
+}
+
+type pointAnnotations struct {
+	Objects []struct {
+		Label string    `json:"label" description:"short name of the object"`
+		Point []float64 `json:"point" description:"object center as [y, x] normalized to 0-1000"`
+	} `json:"objects"`
+}
+
+// DetectBoxes asks a vision model for bounding boxes of the described objects on the image
+func DetectBoxes(ctx context.Context, client Client, image Part, target string, opts ...CallOption) ([]Box, error) {
+	messages := []Message{{
+		Role: User,
+		Content: fmt.Sprintf("Detect %s on the image. For each object return its label and its bounding box "+
+			"as box_2d: [ymin, xmin, ymax, xmax] normalized to 0-1000.", target),
+		Parts: []Part{image},
+	}}
+
+	result, err := completeTyped[boxAnnotations](ctx, client, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	boxes := make([]Box, 0, len(result.Objects))
+	for _, obj := range result.Objects {
+		box, err := (annotation{Label: obj.Label, Box2D: obj.Box2D}).box()
+		if err != nil {
+			return nil, err
+		}
+		boxes = append(boxes, box)
+	}
+	return boxes, nil
+}
+
+// DetectPoints asks a vision model for the center points of the described objects on the image
+func DetectPoints(ctx context.Context, client Client, image Part, target string, opts ...CallOption) ([]Point, error) {
+	messages := []Message{{
+		Role: User,
+		Content: fmt.Sprintf("Point to %s on the image. For each object return its label and its center "+
+			"as point: [y, x] normalized to 0-1000.", target),
+		Parts: []Part{image},
+	}}
+
+	result, err := completeTyped[pointAnnotations](ctx, client, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]Point, 0, len(result.Objects))
+	for _, obj := range result.Objects {
+		point, err := (annotation{Label: obj.Label, Point: obj.Point}).point()
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// ParseBoxes parses bounding boxes from a free-form model response.
+// It accepts a JSON array (optionally inside a markdown code block, or wrapped in an object)
+// with either Gemini box_2d values or explicit x_min/y_min/x_max/y_max fields.
+func ParseBoxes(text string) ([]Box, error) {
+	items, err := parseAnnotations(text)
+	if err != nil {
+		return nil, err
+	}
+
+	boxes := make([]Box, 0, len(items))
+	for _, item := range items {
+		box, err := item.box()
+		if err != nil {
+			return nil, err
+		}
+		boxes = append(boxes, box)
+	}
+	return boxes, nil
+}
+
+// ParsePoints parses points from a free-form model response.
+// It accepts Gemini point values ([y, x] in 0..1000) or explicit x/y fields.
+func ParsePoints(text string) ([]Point, error) {
+	items, err := parseAnnotations(text)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]Point, 0, len(items))
+	for _, item := range items {
+		point, err := item.point()
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// parseAnnotations finds the JSON payload in the text and decodes the list of objects
+func parseAnnotations(text string) ([]annotation, error) {
+	text = strings.TrimSpace(text)
+	if start := strings.Index(text, "```"); start >= 0 {
+		text = text[start+3:]
+		text = strings.TrimPrefix(text, "json")
+		if end := strings.Index(text, "```"); end >= 0 {
+			text = text[:end]
+		}
+		text = strings.TrimSpace(text)
+	}
+
+	var items []annotation
+	if strings.HasPrefix(text, "[") {
+		if err := json.Unmarshal([]byte(text), &items); err != nil {
+			return nil, fmt.Errorf("failed to parse annotations: %w", err)
+		}
+		return items, nil
+	}
+
+	// An object wrapping the list, e.g. {"objects": [...]}; the "objects" field of the
+	// structured output comes first, other fields in the order of their names
+	var wrapper map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(text), &wrapper); err != nil {
+		return nil, fmt.Errorf("failed to parse annotations: %w", err)
+	}
+	keys := slices.Sorted(maps.Keys(wrapper))
+	if i := slices.Index(keys, "objects"); i > 0 {
+		keys = append([]string{"objects"}, slices.Delete(keys, i, i+1)...)
+	}
+	for _, key := range keys {
+		if err := json.Unmarshal(wrapper[key], &items); err == nil {
+			return items, nil
+		}
+	}
+	return nil, fmt.Errorf("no list of annotations found in the response")
+}
+
+func (a annotation) box() (Box, error) {
+	if len(a.Box2D) > 0 {
+		if len(a.Box2D) != 4 {
+			return Box{}, fmt.Errorf("box_2d of %q must have 4 values, got %d", a.Label, len(a.Box2D))
+		}
+		return Box{
+			Label: a.Label,
+			YMin:  a.Box2D[0] / visionScale,
+			XMin:  a.Box2D[1] / visionScale,
+			YMax:  a.Box2D[2] / visionScale,
+			XMax:  a.Box2D[3] / visionScale,
+		}, nil
+	}
+
+	if a.XMin == nil || a.YMin == nil || a.XMax == nil || a.YMax == nil {
+		return Box{}, fmt.Errorf("no bounding box for %q", a.Label)
+	}
+	scale := coordinateScale(*a.XMin, *a.YMin, *a.XMax, *a.YMax)
+	return Box{
+		Label: a.Label,
+		XMin:  *a.XMin / scale,
+		YMin:  *a.YMin / scale,
+		XMax:  *a.XMax / scale,
+		YMax:  *a.YMax / scale,
+	}, nil
+}
+
+func (a annotation) point() (Point, error) {
+	if len(a.Point) > 0 {
+		if len(a.Point) != 2 {
+			return Point{}, fmt.Errorf("point of %q must have 2 values, got %d", a.Label, len(a.Point))
+		}
+		return Point{Label: a.Label, Y: a.Point[0] / visionScale, X: a.Point[1] / visionScale}, nil
+	}
+
+	if a.X == nil || a.Y == nil {
+		return Point{}, fmt.Errorf("no point for %q", a.Label)
+	}
+	scale := coordinateScale(*a.X, *a.Y)
+	return Point{Label: a.Label, X: *a.X / scale, Y: *a.Y / scale}, nil
+}
+
+// coordinateScale guesses whether explicit coordinates are already normalized (0..1) or use 0..1000
+func coordinateScale(values ...float64) float64 {
+	for _, v := range values {
+		if v > 1 {
+			return visionScale
+		}
+	}
+	return 1
+}
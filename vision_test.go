@@ -0,0 +1,50 @@
+package echo
+
+import "testing"
+
+func TestParseBoxes(t *testing.T) {
+	gemini := "```json\n[{\"box_2d\": [100, 200, 500, 600], \"label\": \"cat\"}]\n```"
+	boxes, err := ParseBoxes(gemini)
+	if err != nil {
+		t.Fatalf("ParseBoxes() error = %v", err)
+	}
+	want := Box{Label: "cat", XMin: 0.2, YMin: 0.1, XMax: 0.6, YMax: 0.5}
+	if len(boxes) != 1 || boxes[0] != want {
+		t.Errorf("ParseBoxes() = %+v, want %+v", boxes, want)
+	}
+	if x0, y0, x1, y1 := boxes[0].Pixels(1000, 500); x0 != 200 || y0 != 50 || x1 != 600 || y1 != 250 {
+		t.Errorf("Pixels() = %d,%d,%d,%d", x0, y0, x1, y1)
+	}
+
+	explicit := `{"objects": [{"label": "dog", "x_min": 0.1, "y_min": 0.2, "x_max": 0.3, "y_max": 0.4}]}`
+	boxes, err = ParseBoxes(explicit)
+	if err != nil {
+		t.Fatalf("ParseBoxes() error = %v", err)
+	}
+	want = Box{Label: "dog", XMin: 0.1, YMin: 0.2, XMax: 0.3, YMax: 0.4}
+	if len(boxes) != 1 || boxes[0] != want {
+		t.Errorf("ParseBoxes() = %+v, want %+v", boxes, want)
+	}
+
+	// The objects field wins over other lists, whatever the map order
+	for range 20 {
+		boxes, err = ParseBoxes(`{"alternatives": [], "objects": [{"label": "dog", "box_2d": [1, 2, 3, 4]}], "zones": []}`)
+		if err != nil || len(boxes) != 1 {
+			t.Fatalf("ParseBoxes() = %+v, %v", boxes, err)
+		}
+	}
+
+	if _, err := ParseBoxes(`[{"label": "cat", "box_2d": [1, 2]}]`); err == nil {
+		t.Error("Expected error for malformed box")
+	}
+}
+
+func TestParsePoints(t *testing.T) {
+	points, err := ParsePoints(`[{"point": [250, 750], "label": "cup"}, {"x": 10, "y": 20, "label": "pen"}]`)
+	if err != nil {
+		t.Fatalf("ParsePoints() error = %v", err)
+	}
+	if len(points) != 2 || points[0] != (Point{Label: "cup", X: 0.75, Y: 0.25}) || points[1] != (Point{Label: "pen", X: 0.01, Y: 0.02}) {
+		t.Errorf("ParsePoints() = %+v", points)
+	}
+}
@@ -43,6 +43,23 @@ type VoyageEmbeddingResponse struct {
 	} `json:"usage,omitempty"`
 }
 
+// VoyageMultimodalRequest is the request for the multimodal embeddings endpoint
+type VoyageMultimodalRequest struct {
+	Inputs []VoyageMultimodalInput `json:"inputs"`
+	Model  string                  `json:"model"`
+}
+
+type VoyageMultimodalInput struct {
+	Content []VoyageMultimodalContent `json:"content"`
+}
+
+type VoyageMultimodalContent struct {
+	Type        string `json:"type"` // "text", "image_url" or "image_base64"
+	Text        string `json:"text,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	ImageBase64 string `json:"image_base64,omitempty"`
+}
+
 type VoyageRerankRequest struct {
 	Query     string   `json:"query"`
 	Documents []string `json:"documents"`
@@ -64,6 +81,12 @@ type VoyageRerankResponse struct {
 	Model string `json:"model"`
 }
 
+// validateCompletion implements the provider interface but returns an error
+// Voyage AI only supports embeddings, not chat completions
+func (p *VoyageProvider) validateCompletion(messages []Message, cfg CallConfig) error {
+	return fmt.Errorf("Voyage AI only supports embeddings, not chat completions. Use GetEmbeddings() instead")
+}
+
 // call implements the provider interface but returns an error
 // Voyage AI only supports embeddings, not chat completions
 func (p *VoyageProvider) call(ctx context.Context, messages []Message, cfg CallConfig) (*Response, error) {
@@ -96,8 +119,8 @@ func (p *VoyageProvider) getEmbeddings(ctx context.Context, text string, cfg Cal
 	}
 
 	resp := VoyageEmbeddingResponse{}
-	err := callHTTPAPI(ctx, baseURL, func(req *http.Request) {
-		req.Header.Set("Authorization", "Bearer "+p.Key)
+	err := callHTTPAPI(ctx, cfg, baseURL, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+cfg.apiKey(p.Key))
 	}, body, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("Voyage AI embedding API call failed: %w", err)
@@ -128,6 +151,73 @@ func (p *VoyageProvider) getEmbeddings(ctx context.Context, text string, cfg Cal
 	return response, nil
 }
 
+// getMultimodalEmbeddings implements the provider interface for Voyage AI multimodal embeddings
+func (p *VoyageProvider) getMultimodalEmbeddings(ctx context.Context, parts []Part, cfg CallConfig) (*EmbeddingResponse, error) {
+	// Use provided model or default to voyage-multimodal-3
+	model := cfg.Model
+	if model == "" {
+		model = "voyage-multimodal-3"
+	}
+
+	content := make([]VoyageMultimodalContent, 0, len(parts))
+	for _, part := range parts {
+		switch part.Type {
+		case PartText:
+			content = append(content, VoyageMultimodalContent{Type: "text", Text: part.Text})
+		case PartImage:
+			if part.URL != "" {
+				content = append(content, VoyageMultimodalContent{Type: "image_url", ImageURL: part.URL})
+			} else {
+				content = append(content, VoyageMultimodalContent{Type: "image_base64", ImageBase64: part.dataURL()})
+			}
+		default:
+			return nil, fmt.Errorf("Voyage AI does not support %s parts in multimodal embeddings", part.Type)
+		}
+	}
+
+	body := VoyageMultimodalRequest{
+		Model:  model,
+		Inputs: []VoyageMultimodalInput{{Content: content}},
+	}
+
+	// Set default base URL if not provided
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.voyageai.com/v1/multimodalembeddings"
+	}
+
+	resp := VoyageEmbeddingResponse{}
+	err := callHTTPAPI(ctx, cfg, baseURL, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+cfg.apiKey(p.Key))
+	}, body, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("Voyage AI multimodal embedding API call failed: %w", err)
+	}
+
+	// Check for errors in the response
+	if resp.Error != nil {
+		return nil, fmt.Errorf("Voyage AI multimodal embedding API error: %s", resp.Error.Message)
+	}
+
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("no embedding data in response")
+	}
+
+	response := &EmbeddingResponse{
+		Embedding: resp.Data[0].Embedding,
+	}
+
+	// Add metadata if usage information is available
+	if resp.Usage != nil {
+		response.Metadata = Metadata{
+			"total_tokens": resp.Usage.TotalTokens,
+			"model":        resp.Model,
+		}
+	}
+
+	return response, nil
+}
+
 // reRank implements the provider interface for Voyage AI reranking
 func (p *VoyageProvider) reRank(ctx context.Context, query string, documents []string, cfg CallConfig) (*RerankResponse, error) {
 	// Use provided model or default to rerank-2.5
@@ -149,8 +239,8 @@ func (p *VoyageProvider) reRank(ctx context.Context, query string, documents []s
 	}
 
 	resp := VoyageRerankResponse{}
-	err := callHTTPAPI(ctx, baseURL, func(req *http.Request) {
-		req.Header.Set("Authorization", "Bearer "+p.Key)
+	err := callHTTPAPI(ctx, cfg, baseURL, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+cfg.apiKey(p.Key))
 	}, body, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("Voyage AI rerank API call failed: %w", err)
@@ -241,8 +331,8 @@ func (p *VoyageProvider) buildEmbeddingRequest(ctx context.Context, req *Embeddi
 	}
 
 	var voyageResp VoyageEmbeddingResponse
-	err := callHTTPAPI(ctx, baseURL, func(httpReq *http.Request) {
-		httpReq.Header.Set("Authorization", "Bearer "+p.Key)
+	err := callHTTPAPI(ctx, cfg, baseURL, func(httpReq *http.Request) {
+		httpReq.Header.Set("Authorization", "Bearer "+cfg.apiKey(p.Key))
 	}, body, &voyageResp)
 	if err != nil {
 		return nil, fmt.Errorf("Voyage AI embedding API call failed: %w", err)
@@ -306,8 +396,8 @@ func (p *VoyageProvider) buildRerankRequest(ctx context.Context, req *RerankRequ
 	}
 
 	var voyageResp VoyageRerankResponse
-	err := callHTTPAPI(ctx, baseURL, func(httpReq *http.Request) {
-		httpReq.Header.Set("Authorization", "Bearer "+p.Key)
+	err := callHTTPAPI(ctx, cfg, baseURL, func(httpReq *http.Request) {
+		httpReq.Header.Set("Authorization", "Bearer "+cfg.apiKey(p.Key))
 	}, body, &voyageResp)
 	if err != nil {
 		return nil, fmt.Errorf("Voyage AI rerank API call failed: %w", err)
@@ -351,6 +441,13 @@ func (p *VoyageProvider) writeCompletionResponse(w http.ResponseWriter, resp *Co
 	return fmt.Errorf("Voyage AI only supports embeddings and reranking, not chat completions")
 }
 
+// writeCompletionStream writes a streamed completion to the HTTP response writer
+// Voyage AI only supports embeddings and reranking, not chat completions
+func (p *VoyageProvider) writeCompletionStream(w http.ResponseWriter, model string, stream *StreamResponse) error {
+	go drain(stream)
+	return fmt.Errorf("Voyage AI only supports embeddings and reranking, not chat completions")
+}
+
 // writeEmbeddingResponse writes a UnifiedEmbeddingResponse as JSON to the HTTP response writer
 func (p *VoyageProvider) writeEmbeddingResponse(w http.ResponseWriter, resp *UnifiedEmbeddingResponse) error {
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,52 @@
+package echo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVoyageMultimodalEmbeddings(t *testing.T) {
+	var request VoyageMultimodalRequest
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Write([]byte(`{"data": [{"embedding": [0.6, 0.8], "index": 0}], "model": "voyage-multimodal-3",
+			"usage": {"total_tokens": 42}}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewCommonClient(map[string]string{"voyage": "key"}, WithBaseURL(server.URL))
+	parts := []Part{
+		TextPart("a red bicycle"),
+		ImageURLPart("https://example.com/bike.png"),
+		ImagePart([]byte{0x89, 'P', 'N', 'G'}, "image/png"),
+	}
+	resp, err := client.GetMultimodalEmbeddings(context.Background(), parts, WithModel("voyage/voyage-multimodal-3"))
+	if err != nil {
+		t.Fatalf("GetMultimodalEmbeddings() error = %v", err)
+	}
+
+	if auth != "Bearer key" || request.Model != "voyage-multimodal-3" || len(request.Inputs) != 1 {
+		t.Fatalf("Unexpected request %+v, auth %q", request, auth)
+	}
+	content := request.Inputs[0].Content
+	if len(content) != 3 || content[0].Type != "text" || content[0].Text != "a red bicycle" ||
+		content[1].Type != "image_url" || content[1].ImageURL != "https://example.com/bike.png" ||
+		content[2].Type != "image_base64" || content[2].ImageBase64 != "data:image/png;base64,iVBORw==" {
+		t.Errorf("Unexpected content %+v", content)
+	}
+	if len(resp.Embedding) != 2 || resp.Embedding[1] != 0.8 || resp.Metadata["total_tokens"] != 42 {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+
+	if _, err := client.GetMultimodalEmbeddings(context.Background(), []Part{AudioPart([]byte{1}, "audio/wav")}, WithModel("voyage/voyage-multimodal-3")); err == nil {
+		t.Error("Expected an error for audio parts")
+	}
+	if _, err := client.GetMultimodalEmbeddings(context.Background(), nil, WithModel("voyage/voyage-multimodal-3")); err == nil {
+		t.Error("Expected an error for empty content")
+	}
+}
@@ -0,0 +1,194 @@
+package echo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Job is a completion request processed by a Worker
+type Job struct {
+	ID          string    `json:"id"`
+	Messages    []Message `json:"messages"`
+	Model       string    `json:"model,omitempty"` // overrides the model of the client
+	SystemMsg   string    `json:"system,omitempty"`
+	Temperature *float32  `json:"temperature,omitempty"`
+	MaxTokens   *int      `json:"max_tokens,omitempty"`
+}
+
+// JobResult is the outcome of a job, published to the queue
+type JobResult struct {
+	ID       string    `json:"id"`
+	Response *Response `json:"response,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Attempts int       `json:"attempts"`
+}
+
+// Queue delivers jobs to a Worker and takes their results. Implementations adapt
+// NATS JetStream, SQS, Redis streams and similar; a job should be acknowledged
+// once its result is published, so jobs of a crashed worker are delivered again.
+type Queue interface {
+	// Receive blocks until a job is available or the context is done
+	Receive(ctx context.Context) (Job, error)
+	// Publish stores or sends the result of a job
+	Publish(ctx context.Context, result JobResult) error
+}
+
+// Worker consumes completion jobs from a queue, runs them through the client
+// and publishes the results
+type Worker struct {
+	Client      Client
+	Queue       Queue
+	Concurrency int           // jobs processed in parallel, 1 when not set
+	MaxAttempts int           // calls made per job before it fails, 3 when not set
+	RetryDelay  time.Duration // delay before the second attempt, doubled for each next one; 1s when not set
+	Options     []CallOption  // applied to every job
+}
+
+// Run processes jobs until the context is done or the queue fails. Jobs in progress
+// are finished before it returns; the context error is returned on shutdown.
+func (w *Worker) Run(ctx context.Context) error {
+	concurrency := max(w.Concurrency, 1)
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		job, err := w.Queue.Receive(ctx)
+		if err != nil {
+			<-slots
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			// Results of finished jobs are published even during shutdown
+			w.Queue.Publish(context.WithoutCancel(ctx), w.process(ctx, job))
+		}()
+	}
+}
+
+// process runs a job, repeating failed calls with exponential backoff
+func (w *Worker) process(ctx context.Context, job Job) JobResult {
+	opts := append([]CallOption{}, w.Options...)
+	if job.Model != "" {
+		opts = append(opts, WithModel(job.Model))
+	}
+	if job.SystemMsg != "" {
+		opts = append(opts, WithSystemMessage(job.SystemMsg))
+	}
+	if job.Temperature != nil {
+		opts = append(opts, WithTemperature(*job.Temperature))
+	}
+	if job.MaxTokens != nil {
+		opts = append(opts, WithMaxTokens(*job.MaxTokens))
+	}
+
+	attempts := w.MaxAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	delay := w.RetryDelay
+	if delay <= 0 {
+		delay = time.Second
+	}
+
+	result := JobResult{ID: job.ID}
+	for {
+		result.Attempts++
+		resp, err := w.Client.Complete(ctx, job.Messages, opts...)
+		if err == nil {
+			result.Response, result.Error = resp, ""
+			return result
+		}
+		result.Error = err.Error()
+
+		// Exhausted budgets, rejected requests, local errors and cancellation don't go away with a retry
+		if result.Attempts >= attempts || !retryable(err) || ctx.Err() != nil {
+			return result
+		}
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-ctx.Done():
+			return result
+		}
+	}
+}
+
+// retryable reports whether a failed call may succeed later: rate limits, server and network errors.
+// Local failures, such as an unknown provider or an invalid message chain, are final.
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	var urlErr *url.Error
+	return errors.As(err, &netErr) || errors.As(err, &urlErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// MemoryQueue is an in-process Queue, for tests and single-binary deployments
+type MemoryQueue struct {
+	jobs    chan Job
+	results chan JobResult
+}
+
+// NewMemoryQueue creates a queue holding up to size pending jobs and unread results
+func NewMemoryQueue(size int) *MemoryQueue {
+	return &MemoryQueue{
+		jobs:    make(chan Job, size),
+		results: make(chan JobResult, size),
+	}
+}
+
+// Submit adds a job, blocking while the queue is full
+func (q *MemoryQueue) Submit(ctx context.Context, job Job) error {
+	select {
+	case q.jobs <- job:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Results returns the channel with the results of processed jobs
+func (q *MemoryQueue) Results() <-chan JobResult {
+	return q.results
+}
+
+// Receive implements the Queue interface
+func (q *MemoryQueue) Receive(ctx context.Context) (Job, error) {
+	select {
+	case job := <-q.jobs:
+		return job, nil
+	case <-ctx.Done():
+		return Job{}, ctx.Err()
+	}
+}
+
+// Publish implements the Queue interface
+func (q *MemoryQueue) Publish(ctx context.Context, result JobResult) error {
+	select {
+	case q.results <- result:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
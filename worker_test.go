@@ -0,0 +1,105 @@
+package echo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyProvider fails the first calls, then answers like the mock provider
+type flakyProvider struct {
+	MockProvider
+	failures atomic.Int32
+}
+
+func (p *flakyProvider) call(ctx context.Context, messages []Message, cfg CallConfig) (*Response, error) {
+	if p.failures.Add(-1) >= 0 {
+		return nil, fmt.Errorf("temporary failure: %w", io.ErrUnexpectedEOF)
+	}
+	return p.MockProvider.call(ctx, messages, cfg)
+}
+
+func TestWorker(t *testing.T) {
+	provider := &flakyProvider{}
+	provider.failures.Store(1)
+	client, _ := NewClient(WithModel("mock/any"))
+	client.SetProvider("mock", provider)
+
+	queue := NewMemoryQueue(10)
+	worker := &Worker{Client: client, Queue: queue, Concurrency: 2, RetryDelay: time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- worker.Run(ctx) }()
+
+	for i := 0; i < 3; i++ {
+		queue.Submit(ctx, Job{ID: fmt.Sprint(i), Messages: QuickMessage(fmt.Sprint("job ", i))})
+	}
+
+	attempts := 0
+	for i := 0; i < 3; i++ {
+		result := <-queue.Results()
+		if result.Error != "" {
+			t.Errorf("Job %s failed: %s", result.ID, result.Error)
+			continue
+		}
+		if result.Response.Text != "[user]: job "+result.ID {
+			t.Errorf("Unexpected result of job %s: %q", result.ID, result.Response.Text)
+		}
+		attempts += result.Attempts
+	}
+	if attempts != 4 {
+		t.Errorf("Expected one retry, got %d attempts for 3 jobs", attempts)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run() error = %v", err)
+	}
+}
+
+// statusProvider fails every call with the given HTTP status
+type statusProvider struct {
+	MockProvider
+	status int
+	calls  atomic.Int32
+}
+
+func (p *statusProvider) call(ctx context.Context, messages []Message, cfg CallConfig) (*Response, error) {
+	p.calls.Add(1)
+	return nil, &APIError{StatusCode: p.status, Message: "failed"}
+}
+
+func TestWorkerRetryable(t *testing.T) {
+	tests := []struct {
+		status   int
+		attempts int
+	}{
+		{401, 1},
+		{400, 1},
+		{429, 3},
+		{503, 3},
+	}
+	for _, tt := range tests {
+		provider := &statusProvider{status: tt.status}
+		client, _ := NewClient(WithModel("mock/any"))
+		client.SetProvider("mock", provider)
+
+		worker := &Worker{Client: client, MaxAttempts: 3, RetryDelay: time.Millisecond}
+		result := worker.process(context.Background(), Job{ID: "1", Messages: QuickMessage("hi")})
+		if result.Error == "" || result.Attempts != tt.attempts || int(provider.calls.Load()) != tt.attempts {
+			t.Errorf("Status %d: got %d attempts (%d calls), error %q, want %d attempts",
+				tt.status, result.Attempts, provider.calls.Load(), result.Error, tt.attempts)
+		}
+	}
+
+	// Local errors are not retried
+	client, _ := NewClient(WithModel("missing/any"))
+	worker := &Worker{Client: client, MaxAttempts: 3, RetryDelay: time.Millisecond}
+	if result := worker.process(context.Background(), Job{ID: "1", Messages: QuickMessage("hi")}); result.Error == "" || result.Attempts != 1 {
+		t.Errorf("Unknown provider: got %d attempts, error %q", result.Attempts, result.Error)
+	}
+}
@@ -1,12 +1,9 @@
 package echo
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 )
 
@@ -14,6 +11,11 @@ import (
 type XAIRequest struct {
 	Model         string          `json:"model"`
 	Temperature   *float32        `json:"temperature,omitempty"`
+	TopP          *float32        `json:"top_p,omitempty"`
+	FreqPenalty   *float32        `json:"frequency_penalty,omitempty"`
+	PresPenalty   *float32        `json:"presence_penalty,omitempty"`
+	Seed          *int            `json:"seed,omitempty"`
+	N             int             `json:"n,omitempty"`
 	MaxTokens     *int            `json:"max_completion_tokens,omitempty"`
 	Messages      []OpenAIMessage `json:"messages"`
 	Stream        bool            `json:"stream,omitempty"`
@@ -23,6 +25,9 @@ type XAIRequest struct {
 	ResponseFormat  *OpenAIResponseFormat `json:"response_format,omitempty"`
 	ReasoningEffort string                `json:"reasoning_effort,omitempty"`
 	Store           *bool                 `json:"store,omitempty"` // xAI-specific: set to false to disable server-side storage
+	User            string                `json:"user,omitempty"`
+	Tools           []OpenAITool          `json:"tools,omitempty"`
+	ToolChoice      any                   `json:"tool_choice,omitempty"`
 }
 
 // XAIError represents an error from the xAI API
@@ -33,11 +38,15 @@ type XAIError struct {
 
 // XAIResponse represents a response from the xAI chat completions API
 type XAIResponse struct {
-	Error   *XAIError `json:"error,omitempty"`
-	Choices []struct {
+	Error             *XAIError `json:"error,omitempty"`
+	Model             string    `json:"model,omitempty"`
+	SystemFingerprint string    `json:"system_fingerprint,omitempty"`
+	Choices           []struct {
 		Message struct {
-			Content string `json:"content"`
+			Content   string           `json:"content"`
+			ToolCalls []OpenAIToolCall `json:"tool_calls,omitempty"`
 		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
 	Usage *struct {
 		PromptTokens     int `json:"prompt_tokens"`
@@ -48,10 +57,12 @@ type XAIResponse struct {
 
 // XAIStreamResponse represents a streaming response chunk from xAI
 type XAIStreamResponse struct {
-	Choices []struct {
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+	Choices           []struct {
 		Delta struct {
 			Content string `json:"content"`
 		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
 	Usage *struct {
 		PromptTokens     int `json:"prompt_tokens"`
@@ -78,10 +89,18 @@ func prepareXAIRequest(messages []Message, streaming bool, cfg CallConfig) (XAIR
 	if err := validateMessages(messages); err != nil {
 		return XAIRequest{}, fmt.Errorf("invalid message chain: %w", err)
 	}
+	if len(cfg.LogitBias) > 0 {
+		return XAIRequest{}, fmt.Errorf("logit bias is not supported by xAI")
+	}
+	if cfg.TopK != nil {
+		return XAIRequest{}, fmt.Errorf("top k is not supported by xAI, use WithTopP")
+	}
+	if cfg.ThinkingBudget > 0 {
+		return XAIRequest{}, fmt.Errorf("thinking budget is not supported by xAI, use WithReasoningEffort")
+	}
 
 	// Convert messages to OpenAI format (xAI is OpenAI-compatible)
 	xaiMessages := []OpenAIMessage{}
-	systemMessageProcessed := false
 
 	for _, msg := range messages {
 		switch msg.Role {
@@ -93,42 +112,46 @@ func prepareXAIRequest(messages []Message, streaming bool, cfg CallConfig) (XAIR
 					Content: msg.Content,
 				})
 			}
-			systemMessageProcessed = true
 		case User:
-			xaiMessages = append(xaiMessages, OpenAIMessage{
-				Role:    "user",
-				Content: msg.Content,
-			})
+			userMsg, err := toOpenAIMessage("user", msg)
+			if err != nil {
+				return XAIRequest{}, err
+			}
+			xaiMessages = append(xaiMessages, userMsg)
 		case Agent:
+			xaiMessages = append(xaiMessages, toOpenAIAgentMessage(msg))
+		case ToolResult:
 			xaiMessages = append(xaiMessages, OpenAIMessage{
-				Role:    "assistant",
-				Content: msg.Content,
+				Role:       "tool",
+				Content:    msg.Content,
+				ToolCallID: msg.ToolCallID,
 			})
 		}
 	}
 
-	// Handle WithSystemMessage option
+	// Handle WithSystemMessage option, the chain system message was skipped above
 	if cfg.SystemMsg != "" {
-		// Insert system message at the beginning
 		systemMsg := OpenAIMessage{
 			Role:    "system",
 			Content: cfg.SystemMsg,
 		}
-		if systemMessageProcessed {
-			// Replace the first message (which should be system)
-			xaiMessages = append([]OpenAIMessage{systemMsg}, xaiMessages[1:]...)
-		} else {
-			// Add system message at the beginning
-			xaiMessages = append([]OpenAIMessage{systemMsg}, xaiMessages...)
-		}
+		xaiMessages = append([]OpenAIMessage{systemMsg}, xaiMessages...)
 	}
 
 	req := XAIRequest{
 		Model:       cfg.Model,
 		Temperature: cfg.Temperature,
+		TopP:        cfg.TopP,
+		FreqPenalty: cfg.FrequencyPenalty,
+		PresPenalty: cfg.PresencePenalty,
+		Seed:        cfg.Seed,
+		N:           cfg.N,
 		MaxTokens:   cfg.MaxTokens,
 		Messages:    xaiMessages,
+		User:        cfg.UserID,
 		Stream:      streaming,
+		Tools:       openAITools(cfg.Tools),
+		ToolChoice:  openAIToolChoice(cfg.ToolChoice),
 	}
 
 	// Add stream options for usage stats when streaming
@@ -161,6 +184,11 @@ func prepareXAIRequest(messages []Message, streaming bool, cfg CallConfig) (XAIR
 		}
 	}
 
+	// Loose JSON output when no schema is given
+	if cfg.JSONMode && cfg.StructuredOutput == nil {
+		req.ResponseFormat = &OpenAIResponseFormat{Type: "json_object"}
+	}
+
 	// Add reasoning effort if configured
 	if cfg.ReasoningEffort != "" {
 		req.ReasoningEffort = cfg.ReasoningEffort
@@ -169,6 +197,12 @@ func prepareXAIRequest(messages []Message, streaming bool, cfg CallConfig) (XAIR
 	return req, nil
 }
 
+// validateCompletion implements the provider interface
+func (p *XAIProvider) validateCompletion(messages []Message, cfg CallConfig) error {
+	_, err := prepareXAIRequest(messages, false, cfg)
+	return err
+}
+
 // call implements the provider interface for xAI
 func (p *XAIProvider) call(ctx context.Context, messages []Message, cfg CallConfig) (*Response, error) {
 	body, err := prepareXAIRequest(messages, false, cfg)
@@ -183,8 +217,8 @@ func (p *XAIProvider) call(ctx context.Context, messages []Message, cfg CallConf
 	}
 
 	resp := XAIResponse{}
-	err = callHTTPAPI(ctx, baseURL, func(req *http.Request) {
-		req.Header.Set("Authorization", "Bearer "+p.Key)
+	err = callHTTPAPI(ctx, cfg, baseURL, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+cfg.apiKey(p.Key))
 	}, body, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("xAI API call failed: %w", err)
@@ -201,16 +235,32 @@ func (p *XAIProvider) call(ctx context.Context, messages []Message, cfg CallConf
 	}
 
 	response := &Response{
-		Text: resp.Choices[0].Message.Content,
+		Text:      resp.Choices[0].Message.Content,
+		Model:     resp.Model,
+		ToolCalls: fromOpenAIToolCalls(resp.Choices[0].Message.ToolCalls),
+	}
+
+	response.Metadata = Metadata{
+		"finish_reason": resp.Choices[0].FinishReason,
+	}
+	if len(resp.Choices) > 1 {
+		for _, c := range resp.Choices {
+			response.Choices = append(response.Choices, Choice{
+				Text:         c.Message.Content,
+				ToolCalls:    fromOpenAIToolCalls(c.Message.ToolCalls),
+				FinishReason: c.FinishReason,
+			})
+		}
 	}
 
 	// Add metadata if usage information is available
 	if resp.Usage != nil {
-		response.Metadata = Metadata{
-			"total_tokens":      resp.Usage.TotalTokens,
-			"prompt_tokens":     resp.Usage.PromptTokens,
-			"completion_tokens": resp.Usage.CompletionTokens,
-		}
+		response.Metadata["total_tokens"] = resp.Usage.TotalTokens
+		response.Metadata["prompt_tokens"] = resp.Usage.PromptTokens
+		response.Metadata["completion_tokens"] = resp.Usage.CompletionTokens
+	}
+	if resp.SystemFingerprint != "" {
+		response.Metadata["system_fingerprint"] = resp.SystemFingerprint
 	}
 
 	return response, nil
@@ -230,8 +280,8 @@ func (p *XAIProvider) streamCall(ctx context.Context, messages []Message, cfg Ca
 	}
 
 	// Get streaming response
-	respBody, err := streamHTTPAPI(ctx, baseURL, func(req *http.Request) {
-		req.Header.Set("Authorization", "Bearer "+p.Key)
+	respBody, err := streamHTTPAPI(ctx, cfg, baseURL, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+cfg.apiKey(p.Key))
 	}, body)
 	if err != nil {
 		return nil, fmt.Errorf("xAI streaming API call failed: %w", err)
@@ -243,43 +293,21 @@ func (p *XAIProvider) streamCall(ctx context.Context, messages []Message, cfg Ca
 	// Start goroutine to process stream
 	go func() {
 		defer close(ch)
-		defer respBody.Close()
-
-		reader := bufio.NewReader(respBody)
-		for {
-			line, err := reader.ReadBytes('\n')
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				ch <- StreamChunk{Error: fmt.Errorf("read error: %w", err)}
-				return
-			}
-
-			// Skip empty lines
-			line = bytes.TrimSpace(line)
-			if len(line) == 0 {
-				continue
-			}
-
-			// Check for SSE data prefix
-			if !bytes.HasPrefix(line, dataPrefix) {
-				continue
-			}
+		defer closeOnDone(ctx, respBody)()
+		out := chunkSender{ctx: ctx, ch: ch}
 
-			// Remove "data: " prefix
-			data := bytes.TrimPrefix(line, dataPrefix)
-
-			// Check for end of stream
-			if bytes.Equal(data, doneMarker) {
-				return
-			}
-
-			// Parse JSON
+		err := parseSSEData(respBody, func(data []byte) error {
 			var streamResp XAIStreamResponse
 			if err := json.Unmarshal(data, &streamResp); err != nil {
-				ch <- StreamChunk{Error: fmt.Errorf("json parse error: %w", err)}
-				return
+				return fmt.Errorf("json parse error: %w", err)
+			}
+
+			// The last chunk with a choice carries the finish reason
+			if len(streamResp.Choices) > 0 && streamResp.Choices[0].FinishReason != "" {
+				meta := Metadata{"finish_reason": streamResp.Choices[0].FinishReason}
+				if err := out.send(StreamChunk{Meta: &meta}); err != nil {
+					return err
+				}
 			}
 
 			// Check if this is a usage chunk (has usage data but no choices)
@@ -290,15 +318,23 @@ func (p *XAIProvider) streamCall(ctx context.Context, messages []Message, cfg Ca
 					"prompt_tokens":     streamResp.Usage.PromptTokens,
 					"completion_tokens": streamResp.Usage.CompletionTokens,
 				}
-				ch <- StreamChunk{
-					Meta: &meta,
+				if streamResp.SystemFingerprint != "" {
+					meta["system_fingerprint"] = streamResp.SystemFingerprint
 				}
+				return out.send(StreamChunk{
+					Meta: &meta,
+				})
 			} else if len(streamResp.Choices) > 0 && streamResp.Choices[0].Delta.Content != "" {
 				// Normal content chunk
-				ch <- StreamChunk{
+				return out.send(StreamChunk{
 					Data: streamResp.Choices[0].Delta.Content,
-				}
+				})
 			}
+			return nil
+		})
+
+		if err != nil {
+			out.fail(err)
 		}
 	}()
 
@@ -311,6 +347,12 @@ func (p *XAIProvider) getEmbeddings(ctx context.Context, text string, cfg CallCo
 	return nil, fmt.Errorf("xAI does not currently support embeddings API")
 }
 
+// getMultimodalEmbeddings implements the provider interface for xAI
+// Note: xAI does not support multimodal embeddings
+func (p *XAIProvider) getMultimodalEmbeddings(ctx context.Context, parts []Part, cfg CallConfig) (*EmbeddingResponse, error) {
+	return nil, fmt.Errorf("xAI does not support multimodal embeddings")
+}
+
 // reRank implements the provider interface for xAI
 // Note: xAI does not support reranking API
 func (p *XAIProvider) reRank(ctx context.Context, query string, documents []string, cfg CallConfig) (*RerankResponse, error) {
@@ -368,8 +410,8 @@ func (p *XAIProvider) buildCompletionRequest(ctx context.Context, req *Completio
 
 	// Make the API call
 	var xaiResp XAIResponse
-	err := callHTTPAPI(ctx, baseURL, func(httpReq *http.Request) {
-		httpReq.Header.Set("Authorization", "Bearer "+p.Key)
+	err := callHTTPAPI(ctx, cfg, baseURL, func(httpReq *http.Request) {
+		httpReq.Header.Set("Authorization", "Bearer "+cfg.apiKey(p.Key))
 	}, xaiReq, &xaiResp)
 	if err != nil {
 		return nil, fmt.Errorf("xAI API call failed: %w", err)
@@ -401,7 +443,7 @@ func (p *XAIProvider) buildCompletionRequest(ctx context.Context, req *Completio
 		completionResp.Choices[i].Index = i
 		completionResp.Choices[i].Message.Role = "assistant"
 		completionResp.Choices[i].Message.Content = choice.Message.Content
-		completionResp.Choices[i].FinishReason = "stop"
+		completionResp.Choices[i].FinishReason = choice.FinishReason
 	}
 
 	// Copy usage if available
@@ -438,6 +480,11 @@ func (p *XAIProvider) writeCompletionResponse(w http.ResponseWriter, resp *Compl
 	return json.NewEncoder(w).Encode(resp)
 }
 
+// writeCompletionStream writes a streamed completion as OpenAI chunk events, the format of xAI
+func (p *XAIProvider) writeCompletionStream(w http.ResponseWriter, model string, stream *StreamResponse) error {
+	return writeOpenAIStream(w, model, stream)
+}
+
 // writeEmbeddingResponse writes a UnifiedEmbeddingResponse as JSON to the HTTP response writer
 // xAI does not support embeddings, so this returns an error
 func (p *XAIProvider) writeEmbeddingResponse(w http.ResponseWriter, resp *UnifiedEmbeddingResponse) error {